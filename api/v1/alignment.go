@@ -0,0 +1,35 @@
+package v1
+
+// AlignmentRequest is used to align provider projections to the projections of a reference provider.
+type AlignmentRequest struct {
+	// Reference is the UID of the provider the projections are aligned to.
+	Reference string `json:"reference"`
+	// Projection algorithm of the aligned projections.
+	// If not set, PCA projections are aligned.
+	Projection Projection `json:"projection,omitempty"`
+	// Dim of the aligned projections.
+	// If not set, 2D projections are aligned.
+	Dim Dim `json:"dim,omitempty"`
+	// Key is the metadata key the embeddings of both providers are matched by.
+	// If not set, embeddings are matched by their label.
+	Key string `json:"key,omitempty"`
+}
+
+// Alignment is the result of the Procrustes analysis of the projections of two providers.
+type Alignment struct {
+	// Reference is the UID of the provider the projections are aligned to.
+	Reference string `json:"reference"`
+	// Projection algorithm of the aligned projections.
+	Projection Projection `json:"projection"`
+	// Dim of the aligned projections.
+	Dim Dim `json:"dim"`
+	// Key is the metadata key the embeddings were matched by.
+	Key string `json:"key"`
+	// Matched is the number of the matched embeddings.
+	Matched int `json:"matched"`
+	// Disparity is the sum of squared differences of the standardised matched projections
+	// after the alignment. It ranges from 0 (identical shapes) to 1 (unrelated shapes).
+	Disparity float64 `json:"disparity"`
+	// Embeddings are the aligned projections of the provider.
+	Embeddings []Embedding `json:"embeddings"`
+}
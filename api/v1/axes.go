@@ -0,0 +1,31 @@
+package v1
+
+// Axis is a semantic axis running from the negative to the positive anchor.
+// Embeddings are placed on the axis by their scalar projection
+// onto the difference of the positive and the negative anchor.
+type Axis struct {
+	// Name of the axis, e.g. negative-positive.
+	Name string `json:"name,omitempty"`
+	// Negative anchor of the axis.
+	Negative Anchor `json:"negative"`
+	// Positive anchor of the axis.
+	Positive Anchor `json:"positive"`
+}
+
+// Anchor is a pole of a semantic axis.
+// It's given either by text which is embedded by the provider embedder,
+// by the UID of a provider embedding or by the embedding values.
+type Anchor struct {
+	// Text of the anchor.
+	Text string `json:"text,omitempty"`
+	// UID of the anchor embedding.
+	UID string `json:"uid,omitempty"`
+	// Values of the anchor embedding.
+	// They're set when the anchor text is embedded.
+	Values []float64 `json:"values,omitempty"`
+}
+
+// Valid returns true if the anchor is set.
+func (a Anchor) Valid() bool {
+	return a.Text != "" || a.UID != "" || len(a.Values) > 0
+}
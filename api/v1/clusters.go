@@ -0,0 +1,158 @@
+package v1
+
+import "fmt"
+
+// ClusterAlgorithm is embeddings clustering algorithm.
+type ClusterAlgorithm string
+
+const (
+	// KMeans partitions embeddings into k clusters minimising the within-cluster sum of squares.
+	// https://en.wikipedia.org/wiki/K-means_clustering
+	KMeans ClusterAlgorithm = "kmeans"
+	// DBSCAN groups embeddings which are densely packed together and marks the remaining embeddings as noise.
+	// https://en.wikipedia.org/wiki/DBSCAN
+	DBSCAN ClusterAlgorithm = "dbscan"
+	// HDBSCAN extracts the most stable clusters from the hierarchy of DBSCAN clusterings of all densities.
+	// https://hdbscan.readthedocs.io/en/latest/how_hdbscan_works.html
+	HDBSCAN ClusterAlgorithm = "hdbscan"
+)
+
+const (
+	// ClusterMetaKey is the metadata key of the embedding cluster ID.
+	ClusterMetaKey = "cluster"
+	// ClusterDistanceMetaKey is the metadata key of the distance of the embedding to its cluster centroid.
+	ClusterDistanceMetaKey = "cluster_distance"
+)
+
+const (
+	// NoiseCluster is the cluster ID of the embeddings marked as noise by density-based clustering.
+	NoiseCluster = -1
+	// MaxSweep is the maximum number of k evaluated by the clustering sweep.
+	MaxSweep = 32
+)
+
+// ClusterOptions configure embeddings clustering.
+type ClusterOptions struct {
+	// Algorithm used to cluster embeddings.
+	// It defaults to KMeans.
+	Algorithm ClusterAlgorithm `json:"algorithm,omitempty"`
+	// K is the number of k-means clusters.
+	K int `json:"k"`
+	// Seed is the random seed.
+	Seed *int64 `json:"seed,omitempty"`
+	// MaxIterations of the clustering algorithm.
+	MaxIterations int `json:"max_iterations,omitempty"`
+	// BatchSize of mini-batch k-means.
+	// If not set, mini-batch k-means is only used for large providers.
+	BatchSize int `json:"batch_size,omitempty"`
+	// Sweep evaluates clustering for the given range of k.
+	Sweep *ClusterRange `json:"sweep,omitempty"`
+	// Metric measuring the distance of embeddings in density-based clustering.
+	// It defaults to Euclidean. Dot product is not a distance so it is not supported.
+	// K-means only supports Euclidean metric.
+	Metric Metric `json:"metric,omitempty"`
+	// Eps is the maximum distance of neighbouring embeddings in DBSCAN.
+	Eps float64 `json:"eps,omitempty"`
+	// MinPoints is the number of neighbours, including the embedding itself,
+	// which make an embedding a core point in density-based clustering.
+	MinPoints int `json:"min_points,omitempty"`
+	// MinClusterSize is the minimum size of HDBSCAN clusters.
+	MinClusterSize int `json:"min_cluster_size,omitempty"`
+}
+
+// ClusterRange is an inclusive range of the number of clusters.
+type ClusterRange struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// Validate returns error if the options are invalid.
+func (o *ClusterOptions) Validate() error {
+	if o.MaxIterations < 0 || o.BatchSize < 0 || o.MinPoints < 0 || o.MinClusterSize < 0 {
+		return fmt.Errorf("invalid options: max_iterations=%d/batch_size=%d/min_points=%d/min_cluster_size=%d",
+			o.MaxIterations, o.BatchSize, o.MinPoints, o.MinClusterSize)
+	}
+	if o.Metric != "" && !o.Metric.Valid() {
+		return fmt.Errorf("invalid metric: %v", o.Metric)
+	}
+	// NOTE: dot product is a similarity rather than a distance
+	if o.Metric == Dot {
+		return fmt.Errorf("invalid metric: %v is not a distance", o.Metric)
+	}
+
+	switch o.Algorithm {
+	case "", KMeans:
+		if o.K < 1 {
+			return fmt.Errorf("invalid k: %d", o.K)
+		}
+		if o.Metric != "" && o.Metric != Euclidean {
+			return fmt.Errorf("invalid metric: %s does not support %v", KMeans, o.Metric)
+		}
+		if s := o.Sweep; s != nil {
+			if s.Min < 2 || s.Max < s.Min {
+				return fmt.Errorf("invalid sweep: [%d, %d]", s.Min, s.Max)
+			}
+			if s.Max-s.Min+1 > MaxSweep {
+				return fmt.Errorf("invalid sweep: %d values of k exceed max: %d", s.Max-s.Min+1, MaxSweep)
+			}
+		}
+		return nil
+	case DBSCAN:
+		if o.Eps <= 0 {
+			return fmt.Errorf("invalid eps: %v", o.Eps)
+		}
+	case HDBSCAN:
+		if o.MinClusterSize == 1 {
+			return fmt.Errorf("invalid min_cluster_size: %d", o.MinClusterSize)
+		}
+	default:
+		return fmt.Errorf("invalid algorithm: %v", o.Algorithm)
+	}
+
+	// NOTE: density-based clustering finds the number of clusters
+	if o.Sweep != nil {
+		return fmt.Errorf("invalid sweep: %s does not support sweep", o.Algorithm)
+	}
+	return nil
+}
+
+// ClusterScore evaluates clustering into K clusters.
+type ClusterScore struct {
+	// K is the number of clusters.
+	K int `json:"k"`
+	// Inertia is the sum of squared distances of embeddings to their cluster centroids.
+	Inertia float64 `json:"inertia"`
+	// Silhouette is the mean silhouette coefficient of the clustering.
+	// https://en.wikipedia.org/wiki/Silhouette_(clustering)
+	Silhouette float64 `json:"silhouette"`
+}
+
+// Clustering of provider embeddings.
+type Clustering struct {
+	// Algorithm used to cluster embeddings.
+	Algorithm ClusterAlgorithm `json:"algorithm"`
+	// K is the number of clusters, excluding noise.
+	K int `json:"k"`
+	// Metric measuring the distance of embeddings.
+	Metric Metric `json:"metric"`
+	// Noise is the number of embeddings marked as noise.
+	Noise int `json:"noise"`
+	// Seed used to cluster embeddings.
+	Seed int64 `json:"seed"`
+	// MiniBatch is true if mini-batch k-means was used.
+	MiniBatch bool `json:"mini_batch"`
+	// Iterations run until convergence.
+	Iterations int `json:"iterations"`
+	// Inertia is the sum of squared distances of embeddings to their cluster centroids.
+	Inertia float64 `json:"inertia"`
+	// Silhouette is the mean silhouette coefficient of the clustering.
+	// Embeddings marked as noise are excluded from it.
+	Silhouette float64 `json:"silhouette"`
+	// Centroids of the clusters indexed by cluster ID.
+	// Centroids of density-based clusters are the means of their embeddings.
+	Centroids [][]float64 `json:"centroids"`
+	// Sizes of the clusters indexed by cluster ID.
+	Sizes []int `json:"sizes"`
+	// Sweep scores clustering for the requested range of k.
+	Sweep []ClusterScore `json:"sweep,omitempty"`
+}
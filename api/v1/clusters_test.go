@@ -0,0 +1,37 @@
+package v1
+
+import "testing"
+
+func TestClusterOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		opts  ClusterOptions
+		valid bool
+	}{
+		{name: "Valid", opts: ClusterOptions{K: 2}, valid: true},
+		{name: "Sweep", opts: ClusterOptions{Algorithm: KMeans, K: 2, Sweep: &ClusterRange{Min: 2, Max: 8}}, valid: true},
+		{name: "Algorithm", opts: ClusterOptions{Algorithm: "foo", K: 2}},
+		{name: "K", opts: ClusterOptions{}},
+		{name: "BatchSize", opts: ClusterOptions{K: 2, BatchSize: -1}},
+		{name: "SweepMin", opts: ClusterOptions{K: 2, Sweep: &ClusterRange{Min: 1, Max: 4}}},
+		{name: "SweepRange", opts: ClusterOptions{K: 2, Sweep: &ClusterRange{Min: 4, Max: 3}}},
+		{name: "SweepSize", opts: ClusterOptions{K: 2, Sweep: &ClusterRange{Min: 2, Max: 2 + MaxSweep}}},
+		{name: "KMeansMetric", opts: ClusterOptions{K: 2, Metric: Cosine}},
+		{name: "DBSCAN", opts: ClusterOptions{Algorithm: DBSCAN, Eps: 0.5, MinPoints: 3, Metric: Cosine}, valid: true},
+		{name: "DBSCANEps", opts: ClusterOptions{Algorithm: DBSCAN}},
+		{name: "DBSCANSweep", opts: ClusterOptions{Algorithm: DBSCAN, Eps: 0.5, Sweep: &ClusterRange{Min: 2, Max: 4}}},
+		{name: "HDBSCAN", opts: ClusterOptions{Algorithm: HDBSCAN, MinClusterSize: 10}, valid: true},
+		{name: "HDBSCANMinClusterSize", opts: ClusterOptions{Algorithm: HDBSCAN, MinClusterSize: 1}},
+		{name: "MinPoints", opts: ClusterOptions{Algorithm: HDBSCAN, MinPoints: -1}},
+		{name: "Metric", opts: ClusterOptions{Algorithm: DBSCAN, Eps: 0.5, Metric: "foo"}},
+		{name: "DotMetric", opts: ClusterOptions{Algorithm: HDBSCAN, Metric: Dot}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.opts.Validate(); (err == nil) != tc.valid {
+				t.Fatalf("expected valid: %v, got error: %v", tc.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// DedupMode is the mode of duplicate detection.
+type DedupMode string
+
+const (
+	// DedupOff disables duplicate detection.
+	DedupOff DedupMode = "off"
+	// DedupText detects embeddings of the same text by the hash of their text.
+	DedupText DedupMode = "text"
+	// DedupVector detects embeddings whose cosine similarity reaches the policy threshold.
+	DedupVector DedupMode = "vector"
+)
+
+// DedupAction is the action taken on duplicates of the stored embeddings.
+type DedupAction string
+
+const (
+	// DedupSkip skips the duplicates.
+	DedupSkip DedupAction = "skip"
+	// DedupMerge merges the metadata of the duplicates into the stored embeddings
+	// overwriting the values of their existing metadata keys.
+	DedupMerge DedupAction = "merge"
+)
+
+const (
+	// TextHashMetaKey is the metadata key of the hash of the embedded text.
+	TextHashMetaKey = "text_hash"
+)
+
+// DefaultDedupThreshold is the default cosine similarity threshold of vector duplicates.
+const DefaultDedupThreshold = 0.98
+
+// TextHash returns the hash of the embedded text.
+func TextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// DedupPolicy is the policy of provider embeddings deduplication.
+type DedupPolicy struct {
+	// Mode of duplicate detection.
+	Mode DedupMode `json:"mode"`
+	// Threshold is the minimum cosine similarity of vector duplicates.
+	// It defaults to DefaultDedupThreshold.
+	Threshold float64 `json:"threshold,omitempty"`
+	// Action taken on duplicates.
+	// It defaults to DedupSkip.
+	Action DedupAction `json:"action,omitempty"`
+}
+
+// Validate returns error if the policy is invalid.
+func (p *DedupPolicy) Validate() error {
+	switch p.Mode {
+	case DedupOff, DedupText, DedupVector:
+	default:
+		return fmt.Errorf("invalid mode: %v", p.Mode)
+	}
+	switch p.Action {
+	case "", DedupSkip, DedupMerge:
+	default:
+		return fmt.Errorf("invalid action: %v", p.Action)
+	}
+	if p.Threshold < 0 || p.Threshold > 1 {
+		return fmt.Errorf("invalid threshold: %v", p.Threshold)
+	}
+	return nil
+}
+
+// MinSimilarity returns the minimum cosine similarity of vector duplicates.
+func (p *DedupPolicy) MinSimilarity() float64 {
+	if p.Threshold > 0 {
+		return p.Threshold
+	}
+	return DefaultDedupThreshold
+}
+
+// Duplicates are groups of duplicate provider embeddings.
+type Duplicates struct {
+	// Mode of duplicate detection.
+	Mode DedupMode `json:"mode"`
+	// Threshold is the minimum cosine similarity of vector duplicates.
+	Threshold float64 `json:"threshold,omitempty"`
+	// Groups of duplicate embeddings.
+	Groups [][]Embedding `json:"groups"`
+}
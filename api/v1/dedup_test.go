@@ -0,0 +1,36 @@
+package v1
+
+import "testing"
+
+func TestDedupPolicyValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy DedupPolicy
+		valid  bool
+	}{
+		{name: "Off", policy: DedupPolicy{Mode: DedupOff}, valid: true},
+		{name: "Text", policy: DedupPolicy{Mode: DedupText, Action: DedupMerge}, valid: true},
+		{name: "Vector", policy: DedupPolicy{Mode: DedupVector, Threshold: 0.9, Action: DedupSkip}, valid: true},
+		{name: "Empty", policy: DedupPolicy{}},
+		{name: "Mode", policy: DedupPolicy{Mode: "foo"}},
+		{name: "Action", policy: DedupPolicy{Mode: DedupText, Action: "foo"}},
+		{name: "Threshold", policy: DedupPolicy{Mode: DedupVector, Threshold: 1.1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.policy.Validate(); (err == nil) != tc.valid {
+				t.Fatalf("expected valid: %v, got error: %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestTextHash(t *testing.T) {
+	if TextHash("foo") != TextHash("foo") {
+		t.Fatal("expected equal hashes of the same text")
+	}
+	if TextHash("foo") == TextHash("bar") {
+		t.Fatal("expected different hashes of different texts")
+	}
+}
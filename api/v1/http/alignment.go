@@ -0,0 +1,94 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// AlignProviderProjections aligns provider projections to the projections of the reference provider.
+// Embeddings of both providers are matched by the value of the given metadata key.
+// @Summary Align provider projections to the projections of a reference provider.
+// @Description Returns provider projections aligned to the reference provider projections by Procrustes analysis along with the disparity of the matched projections.
+// @Tags providers
+// @Accept json
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param alignment body v1.AlignmentRequest true "Align provider projections"
+// @Success 200 {object} v1.AlignmentResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/alignment [post]
+func (s *Server) AlignProviderProjections(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	req := new(v1.AlignmentRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	ref, err := uuid.Parse(req.Reference)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("invalid reference provider: %v", err),
+		})
+	}
+
+	projection := v1.PCA
+	if req.Projection != "" {
+		projection = v1.Projection(strings.ToLower(string(req.Projection)))
+		if _, ok := v1.GetProjector(projection); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid projection: %v", req.Projection),
+			})
+		}
+	}
+
+	dim := v1.Dim2D
+	if req.Dim != "" {
+		dim = v1.Dim(strings.ToUpper(string(req.Dim)))
+		if !dim.Valid() {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid dimension: %v", req.Dim),
+			})
+		}
+	}
+
+	key := v1.LabelMetaKey
+	if req.Key != "" {
+		key = req.Key
+	}
+
+	alignment, err := s.ProvidersService.AlignProviderProjections(c.UserContext(), uid.String(), ref.String(), projection, dim, key)
+	if err != nil {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.EINVALID:
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.AlignmentResponse{
+		Alignment: alignment,
+	})
+}
@@ -0,0 +1,148 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func MustSeedLabeledEmbeddings(t *testing.T, ps v1.ProvidersService, p *v1.Provider) {
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 2.0, 3.0, 4.0}, Metadata: map[string]any{v1.LabelMetaKey: "a"}},
+		{Values: []float64{2.0, 1.0, 4.0, 3.0}, Metadata: map[string]any{v1.LabelMetaKey: "b"}},
+		{Values: []float64{4.0, 3.0, 2.0, 1.0}, Metadata: map[string]any{v1.LabelMetaKey: "c"}},
+		{Values: []float64{3.0, 4.0, 1.0, 2.0}, Metadata: map[string]any{v1.LabelMetaKey: "d"}},
+	}
+	if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAlignProviderProjections(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 2)
+		for _, p := range px {
+			MustSeedLabeledEmbeddings(t, ps, p)
+		}
+
+		testBody, err := json.Marshal(v1.AlignmentRequest{Reference: px[0].UID, Dim: "2d"})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/alignment", px[1].UID)
+		req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		res := new(v1.AlignmentResponse)
+		if err := json.Unmarshal(body, res); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		a := res.Alignment
+		if a.Reference != px[0].UID || a.Projection != v1.PCA || a.Dim != v1.Dim2D || a.Key != v1.LabelMetaKey {
+			t.Fatalf("unexpected alignment: %#v", a)
+		}
+		if a.Matched != 4 || len(a.Embeddings) != 4 {
+			t.Fatalf("expected 4 aligned projections, got: %d", len(a.Embeddings))
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 2)
+		for _, p := range px {
+			MustSeedLabeledEmbeddings(t, ps, p)
+		}
+
+		reqs := []v1.AlignmentRequest{
+			{Reference: "foo"},
+			{Reference: px[0].UID, Projection: "foo"},
+			{Reference: px[0].UID, Dim: "0D"},
+			{Reference: px[0].UID, Dim: v1.Dim1D},
+			{Reference: px[0].UID, Key: "foo"},
+		}
+
+		for _, r := range reqs {
+			testBody, err := json.Marshal(r)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/alignment", px[1].UID)
+			req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("%#v: expected status code: %d, got: %d", r, http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		testBody, err := json.Marshal(v1.AlignmentRequest{Reference: "97153afd-c434-4ca0-a35b-7467fcd08df1"})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/alignment", px[0].UID)
+		req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// ClusterProviderEmbeddings schedules a job which clusters embeddings of the provider with the given uid.
+// Cluster IDs and distances to the cluster centroids are stored in the embeddings metadata
+// so the provider projections can be coloured by cluster.
+// Embeddings marked as noise by density-based clustering are assigned v1.NoiseCluster.
+// @Summary Schedule clustering embeddings of the provider with the given UID.
+// @Description Schedules a job which clusters the original provider embeddings and stores the cluster assignments in the embeddings metadata. Density-based clustering (dbscan, hdbscan) marks outliers with the noise cluster -1. The finished job result contains cluster centroids, inertia and the number of noise embeddings, plus inertia and silhouette scores of the requested range of k. Returns the scheduled job.
+// @Tags providers
+// @Accept json
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param clusters body v1.ClusterOptions true "Clustering options"
+// @Success 202 {object} v1.Job{result=v1.Clustering}
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 409 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/clusters [post]
+func (s *Server) ClusterProviderEmbeddings(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	req := new(v1.ClusterOptions)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if req.Algorithm == "" {
+		req.Algorithm = v1.KMeans
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return s.addJob(c, v1.ClustersJob, uid.String(), func(ctx context.Context) error {
+		clustering, err := s.ProvidersService.ClusterProviderEmbeddings(ctx, uid.String(), req)
+		if err != nil {
+			return err
+		}
+		v1.ReportResult(ctx, clustering)
+		return nil
+	})
+}
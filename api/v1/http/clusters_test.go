@@ -0,0 +1,206 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func TestClusterProviderEmbeddings(t *testing.T) {
+	t.Run("202", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		seed := int64(1)
+		testCases := []struct {
+			opts   v1.ClusterOptions
+			status v1.JobStatus
+		}{
+			{opts: v1.ClusterOptions{K: 2, Seed: &seed, Sweep: &v1.ClusterRange{Min: 2, Max: 3}}, status: v1.JobDone},
+			// NOTE: there are only 4 embeddings
+			{opts: v1.ClusterOptions{K: 5}, status: v1.JobFailed},
+		}
+
+		for _, tc := range testCases {
+			testBody, err := json.Marshal(tc.opts)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/clusters", px[0].UID)
+			req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusAccepted {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+
+			job := new(v1.Job)
+			if err := json.Unmarshal(body, job); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if job.Kind != v1.ClustersJob {
+				t.Fatalf("expected job kind: %s, got: %s", v1.ClustersJob, job.Kind)
+			}
+
+			job = MustWaitJob(t, s.JobsService, job.UID)
+			if job.Status != tc.status {
+				t.Fatalf("expected job status: %s, got: %s (%s)", tc.status, job.Status, job.Error)
+			}
+			if tc.status != v1.JobDone {
+				continue
+			}
+
+			c, ok := job.Result.(*v1.Clustering)
+			if !ok {
+				t.Fatalf("unexpected job result: %#v", job.Result)
+			}
+			if c.Algorithm != v1.KMeans || c.K != 2 || c.Seed != seed {
+				t.Fatalf("unexpected clustering: %#v", c)
+			}
+			if len(c.Centroids) != 2 || len(c.Sweep) != 2 {
+				t.Fatalf("expected 2 centroids and 2 scores, got: %d, %d", len(c.Centroids), len(c.Sweep))
+			}
+		}
+	})
+
+	t.Run("202/WriteTimeout", func(t *testing.T) {
+		timeout := 10 * time.Millisecond
+		s, err := NewServer(WithWriteTimeout(timeout))
+		if err != nil {
+			t.Fatalf("failed to created new server: %v", err)
+		}
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		// NOTE: the embeddings are clustered for much longer than the write timeout
+		s.ProvidersService = &slowProvidersService{ProvidersService: ps, steps: 5, interval: timeout}
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+
+		testBody, err := json.Marshal(v1.ClusterOptions{K: 2})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/clusters", px[0].UID)
+		req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusAccepted {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+		}
+
+		job := new(v1.Job)
+		if err := json.NewDecoder(resp.Body).Decode(job); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		job = MustWaitJob(t, s.JobsService, job.UID)
+		if job.Status != v1.JobDone {
+			t.Fatalf("expected job status: %s, got: %s (%s)", v1.JobDone, job.Status, job.Error)
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		testCases := []struct {
+			uid  string
+			opts v1.ClusterOptions
+		}{
+			{uid: "foo", opts: v1.ClusterOptions{K: 2}},
+			{uid: uid, opts: v1.ClusterOptions{}},
+			{uid: uid, opts: v1.ClusterOptions{Algorithm: "foo", K: 2}},
+			{uid: uid, opts: v1.ClusterOptions{K: 2, Sweep: &v1.ClusterRange{Min: 3, Max: 2}}},
+			{uid: uid, opts: v1.ClusterOptions{Algorithm: v1.DBSCAN}},
+			{uid: uid, opts: v1.ClusterOptions{Algorithm: v1.DBSCAN, Eps: 1, Sweep: &v1.ClusterRange{Min: 2, Max: 3}}},
+			{uid: uid, opts: v1.ClusterOptions{K: 2, Metric: v1.Cosine}},
+		}
+
+		for _, tc := range testCases {
+			testBody, err := json.Marshal(tc.opts)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/clusters", tc.uid)
+			req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		testBody, err := json.Marshal(v1.ClusterOptions{K: 2})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/clusters", "97153afd-c434-4ca0-a35b-7467fcd08df1")
+		req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// UpdateProviderDedup sets the dedup policy of the provider with the given uid.
+// The policy is applied when updating the provider embeddings.
+// @Summary Set dedup policy for the provider with the given UID.
+// @Description Sets the policy skipping or merging duplicates of the stored embeddings when updating provider embeddings.
+// @Tags providers
+// @Accept json
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param policy body v1.DedupPolicy true "Dedup policy"
+// @Success 200 {object} v1.DedupResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/dedup [put]
+func (s *Server) UpdateProviderDedup(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	req := new(v1.DedupPolicy)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	policy, err := s.ProvidersService.UpdateProviderDedup(c.UserContext(), uid.String(), req)
+	if err != nil {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.EINVALID:
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.DedupResponse{
+		Policy: policy,
+	})
+}
+
+// GetProviderDedup returns the dedup policy of the provider with the given uid.
+// @Summary Get dedup policy of the provider with the given UID.
+// @Description Returns the provider dedup policy. Dedup is off unless the policy has been set.
+// @Tags providers
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Success 200 {object} v1.DedupResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/dedup [get]
+func (s *Server) GetProviderDedup(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	policy, err := s.ProvidersService.GetProviderDedup(c.UserContext(), uid.String())
+	if err != nil {
+		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.DedupResponse{
+		Policy: policy,
+	})
+}
+
+// GetProviderDuplicates returns the groups of duplicate embeddings of the provider with the given uid.
+// Unless the mode is given, duplicates are detected using the provider dedup policy
+// or by their vector similarity if dedup is off.
+// @Summary Get duplicate embeddings of the provider with the given UID.
+// @Description Returns groups of stored embeddings with the same text hash or with cosine similarity reaching the threshold.
+// @Tags providers
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param mode query string false "Dedup mode: text or vector"
+// @Param threshold query number false "Minimum cosine similarity of vector duplicates, defaults to 0.98"
+// @Success 200 {object} v1.DuplicatesResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/duplicates [get]
+func (s *Server) GetProviderDuplicates(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	policy := new(v1.DedupPolicy)
+	if m := c.Query("mode"); m != "" {
+		policy.Mode = v1.DedupMode(strings.ToLower(m))
+		if policy.Mode != v1.DedupText && policy.Mode != v1.DedupVector {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid mode: %v", m),
+			})
+		}
+	}
+	if t := c.Query("threshold"); t != "" {
+		policy.Threshold, err = strconv.ParseFloat(t, 64)
+		if err != nil || policy.Threshold <= 0 || policy.Threshold > 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid threshold: %v", t),
+			})
+		}
+	}
+
+	if policy.Mode == "" {
+		cur, err := s.ProvidersService.GetProviderDedup(c.UserContext(), uid.String())
+		if err != nil {
+			if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+				return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+					Error: err.Error(),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		policy.Mode = v1.DedupVector
+		if cur.Mode != v1.DedupOff {
+			policy.Mode = cur.Mode
+		}
+		if policy.Threshold == 0 {
+			policy.Threshold = cur.Threshold
+		}
+	}
+
+	dups, err := s.ProvidersService.GetProviderDuplicates(c.UserContext(), uid.String(), policy)
+	if err != nil {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.EINVALID:
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.DuplicatesResponse{
+		Duplicates: dups,
+	})
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func TestProviderDedup(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		testBody, err := json.Marshal(v1.DedupPolicy{Mode: v1.DedupVector, Threshold: 0.9})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/dedup", px[0].UID)
+		req := httptest.NewRequest("PUT", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		req = httptest.NewRequest("GET", urlPath, nil)
+		resp, err = s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		res := new(v1.DedupResponse)
+		if err := json.Unmarshal(body, res); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if res.Policy.Mode != v1.DedupVector || res.Policy.Threshold != 0.9 {
+			t.Fatalf("unexpected policy: %#v", res.Policy)
+		}
+
+		// NOTE: duplicates are detected using the provider policy
+		urlPath = fmt.Sprintf("/api/v1/providers/%s/duplicates", px[0].UID)
+		req = httptest.NewRequest("GET", urlPath, nil)
+		resp, err = s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		dupRes := new(v1.DuplicatesResponse)
+		if err := json.Unmarshal(body, dupRes); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		dups := dupRes.Duplicates
+		if dups.Mode != v1.DedupVector || dups.Threshold != 0.9 {
+			t.Fatalf("unexpected duplicates: %#v", dups)
+		}
+		labels := make([]string, 0, 4)
+		for _, g := range dups.Groups {
+			for _, e := range g {
+				labels = append(labels, e.Metadata[v1.LabelMetaKey].(string))
+			}
+		}
+		if len(dups.Groups) != 2 || fmt.Sprint(labels) != "[a b c d]" {
+			t.Fatalf("expected groups: [a b], [c d], got: %v", labels)
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		testCases := []struct {
+			uid    string
+			policy v1.DedupPolicy
+		}{
+			{uid: "foo", policy: v1.DedupPolicy{Mode: v1.DedupText}},
+			{uid: uid, policy: v1.DedupPolicy{Mode: "foo"}},
+			{uid: uid, policy: v1.DedupPolicy{Mode: v1.DedupText, Action: "foo"}},
+			{uid: uid, policy: v1.DedupPolicy{Mode: v1.DedupVector, Threshold: 2}},
+		}
+
+		for _, tc := range testCases {
+			testBody, err := json.Marshal(tc.policy)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/dedup", tc.uid)
+			req := httptest.NewRequest("PUT", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusBadRequest, code)
+			}
+		}
+
+		for _, query := range []string{"mode=off", "mode=foo", "threshold=foo", "threshold=0", "threshold=1.5"} {
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/duplicates?%s", uid, query)
+			req := httptest.NewRequest("GET", urlPath, nil)
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("query %q expected status code: %d, got: %d", query, http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		uid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+		for _, urlPath := range []string{
+			fmt.Sprintf("/api/v1/providers/%s/dedup", uid),
+			fmt.Sprintf("/api/v1/providers/%s/duplicates", uid),
+		} {
+			req := httptest.NewRequest("GET", urlPath, nil)
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusNotFound {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+			}
+		}
+	})
+}
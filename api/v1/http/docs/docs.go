@@ -65,6 +65,124 @@ const docTemplate = `{
                 }
             }
         },
+        "/v1/jobs/{uid}": {
+            "get": {
+                "description": "Returns the status and progress of the job with the given UID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Get job by UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job UID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Cancels the job with the given UID. Running jobs are cancelled asynchronously.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Cancel job by UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job UID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projections": {
+            "get": {
+                "description": "Returns all available projection algorithms and their parameters.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "projections"
+                ],
+                "summary": "Get projection algorithms.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.AlgorithmsResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/providers": {
             "get": {
                 "description": "Get all available providers.",
@@ -152,42 +270,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/providers/{uid}/embeddings": {
-            "get": {
-                "description": "Returns embeddings for the provider with the given UID.",
+        "/v1/providers/{uid}/alignment": {
+            "post": {
+                "description": "Returns provider projections aligned to the reference provider projections by Procrustes analysis along with the disparity of the matched projections.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "providers"
                 ],
-                "summary": "Get embeddings by provider UID.",
+                "summary": "Align provider projections to the projections of a reference provider.",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Provider UID",
-                        "name": "id",
+                        "name": "uid",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Result offset",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Result limit",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "Align provider projections",
+                        "name": "alignment",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.AlignmentRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.EmbeddingsResponse"
+                            "$ref": "#/definitions/v1.AlignmentResponse"
                         }
                     },
                     "400": {
@@ -209,9 +327,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update provider embeddings.",
+            }
+        },
+        "/v1/providers/{uid}/clusters": {
+            "post": {
+                "description": "Schedules a job which clusters the original provider embeddings and stores the cluster assignments in the embeddings metadata. Density-based clustering (dbscan, hdbscan) marks outliers with the noise cluster -1. The finished job result contains cluster centroids, inertia and the number of noise embeddings, plus inertia and silhouette scores of the requested range of k. Returns the scheduled job.",
                 "consumes": [
                     "application/json"
                 ],
@@ -221,33 +341,95 @@ const docTemplate = `{
                 "tags": [
                     "providers"
                 ],
-                "summary": "Fetch and store embeddings for the provider with the given UID.",
+                "summary": "Schedule clustering embeddings of the provider with the given UID.",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Provider UID",
-                        "name": "id",
+                        "name": "uid",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Update provider embeddings",
-                        "name": "provider",
+                        "description": "Clustering options",
+                        "name": "clusters",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.EmbeddingsUpdate"
+                            "$ref": "#/definitions/v1.ClusterOptions"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/v1.Job"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "result": {
+                                            "$ref": "#/definitions/v1.Clustering"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
                         }
                     }
+                }
+            }
+        },
+        "/v1/providers/{uid}/dedup": {
+            "get": {
+                "description": "Returns the provider dedup policy. Dedup is off unless the policy has been set.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Get dedup policy of the provider with the given UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/v1.Embedding"
-                            }
+                            "$ref": "#/definitions/v1.DedupResponse"
                         }
                     },
                     "400": {
@@ -270,15 +452,18 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "Delete embeddings by provider UID. This also drops projections.",
+            "put": {
+                "description": "Sets the policy skipping or merging duplicates of the stored embeddings when updating provider embeddings.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "providers"
                 ],
-                "summary": "Delete embeddings by provider UID.",
+                "summary": "Set dedup policy for the provider with the given UID.",
                 "parameters": [
                     {
                         "type": "string",
@@ -286,13 +471,22 @@ const docTemplate = `{
                         "name": "uid",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Dedup policy",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.DedupPolicy"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Provider embeddings deleted successfully",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/v1.DedupResponse"
                         }
                     },
                     "400": {
@@ -301,6 +495,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/v1.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -310,34 +510,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/providers/{uid}/projections": {
+        "/v1/providers/{uid}/duplicates": {
             "get": {
-                "description": "Returns embedding projections for the provider with the given UID.",
+                "description": "Returns groups of stored embeddings with the same text hash or with cosine similarity reaching the threshold.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "providers"
                 ],
-                "summary": "Get embeddings projections by provider UID.",
+                "summary": "Get duplicate embeddings of the provider with the given UID.",
                 "parameters": [
                     {
                         "type": "string",
                         "description": "Provider UID",
-                        "name": "id",
+                        "name": "uid",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Result offset",
-                        "name": "offset",
+                        "type": "string",
+                        "description": "Dedup mode: text or vector",
+                        "name": "mode",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Result limit",
-                        "name": "limit",
+                        "type": "number",
+                        "description": "Minimum cosine similarity of vector duplicates, defaults to 0.98",
+                        "name": "threshold",
                         "in": "query"
                     }
                 ],
@@ -345,7 +545,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.ProjectionsResponse"
+                            "$ref": "#/definitions/v1.DuplicatesResponse"
                         }
                     },
                     "400": {
@@ -367,19 +567,18 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Recompute provider projections.",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/v1/providers/{uid}/embeddings": {
+            "get": {
+                "description": "Returns embeddings for the provider with the given UID.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "providers"
                 ],
-                "summary": "Recompute embeddings projections for a provider by UID and return them.",
+                "summary": "Get embeddings by provider UID.",
                 "parameters": [
                     {
                         "type": "string",
@@ -389,20 +588,23 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Update embeddings projections",
-                        "name": "provider",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.ProjectionsUpdate"
-                        }
+                        "type": "integer",
+                        "description": "Result offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Result limit",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.Embedding"
+                            "$ref": "#/definitions/v1.EmbeddingsResponse"
                         }
                     },
                     "400": {
@@ -424,208 +626,2319 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "v1.Chunking": {
+            },
+            "put": {
+                "description": "Update provider embeddings.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Fetch and store embeddings for the provider with the given UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update provider embeddings",
+                        "name": "provider",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.EmbeddingsUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v1.Embedding"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete embeddings by provider UID. This also drops projections.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Delete embeddings by provider UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Provider embeddings deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/embeddings/{eid}/neighbors": {
+            "get": {
+                "description": "Returns k stored embeddings nearest to the embedding with the given UID in the original vector space. Cosine and dot scores are similarities, euclidean scores are distances.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Get neighbours of provider embedding.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Embedding UID",
+                        "name": "eid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of neighbours, defaults to 10",
+                        "name": "k",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Distance metric: cosine, dot or euclidean, defaults to cosine",
+                        "name": "metric",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.NeighboursResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/matrix": {
+            "get": {
+                "description": "Returns the current version of the provider projection matrix.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Get projection matrix of the provider with the given UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.MatrixResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Stores a new version of the provider projection matrix used by the matrix projection.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Upload projection matrix for the provider with the given UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Projection matrix",
+                        "name": "matrix",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ProjectionMatrix"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.MatrixResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete provider projection matrix. Projections computed using the matrix are kept until they're recomputed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Delete projection matrix of the provider with the given UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Provider matrix deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/outliers": {
+            "post": {
+                "description": "Schedules a job which scores the original provider embeddings for outlierness using local outlier factor (lof) or the distance to the k-th nearest neighbour (knn) and stores the scores in the embeddings metadata. The finished job result contains the top outliers. Returns the scheduled job.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Schedule detecting outliers of embeddings of the provider with the given UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Outlier detection options",
+                        "name": "outliers",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.OutlierOptions"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/v1.Job"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "result": {
+                                            "$ref": "#/definitions/v1.Outliers"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/projections": {
+            "get": {
+                "description": "Returns embedding projections for the provider with the given UID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Get embeddings projections by provider UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Result offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Result limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Projection dimension, e.g. 2D",
+                        "name": "dim",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Projection algorithm",
+                        "name": "projection",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ProjectionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Schedule recomputing provider projections or computing a named view. Returns the scheduled job.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Schedule recomputing embeddings projections for a provider by UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update embeddings projections",
+                        "name": "provider",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ProjectionsUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/v1.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/projections/query": {
+            "post": {
+                "description": "Returns estimated projections of the query text without storing them. Linear projections use their fitted transform, non-linear projections place the query among its nearest neighbours.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Place a query text into the projections of the provider with the given UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Projection query",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ProjectionQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ProjectionQueryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/projections/stream": {
+            "get": {
+                "description": "Recomputes provider projections and streams intermediate layouts of iterative algorithms as server-sent events.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Stream recomputing embeddings projections for a provider by UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Projection algorithm, defaults to tsne",
+                        "name": "projection",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of iterations between streamed layouts",
+                        "name": "every",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Perplexity of t-SNE",
+                        "name": "perplexity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Learning rate",
+                        "name": "learning_rate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of iterations",
+                        "name": "max_iterations",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Random seed",
+                        "name": "seed",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated projection dimensions, e.g. 2D,3D",
+                        "name": "dims",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ProjectionStep"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/search": {
+            "post": {
+                "description": "Embeds the query text and returns the k most similar stored embeddings with their similarity scores and metadata.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Search embeddings of the provider with the given UID.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Search query",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SearchQuery"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.SearchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/similarities": {
+            "post": {
+                "description": "Schedules a job which computes the pairwise similarity matrix of the embeddings selected by their UIDs or by a metadata filter, optionally reordered by hierarchical clustering so the blocks of similar embeddings are adjacent. The finished job result contains the similarity matrix. Returns the scheduled job.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "providers"
+                ],
+                "summary": "Schedule computing pairwise similarities of provider embeddings.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Similarity request",
+                        "name": "similarity",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SimilarityRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/v1.Job"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "result": {
+                                            "$ref": "#/definitions/v1.Similarity"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/views": {
+            "get": {
+                "description": "Returns all views of the provider with the given UID without their projections.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "views"
+                ],
+                "summary": "Get all provider views.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ViewsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/providers/{uid}/views/{name}": {
+            "get": {
+                "description": "Returns the named view of the provider with the given UID including its projections.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "views"
+                ],
+                "summary": "Get provider view by name.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "View name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Result offset",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Result limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Projection dimension, e.g. 2D",
+                        "name": "dim",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ViewResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete the named view of the provider with the given UID.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "views"
+                ],
+                "summary": "Delete provider view by name.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "View name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Provider view deleted successfully",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/v1.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "v1.AlgorithmsResponse": {
+            "type": "object",
+            "properties": {
+                "algorithms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ProjectionAlgorithm"
+                    }
+                }
+            }
+        },
+        "v1.Alignment": {
+            "type": "object",
+            "properties": {
+                "dim": {
+                    "description": "Dim of the aligned projections.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Dim"
+                        }
+                    ]
+                },
+                "disparity": {
+                    "description": "Disparity is the sum of squared differences of the standardised matched projections\nafter the alignment. It ranges from 0 (identical shapes) to 1 (unrelated shapes).",
+                    "type": "number"
+                },
+                "embeddings": {
+                    "description": "Embeddings are the aligned projections of the provider.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.Embedding"
+                    }
+                },
+                "key": {
+                    "description": "Key is the metadata key the embeddings were matched by.",
+                    "type": "string"
+                },
+                "matched": {
+                    "description": "Matched is the number of the matched embeddings.",
+                    "type": "integer"
+                },
+                "projection": {
+                    "description": "Projection algorithm of the aligned projections.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Projection"
+                        }
+                    ]
+                },
+                "reference": {
+                    "description": "Reference is the UID of the provider the projections are aligned to.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.AlignmentRequest": {
+            "type": "object",
+            "properties": {
+                "dim": {
+                    "description": "Dim of the aligned projections.\nIf not set, 2D projections are aligned.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Dim"
+                        }
+                    ]
+                },
+                "key": {
+                    "description": "Key is the metadata key the embeddings of both providers are matched by.\nIf not set, embeddings are matched by their label.",
+                    "type": "string"
+                },
+                "projection": {
+                    "description": "Projection algorithm of the aligned projections.\nIf not set, PCA projections are aligned.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Projection"
+                        }
+                    ]
+                },
+                "reference": {
+                    "description": "Reference is the UID of the provider the projections are aligned to.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.AlignmentResponse": {
+            "type": "object",
+            "properties": {
+                "alignment": {
+                    "$ref": "#/definitions/v1.Alignment"
+                }
+            }
+        },
+        "v1.Anchor": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "description": "Text of the anchor.",
+                    "type": "string"
+                },
+                "uid": {
+                    "description": "UID of the anchor embedding.",
+                    "type": "string"
+                },
+                "values": {
+                    "description": "Values of the anchor embedding.\nThey're set when the anchor text is embedded.",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                }
+            }
+        },
+        "v1.Axis": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "description": "Name of the axis, e.g. negative-positive.",
+                    "type": "string"
+                },
+                "negative": {
+                    "description": "Negative anchor of the axis.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Anchor"
+                        }
+                    ]
+                },
+                "positive": {
+                    "description": "Positive anchor of the axis.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Anchor"
+                        }
+                    ]
+                }
+            }
+        },
+        "v1.Chunking": {
+            "type": "object",
+            "properties": {
+                "overlap": {
+                    "description": "Overlap between chunks.",
+                    "type": "integer"
+                },
+                "sep": {
+                    "description": "Sep keeps separator in chunks.",
+                    "type": "boolean"
+                },
+                "size": {
+                    "description": "Size of each chunk.",
+                    "type": "integer"
+                },
+                "trim": {
+                    "description": "Trim empty space chars.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "v1.ChunkingInput": {
+            "type": "object",
+            "properties": {
+                "input": {
+                    "description": "Input to split into chunks.",
+                    "type": "string"
+                },
+                "options": {
+                    "description": "Options to configure chunking.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Chunking"
+                        }
+                    ]
+                }
+            }
+        },
+        "v1.ChunkingResponse": {
+            "type": "object",
+            "properties": {
+                "chunks": {
+                    "description": "Chunks contain indices into the chunked input.",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                }
+            }
+        },
+        "v1.ClusterAlgorithm": {
+            "type": "string",
+            "enum": [
+                "kmeans",
+                "dbscan",
+                "hdbscan"
+            ],
+            "x-enum-varnames": [
+                "KMeans",
+                "DBSCAN",
+                "HDBSCAN"
+            ]
+        },
+        "v1.ClusterOptions": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "description": "Algorithm used to cluster embeddings.\nIt defaults to KMeans.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ClusterAlgorithm"
+                        }
+                    ]
+                },
+                "batch_size": {
+                    "description": "BatchSize of mini-batch k-means.\nIf not set, mini-batch k-means is only used for large providers.",
+                    "type": "integer"
+                },
+                "eps": {
+                    "description": "Eps is the maximum distance of neighbouring embeddings in DBSCAN.",
+                    "type": "number"
+                },
+                "k": {
+                    "description": "K is the number of k-means clusters.",
+                    "type": "integer"
+                },
+                "max_iterations": {
+                    "description": "MaxIterations of the clustering algorithm.",
+                    "type": "integer"
+                },
+                "metric": {
+                    "description": "Metric measuring the distance of embeddings in density-based clustering.\nIt defaults to Euclidean. Dot product is not a distance so it is not supported.\nK-means only supports Euclidean metric.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Metric"
+                        }
+                    ]
+                },
+                "min_cluster_size": {
+                    "description": "MinClusterSize is the minimum size of HDBSCAN clusters.",
+                    "type": "integer"
+                },
+                "min_points": {
+                    "description": "MinPoints is the number of neighbours, including the embedding itself,\nwhich make an embedding a core point in density-based clustering.",
+                    "type": "integer"
+                },
+                "seed": {
+                    "description": "Seed is the random seed.",
+                    "type": "integer"
+                },
+                "sweep": {
+                    "description": "Sweep evaluates clustering for the given range of k.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ClusterRange"
+                        }
+                    ]
+                }
+            }
+        },
+        "v1.ClusterRange": {
+            "type": "object",
+            "properties": {
+                "max": {
+                    "type": "integer"
+                },
+                "min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.ClusterScore": {
+            "type": "object",
+            "properties": {
+                "inertia": {
+                    "description": "Inertia is the sum of squared distances of embeddings to their cluster centroids.",
+                    "type": "number"
+                },
+                "k": {
+                    "description": "K is the number of clusters.",
+                    "type": "integer"
+                },
+                "silhouette": {
+                    "description": "Silhouette is the mean silhouette coefficient of the clustering.\nhttps://en.wikipedia.org/wiki/Silhouette_(clustering)",
+                    "type": "number"
+                }
+            }
+        },
+        "v1.Clustering": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "description": "Algorithm used to cluster embeddings.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ClusterAlgorithm"
+                        }
+                    ]
+                },
+                "centroids": {
+                    "description": "Centroids of the clusters indexed by cluster ID.\nCentroids of density-based clusters are the means of their embeddings.",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "inertia": {
+                    "description": "Inertia is the sum of squared distances of embeddings to their cluster centroids.",
+                    "type": "number"
+                },
+                "iterations": {
+                    "description": "Iterations run until convergence.",
+                    "type": "integer"
+                },
+                "k": {
+                    "description": "K is the number of clusters, excluding noise.",
+                    "type": "integer"
+                },
+                "metric": {
+                    "description": "Metric measuring the distance of embeddings.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Metric"
+                        }
+                    ]
+                },
+                "mini_batch": {
+                    "description": "MiniBatch is true if mini-batch k-means was used.",
+                    "type": "boolean"
+                },
+                "noise": {
+                    "description": "Noise is the number of embeddings marked as noise.",
+                    "type": "integer"
+                },
+                "seed": {
+                    "description": "Seed used to cluster embeddings.",
+                    "type": "integer"
+                },
+                "silhouette": {
+                    "description": "Silhouette is the mean silhouette coefficient of the clustering.\nEmbeddings marked as noise are excluded from it.",
+                    "type": "number"
+                },
+                "sizes": {
+                    "description": "Sizes of the clusters indexed by cluster ID.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "sweep": {
+                    "description": "Sweep scores clustering for the requested range of k.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ClusterScore"
+                    }
+                }
+            }
+        },
+        "v1.DedupAction": {
+            "type": "string",
+            "enum": [
+                "skip",
+                "merge"
+            ],
+            "x-enum-varnames": [
+                "DedupSkip",
+                "DedupMerge"
+            ]
+        },
+        "v1.DedupMode": {
+            "type": "string",
+            "enum": [
+                "off",
+                "text",
+                "vector"
+            ],
+            "x-enum-varnames": [
+                "DedupOff",
+                "DedupText",
+                "DedupVector"
+            ]
+        },
+        "v1.DedupPolicy": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "Action taken on duplicates.\nIt defaults to DedupSkip.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.DedupAction"
+                        }
+                    ]
+                },
+                "mode": {
+                    "description": "Mode of duplicate detection.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.DedupMode"
+                        }
+                    ]
+                },
+                "threshold": {
+                    "description": "Threshold is the minimum cosine similarity of vector duplicates.\nIt defaults to DefaultDedupThreshold.",
+                    "type": "number"
+                }
+            }
+        },
+        "v1.DedupResponse": {
+            "type": "object",
+            "properties": {
+                "policy": {
+                    "$ref": "#/definitions/v1.DedupPolicy"
+                }
+            }
+        },
+        "v1.Dim": {
+            "type": "string",
+            "enum": [
+                "1D",
+                "2D",
+                "3D"
+            ],
+            "x-enum-varnames": [
+                "Dim1D",
+                "Dim2D",
+                "Dim3D"
+            ]
+        },
+        "v1.Duplicates": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "description": "Groups of duplicate embeddings.",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/v1.Embedding"
+                        }
+                    }
+                },
+                "mode": {
+                    "description": "Mode of duplicate detection.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.DedupMode"
+                        }
+                    ]
+                },
+                "threshold": {
+                    "description": "Threshold is the minimum cosine similarity of vector duplicates.",
+                    "type": "number"
+                }
+            }
+        },
+        "v1.DuplicatesResponse": {
+            "type": "object",
+            "properties": {
+                "duplicates": {
+                    "$ref": "#/definitions/v1.Duplicates"
+                }
+            }
+        },
+        "v1.Embedding": {
+            "type": "object",
+            "properties": {
+                "metadata": {
+                    "description": "Metadata for the given embedding vector.",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "uid": {
+                    "description": "UID is the unique ID for this embedding.",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Values stores embedding vector values.\nNOTE: the key is set to value - singular\nbecause the API is consumed by ECharts and\nit's just sad ECharts expects value slice.\nWe could handle that in JS but who can be bothered?",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                }
+            }
+        },
+        "v1.EmbeddingsFilter": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "description": "Color of the embeddings.",
+                    "type": "string"
+                },
+                "label": {
+                    "description": "Label supplied when the embeddings were embedded.\nIt matches the raw label rather than the label joined with the embedded text.",
+                    "type": "string"
+                },
+                "metadata": {
+                    "description": "Metadata key-value pairs of the embeddings.",
+                    "type": "object",
+                    "additionalProperties": {}
+                }
+            }
+        },
+        "v1.EmbeddingsResponse": {
+            "type": "object",
+            "properties": {
+                "embeddings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.Embedding"
+                    }
+                },
+                "page": {
+                    "$ref": "#/definitions/v1.Page"
+                }
+            }
+        },
+        "v1.EmbeddingsUpdate": {
             "type": "object",
             "properties": {
-                "overlap": {
-                    "description": "Overlap between chunks.",
+                "chunking": {
+                    "$ref": "#/definitions/v1.Chunking"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "options": {
+                    "$ref": "#/definitions/v1.ProjectionOptions"
+                },
+                "projection": {
+                    "$ref": "#/definitions/v1.Projection"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.Job": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "description": "CreatedAt is the time the job was created.",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error is set if the job has failed.",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "UID of the job.",
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "Kind of the job.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.JobKind"
+                        }
+                    ]
+                },
+                "progress": {
+                    "description": "Progress of the job in the range [0, 1].",
+                    "type": "number"
+                },
+                "provider": {
+                    "description": "Provider UID the job runs for.",
+                    "type": "string"
+                },
+                "result": {
+                    "description": "Result is set if the job has finished successfully and it reported any."
+                },
+                "status": {
+                    "description": "Status of the job.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.JobStatus"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "description": "UpdatedAt is the time the job was last updated.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.JobKind": {
+            "type": "string",
+            "enum": [
+                "projections",
+                "view",
+                "clusters",
+                "outliers",
+                "similarity"
+            ],
+            "x-enum-varnames": [
+                "ProjectionsJob",
+                "ViewJob",
+                "ClustersJob",
+                "OutliersJob",
+                "SimilarityJob"
+            ]
+        },
+        "v1.JobStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "running",
+                "done",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-varnames": [
+                "JobPending",
+                "JobRunning",
+                "JobDone",
+                "JobFailed",
+                "JobCancelled"
+            ]
+        },
+        "v1.MatrixResponse": {
+            "type": "object",
+            "properties": {
+                "matrix": {
+                    "$ref": "#/definitions/v1.ProjectionMatrix"
+                }
+            }
+        },
+        "v1.Metric": {
+            "type": "string",
+            "enum": [
+                "cosine",
+                "dot",
+                "euclidean"
+            ],
+            "x-enum-varnames": [
+                "Cosine",
+                "Dot",
+                "Euclidean"
+            ]
+        },
+        "v1.NeighboursResponse": {
+            "type": "object",
+            "properties": {
+                "embeddings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ScoredEmbedding"
+                    }
+                },
+                "metric": {
+                    "$ref": "#/definitions/v1.Metric"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.OutlierMethod": {
+            "type": "string",
+            "enum": [
+                "lof",
+                "knn"
+            ],
+            "x-enum-varnames": [
+                "LOF",
+                "KNN"
+            ]
+        },
+        "v1.OutlierOptions": {
+            "type": "object",
+            "properties": {
+                "k": {
+                    "description": "K is the number of neighbours.\nIt defaults to DefaultOutlierNeighbours.",
                     "type": "integer"
                 },
-                "sep": {
-                    "description": "Sep keeps separator in chunks.",
+                "method": {
+                    "description": "Method of outlier detection.\nIt defaults to LOF.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.OutlierMethod"
+                        }
+                    ]
+                },
+                "metric": {
+                    "description": "Metric measuring the distance of embeddings.\nIt defaults to Euclidean. Dot product is not a distance so it is not supported.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Metric"
+                        }
+                    ]
+                },
+                "top": {
+                    "description": "Top is the number of the top outliers returned.\nIt defaults to DefaultOutlierTop.",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.Outliers": {
+            "type": "object",
+            "properties": {
+                "embeddings": {
+                    "description": "Embeddings with the highest outlier scores sorted from the highest score.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ScoredEmbedding"
+                    }
+                },
+                "k": {
+                    "description": "K is the number of neighbours.",
+                    "type": "integer"
+                },
+                "method": {
+                    "description": "Method of outlier detection.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.OutlierMethod"
+                        }
+                    ]
+                },
+                "metric": {
+                    "description": "Metric measuring the distance of embeddings.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Metric"
+                        }
+                    ]
+                }
+            }
+        },
+        "v1.Page": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Count is the number of all\nresults if provided.",
+                    "type": "integer"
+                },
+                "next": {
+                    "description": "Next is either a number\nor a string ID which allows\nresuming paging if provided.",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.Projection": {
+            "type": "string",
+            "enum": [
+                "tsne",
+                "pca",
+                "umap",
+                "mds",
+                "random",
+                "axes",
+                "matrix"
+            ],
+            "x-enum-varnames": [
+                "TSNE",
+                "PCA",
+                "UMAP",
+                "MDS",
+                "Random",
+                "Axes",
+                "Matrix"
+            ]
+        },
+        "v1.ProjectionAlgorithm": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "description": "Description of the algorithm.",
+                    "type": "string"
+                },
+                "max_dim": {
+                    "description": "MaxDim is the maximum projection dimension supported by the algorithm.\nIf it's not set the algorithm supports all the projection dimensions.",
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "Name of the algorithm.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Projection"
+                        }
+                    ]
+                },
+                "params": {
+                    "description": "Params of the algorithm.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ProjectionParam"
+                    }
+                }
+            }
+        },
+        "v1.ProjectionMatrix": {
+            "type": "object",
+            "properties": {
+                "mean": {
+                    "description": "Mean is subtracted from the embeddings before they're projected.",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "name": {
+                    "description": "Name of the matrix.",
+                    "type": "string"
+                },
+                "values": {
+                    "description": "Values of the matrix with a row per embedding dimension\nand a column per projection axis, e.g. dim x 2 or dim x 3.",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "version": {
+                    "description": "Version of the matrix.\nIt's incremented every time the matrix is replaced.",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.ProjectionMetrics": {
+            "type": "object",
+            "properties": {
+                "continuity": {
+                    "description": "Continuity penalizes points which are neighbours\nin the original space but not in the projection.",
+                    "type": "number"
+                },
+                "explained_variance": {
+                    "description": "ExplainedVariance is the ratio of the variance explained\nby each projection component. It's only set for PCA.",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "k": {
+                    "description": "K is the neighbourhood size the metrics were computed for.",
+                    "type": "integer"
+                },
+                "knn_preservation": {
+                    "description": "KNNPreservation is the mean ratio of the k nearest\nneighbours preserved by the projection.",
+                    "type": "number"
+                },
+                "trustworthiness": {
+                    "description": "Trustworthiness penalizes points which are neighbours\nin the projection but not in the original space.",
+                    "type": "number"
+                }
+            }
+        },
+        "v1.ProjectionOptions": {
+            "type": "object",
+            "properties": {
+                "axes": {
+                    "description": "Axes are the semantic axes of the axes projection.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.Axis"
+                    }
+                },
+                "center": {
+                    "description": "Center the data before PCA projection.",
                     "type": "boolean"
                 },
-                "size": {
-                    "description": "Size of each chunk.",
+                "dims": {
+                    "description": "Dims are the projection dimensions to compute.\nIf not set, DefaultDims are computed.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.Dim"
+                    }
+                },
+                "landmarks": {
+                    "description": "Landmarks is the maximum number of embeddings projected by the algorithm.\nProjections of larger collections are computed for a random sample\nof landmark embeddings and interpolated for the remaining embeddings.",
+                    "type": "integer"
+                },
+                "learning_rate": {
+                    "description": "LearningRate of iterative algorithms (t-SNE, UMAP).",
+                    "type": "number"
+                },
+                "matrix": {
+                    "description": "Matrix of the matrix projection.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ProjectionMatrix"
+                        }
+                    ]
+                },
+                "max_iterations": {
+                    "description": "MaxIterations of iterative algorithms (t-SNE, UMAP).",
+                    "type": "integer"
+                },
+                "metric": {
+                    "description": "Metric of MDS: euclidean or cosine distances or dot products are preserved.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Metric"
+                        }
+                    ]
+                },
+                "params": {
+                    "description": "Params are algorithm specific parameters.\nParams named after the other options set those options\nand the remaining params are passed to the projection algorithm.",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "perplexity": {
+                    "description": "Perplexity of t-SNE.",
+                    "type": "number"
+                },
+                "seed": {
+                    "description": "Seed of the random number generator.\nIf not set, a random seed is picked and recorded.",
                     "type": "integer"
                 },
-                "trim": {
-                    "description": "Trim empty space chars.",
-                    "type": "boolean"
+                "staleness": {
+                    "description": "Staleness is the ratio of embeddings added since the projections\nhave been computed which triggers recomputing all projections.",
+                    "type": "number"
+                },
+                "whiten": {
+                    "description": "Whiten scales PCA components to unit variance.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "v1.ProjectionParam": {
+            "type": "object",
+            "properties": {
+                "default": {
+                    "description": "Default value of the parameter."
+                },
+                "description": {
+                    "description": "Description of the parameter.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name of the parameter.\nIt's either the JSON name of a ProjectionOptions field\nor the name of an algorithm specific ProjectionOptions parameter.",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Type of the parameter value: number, integer, boolean, string or array.",
+                    "type": "string"
                 }
             }
         },
-        "v1.ChunkingInput": {
+        "v1.ProjectionQuery": {
             "type": "object",
             "properties": {
-                "input": {
-                    "description": "Input to split into chunks.",
+                "dim": {
+                    "description": "Dim of the projection to place the query into.\nIf not set, the query is placed into all the projection dimensions.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Dim"
+                        }
+                    ]
+                },
+                "label": {
+                    "description": "Label of the query.",
                     "type": "string"
                 },
-                "options": {
-                    "description": "Options to configure chunking.",
+                "projection": {
+                    "description": "Projection to place the query into.\nIf not set, the last computed projection is used.",
                     "allOf": [
                         {
-                            "$ref": "#/definitions/v1.Chunking"
+                            "$ref": "#/definitions/v1.Projection"
                         }
                     ]
+                },
+                "text": {
+                    "description": "Text of the query.",
+                    "type": "string"
                 }
             }
         },
-        "v1.ChunkingResponse": {
+        "v1.ProjectionQueryResponse": {
             "type": "object",
             "properties": {
-                "chunks": {
-                    "description": "Chunks contain indices into the chunked input.",
-                    "type": "array",
-                    "items": {
+                "embeddings": {
+                    "type": "object",
+                    "additionalProperties": {
                         "type": "array",
                         "items": {
-                            "type": "integer"
+                            "$ref": "#/definitions/v1.Embedding"
                         }
                     }
+                },
+                "projection": {
+                    "$ref": "#/definitions/v1.Projection"
                 }
             }
         },
-        "v1.Embedding": {
+        "v1.ProjectionStep": {
             "type": "object",
             "properties": {
-                "metadata": {
-                    "description": "Metadata for the given embedding vector.",
-                    "type": "object",
-                    "additionalProperties": {}
-                },
-                "uid": {
-                    "description": "UID is the unique ID for this embedding.",
-                    "type": "string"
+                "dim": {
+                    "description": "Dim of the layout.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Dim"
+                        }
+                    ]
                 },
-                "value": {
-                    "description": "Values stores embedding vector values.\nNOTE: the key is set to value - singular\nbecause the API is consumed by ECharts and\nit's just sad ECharts expects value slice.\nWe could handle that in JS but who can be bothered?",
+                "embeddings": {
+                    "description": "Embeddings are the intermediate projections.\nNOTE: they only carry UIDs and values.",
                     "type": "array",
                     "items": {
-                        "type": "number"
+                        "$ref": "#/definitions/v1.Embedding"
                     }
+                },
+                "iteration": {
+                    "description": "Iteration of the algorithm the layout was computed in.",
+                    "type": "integer"
+                },
+                "iterations": {
+                    "description": "Iterations is the total number of iterations of the algorithm.",
+                    "type": "integer"
+                },
+                "kl_divergence": {
+                    "description": "KLDivergence of the layout.",
+                    "type": "number"
+                },
+                "projection": {
+                    "description": "Projection algorithm computing the layout.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Projection"
+                        }
+                    ]
                 }
             }
         },
-        "v1.EmbeddingsResponse": {
+        "v1.ProjectionsResponse": {
             "type": "object",
             "properties": {
                 "embeddings": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/v1.Embedding"
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/v1.Embedding"
+                        }
+                    }
+                },
+                "metrics": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/v1.ProjectionMetrics"
                     }
                 },
+                "options": {
+                    "$ref": "#/definitions/v1.ProjectionOptions"
+                },
                 "page": {
                     "$ref": "#/definitions/v1.Page"
+                },
+                "projection": {
+                    "$ref": "#/definitions/v1.Projection"
                 }
             }
         },
-        "v1.EmbeddingsUpdate": {
+        "v1.ProjectionsUpdate": {
             "type": "object",
             "properties": {
-                "chunking": {
-                    "$ref": "#/definitions/v1.Chunking"
-                },
-                "label": {
-                    "type": "string"
+                "filter": {
+                    "$ref": "#/definitions/v1.EmbeddingsFilter"
                 },
                 "metadata": {
                     "type": "object",
                     "additionalProperties": {}
                 },
+                "options": {
+                    "$ref": "#/definitions/v1.ProjectionOptions"
+                },
                 "projection": {
                     "$ref": "#/definitions/v1.Projection"
                 },
-                "text": {
+                "view": {
                     "type": "string"
                 }
             }
         },
-        "v1.ErrorResponse": {
+        "v1.Provider": {
             "type": "object",
             "properties": {
-                "error": {
+                "id": {
+                    "description": "UID of the provider's UUID.",
+                    "type": "string"
+                },
+                "metadata": {
+                    "description": "Metadata about the provider.",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "name": {
+                    "description": "Name is the name of the provider",
                     "type": "string"
                 }
             }
         },
-        "v1.Page": {
+        "v1.ProvidersResponse": {
             "type": "object",
             "properties": {
-                "count": {
-                    "description": "Count is the number of all\nresults if provided.",
-                    "type": "integer"
+                "page": {
+                    "$ref": "#/definitions/v1.Page"
                 },
-                "next": {
-                    "description": "Next is either a number\nor a string ID which allows\nresuming paging if provided.",
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.Provider"
+                    }
+                }
+            }
+        },
+        "v1.ScoredEmbedding": {
+            "type": "object",
+            "properties": {
+                "metadata": {
+                    "description": "Metadata for the given embedding vector.",
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "score": {
+                    "description": "Score is the similarity of the embedding to the query.\nHigher scores mean more similar embeddings except for\nEuclidean metric whose scores are distances.",
+                    "type": "number"
+                },
+                "uid": {
+                    "description": "UID is the unique ID for this embedding.",
                     "type": "string"
+                },
+                "value": {
+                    "description": "Values stores embedding vector values.\nNOTE: the key is set to value - singular\nbecause the API is consumed by ECharts and\nit's just sad ECharts expects value slice.\nWe could handle that in JS but who can be bothered?",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
                 }
             }
         },
-        "v1.Projection": {
-            "type": "string",
-            "enum": [
-                "tsne",
-                "pca"
-            ],
-            "x-enum-varnames": [
-                "TSNE",
-                "PCA"
-            ]
+        "v1.SearchQuery": {
+            "type": "object",
+            "properties": {
+                "k": {
+                    "description": "K is the number of the nearest embeddings returned.\nIt defaults to DefaultSearchLimit.",
+                    "type": "integer"
+                },
+                "text": {
+                    "description": "Text of the query.",
+                    "type": "string"
+                }
+            }
         },
-        "v1.ProjectionsResponse": {
+        "v1.SearchResponse": {
             "type": "object",
             "properties": {
                 "embeddings": {
-                    "type": "object",
-                    "additionalProperties": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ScoredEmbedding"
+                    }
+                }
+            }
+        },
+        "v1.Similarity": {
+            "type": "object",
+            "properties": {
+                "labels": {
+                    "description": "Labels of the embeddings in the order of the matrix rows and columns.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "metric": {
+                    "description": "Metric measuring the similarity of the embeddings.\nEuclidean similarities are distances.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Metric"
+                        }
+                    ]
+                },
+                "reordered": {
+                    "description": "Reordered is true if the embeddings are ordered by hierarchical clustering.",
+                    "type": "boolean"
+                },
+                "uids": {
+                    "description": "UIDs of the embeddings in the order of the matrix rows and columns.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "values": {
+                    "description": "Values of the similarity matrix.",
+                    "type": "array",
+                    "items": {
                         "type": "array",
                         "items": {
-                            "$ref": "#/definitions/v1.Embedding"
+                            "type": "number"
                         }
                     }
-                },
-                "page": {
-                    "$ref": "#/definitions/v1.Page"
                 }
             }
         },
-        "v1.ProjectionsUpdate": {
+        "v1.SimilarityRequest": {
             "type": "object",
             "properties": {
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": {}
+                "filter": {
+                    "description": "Filter selecting the embeddings.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.EmbeddingsFilter"
+                        }
+                    ]
                 },
-                "projection": {
-                    "$ref": "#/definitions/v1.Projection"
+                "metric": {
+                    "description": "Metric measuring the similarity of the embeddings.\nIt defaults to DefaultSimilarityMetric.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Metric"
+                        }
+                    ]
+                },
+                "reorder": {
+                    "description": "Reorder orders the embeddings by hierarchical clustering\nso the blocks of similar embeddings are adjacent.",
+                    "type": "boolean"
+                },
+                "uids": {
+                    "description": "UIDs of the embeddings.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "v1.Provider": {
+        "v1.View": {
             "type": "object",
             "properties": {
-                "id": {
-                    "description": "UID of the provider's UUID.",
-                    "type": "string"
+                "count": {
+                    "description": "Count is the number of the projected embeddings.",
+                    "type": "integer"
                 },
-                "metadata": {
-                    "description": "Metadata about the provider.",
+                "embeddings": {
+                    "description": "Embeddings projections keyed by projection dimension.\nThey're only set when fetching a single view.",
                     "type": "object",
-                    "additionalProperties": {}
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/v1.Embedding"
+                        }
+                    }
+                },
+                "filter": {
+                    "description": "Filter selecting the projected embeddings.\nIf not set, all the provider embeddings are projected.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.EmbeddingsFilter"
+                        }
+                    ]
+                },
+                "metrics": {
+                    "description": "Metrics of the view projections keyed by projection dimension.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/v1.ProjectionMetrics"
+                    }
                 },
                 "name": {
-                    "description": "Name is the name of the provider",
+                    "description": "Name of the view.",
                     "type": "string"
+                },
+                "options": {
+                    "description": "Options used to compute the view projections.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.ProjectionOptions"
+                        }
+                    ]
+                },
+                "projection": {
+                    "description": "Projection algorithm used to compute the view projections.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/v1.Projection"
+                        }
+                    ]
                 }
             }
         },
-        "v1.ProvidersResponse": {
+        "v1.ViewResponse": {
             "type": "object",
             "properties": {
                 "page": {
                     "$ref": "#/definitions/v1.Page"
                 },
-                "providers": {
+                "view": {
+                    "$ref": "#/definitions/v1.View"
+                }
+            }
+        },
+        "v1.ViewsResponse": {
+            "type": "object",
+            "properties": {
+                "views": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/v1.Provider"
+                        "$ref": "#/definitions/v1.View"
                     }
                 }
             }
@@ -115,6 +115,12 @@ func FetchEmbeddings(ctx context.Context, embedder any, req *v1.EmbeddingsUpdate
 		// NOTE: if the type assertion fail, stringLabel is emoty string
 		stringLabel, _ := label.(string)
 		md[v1.LabelMetaKey] = getLabel(stringLabel, chunks[i])
+		// NOTE: the raw label lets clients filter the embeddings by the label they supplied
+		if stringLabel != "" {
+			md[v1.RawLabelMetaKey] = stringLabel
+		}
+		// NOTE: the text hash lets providers detect duplicate chunks
+		md[v1.TextHashMetaKey] = v1.TextHash(chunks[i])
 
 		vals = make([]float64, len(emb.Vector))
 		copy(vals, emb.Vector)
@@ -143,3 +149,24 @@ func getLabel(label, chunk string) string {
 
 	return label
 }
+
+// EmbedAnchors embeds the text anchors of the semantic axes set in opts using the provided embedder.
+// Anchors which already have their values set are not embedded.
+func EmbedAnchors(ctx context.Context, embedder any, opts *v1.ProjectionOptions) error {
+	for i := range opts.Axes {
+		for _, a := range []*v1.Anchor{&opts.Axes[i].Negative, &opts.Axes[i].Positive} {
+			if a.Text == "" || len(a.Values) > 0 {
+				continue
+			}
+			embs, err := FetchEmbeddings(ctx, embedder, &v1.EmbeddingsUpdate{Text: a.Text})
+			if err != nil {
+				return err
+			}
+			if len(embs) == 0 {
+				return fmt.Errorf("no embedding of anchor: %q", a.Text)
+			}
+			a.Values = embs[0].Values
+		}
+	}
+	return nil
+}
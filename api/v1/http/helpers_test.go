@@ -2,11 +2,18 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/jobs"
 	"github.com/milosgajdos/embeviz/api/v1/memory"
+	"github.com/milosgajdos/go-embeddings/openai"
 )
 
 func MustProvidersService(t *testing.T, db *memory.DB) v1.ProvidersService {
@@ -17,6 +24,31 @@ func MustProvidersService(t *testing.T, db *memory.DB) v1.ProvidersService {
 	return ps
 }
 
+func MustJobsService(t *testing.T) *jobs.JobsService {
+	js, err := jobs.NewJobsService(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { js.Close() })
+	return js
+}
+
+func MustWaitJob(t *testing.T, js v1.JobsService, uid string) *v1.Job {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := js.GetJobByUID(context.TODO(), uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status.Finished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", uid)
+	return nil
+}
+
 func MustServer(t *testing.T) *Server {
 	s, err := NewServer()
 	if err != nil {
@@ -57,9 +89,9 @@ func MustSeedProviderEmbeddings(t *testing.T, db *memory.DB, p *v1.Provider, cou
 		p.UID: {
 			"meta": p,
 			"embs": embs,
-			"proj": map[v1.Dim][]v1.Embedding{
-				v1.Dim2D: embs,
-				v1.Dim3D: embs,
+			"proj": map[string][]v1.Embedding{
+				v1.ProjectionKey(v1.PCA, v1.Dim2D): embs,
+				v1.ProjectionKey(v1.PCA, v1.Dim3D): embs,
 			},
 		},
 	}
@@ -67,3 +99,68 @@ func MustSeedProviderEmbeddings(t *testing.T, db *memory.DB, p *v1.Provider, cou
 		t.Fatalf("failed to init in-memory store: %v", err)
 	}
 }
+
+// MustEmbedderServer returns a fake OpenAI embeddings API server
+// which embeds every input text into a distinct 4D vector.
+func MustEmbedderServer(t *testing.T) *httptest.Server {
+	var n atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(struct {
+			Input []string `json:"input"`
+		})
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := openai.EmbeddingResponseGen[[]float64]{Object: "list"}
+		for i := range req.Input {
+			x := float64(n.Add(1))
+			resp.Data = append(resp.Data, openai.DataGen[[]float64]{
+				Object:    "embedding",
+				Index:     i,
+				Embedding: []float64{x, x * x, 1 / x, -x},
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Error(err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// slowProvidersService reports the projection steps in the given interval
+// and clusters embeddings in the given number of steps.
+type slowProvidersService struct {
+	v1.ProvidersService
+	steps    int
+	interval time.Duration
+}
+
+func (s *slowProvidersService) ComputeProviderProjections(ctx context.Context, _ string, prj v1.Projection, _ *v1.ProjectionOptions) error {
+	for i := 1; i <= s.steps; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.interval):
+		}
+		v1.ReportStep(ctx, &v1.ProjectionStep{Projection: prj, Dim: v1.Dim2D, Iteration: i, Iterations: s.steps})
+		if v1.Stopped(ctx) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *slowProvidersService) ClusterProviderEmbeddings(ctx context.Context, _ string, opts *v1.ClusterOptions) (*v1.Clustering, error) {
+	for i := 1; i <= s.steps; i++ {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.interval):
+		}
+		v1.ReportProgress(ctx, float64(i)/float64(s.steps))
+	}
+	return &v1.Clustering{Algorithm: opts.Algorithm, K: opts.K}, nil
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+const (
+	// jobsPath is the path jobs are served at.
+	jobsPath = "/api/v1/jobs/"
+)
+
+// GetJobByUID returns the job with the given UID.
+// @Summary Get job by UID.
+// @Description Returns the status and progress of the job with the given UID.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job UID"
+// @Success 200 {object} v1.Job
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/jobs/{uid} [get]
+func (s *Server) GetJobByUID(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	job, err := s.JobsService.GetJobByUID(c.UserContext(), uid.String())
+	if err != nil {
+		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(job)
+}
+
+// CancelJob cancels the job with the given UID.
+// @Summary Cancel job by UID.
+// @Description Cancels the job with the given UID. Running jobs are cancelled asynchronously.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job UID"
+// @Success 202 {object} v1.Job
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 409 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/jobs/{uid} [delete]
+func (s *Server) CancelJob(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	job, err := s.JobsService.CancelJob(c.UserContext(), uid.String())
+	if err != nil {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.ECONFLICT:
+			return c.Status(fiber.StatusConflict).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// addJob schedules a job of the given kind running run for the provider with the given uid
+// and responds with the scheduled job.
+func (s *Server) addJob(c *fiber.Ctx, kind v1.JobKind, uid string, run v1.JobFunc) error {
+	if _, err := s.ProvidersService.GetProviderByUID(c.UserContext(), uid); err != nil {
+		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	job, err := s.JobsService.AddJob(c.UserContext(), kind, uid, run)
+	if err != nil {
+		if code := v1.ErrorCode(err); code == v1.ECONFLICT {
+			return c.Status(fiber.StatusConflict).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	c.Location(jobsPath + job.UID)
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestGetJobByUID(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		s := MustServer(t)
+		js := MustJobsService(t)
+		s.JobsService = js
+
+		job, err := js.AddJob(context.TODO(), v1.ProjectionsJob, "foo", func(ctx context.Context) error { return nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/jobs/%s", job.UID), nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		rJob := new(v1.Job)
+		if err := json.Unmarshal(body, rJob); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		if rJob.UID != job.UID {
+			t.Fatalf("expected job: %s, got: %s", job.UID, rJob.UID)
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		s.JobsService = MustJobsService(t)
+
+		req := httptest.NewRequest("GET", "/api/v1/jobs/foo", nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusBadRequest {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		s.JobsService = MustJobsService(t)
+
+		uid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+		req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/jobs/%s", uid), nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
+
+func TestCancelJob(t *testing.T) {
+	t.Run("202", func(t *testing.T) {
+		s := MustServer(t)
+		js := MustJobsService(t)
+		s.JobsService = js
+
+		job, err := js.AddJob(context.TODO(), v1.ProjectionsJob, "foo", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/v1/jobs/%s", job.UID), nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusAccepted {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		s.JobsService = MustJobsService(t)
+
+		uid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+		req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/v1/jobs/%s", uid), nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
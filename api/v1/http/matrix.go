@@ -0,0 +1,138 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// UpdateProviderMatrix stores a new version of the projection matrix of the provider with the given uid.
+// Unless the version of the matrix is zero, it must match the version of the stored matrix it replaces.
+// @Summary Upload projection matrix for the provider with the given UID.
+// @Description Stores a new version of the provider projection matrix used by the matrix projection.
+// @Tags providers
+// @Accept json
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param matrix body v1.ProjectionMatrix true "Projection matrix"
+// @Success 200 {object} v1.MatrixResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 409 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/matrix [put]
+func (s *Server) UpdateProviderMatrix(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	req := new(v1.ProjectionMatrix)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	m, err := s.ProvidersService.UpdateProviderMatrix(c.UserContext(), uid.String(), req)
+	if err != nil {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.EINVALID:
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.ECONFLICT:
+			return c.Status(fiber.StatusConflict).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.MatrixResponse{
+		Matrix: m,
+	})
+}
+
+// GetProviderMatrix returns the projection matrix of the provider with the given uid.
+// @Summary Get projection matrix of the provider with the given UID.
+// @Description Returns the current version of the provider projection matrix.
+// @Tags providers
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Success 200 {object} v1.MatrixResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/matrix [get]
+func (s *Server) GetProviderMatrix(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	m, err := s.ProvidersService.GetProviderMatrix(c.UserContext(), uid.String())
+	if err != nil {
+		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.MatrixResponse{
+		Matrix: m,
+	})
+}
+
+// DropProviderMatrix drops the projection matrix of the provider with the given uid.
+// @Summary Delete projection matrix of the provider with the given UID.
+// @Description Delete provider projection matrix. Projections computed using the matrix are kept until they're recomputed.
+// @Tags providers
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Success 204 {string} status "Provider matrix deleted successfully"
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/matrix [delete]
+func (s *Server) DropProviderMatrix(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := s.ProvidersService.DropProviderMatrix(c.UserContext(), uid.String()); err != nil {
+		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
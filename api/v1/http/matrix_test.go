@@ -0,0 +1,280 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func MustSeedProviderMatrix(t *testing.T, ps v1.ProvidersService, p *v1.Provider) *v1.ProjectionMatrix {
+	m, err := ps.UpdateProviderMatrix(context.TODO(), p.UID, &v1.ProjectionMatrix{
+		Name:   "foo",
+		Values: [][]float64{{1, 0}, {0, 1}, {0, 0}, {0, 0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestUpdateProviderMatrix(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		cur := MustSeedProviderMatrix(t, ps, px[0])
+
+		testBody, err := json.Marshal(v1.ProjectionMatrix{
+			Version: cur.Version,
+			Values:  [][]float64{{0, 1}, {1, 0}, {0, 0}, {0, 0}},
+			Mean:    []float64{1, 1, 1, 1},
+		})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/matrix", px[0].UID)
+		req := httptest.NewRequest("PUT", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		res := new(v1.MatrixResponse)
+		if err := json.Unmarshal(body, res); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if res.Matrix.Version != cur.Version+1 {
+			t.Fatalf("expected version: %d, got: %d", cur.Version+1, res.Matrix.Version)
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		testCases := []struct {
+			uid    string
+			matrix v1.ProjectionMatrix
+		}{
+			{uid: "foo", matrix: v1.ProjectionMatrix{Values: [][]float64{{1, 0}}}},
+			{uid: uid, matrix: v1.ProjectionMatrix{}},
+			{uid: uid, matrix: v1.ProjectionMatrix{Values: [][]float64{{1, 0}, {1}}}},
+			{uid: uid, matrix: v1.ProjectionMatrix{Values: [][]float64{{1, 0}}, Mean: []float64{1, 1}}},
+		}
+
+		for _, tc := range testCases {
+			testBody, err := json.Marshal(tc.matrix)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/matrix", tc.uid)
+			req := httptest.NewRequest("PUT", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		testBody, err := json.Marshal(v1.ProjectionMatrix{Values: [][]float64{{1, 0}}})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/matrix", "97153afd-c434-4ca0-a35b-7467fcd08df1")
+		req := httptest.NewRequest("PUT", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+
+	t.Run("409", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		cur := MustSeedProviderMatrix(t, ps, px[0])
+
+		testBody, err := json.Marshal(v1.ProjectionMatrix{
+			Version: cur.Version + 1,
+			Values:  cur.Values,
+		})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/matrix", px[0].UID)
+		req := httptest.NewRequest("PUT", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusConflict {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusConflict, code)
+		}
+	})
+}
+
+func TestGetProviderMatrix(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		cur := MustSeedProviderMatrix(t, ps, px[0])
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/matrix", px[0].UID)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		res := new(v1.MatrixResponse)
+		if err := json.Unmarshal(body, res); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if res.Matrix.Version != cur.Version || res.Matrix.Name != cur.Name {
+			t.Fatalf("expected matrix: %#v, got: %#v", cur, res.Matrix)
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/matrix", px[0].UID)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
+
+func TestDropProviderMatrix(t *testing.T) {
+	t.Run("204", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedProviderMatrix(t, ps, px[0])
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/matrix", px[0].UID)
+		req := httptest.NewRequest("DELETE", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNoContent {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNoContent, code)
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/matrix", px[0].UID)
+		req := httptest.NewRequest("DELETE", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// GetProviderEmbeddingNeighbours returns the nearest neighbours of the embedding with the given eid
+// of the provider with the given uid. Neighbours are found in the original vector space
+// so they may differ from the neighbours in the projections.
+// @Summary Get neighbours of provider embedding.
+// @Description Returns k stored embeddings nearest to the embedding with the given UID in the original vector space. Cosine and dot scores are similarities, euclidean scores are distances.
+// @Tags providers
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param eid path string true "Embedding UID"
+// @Param k query int false "Number of neighbours, defaults to 10"
+// @Param metric query string false "Distance metric: cosine, dot or euclidean, defaults to cosine"
+// @Success 200 {object} v1.NeighboursResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/embeddings/{eid}/neighbors [get]
+func (s *Server) GetProviderEmbeddingNeighbours(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	eid, err := uuid.Parse(c.Params("eid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	k := v1.DefaultSearchLimit
+	if v := c.Query("k"); v != "" {
+		k, err = strconv.Atoi(v)
+		if err != nil || k <= 0 || k > v1.MaxSearchLimit {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid k: %v, must be between 1 and %d", v, v1.MaxSearchLimit),
+			})
+		}
+	}
+
+	metric := v1.DefaultNeighboursMetric
+	if m := c.Query("metric"); m != "" {
+		metric = v1.Metric(strings.ToLower(m))
+		if !metric.Valid() {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid metric: %v", m),
+			})
+		}
+	}
+
+	nbrs, err := s.ProvidersService.GetProviderEmbeddingNeighbours(c.UserContext(), uid.String(), eid.String(), k, metric)
+	if err != nil {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.EINVALID:
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.NeighboursResponse{
+		UID:        eid.String(),
+		Metric:     metric,
+		Embeddings: nbrs,
+	})
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func TestGetProviderEmbeddingNeighbours(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		embs, _, err := ps.GetProviderEmbeddings(context.TODO(), px[0].UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/embeddings/%s/neighbors?k=2&metric=euclidean", px[0].UID, embs[0].UID)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		res := new(v1.NeighboursResponse)
+		if err := json.Unmarshal(body, res); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		if res.UID != embs[0].UID || res.Metric != v1.Euclidean {
+			t.Fatalf("unexpected neighbours of: %s, metric: %s", res.UID, res.Metric)
+		}
+		// NOTE: b and d are the nearest to a in the original space
+		for i, label := range []string{"b", "d"} {
+			if l := res.Embeddings[i].Metadata[v1.LabelMetaKey]; l != label {
+				t.Fatalf("neighbour %d label: %v, expected: %v", i, l, label)
+			}
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+		eid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+
+		testCases := []string{
+			fmt.Sprintf("/api/v1/providers/foo/embeddings/%s/neighbors", eid),
+			fmt.Sprintf("/api/v1/providers/%s/embeddings/foo/neighbors", uid),
+			fmt.Sprintf("/api/v1/providers/%s/embeddings/%s/neighbors?k=0", uid, eid),
+			fmt.Sprintf("/api/v1/providers/%s/embeddings/%s/neighbors?k=foo", uid, eid),
+			fmt.Sprintf("/api/v1/providers/%s/embeddings/%s/neighbors?metric=foo", uid, eid),
+		}
+
+		for _, urlPath := range testCases {
+			req := httptest.NewRequest("GET", urlPath, nil)
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("%s: expected status code: %d, got: %d", urlPath, http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+		uid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+
+		testCases := []string{
+			fmt.Sprintf("/api/v1/providers/%s/embeddings/%s/neighbors", uid, uid),
+			fmt.Sprintf("/api/v1/providers/%s/embeddings/%s/neighbors", px[0].UID, uid),
+		}
+
+		for _, urlPath := range testCases {
+			req := httptest.NewRequest("GET", urlPath, nil)
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusNotFound {
+				t.Fatalf("%s: expected status code: %d, got: %d", urlPath, http.StatusNotFound, code)
+			}
+		}
+	})
+}
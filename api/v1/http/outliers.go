@@ -0,0 +1,56 @@
+package http
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// DetectProviderOutliers schedules a job which scores embeddings of the provider with the given uid for outlierness.
+// Outlier scores are stored in the embeddings metadata so the provider projections
+// can be coloured or filtered by them.
+// @Summary Schedule detecting outliers of embeddings of the provider with the given UID.
+// @Description Schedules a job which scores the original provider embeddings for outlierness using local outlier factor (lof) or the distance to the k-th nearest neighbour (knn) and stores the scores in the embeddings metadata. The finished job result contains the top outliers. Returns the scheduled job.
+// @Tags providers
+// @Accept json
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param outliers body v1.OutlierOptions true "Outlier detection options"
+// @Success 202 {object} v1.Job{result=v1.Outliers}
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 409 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/outliers [post]
+func (s *Server) DetectProviderOutliers(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	req := new(v1.OutlierOptions)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return s.addJob(c, v1.OutliersJob, uid.String(), func(ctx context.Context) error {
+		outliers, err := s.ProvidersService.DetectProviderOutliers(ctx, uid.String(), req)
+		if err != nil {
+			return err
+		}
+		v1.ReportResult(ctx, outliers)
+		return nil
+	})
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func TestDetectProviderOutliers(t *testing.T) {
+	t.Run("202", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		testBody, err := json.Marshal(v1.OutlierOptions{K: 2, Top: 2})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/outliers", px[0].UID)
+		req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusAccepted {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		job := new(v1.Job)
+		if err := json.Unmarshal(body, job); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if job.Kind != v1.OutliersJob {
+			t.Fatalf("expected job kind: %s, got: %s", v1.OutliersJob, job.Kind)
+		}
+
+		job = MustWaitJob(t, s.JobsService, job.UID)
+		if job.Status != v1.JobDone {
+			t.Fatalf("expected job status: %s, got: %s (%s)", v1.JobDone, job.Status, job.Error)
+		}
+
+		o, ok := job.Result.(*v1.Outliers)
+		if !ok {
+			t.Fatalf("unexpected job result: %#v", job.Result)
+		}
+		if o.Method != v1.LOF || o.K != 2 || o.Metric != v1.Euclidean {
+			t.Fatalf("unexpected outliers: %#v", o)
+		}
+		if len(o.Embeddings) != 2 {
+			t.Fatalf("expected 2 top outliers, got: %d", len(o.Embeddings))
+		}
+		for i, e := range o.Embeddings {
+			if e.Metadata[v1.OutlierMetaKey] != e.Score {
+				t.Fatalf("outlier %d metadata score: %v, expected: %v", i, e.Metadata[v1.OutlierMetaKey], e.Score)
+			}
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		testCases := []struct {
+			uid  string
+			opts v1.OutlierOptions
+		}{
+			{uid: "foo", opts: v1.OutlierOptions{}},
+			{uid: uid, opts: v1.OutlierOptions{Method: "foo"}},
+			{uid: uid, opts: v1.OutlierOptions{K: -1}},
+			{uid: uid, opts: v1.OutlierOptions{Top: v1.MaxOutlierTop + 1}},
+			{uid: uid, opts: v1.OutlierOptions{Metric: "foo"}},
+		}
+
+		for _, tc := range testCases {
+			testBody, err := json.Marshal(tc.opts)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/outliers", tc.uid)
+			req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		testBody, err := json.Marshal(v1.OutlierOptions{})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/outliers", "97153afd-c434-4ca0-a35b-7467fcd08df1")
+		req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
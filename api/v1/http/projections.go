@@ -0,0 +1,25 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// GetProjections returns all available projection algorithms.
+// @Summary Get projection algorithms.
+// @Description Returns all available projection algorithms and their parameters.
+// @Tags projections
+// @Produce json
+// @Success 200 {object} v1.AlgorithmsResponse
+// @Router /v1/projections [get]
+func (s *Server) GetProjections(c *fiber.Ctx) error {
+	projectors := v1.Projectors()
+	algorithms := make([]v1.ProjectionAlgorithm, 0, len(projectors))
+	for _, p := range projectors {
+		algorithms = append(algorithms, p.Algorithm())
+	}
+
+	return c.JSON(v1.AlgorithmsResponse{
+		Algorithms: algorithms,
+	})
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+type scaleProjector struct{}
+
+func (scaleProjector) Algorithm() v1.ProjectionAlgorithm {
+	return v1.ProjectionAlgorithm{
+		Name: "scale",
+		Params: []v1.ProjectionParam{
+			{Name: "factor", Type: "number"},
+		},
+	}
+}
+
+func (scaleProjector) Project(_ context.Context, embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	factor, _ := opts.Params["factor"].(float64)
+	n := 2
+	if dim == v1.Dim3D {
+		n = 3
+	}
+	res := make([]v1.Embedding, 0, len(embs))
+	for _, e := range embs {
+		vals := make([]float64, n)
+		for i := range vals {
+			vals[i] = factor * e.Values[i]
+		}
+		res = append(res, v1.Embedding{UID: e.UID, Values: vals})
+	}
+	return res, nil
+}
+
+func init() {
+	if err := v1.RegisterProjector(scaleProjector{}); err != nil {
+		panic(err)
+	}
+}
+
+func TestGetProjections(t *testing.T) {
+	s := MustServer(t)
+
+	req := httptest.NewRequest("GET", "/api/v1/projections", nil)
+
+	resp, err := s.app.Test(req)
+	if err != nil {
+		t.Fatalf("failed to get response: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if code := resp.StatusCode; code != http.StatusOK {
+		t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read response body: %v", err)
+	}
+
+	ar := new(v1.AlgorithmsResponse)
+	if err := json.Unmarshal(body, ar); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+
+	algorithms := make(map[v1.Projection]v1.ProjectionAlgorithm)
+	for _, a := range ar.Algorithms {
+		algorithms[a.Name] = a
+	}
+	for _, p := range []v1.Projection{v1.PCA, v1.TSNE, v1.UMAP, "scale"} {
+		a, ok := algorithms[p]
+		if !ok {
+			t.Fatalf("expected algorithm: %s", p)
+		}
+		if len(a.Params) == 0 {
+			t.Fatalf("expected %s params", p)
+		}
+	}
+}
+
+func TestCustomProjector(t *testing.T) {
+	s := MustServer(t)
+	db := MustOpenDB(t, memory.DSN)
+	ps := MustProvidersService(t, db)
+	s.ProvidersService = ps
+	s.JobsService = MustJobsService(t)
+
+	px := MustSeedProviders(t, ps, 1)
+	uid := px[0].UID
+
+	for code, params := range map[int]map[string]any{
+		http.StatusAccepted:   {"factor": 2.0},
+		http.StatusBadRequest: {"foo": 2.0},
+	} {
+		update := v1.ProjectionsUpdate{
+			Projection: "scale",
+			Options:    &v1.ProjectionOptions{Params: params},
+		}
+		testBody, err := json.Marshal(update)
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections", uid)
+		req := httptest.NewRequest("PATCH", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if got := resp.StatusCode; got != code {
+			t.Fatalf("expected status code: %d, got: %d", code, got)
+		}
+	}
+}
@@ -25,10 +25,50 @@ func (s *Server) registerRoutes(r fiber.Router) {
 	routes.Get("/providers/:uid/projections", s.GetProviderProjections)
 	// update existing provider embeddings
 	routes.Put("/providers/:uid/embeddings", s.UpdateProviderEmbeddings)
+	// get neighbours of existing provider embedding
+	routes.Get("/providers/:uid/embeddings/:eid/neighbors", s.GetProviderEmbeddingNeighbours)
+	// get pairwise similarities of provider embeddings
+	routes.Post("/providers/:uid/similarities", s.GetProviderSimilarities)
 	// drop existing provider embeddings
 	routes.Delete("/providers/:uid/embeddings", s.DropProviderEmbeddings)
 	// compute existing provider projections
 	routes.Patch("/providers/:uid/projections", s.ComputeProviderProjections)
+	// stream recomputing existing provider projections
+	routes.Get("/providers/:uid/projections/stream", s.streamContext(), s.StreamProviderProjections)
+	// place a query into existing provider projections
+	routes.Post("/providers/:uid/projections/query", s.QueryProviderProjections)
+	// search provider embeddings nearest to a query
+	routes.Post("/providers/:uid/search", s.SearchProviderEmbeddings)
+	// get provider views
+	routes.Get("/providers/:uid/views", s.GetProviderViews)
+	// get a provider view by name
+	routes.Get("/providers/:uid/views/:name", s.GetProviderView)
+	// drop a provider view by name
+	routes.Delete("/providers/:uid/views/:name", s.DropProviderView)
+	// get provider projection matrix
+	routes.Get("/providers/:uid/matrix", s.GetProviderMatrix)
+	// upload provider projection matrix
+	routes.Put("/providers/:uid/matrix", s.UpdateProviderMatrix)
+	// drop provider projection matrix
+	routes.Delete("/providers/:uid/matrix", s.DropProviderMatrix)
+	// get provider dedup policy
+	routes.Get("/providers/:uid/dedup", s.GetProviderDedup)
+	// update provider dedup policy
+	routes.Put("/providers/:uid/dedup", s.UpdateProviderDedup)
+	// get duplicate provider embeddings
+	routes.Get("/providers/:uid/duplicates", s.GetProviderDuplicates)
+	// cluster provider embeddings
+	routes.Post("/providers/:uid/clusters", s.ClusterProviderEmbeddings)
+	// detect outliers of provider embeddings
+	routes.Post("/providers/:uid/outliers", s.DetectProviderOutliers)
+	// align provider projections to a reference provider
+	routes.Post("/providers/:uid/alignment", s.AlignProviderProjections)
+	// get available projection algorithms
+	routes.Get("/projections", s.GetProjections)
+	// get a job by UID
+	routes.Get("/jobs/:uid", s.GetJobByUID)
+	// cancel a job by UID
+	routes.Delete("/jobs/:uid", s.CancelJob)
 	// mount graph routes at the root of r
 	r.Mount("/", routes)
 }
@@ -104,7 +144,7 @@ func (s *Server) GetAllProviders(c *fiber.Ctx) error {
 		filter.Limit = limit
 	}
 
-	providers, page, err := s.ProvidersService.GetProviders(context.TODO(), filter)
+	providers, page, err := s.ProvidersService.GetProviders(c.UserContext(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
 			Error: err.Error(),
@@ -136,7 +176,7 @@ func (s *Server) GetProviderByUID(c *fiber.Ctx) error {
 		})
 	}
 
-	provider, err := s.ProvidersService.GetProviderByUID(context.TODO(), uid.String())
+	provider, err := s.ProvidersService.GetProviderByUID(c.UserContext(), uid.String())
 	if err != nil {
 		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
 			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
@@ -189,7 +229,7 @@ func (s *Server) GetProviderEmbeddings(c *fiber.Ctx) error {
 		filter.Limit = limit
 	}
 
-	embeddings, page, err := s.ProvidersService.GetProviderEmbeddings(context.Background(), uid.String(), filter)
+	embeddings, page, err := s.ProvidersService.GetProviderEmbeddings(c.UserContext(), uid.String(), filter)
 	if err != nil {
 		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
 			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
@@ -216,6 +256,8 @@ func (s *Server) GetProviderEmbeddings(c *fiber.Ctx) error {
 // @Param id path string true "Provider UID"
 // @Param offset query int false "Result offset"
 // @Param limit query int false "Result limit"
+// @Param dim query string false "Projection dimension, e.g. 2D"
+// @Param projection query string false "Projection algorithm"
 // @Success 200 {object} v1.ProjectionsResponse
 // @Failure 400 {object} v1.ErrorResponse
 // @Failure 404 {object} v1.ErrorResponse
@@ -243,24 +285,37 @@ func (s *Server) GetProviderProjections(c *fiber.Ctx) error {
 		filter.Limit = limit
 	}
 
-	dim := c.Query("dim")
-	if dim != "" {
-		switch strings.ToUpper(dim) {
-		case string(v1.Dim2D):
-			dim2d := v1.Dim2D
-			filter.Dim = &dim2d
-		case string(v1.Dim3D):
-			dim3d := v1.Dim3D
-			filter.Dim = &dim3d
+	if dim := c.Query("dim"); dim != "" {
+		projDim := v1.Dim(strings.ToUpper(dim))
+		if !projDim.Valid() {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid dimension: %v", dim),
+			})
 		}
+		filter.Dim = &projDim
 	}
 
-	projections, page, err := s.ProvidersService.GetProviderProjections(context.Background(), uid.String(), filter)
+	if prj := c.Query("projection"); prj != "" {
+		projection := v1.Projection(strings.ToLower(prj))
+		if _, ok := v1.GetProjector(projection); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid projection: %v", prj),
+			})
+		}
+		filter.Projection = &projection
+	}
+
+	projections, page, err := s.ProvidersService.GetProviderProjections(c.UserContext(), uid.String(), filter)
 	if err != nil {
-		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
 			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
 				Error: err.Error(),
 			})
+		case v1.EINVALID:
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
 		}
 
 		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
@@ -269,7 +324,10 @@ func (s *Server) GetProviderProjections(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(v1.ProjectionsResponse{
-		Projections: projections,
+		Projection:  projections.Projection,
+		Projections: projections.Embeddings,
+		Options:     projections.Options,
+		Metrics:     projections.Metrics,
 		Page:        page,
 	})
 }
@@ -315,12 +373,16 @@ func (s *Server) UpdateProviderEmbeddings(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.Projection != v1.PCA && req.Projection != v1.TSNE {
+	if err := validateProjection(req.Projection, req.Options); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
-			Error: fmt.Sprintf("invalid projection: %v", req.Projection),
+			Error: err.Error(),
 		})
 	}
 
+	if req.Options, err = s.prepareOptions(c.UserContext(), uid.String(), req.Projection, req.Options); err != nil {
+		return optionsError(c, err)
+	}
+
 	if req.Metadata == nil {
 		req.Metadata = make(map[string]any)
 	}
@@ -329,7 +391,7 @@ func (s *Server) UpdateProviderEmbeddings(c *fiber.Ctx) error {
 		req.Metadata[v1.LabelMetaKey] = req.Label
 	}
 
-	ctx := context.Background()
+	ctx := c.UserContext()
 	embs, err := FetchEmbeddings(ctx, embedder, req)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
@@ -337,7 +399,7 @@ func (s *Server) UpdateProviderEmbeddings(c *fiber.Ctx) error {
 		})
 	}
 
-	res, err := s.ProvidersService.UpdateProviderEmbeddings(ctx, uid.String(), embs, req.Projection)
+	res, err := s.ProvidersService.UpdateProviderEmbeddings(ctx, uid.String(), embs, req.Projection, req.Options)
 	if err != nil {
 		if code := v1.ErrorCode(err); code == v1.EINVALID {
 			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
@@ -370,7 +432,7 @@ func (s *Server) DropProviderEmbeddings(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := s.ProvidersService.DropProviderEmbeddings(context.Background(), uid.String()); err != nil {
+	if err := s.ProvidersService.DropProviderEmbeddings(c.UserContext(), uid.String()); err != nil {
 		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
 			return c.SendStatus(fiber.StatusNoContent)
 		}
@@ -382,18 +444,19 @@ func (s *Server) DropProviderEmbeddings(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-// ComputeProviderProjections recomputes provider projections from scratch by UID.
-// @Summary Recompute embeddings projections for a provider by UID and return them.
-// @Description Recompute provider projections.
+// ComputeProviderProjections schedules a job which recomputes provider projections from scratch by UID.
+// If the view name is set only the embeddings matching the filter are projected and stored in the named view.
+// @Summary Schedule recomputing embeddings projections for a provider by UID.
+// @Description Schedule recomputing provider projections or computing a named view. Returns the scheduled job.
 // @Tags providers
 // @Accept json
 // @Produce json
 // @Param id path string true "Provider UID"
 // @Param provider body v1.ProjectionsUpdate true "Update embeddings projections"
-// @Success 200 {object} v1.ProjectionsResponse
-// @Success 200 {object} v1.Embedding
+// @Success 202 {object} v1.Job
 // @Failure 400 {object} v1.ErrorResponse
 // @Failure 404 {object} v1.ErrorResponse
+// @Failure 409 {object} v1.ErrorResponse
 // @Failure 500 {object} v1.ErrorResponse
 // @Router /v1/providers/{uid}/projections [patch]
 func (s *Server) ComputeProviderProjections(c *fiber.Ctx) error {
@@ -411,9 +474,21 @@ func (s *Server) ComputeProviderProjections(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.Projection != v1.PCA && req.Projection != v1.TSNE {
+	if err := validateProjection(req.Projection, req.Options); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if req.View != "" && !v1.ValidViewName(req.View) {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("invalid view name: %q", req.View),
+		})
+	}
+
+	if req.Filter != nil && req.View == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
-			Error: fmt.Sprintf("invalid projection: %v", req.Projection),
+			Error: "filter requires view name",
 		})
 	}
 
@@ -422,14 +497,176 @@ func (s *Server) ComputeProviderProjections(c *fiber.Ctx) error {
 	}
 	req.Metadata["projection"] = req.Projection
 
-	if err := s.ProvidersService.ComputeProviderProjections(context.Background(), uid.String(), req.Projection); err != nil {
+	if _, err := s.ProvidersService.GetProviderByUID(c.UserContext(), uid.String()); err != nil {
 		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
-			return c.SendStatus(fiber.StatusNoContent)
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	kind, run := v1.ProjectionsJob, func(ctx context.Context) error {
+		return s.ProvidersService.ComputeProviderProjections(ctx, uid.String(), req.Projection, req.Options)
+	}
+	if req.View != "" {
+		kind, run = v1.ViewJob, func(ctx context.Context) error {
+			return s.ProvidersService.ComputeProviderView(ctx, uid.String(), req.View, req.Filter, req.Projection, req.Options)
+		}
+	}
+
+	if req.Options, err = s.prepareOptions(c.UserContext(), uid.String(), req.Projection, req.Options); err != nil {
+		return optionsError(c, err)
+	}
+
+	job, err := s.JobsService.AddJob(c.UserContext(), kind, uid.String(), run)
+	if err != nil {
+		if code := v1.ErrorCode(err); code == v1.ECONFLICT {
+			return c.Status(fiber.StatusConflict).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
 			Error: err.Error(),
 		})
 	}
 
-	return c.SendStatus(fiber.StatusAccepted)
+	c.Location(jobsPath + job.UID)
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// prepareOptions prepares the options opts of the projection p of the provider with the given uid.
+// It sets the options named by opts params to their values so the projectors and the stored
+// options see them as if they were set in opts directly.
+// It returns the prepared options which are nil if opts are nil and the projection does not require any.
+func (s *Server) prepareOptions(ctx context.Context, uid string, p v1.Projection, opts *v1.ProjectionOptions) (*v1.ProjectionOptions, error) {
+	opts, err := opts.ApplyParams()
+	if err != nil {
+		return nil, err
+	}
+	switch p {
+	case v1.Axes:
+		return opts, s.prepareAxes(ctx, uid, p, opts)
+	case v1.Matrix:
+		return s.prepareMatrix(ctx, uid, opts)
+	}
+	return opts, nil
+}
+
+// prepareAxes prepares the options opts of the axes projection p of the provider with the given uid.
+// It embeds the text anchors of the axes using the provider embedder and unless opts request
+// specific dimensions, it requests the projection into the dimension of the number of axes.
+func (s *Server) prepareAxes(ctx context.Context, uid string, p v1.Projection, opts *v1.ProjectionOptions) error {
+	if p != v1.Axes || opts == nil {
+		return nil
+	}
+	if len(opts.Dims) == 0 {
+		opts.Dims = []v1.Dim{v1.NewDim(min(len(opts.Axes), v1.MaxDim))}
+	}
+
+	embedder, ok := s.Embedders[uid]
+	if !ok {
+		for _, axis := range opts.Axes {
+			if (axis.Negative.Text != "" && len(axis.Negative.Values) == 0) ||
+				(axis.Positive.Text != "" && len(axis.Positive.Values) == 0) {
+				return v1.Errorf(v1.EINVALID, "%s provider has no embedder for text anchors", uid)
+			}
+		}
+		return nil
+	}
+
+	if err := EmbedAnchors(ctx, embedder, opts); err != nil {
+		return v1.Errorf(v1.EINTERNAL, "failed to embed anchors: %v", err)
+	}
+	return nil
+}
+
+// prepareMatrix prepares the options opts of the matrix projection of the provider with the given uid.
+// Unless opts carry a projection matrix, it sets the projection matrix uploaded for the provider and
+// unless opts request specific dimensions, it requests the projection into the dimension of the matrix.
+func (s *Server) prepareMatrix(ctx context.Context, uid string, opts *v1.ProjectionOptions) (*v1.ProjectionOptions, error) {
+	if opts == nil {
+		opts = new(v1.ProjectionOptions)
+	}
+	if opts.Matrix == nil {
+		m, err := s.ProvidersService.GetProviderMatrix(ctx, uid)
+		if err != nil {
+			if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+				return nil, v1.Errorf(v1.EINVALID, "%s provider has no projection matrix", uid)
+			}
+			return nil, err
+		}
+		opts.Matrix = m
+	}
+	if len(opts.Dims) == 0 {
+		opts.Dims = []v1.Dim{opts.Matrix.Dim()}
+	}
+	if err := validateProjection(v1.Matrix, opts); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+	return opts, nil
+}
+
+// optionsError writes the error of preparing the projection options to the response.
+func optionsError(c *fiber.Ctx, err error) error {
+	if code := v1.ErrorCode(err); code == v1.EINVALID {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+		Error: err.Error(),
+	})
+}
+
+// validateProjection returns error if projection p is not registered
+// or if any of the projection options opts is invalid.
+func validateProjection(p v1.Projection, opts *v1.ProjectionOptions) error {
+	projector, ok := v1.GetProjector(p)
+	if !ok {
+		return fmt.Errorf("invalid projection: %v", p)
+	}
+	if opts == nil {
+		// NOTE: some projectors require options
+		opts = &v1.ProjectionOptions{}
+	}
+	if len(opts.Params) > 0 {
+		params := make(map[string]struct{})
+		for _, param := range projector.Algorithm().Params {
+			params[param.Name] = struct{}{}
+		}
+		for name := range opts.Params {
+			if _, ok := params[name]; !ok {
+				return fmt.Errorf("invalid options: unknown %s param: %s", p, name)
+			}
+		}
+	}
+	// NOTE: params named after the options are validated as the options
+	opts, err := opts.ApplyParams()
+	if err != nil {
+		return fmt.Errorf("invalid options: %s", v1.ErrorMessage(err))
+	}
+	if opts.Perplexity < 0 || opts.LearningRate < 0 || opts.MaxIterations < 0 {
+		return fmt.Errorf("invalid options: perplexity=%v/learning_rate=%v/max_iterations=%d",
+			opts.Perplexity, opts.LearningRate, opts.MaxIterations)
+	}
+	if opts.Landmarks < 0 {
+		return fmt.Errorf("invalid options: landmarks=%d", opts.Landmarks)
+	}
+	dims := make(map[v1.Dim]struct{}, len(opts.Dims))
+	for _, dim := range opts.Dims {
+		if !projector.Algorithm().Supports(dim) {
+			return fmt.Errorf("invalid options: unsupported %s dimension: %s", p, dim)
+		}
+		if _, ok := dims[dim]; ok {
+			return fmt.Errorf("invalid options: duplicate dimension: %s", dim)
+		}
+		dims[dim] = struct{}{}
+	}
+	if v, ok := projector.(v1.ProjectionValidator); ok {
+		return v.ValidateOptions(opts)
+	}
+	return nil
 }
@@ -2,15 +2,18 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	v1 "github.com/milosgajdos/embeviz/api/v1"
 	"github.com/milosgajdos/embeviz/api/v1/memory"
+	"github.com/milosgajdos/go-embeddings/openai"
 )
 
 func TestGetChunks(t *testing.T) {
@@ -99,6 +102,34 @@ func TestGetChunks(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		testBody, err := json.Marshal(v1.ProjectionsUpdate{Projection: v1.PCA})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		uid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections", uid)
+		req := httptest.NewRequest("PATCH", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
 }
 
 func TestGetAllProviders(t *testing.T) {
@@ -516,6 +547,106 @@ func TestGetProviderProjections(t *testing.T) {
 		}
 	})
 
+	t.Run("200/Projection", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		count := 5
+		MustSeedProviderEmbeddings(t, db, px[0], count)
+
+		for _, prj := range []v1.Projection{v1.PCA, v1.TSNE} {
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/projections?projection=%s", uid, prj)
+			req := httptest.NewRequest("GET", urlPath, nil)
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusOK {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+
+			ret := new(v1.ProjectionsResponse)
+			if err := json.Unmarshal(body, ret); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+
+			if ret.Projection != prj {
+				t.Errorf("expected projection: %s, got: %s", prj, ret.Projection)
+			}
+
+			// NOTE: only PCA projections have been seeded
+			exp := 0
+			if prj == v1.PCA {
+				exp = count
+			}
+			if got := len(ret.Projections[v1.Dim2D]); got != exp {
+				t.Errorf("expected %s projections: %d, got: %d", prj, exp, got)
+			}
+		}
+	})
+
+	t.Run("400/Projection", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections?projection=foo", uid)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusBadRequest {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusBadRequest, code)
+		}
+	})
+
+	t.Run("400/Dim", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		// NOTE: 10D projections have not been computed
+		for _, dim := range []string{"foo", "0D", "10D"} {
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/projections?dim=%s", uid, dim)
+			req := httptest.NewRequest("GET", urlPath, nil)
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("expected %s status code: %d, got: %d", dim, http.StatusBadRequest, code)
+			}
+		}
+	})
+
 	t.Run("400", func(t *testing.T) {
 		s := MustServer(t)
 		db := MustOpenDB(t, memory.DSN)
@@ -585,6 +716,69 @@ func TestGetProviderProjections(t *testing.T) {
 	})
 }
 
+func TestUpdateProviderEmbeddings(t *testing.T) {
+	t.Run("200/Label", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+		srv := MustEmbedderServer(t)
+		s.Embedders = map[string]any{
+			uid: openai.NewClient(openai.WithBaseURL(srv.URL), openai.WithAPIKey("foo")),
+		}
+
+		for _, update := range []v1.EmbeddingsUpdate{
+			{Text: "first foo text", Label: "foo"},
+			{Text: "second foo text", Label: "foo"},
+			{Text: "bar text", Label: "bar"},
+		} {
+			update.Projection = v1.PCA
+			testBody, err := json.Marshal(update)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/embeddings", uid)
+			req := httptest.NewRequest("PUT", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusOK {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+			}
+		}
+
+		embs, _, err := ps.GetProviderEmbeddings(context.TODO(), uid, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if label := embs[0].Metadata[v1.LabelMetaKey]; label != "foo: first foo text" {
+			t.Fatalf("expected label: %q, got: %v", "foo: first foo text", label)
+		}
+
+		// NOTE: the label filter matches the label supplied when embedding the text
+		filter := &v1.EmbeddingsFilter{Label: "foo"}
+		if err := ps.ComputeProviderView(context.TODO(), uid, "foo", filter, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+		view, _, err := ps.GetProviderView(context.TODO(), uid, "foo", v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if view.Count != 2 {
+			t.Fatalf("expected %d embeddings in view, got: %d", 2, view.Count)
+		}
+	})
+}
+
 func TestDropProviderEmbeddings(t *testing.T) {
 	t.Run("204", func(t *testing.T) {
 		s := MustServer(t)
@@ -714,3 +908,379 @@ func TestDropProviderEmbeddings(t *testing.T) {
 		}
 	})
 }
+
+func TestComputeProviderProjections(t *testing.T) {
+	t.Run("202", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		testBody, err := json.Marshal(v1.ProjectionsUpdate{Projection: v1.PCA})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections", uid)
+		req := httptest.NewRequest("PATCH", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusAccepted {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		job := new(v1.Job)
+		if err := json.Unmarshal(body, job); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		if job.UID == "" || job.Provider != uid || job.Kind != v1.ProjectionsJob {
+			t.Fatalf("unexpected job: %#v", job)
+		}
+
+		if loc := resp.Header.Get("Location"); loc != jobsPath+job.UID {
+			t.Fatalf("expected location: %s, got: %s", jobsPath+job.UID, loc)
+		}
+	})
+
+	t.Run("202/Axes", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		testBody, err := json.Marshal(v1.ProjectionsUpdate{
+			Projection: v1.Axes,
+			Options: &v1.ProjectionOptions{
+				Axes: []v1.Axis{{
+					Negative: v1.Anchor{Values: []float64{0, 0, 0, 0}},
+					Positive: v1.Anchor{Values: []float64{1, 0, 0, 0}},
+				}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections", uid)
+		req := httptest.NewRequest("PATCH", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusAccepted {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		job := new(v1.Job)
+		if err := json.Unmarshal(body, job); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		for !job.Status.Finished() {
+			time.Sleep(10 * time.Millisecond)
+			if job, err = s.JobsService.GetJobByUID(context.TODO(), job.UID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if job.Status != v1.JobDone {
+			t.Fatalf("expected job status: %s, got: %s (%s)", v1.JobDone, job.Status, job.Error)
+		}
+
+		// NOTE: a single axis is projected into 1D by default
+		projs, _, err := ps.GetProviderProjections(context.TODO(), uid, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(projs.Embeddings) != 1 || len(projs.Embeddings[v1.Dim1D]) != 4 {
+			t.Fatalf("expected 4 %s projections, got: %v", v1.Dim1D, projs.Embeddings)
+		}
+		for _, e := range projs.Embeddings[v1.Dim1D] {
+			if len(e.Values) != 1 {
+				t.Fatalf("unexpected projection: %v", e.Values)
+			}
+		}
+	})
+
+	t.Run("202/Matrix", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		m := &v1.ProjectionMatrix{Values: [][]float64{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {0, 0, 0}}}
+		if _, err := ps.UpdateProviderMatrix(context.TODO(), uid, m); err != nil {
+			t.Fatal(err)
+		}
+
+		testBody, err := json.Marshal(v1.ProjectionsUpdate{Projection: v1.Matrix})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections", uid)
+		req := httptest.NewRequest("PATCH", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusAccepted {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		job := new(v1.Job)
+		if err := json.Unmarshal(body, job); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		for !job.Status.Finished() {
+			time.Sleep(10 * time.Millisecond)
+			if job, err = s.JobsService.GetJobByUID(context.TODO(), job.UID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if job.Status != v1.JobDone {
+			t.Fatalf("expected job status: %s, got: %s (%s)", v1.JobDone, job.Status, job.Error)
+		}
+
+		// NOTE: the uploaded matrix with 3 columns is projected into 3D by default
+		projs, _, err := ps.GetProviderProjections(context.TODO(), uid, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(projs.Embeddings) != 1 || len(projs.Embeddings[v1.Dim3D]) != 4 {
+			t.Fatalf("expected 4 %s projections, got: %v", v1.Dim3D, projs.Embeddings)
+		}
+		if projs.Options.Matrix == nil || projs.Options.Matrix.Version != 1 {
+			t.Fatalf("expected matrix version 1 options, got: %#v", projs.Options)
+		}
+	})
+
+	t.Run("202/View", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		testBody, err := json.Marshal(v1.ProjectionsUpdate{
+			Projection: v1.PCA,
+			View:       "foo",
+			Filter:     &v1.EmbeddingsFilter{Label: "foo"},
+		})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections", uid)
+		req := httptest.NewRequest("PATCH", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusAccepted {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		job := new(v1.Job)
+		if err := json.Unmarshal(body, job); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		if job.UID == "" || job.Provider != uid || job.Kind != v1.ViewJob {
+			t.Fatalf("unexpected job: %#v", job)
+		}
+	})
+
+	t.Run("202/Params", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		testBody, err := json.Marshal(v1.ProjectionsUpdate{
+			Projection: v1.PCA,
+			Options:    &v1.ProjectionOptions{Params: map[string]any{"center": true, "seed": 7}},
+		})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections", uid)
+		req := httptest.NewRequest("PATCH", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusAccepted {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+		}
+
+		job := new(v1.Job)
+		if err := json.NewDecoder(resp.Body).Decode(job); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if job = MustWaitJob(t, s.JobsService, job.UID); job.Status != v1.JobDone {
+			t.Fatalf("expected done job, got: %#v", job)
+		}
+
+		// NOTE: the params named after the options are stored as the options
+		projs, _, err := ps.GetProviderProjections(context.TODO(), uid, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts := projs.Options; !opts.Center || opts.Seed == nil || *opts.Seed != 7 || opts.Params != nil {
+			t.Fatalf("unexpected options: %#v", opts)
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		updates := []v1.ProjectionsUpdate{
+			{Projection: "foo"},
+			{Projection: v1.TSNE, Options: &v1.ProjectionOptions{Perplexity: -1}},
+			{Projection: v1.UMAP, Options: &v1.ProjectionOptions{MaxIterations: -1}},
+			{Projection: v1.PCA, Options: &v1.ProjectionOptions{Landmarks: -1}},
+			{Projection: v1.MDS, Options: &v1.ProjectionOptions{Metric: "foo"}},
+			{Projection: v1.TSNE, Options: &v1.ProjectionOptions{Params: map[string]any{"perplexity": -1}}},
+			{Projection: v1.TSNE, Options: &v1.ProjectionOptions{Params: map[string]any{"perplexity": "foo"}}},
+			{Projection: v1.TSNE, Options: &v1.ProjectionOptions{Params: map[string]any{"center": true}}},
+			{Projection: v1.TSNE, Options: &v1.ProjectionOptions{Dims: []v1.Dim{v1.NewDim(10)}}},
+			{Projection: v1.PCA, Options: &v1.ProjectionOptions{Dims: []v1.Dim{v1.Dim2D, v1.Dim2D}}},
+			{Projection: v1.PCA, Filter: &v1.EmbeddingsFilter{Label: "foo"}},
+			{Projection: v1.PCA, View: "foo/bar"},
+			{Projection: v1.Axes},
+			{Projection: v1.Axes, Options: &v1.ProjectionOptions{Axes: []v1.Axis{{Negative: v1.Anchor{UID: "foo"}}}}},
+			{Projection: v1.Axes, Options: &v1.ProjectionOptions{
+				Axes: []v1.Axis{{Negative: v1.Anchor{UID: "foo"}, Positive: v1.Anchor{UID: "bar"}}},
+				Dims: []v1.Dim{v1.Dim2D},
+			}},
+			{Projection: v1.Axes, Options: &v1.ProjectionOptions{
+				Axes: []v1.Axis{{Negative: v1.Anchor{Text: "foo"}, Positive: v1.Anchor{Text: "bar"}}},
+			}},
+			{Projection: v1.Matrix},
+			{Projection: v1.Matrix, Options: &v1.ProjectionOptions{
+				Matrix: &v1.ProjectionMatrix{Values: [][]float64{{1, 0}, {0, 1}}},
+				Dims:   []v1.Dim{v1.Dim3D},
+			}},
+		}
+
+		for _, update := range updates {
+			testBody, err := json.Marshal(update)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/projections", uid)
+			req := httptest.NewRequest("PATCH", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		testBody, err := json.Marshal(v1.ProjectionsUpdate{Projection: v1.PCA})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		uid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections", uid)
+		req := httptest.NewRequest("PATCH", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// QueryProviderProjections places the query text into the existing provider projections.
+// The query is embedded by the provider embedder and projected using the fitted projection
+// models; neither its embedding nor its projections are stored.
+// @Summary Place a query text into the projections of the provider with the given UID.
+// @Description Returns estimated projections of the query text without storing them. Linear projections use their fitted transform, non-linear projections place the query among its nearest neighbours.
+// @Tags providers
+// @Accept json
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param query body v1.ProjectionQuery true "Projection query"
+// @Success 200 {object} v1.ProjectionQueryResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/projections/query [post]
+func (s *Server) QueryProviderProjections(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	req := new(v1.ProjectionQuery)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if req.Text == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("empty query provided to %s provider", uid.String()),
+		})
+	}
+
+	var filter v1.ProviderFilter
+
+	if req.Projection != "" {
+		projection := v1.Projection(strings.ToLower(string(req.Projection)))
+		if _, ok := v1.GetProjector(projection); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid projection: %v", req.Projection),
+			})
+		}
+		filter.Projection = &projection
+	}
+
+	if req.Dim != "" {
+		dim := v1.Dim(strings.ToUpper(string(req.Dim)))
+		if !dim.Valid() {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid dimension: %v", req.Dim),
+			})
+		}
+		filter.Dim = &dim
+	}
+
+	embedder, ok := s.Embedders[uid.String()]
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("%s provider not found", uid.String()),
+		})
+	}
+
+	update := &v1.EmbeddingsUpdate{
+		Text:     req.Text,
+		Metadata: map[string]any{},
+	}
+	if req.Label != "" {
+		update.Metadata[v1.LabelMetaKey] = req.Label
+	}
+
+	ctx := c.UserContext()
+	embs, err := FetchEmbeddings(ctx, embedder, update)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	projections, err := s.ProvidersService.PlaceProviderEmbeddings(ctx, uid.String(), embs, filter)
+	if err != nil {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.EINVALID:
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.ProjectionQueryResponse{
+		Projection:  projections.Projection,
+		Projections: projections.Embeddings,
+	})
+}
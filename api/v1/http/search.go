@@ -0,0 +1,96 @@
+package http
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// SearchProviderEmbeddings searches embeddings of the provider with the given uid
+// nearest to the embedding of the query text. The query is embedded by the provider
+// embedder; neither the query text nor its embedding are stored.
+// @Summary Search embeddings of the provider with the given UID.
+// @Description Embeds the query text and returns the k most similar stored embeddings with their similarity scores and metadata.
+// @Tags providers
+// @Accept json
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param query body v1.SearchQuery true "Search query"
+// @Success 200 {object} v1.SearchResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/search [post]
+func (s *Server) SearchProviderEmbeddings(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	req := new(v1.SearchQuery)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if req.Text == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("empty query provided to %s provider", uid.String()),
+		})
+	}
+
+	if req.K == 0 {
+		req.K = v1.DefaultSearchLimit
+	}
+	if req.K < 0 || req.K > v1.MaxSearchLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("invalid k: %d, must be between 1 and %d", req.K, v1.MaxSearchLimit),
+		})
+	}
+
+	embedder, ok := s.Embedders[uid.String()]
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("%s provider not found", uid.String()),
+		})
+	}
+
+	ctx := c.UserContext()
+	embs, err := FetchEmbeddings(ctx, embedder, &v1.EmbeddingsUpdate{Text: req.Text})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if len(embs) == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("no embedding of the query returned by %s provider", uid.String()),
+		})
+	}
+
+	results, err := s.ProvidersService.SearchProviderEmbeddings(ctx, uid.String(), embs[0].Values, req.K)
+	if err != nil {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.EINVALID:
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.SearchResponse{
+		Embeddings: results,
+	})
+}
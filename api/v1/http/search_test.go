@@ -0,0 +1,85 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func TestSearchProviderEmbeddings(t *testing.T) {
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		testCases := []struct {
+			uid   string
+			query v1.SearchQuery
+		}{
+			{uid: "foo", query: v1.SearchQuery{Text: "foo"}},
+			{uid: uid, query: v1.SearchQuery{}},
+			{uid: uid, query: v1.SearchQuery{Text: "foo", K: -1}},
+			{uid: uid, query: v1.SearchQuery{Text: "foo", K: v1.MaxSearchLimit + 1}},
+		}
+
+		for _, tc := range testCases {
+			testBody, err := json.Marshal(tc.query)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/search", tc.uid)
+			req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+
+		testBody, err := json.Marshal(v1.SearchQuery{Text: "foo"})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		// NOTE: the seeded provider has no embedder
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/search", px[0].UID)
+		req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
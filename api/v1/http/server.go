@@ -24,10 +24,19 @@ type Server struct {
 	app *fiber.App
 	// ln is a network listener.
 	ln net.Listener
+	// ctx is the base context of all requests.
+	// It's cancelled when the server is shut down.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// writeTimeout is the maximum duration of writing a response.
+	// Streamed responses apply it to every write instead.
+	writeTimeout time.Duration
 	// Addr is bind address
 	Addr string
 	// ProvidersService provides access to Provider enpoints.
 	ProvidersService v1.ProvidersService
+	// JobsService runs asynchronous jobs.
+	JobsService v1.JobsService
 	// Embedders
 	// NOTE: this is a major hack
 	Embedders map[string]any
@@ -84,13 +93,18 @@ func NewServer(options ...Option) (*Server, error) {
 	c.ReadTimeout = opts.ReadTimeout
 	c.WriteTimeout = opts.WriteTimeout
 
+	ctx, cancel := context.WithCancel(context.Background())
 	s := &Server{
-		app: fiber.New(c),
+		app:          fiber.New(c),
+		ctx:          ctx,
+		cancel:       cancel,
+		writeTimeout: opts.WriteTimeout,
 	}
 
 	// TODO: comment this out
 	//s.app.Use(recover.New())
 	s.app.Use(logger.New())
+	s.app.Use(s.requestContext(opts.WriteTimeout))
 	s.app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowMethods: "GET,POST,PUT,DELETE,PATCH",
@@ -129,6 +143,9 @@ func (s *Server) Listen() error {
 
 // Close gracefully shuts down the server.
 func (s *Server) Close(ctx context.Context) error {
+	// NOTE: this stops all in-flight requests
+	s.cancel()
+
 	errChan := make(chan error, 1)
 	go func() {
 		select {
@@ -144,3 +161,42 @@ func (s *Server) Close(ctx context.Context) error {
 		return err
 	}
 }
+
+// requestContext returns a handler which sets the request user context.
+// The context is cancelled when the request handler returns, when the
+// server is shut down or when the given timeout expires if it's positive.
+// NOTE: fasthttp does not notify handlers about closed client connections,
+// so the timeout is what stops abandoned requests from running forever.
+// Work which may outlast the timeout must be scheduled as a job instead
+// as the job contexts do not derive from the request context.
+func (s *Server) requestContext(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var (
+			ctx    context.Context
+			cancel context.CancelFunc
+		)
+		if timeout > 0 {
+			ctx, cancel = context.WithTimeout(s.ctx, timeout)
+		} else {
+			ctx, cancel = context.WithCancel(s.ctx)
+		}
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
+
+// streamContext returns a handler which replaces the request user context
+// with a context which is only cancelled when the handler returns or when
+// the server is shut down. Streamed responses are written after their handlers
+// return so they must not be bound by the request timeout.
+func (s *Server) streamContext() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancel(s.ctx)
+		defer cancel()
+
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// GetProviderSimilarities schedules a job which computes the pairwise similarity matrix of the embeddings
+// of the provider with the given uid selected either by their UIDs or by a metadata filter.
+// @Summary Schedule computing pairwise similarities of provider embeddings.
+// @Description Schedules a job which computes the pairwise similarity matrix of the embeddings selected by their UIDs or by a metadata filter, optionally reordered by hierarchical clustering so the blocks of similar embeddings are adjacent. The finished job result contains the similarity matrix. Returns the scheduled job.
+// @Tags providers
+// @Accept json
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param similarity body v1.SimilarityRequest true "Similarity request"
+// @Success 202 {object} v1.Job{result=v1.Similarity}
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 409 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/similarities [post]
+func (s *Server) GetProviderSimilarities(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	req := new(v1.SimilarityRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return s.addJob(c, v1.SimilarityJob, uid.String(), func(ctx context.Context) error {
+		sim, err := s.ProvidersService.GetProviderSimilarities(ctx, uid.String(), req)
+		if err != nil {
+			return err
+		}
+		v1.ReportResult(ctx, sim)
+		return nil
+	})
+}
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func TestGetProviderSimilarities(t *testing.T) {
+	t.Run("202", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		embs, _, err := ps.GetProviderEmbeddings(context.TODO(), px[0].UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		uids := make([]string, 0, len(embs))
+		for _, e := range embs {
+			uids = append(uids, e.UID)
+		}
+
+		testCases := []struct {
+			req    v1.SimilarityRequest
+			status v1.JobStatus
+		}{
+			{req: v1.SimilarityRequest{UIDs: uids, Reorder: true}, status: v1.JobDone},
+			// NOTE: the embedding does not exist
+			{req: v1.SimilarityRequest{UIDs: []string{"97153afd-c434-4ca0-a35b-7467fcd08df1"}}, status: v1.JobFailed},
+			// NOTE: no embeddings match the filter
+			{req: v1.SimilarityRequest{Filter: &v1.EmbeddingsFilter{Label: "foo"}}, status: v1.JobFailed},
+		}
+
+		for _, tc := range testCases {
+			testBody, err := json.Marshal(tc.req)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/similarities", px[0].UID)
+			req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusAccepted {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusAccepted, code)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+
+			job := new(v1.Job)
+			if err := json.Unmarshal(body, job); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if job.Kind != v1.SimilarityJob {
+				t.Fatalf("expected job kind: %s, got: %s", v1.SimilarityJob, job.Kind)
+			}
+
+			job = MustWaitJob(t, s.JobsService, job.UID)
+			if job.Status != tc.status {
+				t.Fatalf("expected job status: %s, got: %s (%s)", tc.status, job.Status, job.Error)
+			}
+			if tc.status != v1.JobDone {
+				continue
+			}
+
+			sim, ok := job.Result.(*v1.Similarity)
+			if !ok {
+				t.Fatalf("unexpected job result: %#v", job.Result)
+			}
+			if !sim.Reordered || sim.Metric != v1.DefaultSimilarityMetric {
+				t.Fatalf("unexpected similarity: %#v", sim)
+			}
+			if len(sim.UIDs) != len(uids) || len(sim.Labels) != len(uids) || len(sim.Values) != len(uids) {
+				t.Fatalf("expected %d embeddings, got: %d uids, %d labels, %d rows", len(uids), len(sim.UIDs), len(sim.Labels), len(sim.Values))
+			}
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+		MustSeedLabeledEmbeddings(t, ps, px[0])
+
+		testCases := []struct {
+			uid string
+			req v1.SimilarityRequest
+		}{
+			{uid: "foo", req: v1.SimilarityRequest{UIDs: []string{"foo"}}},
+			{uid: uid, req: v1.SimilarityRequest{}},
+			{uid: uid, req: v1.SimilarityRequest{Filter: &v1.EmbeddingsFilter{Label: "a"}, Metric: "foo"}},
+		}
+
+		for _, tc := range testCases {
+			testBody, err := json.Marshal(tc.req)
+			if err != nil {
+				t.Fatalf("failed to serialise req body: %v", err)
+			}
+
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/similarities", tc.uid)
+			req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("expected status code: %d, got: %d", http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		uid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+		testBody, err := json.Marshal(v1.SimilarityRequest{UIDs: []string{uid}})
+		if err != nil {
+			t.Fatalf("failed to serialise req body: %v", err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/similarities", uid)
+		req := httptest.NewRequest("POST", urlPath, bytes.NewReader(testBody))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
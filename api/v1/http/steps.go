@@ -0,0 +1,243 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+const (
+	// defaultStepInterval is the default number of iterations between the streamed layouts.
+	defaultStepInterval = 10
+	// stepsBufferSize is the number of layouts buffered for slow clients.
+	stepsBufferSize = 16
+	// stepsPollInterval is the interval the status of the streamed job is checked in.
+	stepsPollInterval = 250 * time.Millisecond
+)
+
+// StreamProviderProjections schedules a job which recomputes provider projections
+// and streams the intermediate layouts of iterative algorithms as server-sent events.
+// The stream emits "step" events with v1.ProjectionStep data followed by a single "done" event
+// with the finished v1.Job data. Closing the stream stops the job early and keeps the last
+// reported layout as the provider projection rather than discarding the computation.
+// NOTE: the server write timeout applies to every streamed event rather than to the whole stream.
+// @Summary Stream recomputing embeddings projections for a provider by UID.
+// @Description Recomputes provider projections and streams intermediate layouts of iterative algorithms as server-sent events.
+// @Tags providers
+// @Produce text/event-stream
+// @Param uid path string true "Provider UID"
+// @Param projection query string false "Projection algorithm, defaults to tsne"
+// @Param every query int false "Number of iterations between streamed layouts"
+// @Param perplexity query number false "Perplexity of t-SNE"
+// @Param learning_rate query number false "Learning rate"
+// @Param max_iterations query int false "Maximum number of iterations"
+// @Param seed query int false "Random seed"
+// @Param dims query string false "Comma separated projection dimensions, e.g. 2D,3D"
+// @Success 200 {object} v1.ProjectionStep
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 409 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/projections/stream [get]
+func (s *Server) StreamProviderProjections(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	projection := v1.TSNE
+	if prj := c.Query("projection"); prj != "" {
+		projection = v1.Projection(strings.ToLower(prj))
+	}
+
+	every := defaultStepInterval
+	if e := c.Query("every"); e != "" {
+		every, err = strconv.Atoi(e)
+		if err != nil || every <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid step interval: %v", e),
+			})
+		}
+	}
+
+	opts, err := queryProjectionOptions(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if err := validateProjection(projection, opts); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if _, err := s.ProvidersService.GetProviderByUID(c.UserContext(), uid.String()); err != nil {
+		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if opts, err = s.prepareOptions(c.UserContext(), uid.String(), projection, opts); err != nil {
+		return optionsError(c, err)
+	}
+
+	steps := make(chan *v1.ProjectionStep, stepsBufferSize)
+	stop := make(chan struct{})
+	job, err := s.JobsService.AddJob(c.UserContext(), v1.ProjectionsJob, uid.String(), func(ctx context.Context) error {
+		ctx = v1.WithStop(ctx, stop)
+		ctx = v1.WithSteps(ctx, every, func(step *v1.ProjectionStep) {
+			// NOTE: layouts are dropped rather than stalling the computation for slow clients
+			select {
+			case steps <- step:
+			default:
+			}
+		})
+		return s.ProvidersService.ComputeProviderProjections(ctx, uid.String(), projection, opts)
+	})
+	if err != nil {
+		if code := v1.ErrorCode(err); code == v1.ECONFLICT {
+			return c.Status(fiber.StatusConflict).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	c.Location(jobsPath + job.UID)
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+
+	conn := c.Context().Conn()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ticker := time.NewTicker(stepsPollInterval)
+		defer ticker.Stop()
+
+		// NOTE: fasthttp sets a single write deadline for the whole response
+		// so it's extended before every write to keep long streams alive.
+		extend := func() {
+			if s.writeTimeout > 0 {
+				_ = conn.SetWriteDeadline(time.Now().Add(s.writeTimeout))
+			}
+		}
+
+		for {
+			select {
+			case step := <-steps:
+				extend()
+				if err := writeEvent(w, "step", step); err != nil {
+					// NOTE: the client has gone away
+					close(stop)
+					return
+				}
+			case <-ticker.C:
+				j, err := s.JobsService.GetJobByUID(context.Background(), job.UID)
+				extend()
+				if err != nil {
+					_ = writeEvent(w, "error", v1.ErrorResponse{Error: err.Error()})
+					return
+				}
+				if !j.Status.Finished() {
+					// NOTE: comments keep the connection alive and detect gone clients
+					if _, err := w.WriteString(": ping\n\n"); err != nil || w.Flush() != nil {
+						close(stop)
+						return
+					}
+					continue
+				}
+				for len(steps) > 0 {
+					extend()
+					if err := writeEvent(w, "step", <-steps); err != nil {
+						return
+					}
+				}
+				_ = writeEvent(w, "done", j)
+				return
+			}
+		}
+	})
+
+	return nil
+}
+
+// writeEvent writes the server-sent event of the given name with JSON encoded data and flushes w.
+func writeEvent(w *bufio.Writer, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// queryProjectionOptions returns projection options read from the request query.
+// It returns nil if the query does not set any options.
+func queryProjectionOptions(c *fiber.Ctx) (*v1.ProjectionOptions, error) {
+	var opts v1.ProjectionOptions
+	set := false
+
+	if v := c.Query("perplexity"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid perplexity: %v", v)
+		}
+		opts.Perplexity, set = f, true
+	}
+	if v := c.Query("learning_rate"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid learning_rate: %v", v)
+		}
+		opts.LearningRate, set = f, true
+	}
+	if v := c.Query("max_iterations"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max_iterations: %v", v)
+		}
+		opts.MaxIterations, set = i, true
+	}
+	if v := c.Query("seed"); v != "" {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed: %v", v)
+		}
+		opts.Seed, set = &seed, true
+	}
+	if v := c.Query("dims"); v != "" {
+		for _, d := range strings.Split(v, ",") {
+			dim := v1.Dim(strings.ToUpper(strings.TrimSpace(d)))
+			if !dim.Valid() {
+				return nil, fmt.Errorf("invalid dimension: %v", d)
+			}
+			opts.Dims = append(opts.Dims, dim)
+		}
+		set = true
+	}
+
+	if !set {
+		return nil, nil
+	}
+	return &opts, nil
+}
@@ -0,0 +1,272 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func TestStreamProviderProjections(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		embs := make([]v1.Embedding, 10)
+		for i := range embs {
+			embs[i] = v1.Embedding{Values: []float64{float64(i), float64(i * i), 1, float64(10 - i)}}
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), uid, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections/stream?max_iterations=20&every=5&dims=2D", uid)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+		if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("expected content type: text/event-stream, got: %s", ct)
+		}
+
+		steps, job := readStream(t, resp.Body)
+
+		if len(steps) != 4 {
+			t.Fatalf("expected steps: %d, got: %d", 4, len(steps))
+		}
+		for _, step := range steps {
+			if step.Projection != v1.TSNE || step.Dim != v1.Dim2D || len(step.Embeddings) != len(embs) {
+				t.Fatalf("unexpected step: %#v", step)
+			}
+		}
+		if job == nil || job.Status != v1.JobDone {
+			t.Fatalf("expected done job, got: %#v", job)
+		}
+
+		px2, _, err := ps.GetProviderProjections(context.TODO(), uid, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if px2.Projection != v1.TSNE {
+			t.Fatalf("expected projection: %s, got: %s", v1.TSNE, px2.Projection)
+		}
+	})
+
+	t.Run("WriteTimeout", func(t *testing.T) {
+		timeout := 100 * time.Millisecond
+		s, err := NewServer(WithWriteTimeout(timeout))
+		if err != nil {
+			t.Fatalf("failed to created new server: %v", err)
+		}
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		// NOTE: the steps are streamed for much longer than the write timeout
+		s.ProvidersService = &slowProvidersService{ProvidersService: ps, steps: 6, interval: timeout / 2}
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		go func() { _ = s.app.Listener(ln) }()
+		t.Cleanup(func() { _ = s.Close(context.Background()) })
+
+		urlPath := fmt.Sprintf("http://%s/api/v1/providers/%s/projections/stream?every=1", ln.Addr(), uid)
+		resp, err := http.Get(urlPath)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		steps, job := readStream(t, resp.Body)
+		if len(steps) != 6 {
+			t.Fatalf("expected steps: %d, got: %d", 6, len(steps))
+		}
+		if job == nil || job.Status != v1.JobDone {
+			t.Fatalf("expected done job, got: %#v", job)
+		}
+	})
+
+	t.Run("Disconnect", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+		s.JobsService = MustJobsService(t)
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		embs := make([]v1.Embedding, 10)
+		for i := range embs {
+			embs[i] = v1.Embedding{Values: []float64{float64(i), float64(i * i), 1, float64(10 - i)}}
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), uid, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen: %v", err)
+		}
+		go func() { _ = s.app.Listener(ln) }()
+		t.Cleanup(func() { _ = s.Close(context.Background()) })
+
+		// NOTE: the job would run for much longer than the test unless it's stopped
+		urlPath := fmt.Sprintf("http://%s/api/v1/providers/%s/projections/stream?max_iterations=100000000&every=1&dims=2D", ln.Addr(), uid)
+		resp, err := http.Get(urlPath)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		jobUID := strings.TrimPrefix(resp.Header.Get("Location"), jobsPath)
+
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil || line != "event: step\n" {
+			t.Fatalf("expected step event, got: %q, err: %v", line, err)
+		}
+		resp.Body.Close()
+
+		var job *v1.Job
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if job, err = s.JobsService.GetJobByUID(context.TODO(), jobUID); err != nil {
+				t.Fatal(err)
+			}
+			if job.Status.Finished() {
+				break
+			}
+		}
+		if job.Status != v1.JobDone {
+			t.Fatalf("expected done job, got: %#v", job)
+		}
+
+		px2, _, err := ps.GetProviderProjections(context.TODO(), uid, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if px2.Projection != v1.TSNE {
+			t.Fatalf("expected projection: %s, got: %s", v1.TSNE, px2.Projection)
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		uid := px[0].UID
+
+		queries := []string{
+			"projection=foo",
+			"every=0",
+			"every=foo",
+			"perplexity=foo",
+			"perplexity=-1",
+			"dims=0D",
+			"dims=2D,2D",
+		}
+
+		for _, q := range queries {
+			urlPath := fmt.Sprintf("/api/v1/providers/%s/projections/stream?%s", uid, q)
+			req := httptest.NewRequest("GET", urlPath, nil)
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("%s: expected status code: %d, got: %d", q, http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		s.ProvidersService = MustProvidersService(t, db)
+
+		uid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/projections/stream", uid)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
+
+// readStream reads the projection steps and the finished job from the server-sent events stream r.
+func readStream(t *testing.T, r io.Reader) ([]*v1.ProjectionStep, *v1.Job) {
+	var (
+		event string
+		steps []*v1.ProjectionStep
+		job   *v1.Job
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data := []byte(strings.TrimPrefix(line, "data: "))
+			switch event {
+			case "step":
+				step := new(v1.ProjectionStep)
+				if err := json.Unmarshal(data, step); err != nil {
+					t.Fatalf("failed to decode step: %v", err)
+				}
+				steps = append(steps, step)
+			case "done":
+				job = new(v1.Job)
+				if err := json.Unmarshal(data, job); err != nil {
+					t.Fatalf("failed to decode job: %v", err)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+	return steps, job
+}
@@ -0,0 +1,165 @@
+package http
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// GetProviderViews returns all views of the provider with the given uid.
+// @Summary Get all provider views.
+// @Description Returns all views of the provider with the given UID without their projections.
+// @Tags views
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Success 200 {object} v1.ViewsResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/views [get]
+func (s *Server) GetProviderViews(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	views, err := s.ProvidersService.GetProviderViews(c.UserContext(), uid.String())
+	if err != nil {
+		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.ViewsResponse{
+		Views: views,
+	})
+}
+
+// GetProviderView returns the named view of the provider with the given uid.
+// @Summary Get provider view by name.
+// @Description Returns the named view of the provider with the given UID including its projections.
+// @Tags views
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param name path string true "View name"
+// @Param offset query int false "Result offset"
+// @Param limit query int false "Result limit"
+// @Param dim query string false "Projection dimension, e.g. 2D"
+// @Success 200 {object} v1.ViewResponse
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/views/{name} [get]
+func (s *Server) GetProviderView(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	name := c.Params("name")
+	if !v1.ValidViewName(name) {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("invalid view name: %q", name),
+		})
+	}
+
+	var filter v1.ProviderFilter
+	filter.Limit = v1.DefaultLimit
+
+	// NOTE(milosgajdos): we don't care if the conversion fails here.
+	// If it does we'll get 0 and use the default values.
+	offset := c.QueryInt("offset")
+	limit := c.QueryInt("limit")
+	if offset > 0 {
+		filter.Offset = offset
+	}
+	if limit > 0 {
+		filter.Limit = limit
+	}
+
+	if dim := c.Query("dim"); dim != "" {
+		projDim := v1.Dim(strings.ToUpper(dim))
+		if !projDim.Valid() {
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: fmt.Sprintf("invalid dimension: %v", dim),
+			})
+		}
+		filter.Dim = &projDim
+	}
+
+	view, page, err := s.ProvidersService.GetProviderView(c.UserContext(), uid.String(), name, filter)
+	if err != nil {
+		switch v1.ErrorCode(err) {
+		case v1.ENOTFOUND:
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		case v1.EINVALID:
+			return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(v1.ViewResponse{
+		View: view,
+		Page: page,
+	})
+}
+
+// DropProviderView drops the named view of the provider with the given uid.
+// @Summary Delete provider view by name.
+// @Description Delete the named view of the provider with the given UID.
+// @Tags views
+// @Produce json
+// @Param uid path string true "Provider UID"
+// @Param name path string true "View name"
+// @Success 204 {string} status "Provider view deleted successfully"
+// @Failure 400 {object} v1.ErrorResponse
+// @Failure 404 {object} v1.ErrorResponse
+// @Failure 500 {object} v1.ErrorResponse
+// @Router /v1/providers/{uid}/views/{name} [delete]
+func (s *Server) DropProviderView(c *fiber.Ctx) error {
+	uid, err := uuid.Parse(c.Params("uid"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	name := c.Params("name")
+	if !v1.ValidViewName(name) {
+		return c.Status(fiber.StatusBadRequest).JSON(v1.ErrorResponse{
+			Error: fmt.Sprintf("invalid view name: %q", name),
+		})
+	}
+
+	if err := s.ProvidersService.DropProviderView(c.UserContext(), uid.String(), name); err != nil {
+		if code := v1.ErrorCode(err); code == v1.ENOTFOUND {
+			return c.Status(fiber.StatusNotFound).JSON(v1.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(v1.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,239 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/memory"
+)
+
+func MustSeedProviderView(t *testing.T, ps v1.ProvidersService, p *v1.Provider, name string) {
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 2.0, 3.0, 4.0}, Metadata: map[string]any{v1.LabelMetaKey: "foo: text", v1.RawLabelMetaKey: "foo"}},
+		{Values: []float64{2.0, 1.0, 4.0, 3.0}, Metadata: map[string]any{v1.LabelMetaKey: "foo: text", v1.RawLabelMetaKey: "foo"}},
+		{Values: []float64{4.0, 3.0, 2.0, 1.0}, Metadata: map[string]any{v1.LabelMetaKey: "foo: text", v1.RawLabelMetaKey: "foo"}},
+		{Values: []float64{3.0, 4.0, 1.0, 2.0}, Metadata: map[string]any{v1.LabelMetaKey: "bar: text", v1.RawLabelMetaKey: "bar"}},
+	}
+	if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+		t.Fatal(err)
+	}
+	filter := &v1.EmbeddingsFilter{Label: "foo"}
+	if err := ps.ComputeProviderView(context.TODO(), p.UID, name, filter, v1.PCA, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetProviderViews(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedProviderView(t, ps, px[0], "foo")
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/views", px[0].UID)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		views := new(v1.ViewsResponse)
+		if err := json.Unmarshal(body, views); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		if len(views.Views) != 1 || views.Views[0].Name != "foo" || views.Views[0].Count != 3 {
+			t.Fatalf("unexpected views: %v", views.Views)
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		s.ProvidersService = MustProvidersService(t, db)
+
+		uid := "97153afd-c434-4ca0-a35b-7467fcd08df1"
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/views", uid)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
+
+func TestGetProviderView(t *testing.T) {
+	t.Run("200", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedProviderView(t, ps, px[0], "foo")
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/views/foo?dim=2d&limit=2", px[0].UID)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusOK {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusOK, code)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("failed to read response body: %v", err)
+		}
+
+		view := new(v1.ViewResponse)
+		if err := json.Unmarshal(body, view); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		if len(view.View.Embeddings) != 1 || len(view.View.Embeddings[v1.Dim2D]) != 2 {
+			t.Fatalf("expected 2 %s view projections, got: %v", v1.Dim2D, view.View.Embeddings)
+		}
+		if *view.Page.Count != 3 {
+			t.Fatalf("expected page count: %d, got: %d", 3, *view.Page.Count)
+		}
+	})
+
+	t.Run("400", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedProviderView(t, ps, px[0], "foo")
+
+		urlPaths := []string{
+			"/api/v1/providers/foo/views/foo",
+			fmt.Sprintf("/api/v1/providers/%s/views/foo?dim=0D", px[0].UID),
+			fmt.Sprintf("/api/v1/providers/%s/views/foo?dim=5D", px[0].UID),
+			fmt.Sprintf("/api/v1/providers/%s/views/foo%%20bar", px[0].UID),
+		}
+
+		for _, urlPath := range urlPaths {
+			req := httptest.NewRequest("GET", urlPath, nil)
+
+			resp, err := s.app.Test(req)
+			if err != nil {
+				t.Fatalf("failed to get response: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if code := resp.StatusCode; code != http.StatusBadRequest {
+				t.Fatalf("%s: expected status code: %d, got: %d", urlPath, http.StatusBadRequest, code)
+			}
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/views/foo", px[0].UID)
+		req := httptest.NewRequest("GET", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
+
+func TestDropProviderView(t *testing.T) {
+	t.Run("204", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+		MustSeedProviderView(t, ps, px[0], "foo")
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/views/foo", px[0].UID)
+		req := httptest.NewRequest("DELETE", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNoContent {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNoContent, code)
+		}
+
+		views, err := ps.GetProviderViews(context.TODO(), px[0].UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(views) != 0 {
+			t.Fatalf("expected no views, got: %d", len(views))
+		}
+	})
+
+	t.Run("404", func(t *testing.T) {
+		s := MustServer(t)
+		db := MustOpenDB(t, memory.DSN)
+		ps := MustProvidersService(t, db)
+		s.ProvidersService = ps
+
+		px := MustSeedProviders(t, ps, 1)
+
+		urlPath := fmt.Sprintf("/api/v1/providers/%s/views/foo", px[0].UID)
+		req := httptest.NewRequest("DELETE", urlPath, nil)
+
+		resp, err := s.app.Test(req)
+		if err != nil {
+			t.Fatalf("failed to get response: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if code := resp.StatusCode; code != http.StatusNotFound {
+			t.Fatalf("expected status code: %d, got: %d", http.StatusNotFound, code)
+		}
+	})
+}
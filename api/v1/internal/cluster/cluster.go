@@ -0,0 +1,276 @@
+// Package cluster implements clustering of embeddings.
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/internal/search"
+)
+
+const (
+	// DefaultMaxIterations is the default maximum number of k-means iterations.
+	DefaultMaxIterations = 300
+	// DefaultMiniBatchIterations is the default maximum number of mini-batch k-means iterations.
+	DefaultMiniBatchIterations = 100
+	// DefaultBatchSize is the default batch size of mini-batch k-means.
+	DefaultBatchSize = 1024
+	// MiniBatchThreshold is the number of embeddings above which mini-batch k-means is used by default.
+	MiniBatchThreshold = 10000
+	// SilhouetteSampleSize is the maximum number of embeddings the silhouette coefficient is computed on.
+	SilhouetteSampleSize = 1000
+	// DefaultMinPoints is the default number of neighbours of DBSCAN core points.
+	DefaultMinPoints = 5
+	// DefaultMinClusterSize is the default minimum size of HDBSCAN clusters.
+	DefaultMinClusterSize = 5
+)
+
+// Assignment is the cluster assignment of an embedding.
+type Assignment struct {
+	// Cluster ID.
+	// It is v1.NoiseCluster for noise.
+	Cluster int
+	// Distance to the cluster centroid.
+	// Noise is assigned the distance to the nearest centroid.
+	Distance float64
+}
+
+// Metadata sets the cluster assignment in the metadata md.
+func (a Assignment) Metadata(md map[string]any) {
+	md[v1.ClusterMetaKey] = a.Cluster
+	md[v1.ClusterDistanceMetaKey] = a.Distance
+}
+
+// Cluster clusters embeddings embs using the given options.
+// It returns the clustering and the cluster assignments of embs.
+// If opts request a sweep, the clustering is scored for every k in the sweep range
+// which does not exceed the number of the embeddings.
+func Cluster(ctx context.Context, embs []v1.Embedding, opts *v1.ClusterOptions) (*v1.Clustering, []Assignment, error) {
+	if len(embs) == 0 {
+		return nil, nil, errors.New("no embeddings to cluster")
+	}
+
+	data, err := values(embs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seed := time.Now().UnixNano()
+	if opts.Seed != nil {
+		seed = *opts.Seed
+	}
+	metric := v1.Euclidean
+	if opts.Metric != "" {
+		metric = opts.Metric
+	}
+	dist, err := search.Distance(metric)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	algo := opts.Algorithm
+	if algo == "" {
+		algo = v1.KMeans
+	}
+
+	var (
+		res       *result
+		batchSize int
+	)
+	switch algo {
+	case v1.KMeans:
+		if opts.K > len(data) {
+			return nil, nil, fmt.Errorf("k %d exceeds the number of embeddings: %d", opts.K, len(data))
+		}
+		batchSize = opts.BatchSize
+		if batchSize == 0 && len(data) > MiniBatchThreshold {
+			batchSize = DefaultBatchSize
+		}
+		if batchSize >= len(data) {
+			batchSize = 0
+		}
+		res, err = kmeans(ctx, data, opts.K, opts.MaxIterations, batchSize, rand.New(rand.NewSource(seed)))
+	case v1.DBSCAN:
+		minPts := opts.MinPoints
+		if minPts == 0 {
+			minPts = DefaultMinPoints
+		}
+		res, err = dbscan(ctx, data, opts.Eps, minPts, dist)
+	case v1.HDBSCAN:
+		minSize := opts.MinClusterSize
+		if minSize == 0 {
+			minSize = DefaultMinClusterSize
+		}
+		minPts := opts.MinPoints
+		if minPts == 0 {
+			minPts = minSize
+		}
+		res, err = hdbscan(ctx, data, minPts, minSize, dist)
+	default:
+		return nil, nil, fmt.Errorf("unsupported algorithm: %v", algo)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// NOTE: silhouette is computed on a sample shared by all the scored clusterings
+	sample := sampleIndices(len(data), SilhouetteSampleSize, rand.New(rand.NewSource(seed)))
+	dists := pairwiseDistances(data, sample, dist)
+
+	clustering := &v1.Clustering{
+		Algorithm:  algo,
+		K:          len(res.centroids),
+		Metric:     metric,
+		Noise:      res.noise(),
+		Seed:       seed,
+		MiniBatch:  batchSize > 0,
+		Iterations: res.iterations,
+		Inertia:    res.inertia,
+		Silhouette: silhouette(dists, sampleLabels(res.labels, sample), len(res.centroids)),
+		Centroids:  res.centroids,
+		Sizes:      res.sizes,
+	}
+
+	// NOTE: the clustering and every scored k make up equal shares of the progress
+	steps := 1
+	if s := opts.Sweep; s != nil {
+		steps += max(0, min(s.Max, len(data))-s.Min+1)
+	}
+	v1.ReportProgress(ctx, 1/float64(steps))
+
+	if s := opts.Sweep; s != nil {
+		for k := s.Min; k <= s.Max && k <= len(data); k++ {
+			r, err := kmeans(ctx, data, k, opts.MaxIterations, batchSize, rand.New(rand.NewSource(seed)))
+			if err != nil {
+				return nil, nil, err
+			}
+			clustering.Sweep = append(clustering.Sweep, v1.ClusterScore{
+				K:          k,
+				Inertia:    r.inertia,
+				Silhouette: silhouette(dists, sampleLabels(r.labels, sample), k),
+			})
+			v1.ReportProgress(ctx, float64(k-s.Min+2)/float64(steps))
+		}
+	}
+
+	assignments := make([]Assignment, len(data))
+	for i := range assignments {
+		assignments[i] = Assignment{
+			Cluster:  res.labels[i],
+			Distance: res.dists[i],
+		}
+	}
+
+	return clustering, assignments, nil
+}
+
+// values returns the values of embs.
+// It returns error if embs have different dimensions.
+func values(embs []v1.Embedding) ([][]float64, error) {
+	data := make([][]float64, len(embs))
+	for i, e := range embs {
+		if len(e.Values) != len(embs[0].Values) {
+			return nil, fmt.Errorf("embedding %d dimension mismatch: %d != %d", i, len(e.Values), len(embs[0].Values))
+		}
+		data[i] = e.Values
+	}
+	return data, nil
+}
+
+// result of clustering.
+type result struct {
+	// labels are the cluster IDs of the data.
+	labels []int
+	// dists are the distances of the data to their cluster centroids.
+	// Noise is assigned the distance to the nearest centroid.
+	dists      []float64
+	centroids  [][]float64
+	sizes      []int
+	inertia    float64
+	iterations int
+}
+
+// noise returns the number of the data labelled as noise.
+func (r *result) noise() int {
+	n := 0
+	for _, l := range r.labels {
+		if l == v1.NoiseCluster {
+			n++
+		}
+	}
+	return n
+}
+
+// densityResult returns the result of the density-based clustering of data with the given labels.
+// The centroids are the means of the clusters and the distances are measured by dist.
+func densityResult(data [][]float64, labels []int, dist func(x, y []float64) float64) *result {
+	k := 0
+	for _, l := range labels {
+		k = max(k, l+1)
+	}
+
+	res := &result{
+		labels:    labels,
+		dists:     make([]float64, len(data)),
+		centroids: make([][]float64, k),
+		sizes:     make([]int, k),
+	}
+	for c := range res.centroids {
+		res.centroids[c] = make([]float64, len(data[0]))
+	}
+	for i, l := range labels {
+		if l == v1.NoiseCluster {
+			continue
+		}
+		res.sizes[l]++
+		for j, v := range data[i] {
+			res.centroids[l][j] += v
+		}
+	}
+	for c, centroid := range res.centroids {
+		for j := range centroid {
+			centroid[j] /= float64(res.sizes[c])
+		}
+	}
+	for i, l := range labels {
+		if l != v1.NoiseCluster {
+			res.dists[i] = dist(data[i], res.centroids[l])
+			res.inertia += res.dists[i] * res.dists[i]
+			continue
+		}
+		if k > 0 {
+			res.dists[i] = math.Inf(1)
+			for _, centroid := range res.centroids {
+				res.dists[i] = math.Min(res.dists[i], dist(data[i], centroid))
+			}
+		}
+	}
+
+	return res
+}
+
+// sampleIndices returns the indices of at most size randomly sampled items out of n.
+func sampleIndices(n, size int, rnd *rand.Rand) []int {
+	if n <= size {
+		idx := make([]int, n)
+		for i := range idx {
+			idx[i] = i
+		}
+		return idx
+	}
+	return rnd.Perm(n)[:size]
+}
+
+// sampleLabels returns the labels of the sampled items.
+func sampleLabels(labels, sample []int) []int {
+	res := make([]int, len(sample))
+	for i, idx := range sample {
+		res[i] = labels[idx]
+	}
+	return res
+}
@@ -0,0 +1,214 @@
+package cluster
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/internal/search"
+)
+
+// blobs returns n embeddings around each of the given centers.
+func blobs(centers [][]float64, n int, seed int64) []v1.Embedding {
+	rnd := rand.New(rand.NewSource(seed))
+	embs := make([]v1.Embedding, 0, n*len(centers))
+	for _, c := range centers {
+		for i := 0; i < n; i++ {
+			vals := make([]float64, len(c))
+			for j := range vals {
+				vals[j] = c[j] + rnd.NormFloat64()*0.1
+			}
+			embs = append(embs, v1.Embedding{Values: vals})
+		}
+	}
+	return embs
+}
+
+func TestCluster(t *testing.T) {
+	centers := [][]float64{{0, 0, 0}, {10, 0, 0}, {0, 10, 10}}
+	embs := blobs(centers, 30, 1)
+	seed := int64(1)
+
+	t.Run("KMeans", func(t *testing.T) {
+		res, assignments, err := Cluster(context.TODO(), embs, &v1.ClusterOptions{K: 3, Seed: &seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.MiniBatch {
+			t.Fatal("unexpected mini-batch k-means")
+		}
+		if !reflect.DeepEqual(res.Sizes, []int{30, 30, 30}) {
+			t.Fatalf("expected equal cluster sizes, got: %v", res.Sizes)
+		}
+		if res.Silhouette < 0.9 {
+			t.Fatalf("expected silhouette close to 1, got: %v", res.Silhouette)
+		}
+		// NOTE: all the embeddings of a blob must be assigned to the same cluster
+		for i, a := range assignments {
+			if a.Cluster != assignments[i/30*30].Cluster {
+				t.Fatalf("embedding %d assigned to cluster %d, expected: %d", i, a.Cluster, assignments[i/30*30].Cluster)
+			}
+			if a.Distance > 1 {
+				t.Fatalf("embedding %d distance to centroid: %v", i, a.Distance)
+			}
+		}
+
+		again, _, err := Cluster(context.TODO(), embs, &v1.ClusterOptions{K: 3, Seed: &seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res, again) {
+			t.Fatal("expected the same clustering for the same seed")
+		}
+	})
+
+	t.Run("MiniBatch", func(t *testing.T) {
+		res, _, err := Cluster(context.TODO(), embs, &v1.ClusterOptions{K: 3, Seed: &seed, BatchSize: 16})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.MiniBatch {
+			t.Fatal("expected mini-batch k-means")
+		}
+		if !reflect.DeepEqual(res.Sizes, []int{30, 30, 30}) {
+			t.Fatalf("expected equal cluster sizes, got: %v", res.Sizes)
+		}
+	})
+
+	t.Run("Sweep", func(t *testing.T) {
+		var progress []float64
+		ctx := v1.WithProgress(context.TODO(), func(p float64) { progress = append(progress, p) })
+		res, _, err := Cluster(ctx, embs, &v1.ClusterOptions{K: 3, Seed: &seed, Sweep: &v1.ClusterRange{Min: 2, Max: 5}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Sweep) != 4 {
+			t.Fatalf("expected 4 scores, got: %d", len(res.Sweep))
+		}
+		if len(progress) != 5 || progress[len(progress)-1] != 1 {
+			t.Fatalf("expected 5 progress reports ending at 1, got: %v", progress)
+		}
+		best := res.Sweep[0]
+		for i, s := range res.Sweep {
+			if i > 0 && s.Inertia > res.Sweep[i-1].Inertia {
+				t.Fatalf("expected non-increasing inertia, got: %v", res.Sweep)
+			}
+			if s.Silhouette > best.Silhouette {
+				best = s
+			}
+		}
+		if best.K != 3 {
+			t.Fatalf("expected the best silhouette for k: 3, got: %d", best.K)
+		}
+	})
+
+	// NOTE: outliers far away from the blobs and from each other
+	outliers := []v1.Embedding{
+		{Values: []float64{50, 50, 50}},
+		{Values: []float64{-50, 50, -50}},
+	}
+	noisy := append(append([]v1.Embedding{}, embs...), outliers...)
+
+	for _, opts := range []*v1.ClusterOptions{
+		{Algorithm: v1.DBSCAN, Eps: 1},
+		{Algorithm: v1.HDBSCAN},
+	} {
+		t.Run(string(opts.Algorithm), func(t *testing.T) {
+			res, assignments, err := Cluster(context.TODO(), noisy, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.K != 3 {
+				t.Fatalf("expected 3 clusters, got: %d", res.K)
+			}
+			if res.Noise != len(outliers) {
+				t.Fatalf("expected %d noise embeddings, got: %d", len(outliers), res.Noise)
+			}
+			if !reflect.DeepEqual(res.Sizes, []int{30, 30, 30}) {
+				t.Fatalf("expected equal cluster sizes, got: %v", res.Sizes)
+			}
+			for i, a := range assignments[:len(embs)] {
+				if a.Cluster != assignments[i/30*30].Cluster {
+					t.Fatalf("embedding %d assigned to cluster %d, expected: %d", i, a.Cluster, assignments[i/30*30].Cluster)
+				}
+			}
+			for i, a := range assignments[len(embs):] {
+				if a.Cluster != v1.NoiseCluster {
+					t.Fatalf("outlier %d assigned to cluster: %d", i, a.Cluster)
+				}
+				if a.Distance < 40 {
+					t.Fatalf("outlier %d distance to the nearest centroid: %v", i, a.Distance)
+				}
+			}
+			if res.Silhouette < 0.9 {
+				t.Fatalf("expected silhouette close to 1, got: %v", res.Silhouette)
+			}
+		})
+	}
+
+	t.Run("Cosine", func(t *testing.T) {
+		// NOTE: scaled vectors have the same direction
+		var dirs []v1.Embedding
+		for i := 1; i <= 10; i++ {
+			f := float64(i)
+			dirs = append(dirs, v1.Embedding{Values: []float64{f, 0}}, v1.Embedding{Values: []float64{0, f}})
+		}
+		res, assignments, err := Cluster(context.TODO(), dirs, &v1.ClusterOptions{Algorithm: v1.DBSCAN, Metric: v1.Cosine, Eps: 0.01, MinPoints: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Metric != v1.Cosine || res.K != 2 || res.Noise != 0 {
+			t.Fatalf("expected 2 cosine clusters without noise, got: %d clusters, %d noise", res.K, res.Noise)
+		}
+		for i, a := range assignments {
+			if a.Cluster != assignments[i%2].Cluster {
+				t.Fatalf("embedding %d assigned to cluster %d, expected: %d", i, a.Cluster, assignments[i%2].Cluster)
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, _, err := Cluster(context.TODO(), embs, &v1.ClusterOptions{K: len(embs) + 1}); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, _, err := Cluster(context.TODO(), nil, &v1.ClusterOptions{K: 1}); err == nil {
+			t.Fatal("expected error")
+		}
+		mixed := []v1.Embedding{{Values: []float64{1, 2}}, {Values: []float64{1}}}
+		if _, _, err := Cluster(context.TODO(), mixed, &v1.ClusterOptions{K: 1}); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, _, err := Cluster(context.TODO(), embs, &v1.ClusterOptions{Algorithm: v1.DBSCAN, Eps: 1, Metric: "foo"}); err == nil {
+			t.Fatal("expected error")
+		}
+		// NOTE: dot product is not a distance
+		if _, _, err := Cluster(context.TODO(), embs, &v1.ClusterOptions{Algorithm: v1.DBSCAN, Eps: 1, Metric: v1.Dot}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestSilhouette(t *testing.T) {
+	data := [][]float64{{0}, {1}, {10}, {11}}
+	dist, err := search.Distance(v1.Euclidean)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dists := pairwiseDistances(data, []int{0, 1, 2, 3}, dist)
+
+	// NOTE: a = 1 and b = 10.5 for the outer and 9.5 for the inner points
+	exp := 1 - (1/10.5+1/9.5)/2
+	if got := silhouette(dists, []int{0, 0, 1, 1}, 2); got-exp > 1e-9 || exp-got > 1e-9 {
+		t.Fatalf("expected silhouette: %v, got: %v", exp, got)
+	}
+	if got := silhouette(dists, []int{0, 0, 0, 0}, 1); got != 0 {
+		t.Fatalf("expected zero silhouette, got: %v", got)
+	}
+	// NOTE: noise is excluded from the coefficient; the coefficient of the singleton is zero
+	exp = (2 - 1/10.0 - 1/9.0) / 3
+	if got := silhouette(dists, []int{0, 0, 1, v1.NoiseCluster}, 2); got-exp > 1e-9 || exp-got > 1e-9 {
+		t.Fatalf("expected silhouette: %v, got: %v", exp, got)
+	}
+}
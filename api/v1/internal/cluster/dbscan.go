@@ -0,0 +1,76 @@
+package cluster
+
+import (
+	"context"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// dbscan clusters data using DBSCAN.
+// Core points have at least minPts neighbours, including themselves, within eps distance measured by dist.
+// Clusters consist of the core points reachable from each other and their neighbours; the rest of the data is noise.
+// See: https://en.wikipedia.org/wiki/DBSCAN
+func dbscan(ctx context.Context, data [][]float64, eps float64, minPts int, dist func(x, y []float64) float64) (*result, error) {
+	labels := make([]int, len(data))
+	visited := make([]bool, len(data))
+	for i := range labels {
+		labels[i] = v1.NoiseCluster
+	}
+
+	neighbours := func(i int) []int {
+		var idx []int
+		for j := range data {
+			if dist(data[i], data[j]) <= eps {
+				idx = append(idx, j)
+			}
+		}
+		return idx
+	}
+
+	k := 0
+	for i := range data {
+		if visited[i] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		visited[i] = true
+
+		queue := neighbours(i)
+		if len(queue) < minPts {
+			continue
+		}
+		labels[i] = k
+		queued := make(map[int]bool, len(queue))
+		for _, j := range queue {
+			queued[j] = true
+		}
+		for len(queue) > 0 {
+			j := queue[0]
+			queue = queue[1:]
+			// NOTE: border points previously marked as noise join the cluster
+			if labels[j] == v1.NoiseCluster {
+				labels[j] = k
+			}
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if nbrs := neighbours(j); len(nbrs) >= minPts {
+				for _, n := range nbrs {
+					if !queued[n] {
+						queued[n] = true
+						queue = append(queue, n)
+					}
+				}
+			}
+		}
+		k++
+	}
+
+	return densityResult(data, labels, dist), nil
+}
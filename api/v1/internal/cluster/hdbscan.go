@@ -0,0 +1,280 @@
+package cluster
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// minDistance bounds the distances inverted into the density levels of HDBSCAN.
+const minDistance = 1e-12
+
+// hdbscan clusters data using HDBSCAN.
+// The core distance of a point is the distance to its minPts-th nearest neighbour, including itself.
+// Clusters are selected from the hierarchy of the mutual reachability distances measured by dist
+// by their stability; clusters smaller than minSize are treated as noise.
+// See: https://hdbscan.readthedocs.io/en/latest/how_hdbscan_works.html
+func hdbscan(ctx context.Context, data [][]float64, minPts, minSize int, dist func(x, y []float64) float64) (*result, error) {
+	n := len(data)
+	labels := make([]int, n)
+	for i := range labels {
+		labels[i] = v1.NoiseCluster
+	}
+	if n < minSize || n < 2 {
+		return densityResult(data, labels, dist), nil
+	}
+
+	core, err := coreDistances(ctx, data, min(minPts, n), dist)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := spanningTree(ctx, data, core, dist)
+	if err != nil {
+		return nil, err
+	}
+	tree := linkage(n, edges)
+	clusters := condense(tree, n, minSize)
+
+	for c, cl := range selectClusters(clusters) {
+		for _, p := range clusters.points(cl) {
+			labels[p] = c
+		}
+	}
+
+	return densityResult(data, labels, dist), nil
+}
+
+// coreDistances returns the distances of data to their k-th nearest neighbours, including themselves.
+func coreDistances(ctx context.Context, data [][]float64, k int, dist func(x, y []float64) float64) ([]float64, error) {
+	core := make([]float64, len(data))
+	dists := make([]float64, len(data))
+	for i := range data {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for j := range data {
+			dists[j] = dist(data[i], data[j])
+		}
+		dists[i] = 0
+		sort.Float64s(dists)
+		core[i] = dists[k-1]
+	}
+	return core, nil
+}
+
+// edge of the minimum spanning tree.
+type edge struct {
+	a, b   int
+	weight float64
+}
+
+// spanningTree returns the edges of the minimum spanning tree of data
+// weighted by the mutual reachability distances of the data sorted by their weights.
+func spanningTree(ctx context.Context, data [][]float64, core []float64, dist func(x, y []float64) float64) ([]edge, error) {
+	n := len(data)
+	inTree := make([]bool, n)
+	weights := make([]float64, n)
+	parents := make([]int, n)
+	for i := range weights {
+		weights[i] = math.Inf(1)
+	}
+
+	edges := make([]edge, 0, n-1)
+	cur := 0
+	for len(edges) < n-1 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		inTree[cur] = true
+		next := -1
+		for j := range data {
+			if inTree[j] {
+				continue
+			}
+			d := math.Max(dist(data[cur], data[j]), math.Max(core[cur], core[j]))
+			if d < weights[j] {
+				weights[j], parents[j] = d, cur
+			}
+			if next < 0 || weights[j] < weights[next] {
+				next = j
+			}
+		}
+		edges = append(edges, edge{a: parents[next], b: next, weight: weights[next]})
+		cur = next
+	}
+
+	sort.SliceStable(edges, func(i, j int) bool {
+		return edges[i].weight < edges[j].weight
+	})
+	return edges, nil
+}
+
+// node of the single linkage tree.
+// Leaves are the data points; node n+i is created by the i-th merge.
+type node struct {
+	left, right int
+	dist        float64
+	size        int
+}
+
+// linkage returns the single linkage tree of n points built from the sorted spanning tree edges.
+func linkage(n int, edges []edge) []node {
+	tree := make([]node, n, 2*n-1)
+	for i := range tree {
+		tree[i] = node{left: -1, right: -1, size: 1}
+	}
+
+	parents := make([]int, 2*n-1)
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+		return i
+	}
+
+	for _, e := range edges {
+		a, b := find(e.a), find(e.b)
+		id := len(tree)
+		tree = append(tree, node{left: a, right: b, dist: e.weight, size: tree[a].size + tree[b].size})
+		parents[a], parents[b] = id, id
+	}
+
+	return tree
+}
+
+// condensed cluster of the condensed tree.
+type condensed struct {
+	children  []int
+	birth     float64
+	stability float64
+	// members are the points falling out of the cluster.
+	members []int
+}
+
+// condensedTree is the single linkage tree condensed to the clusters of at least the minimum size.
+// The root cluster is the first cluster of the tree; children follow their parents.
+type condensedTree []*condensed
+
+// points returns all the points of the cluster c and its descendants.
+func (t condensedTree) points(c int) []int {
+	var pts []int
+	stack := []int{c}
+	for len(stack) > 0 {
+		cl := t[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		pts = append(pts, cl.members...)
+		stack = append(stack, cl.children...)
+	}
+	return pts
+}
+
+// condense condenses the single linkage tree of n points into the clusters of at least minSize points.
+// Density levels are the inverted linkage distances.
+func condense(tree []node, n, minSize int) condensedTree {
+	clusters := condensedTree{{}}
+
+	leaves := func(i int) []int {
+		var pts []int
+		stack := []int{i}
+		for len(stack) > 0 {
+			nd := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if nd < n {
+				pts = append(pts, nd)
+				continue
+			}
+			stack = append(stack, tree[nd].left, tree[nd].right)
+		}
+		return pts
+	}
+
+	type item struct{ node, cluster int }
+	stack := []item{{node: len(tree) - 1, cluster: 0}}
+	for len(stack) > 0 {
+		it := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		cl := clusters[it.cluster]
+		if it.node < n {
+			cl.members = append(cl.members, it.node)
+			continue
+		}
+
+		nd := tree[it.node]
+		lambda := 1 / math.Max(nd.dist, minDistance)
+		left, right := tree[nd.left], tree[nd.right]
+		// NOTE: stability accumulates the density levels the points fall out of the cluster at
+		switch {
+		case left.size >= minSize && right.size >= minSize:
+			cl.stability += (lambda - cl.birth) * float64(nd.size)
+			for _, child := range []int{nd.left, nd.right} {
+				clusters = append(clusters, &condensed{birth: lambda})
+				cl.children = append(cl.children, len(clusters)-1)
+				stack = append(stack, item{node: child, cluster: len(clusters) - 1})
+			}
+		case left.size < minSize && right.size < minSize:
+			cl.stability += (lambda - cl.birth) * float64(nd.size)
+			cl.members = append(cl.members, leaves(nd.left)...)
+			cl.members = append(cl.members, leaves(nd.right)...)
+		case left.size < minSize:
+			cl.stability += (lambda - cl.birth) * float64(left.size)
+			cl.members = append(cl.members, leaves(nd.left)...)
+			stack = append(stack, item{node: nd.right, cluster: it.cluster})
+		default:
+			cl.stability += (lambda - cl.birth) * float64(right.size)
+			cl.members = append(cl.members, leaves(nd.right)...)
+			stack = append(stack, item{node: nd.left, cluster: it.cluster})
+		}
+	}
+
+	return clusters
+}
+
+// selectClusters returns the most stable non-overlapping clusters of the condensed tree clusters
+// excluding its root using the excess of mass method.
+func selectClusters(clusters condensedTree) []int {
+	selected := make([]bool, len(clusters))
+	stability := make([]float64, len(clusters))
+	for c, cl := range clusters {
+		stability[c] = cl.stability
+	}
+
+	for c := len(clusters) - 1; c > 0; c-- {
+		cl := clusters[c]
+		if len(cl.children) == 0 {
+			selected[c] = true
+			continue
+		}
+		sum := 0.0
+		for _, child := range cl.children {
+			sum += stability[child]
+		}
+		if sum > stability[c] {
+			stability[c] = sum
+			continue
+		}
+		selected[c] = true
+		stack := append([]int(nil), cl.children...)
+		for len(stack) > 0 {
+			d := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			selected[d] = false
+			stack = append(stack, clusters[d].children...)
+		}
+	}
+
+	var res []int
+	for c := 1; c < len(clusters); c++ {
+		if selected[c] {
+			res = append(res, c)
+		}
+	}
+	return res
+}
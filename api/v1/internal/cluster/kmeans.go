@@ -0,0 +1,259 @@
+package cluster
+
+import (
+	"context"
+	"math"
+	"math/rand"
+)
+
+// tolerance of mini-batch k-means convergence relative to the mean variance of the data.
+const tolerance = 1e-4
+
+// kmeans clusters data into k clusters.
+// If batchSize is positive, it runs mini-batch k-means with the given batch size.
+// See: https://www.eecs.tufts.edu/~dsculley/papers/fastkmeans.pdf
+func kmeans(ctx context.Context, data [][]float64, k, maxIter, batchSize int, rnd *rand.Rand) (*result, error) {
+	if maxIter == 0 {
+		maxIter = DefaultMaxIterations
+		if batchSize > 0 {
+			maxIter = DefaultMiniBatchIterations
+		}
+	}
+
+	init := data
+	if batchSize > 0 {
+		// NOTE: seeding on a sample keeps the initialization of large data cheap
+		init = make([][]float64, 0, min(len(data), 3*max(batchSize, k)))
+		for _, idx := range sampleIndices(len(data), cap(init), rnd) {
+			init = append(init, data[idx])
+		}
+	}
+	centroids := seedCentroids(init, k, rnd)
+
+	var (
+		iters int
+		err   error
+	)
+	if batchSize > 0 {
+		iters, err = miniBatch(ctx, data, centroids, maxIter, batchSize, rnd)
+	} else {
+		iters, err = lloyd(ctx, data, centroids, maxIter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := &result{
+		labels:     make([]int, len(data)),
+		dists:      make([]float64, len(data)),
+		centroids:  centroids,
+		sizes:      make([]int, k),
+		iterations: iters,
+	}
+	assign(data, centroids, res.labels, res.dists)
+	for i, l := range res.labels {
+		res.sizes[l]++
+		res.inertia += res.dists[i]
+		res.dists[i] = math.Sqrt(res.dists[i])
+	}
+
+	return res, nil
+}
+
+// seedCentroids picks k initial centroids from data using k-means++ seeding.
+// See: https://en.wikipedia.org/wiki/K-means%2B%2B
+func seedCentroids(data [][]float64, k int, rnd *rand.Rand) [][]float64 {
+	centroids := make([][]float64, 0, k)
+	centroids = append(centroids, clone(data[rnd.Intn(len(data))]))
+
+	dists := make([]float64, len(data))
+	for i, x := range data {
+		dists[i] = sqDist(x, centroids[0])
+	}
+	for len(centroids) < k {
+		total := 0.0
+		for _, d := range dists {
+			total += d
+		}
+		next := 0
+		if total > 0 {
+			r := rnd.Float64() * total
+			for i, d := range dists {
+				if r -= d; r <= 0 {
+					next = i
+					break
+				}
+				next = i
+			}
+		} else {
+			// NOTE: all the data coincide with the centroids
+			next = rnd.Intn(len(data))
+		}
+		c := clone(data[next])
+		centroids = append(centroids, c)
+		for i, x := range data {
+			dists[i] = math.Min(dists[i], sqDist(x, c))
+		}
+	}
+
+	return centroids
+}
+
+// lloyd runs Lloyd's k-means iterations updating centroids in place until the assignments do not change.
+// It returns the number of the iterations run.
+func lloyd(ctx context.Context, data [][]float64, centroids [][]float64, maxIter int) (int, error) {
+	k, dim := len(centroids), len(centroids[0])
+	labels := make([]int, len(data))
+	for i := range labels {
+		labels[i] = -1
+	}
+	dists := make([]float64, len(data))
+	counts := make([]int, k)
+
+	iter := 0
+	for iter < maxIter {
+		if err := ctx.Err(); err != nil {
+			return iter, err
+		}
+		iter++
+
+		if changed := assign(data, centroids, labels, dists); changed == 0 {
+			break
+		}
+
+		for c := range centroids {
+			counts[c] = 0
+			for j := range centroids[c] {
+				centroids[c][j] = 0
+			}
+		}
+		for i, x := range data {
+			c := labels[i]
+			counts[c]++
+			for j := 0; j < dim; j++ {
+				centroids[c][j] += x[j]
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				// NOTE: empty clusters are moved to the point farthest from its centroid
+				far := 0
+				for i := range dists {
+					if dists[i] > dists[far] {
+						far = i
+					}
+				}
+				copy(centroids[c], data[far])
+				dists[far] = 0
+				continue
+			}
+			for j := range centroids[c] {
+				centroids[c][j] /= float64(counts[c])
+			}
+		}
+	}
+
+	return iter, nil
+}
+
+// miniBatch runs mini-batch k-means iterations updating centroids in place
+// until the centroids movement drops below the tolerance.
+// It returns the number of the iterations run.
+func miniBatch(ctx context.Context, data [][]float64, centroids [][]float64, maxIter, batchSize int, rnd *rand.Rand) (int, error) {
+	tol := tolerance * meanVariance(data)
+	counts := make([]float64, len(centroids))
+	prev := make([][]float64, len(centroids))
+	for c := range prev {
+		prev[c] = make([]float64, len(centroids[c]))
+	}
+
+	iter := 0
+	for iter < maxIter {
+		if err := ctx.Err(); err != nil {
+			return iter, err
+		}
+		iter++
+
+		for c := range centroids {
+			copy(prev[c], centroids[c])
+		}
+		for b := 0; b < batchSize; b++ {
+			x := data[rnd.Intn(len(data))]
+			c, _ := nearest(x, centroids)
+			counts[c]++
+			eta := 1 / counts[c]
+			for j := range centroids[c] {
+				centroids[c][j] += eta * (x[j] - centroids[c][j])
+			}
+		}
+
+		shift := 0.0
+		for c := range centroids {
+			shift += sqDist(prev[c], centroids[c])
+		}
+		if shift/float64(len(centroids)) <= tol {
+			break
+		}
+	}
+
+	return iter, nil
+}
+
+// assign assigns data to their nearest centroids storing the labels and squared distances in place.
+// It returns the number of the changed labels.
+func assign(data, centroids [][]float64, labels []int, dists []float64) int {
+	changed := 0
+	for i, x := range data {
+		c, d := nearest(x, centroids)
+		if labels[i] != c {
+			changed++
+		}
+		labels[i], dists[i] = c, d
+	}
+	return changed
+}
+
+// nearest returns the index of the centroid nearest to x and its squared distance.
+func nearest(x []float64, centroids [][]float64) (int, float64) {
+	best, bestDist := 0, math.Inf(1)
+	for c, centroid := range centroids {
+		if d := sqDist(x, centroid); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best, bestDist
+}
+
+// meanVariance returns the mean of the variances of the data dimensions.
+func meanVariance(data [][]float64) float64 {
+	dim := len(data[0])
+	mean := make([]float64, dim)
+	for _, x := range data {
+		for j, v := range x {
+			mean[j] += v
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(len(data))
+	}
+	total := 0.0
+	for _, x := range data {
+		total += sqDist(x, mean)
+	}
+	return total / float64(len(data)*dim)
+}
+
+func sqDist(a, b []float64) float64 {
+	d := 0.0
+	for i := range a {
+		diff := a[i] - b[i]
+		d += diff * diff
+	}
+	return d
+}
+
+func clone(x []float64) []float64 {
+	c := make([]float64, len(x))
+	copy(c, x)
+	return c
+}
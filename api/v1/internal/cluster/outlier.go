@@ -0,0 +1,117 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/internal/search"
+)
+
+// lrdEpsilon keeps the local reachability density of duplicates finite.
+const lrdEpsilon = 1e-10
+
+// Outliers scores embeddings embs for outlierness using the given options.
+// It returns the outlier scores of embs and the outlier detection without the top outliers.
+// Higher scores mean more outlying embeddings. LOF scores of inliers are close to 1,
+// KNN scores are the distances to the k-th nearest neighbours.
+// If k is not smaller than the number of embs, all the other embeddings are the neighbours.
+func Outliers(ctx context.Context, embs []v1.Embedding, opts *v1.OutlierOptions) ([]float64, *v1.Outliers, error) {
+	if len(embs) < 2 {
+		return nil, nil, errors.New("insufficient embeddings: needs at least: 2")
+	}
+	data, err := values(embs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res := &v1.Outliers{
+		Method: v1.LOF,
+		K:      v1.DefaultOutlierNeighbours,
+		Metric: v1.Euclidean,
+	}
+	if opts.Method != "" {
+		res.Method = opts.Method
+	}
+	if opts.K > 0 {
+		res.K = opts.K
+	}
+	res.K = min(res.K, len(data)-1)
+	if opts.Metric != "" {
+		res.Metric = opts.Metric
+	}
+	dist, err := search.Distance(res.Metric)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nbrs, dists, err := knn(ctx, data, res.K, dist)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scores := make([]float64, len(data))
+	switch res.Method {
+	case v1.KNN:
+		for i := range scores {
+			scores[i] = dists[i][res.K-1]
+		}
+	case v1.LOF:
+		lrd := make([]float64, len(data))
+		for i := range data {
+			reach := 0.0
+			for j, o := range nbrs[i] {
+				reach += max(dists[o][res.K-1], dists[i][j])
+			}
+			lrd[i] = 1 / (reach/float64(res.K) + lrdEpsilon)
+		}
+		for i := range data {
+			sum := 0.0
+			for _, o := range nbrs[i] {
+				sum += lrd[o]
+			}
+			scores[i] = sum / float64(res.K) / lrd[i]
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported method: %v", res.Method)
+	}
+
+	return scores, res, nil
+}
+
+// knn returns the k nearest neighbours of data and their distances measured by dist
+// sorted from the nearest neighbour.
+func knn(ctx context.Context, data [][]float64, k int, dist func(x, y []float64) float64) ([][]int, [][]float64, error) {
+	nbrs := make([][]int, len(data))
+	dists := make([][]float64, len(data))
+	for i := range data {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		idx := make([]int, 0, k)
+		ds := make([]float64, 0, k)
+		for j := range data {
+			if i == j {
+				continue
+			}
+			d := dist(data[i], data[j])
+			if len(ds) == k && d >= ds[k-1] {
+				continue
+			}
+			// NOTE: insertion into the sorted neighbours is cheap for small k
+			if len(ds) < k {
+				idx, ds = append(idx, j), append(ds, d)
+			} else {
+				idx[k-1], ds[k-1] = j, d
+			}
+			for p := len(ds) - 1; p > 0 && ds[p] < ds[p-1]; p-- {
+				idx[p], idx[p-1] = idx[p-1], idx[p]
+				ds[p], ds[p-1] = ds[p-1], ds[p]
+			}
+		}
+		nbrs[i], dists[i] = idx, ds
+		v1.ReportProgress(ctx, float64(i+1)/float64(len(data)))
+	}
+	return nbrs, dists, nil
+}
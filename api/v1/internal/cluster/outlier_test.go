@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestOutliers(t *testing.T) {
+	embs := blobs([][]float64{{0, 0, 0}, {10, 0, 0}}, 30, 1)
+	// NOTE: the last embedding is far away from both blobs
+	embs = append(embs, v1.Embedding{Values: []float64{5, 5, 5}})
+
+	for _, method := range []v1.OutlierMethod{v1.LOF, v1.KNN} {
+		t.Run(string(method), func(t *testing.T) {
+			scores, res, err := Outliers(context.TODO(), embs, &v1.OutlierOptions{Method: method, K: 5})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Method != method || res.K != 5 || res.Metric != v1.Euclidean {
+				t.Fatalf("unexpected outlier detection: %#v", res)
+			}
+			if len(scores) != len(embs) {
+				t.Fatalf("expected %d scores, got: %d", len(embs), len(scores))
+			}
+			last := len(embs) - 1
+			for i, s := range scores[:last] {
+				if s >= scores[last] {
+					t.Fatalf("embedding %d score %v exceeds the outlier score: %v", i, s, scores[last])
+				}
+			}
+			if method == v1.LOF && scores[last] < 2 {
+				t.Fatalf("expected high outlier factor, got: %v", scores[last])
+			}
+		})
+	}
+
+	t.Run("K", func(t *testing.T) {
+		// NOTE: k is capped by the number of the other embeddings
+		_, res, err := Outliers(context.TODO(), embs[:3], &v1.OutlierOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.K != 2 || res.Method != v1.LOF {
+			t.Fatalf("unexpected outlier detection: %#v", res)
+		}
+	})
+
+	t.Run("Duplicates", func(t *testing.T) {
+		dups := []v1.Embedding{{Values: []float64{1, 1}}, {Values: []float64{1, 1}}, {Values: []float64{1, 1}}}
+		scores, _, err := Outliers(context.TODO(), dups, &v1.OutlierOptions{K: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, s := range scores {
+			if s != 1 {
+				t.Fatalf("embedding %d: expected outlier factor 1, got: %v", i, s)
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, _, err := Outliers(context.TODO(), embs[:1], &v1.OutlierOptions{}); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, _, err := Outliers(context.TODO(), embs, &v1.OutlierOptions{Metric: "foo"}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"math"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// pairwiseDistances returns the distances between the sampled data measured by dist.
+func pairwiseDistances(data [][]float64, sample []int, dist func(x, y []float64) float64) [][]float64 {
+	dists := make([][]float64, len(sample))
+	for i := range dists {
+		dists[i] = make([]float64, len(sample))
+	}
+	for i, a := range sample {
+		for j := i + 1; j < len(sample); j++ {
+			d := dist(data[a], data[sample[j]])
+			dists[i][j], dists[j][i] = d, d
+		}
+	}
+	return dists
+}
+
+// silhouette returns the mean silhouette coefficient of the clustering of data into k clusters
+// given the pairwise distances dists of the data and their cluster labels.
+// The coefficient of data in singleton clusters is zero; so is the coefficient of a single cluster.
+// Noise is excluded from the coefficient.
+// See: https://en.wikipedia.org/wiki/Silhouette_(clustering)
+func silhouette(dists [][]float64, labels []int, k int) float64 {
+	if k < 2 || len(labels) < 2 {
+		return 0
+	}
+
+	n := 0
+	counts := make([]int, k)
+	for _, l := range labels {
+		if l == v1.NoiseCluster {
+			continue
+		}
+		counts[l]++
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+
+	total := 0.0
+	sums := make([]float64, k)
+	for i, l := range labels {
+		if l == v1.NoiseCluster || counts[l] < 2 {
+			continue
+		}
+		for c := range sums {
+			sums[c] = 0
+		}
+		for j, d := range dists[i] {
+			if labels[j] != v1.NoiseCluster {
+				sums[labels[j]] += d
+			}
+		}
+		a := sums[l] / float64(counts[l]-1)
+		b := math.Inf(1)
+		for c, sum := range sums {
+			if c != l && counts[c] > 0 {
+				b = math.Min(b, sum/float64(counts[c]))
+			}
+		}
+		if math.IsInf(b, 1) {
+			continue
+		}
+		if m := math.Max(a, b); m > 0 {
+			total += (b - a) / m
+		}
+	}
+
+	return total / float64(n)
+}
@@ -0,0 +1,309 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/internal/search"
+)
+
+// Batches calls fn with consecutive batches of the clustered embeddings.
+// Every call must iterate over the same embeddings in the same order.
+type Batches func(ctx context.Context, fn func(embs []v1.Embedding) error) error
+
+// Neighbours returns the UIDs of the neighbours of each of the embeddings embs, i.e. of the embeddings
+// within the eps distance, excluding the embeddings themselves. At most limit neighbours are returned
+// unless limit is zero in which case all the neighbours are returned.
+type Neighbours func(ctx context.Context, embs []v1.Embedding, limit int) ([][]string, error)
+
+// streamPasses is the number of passes over the embeddings made by StreamDBSCAN.
+const streamPasses = 4
+
+// StreamDBSCAN clusters the embeddings iterated over by batches using DBSCAN without holding their values
+// in memory. The first pass finds the core points, the second one connects the neighbouring core points
+// and assigns the border points to the cluster of the first core point they neighbour. The remaining passes
+// compute the centroids, the distances to them and the silhouette coefficient on a sample of the embeddings.
+// It returns the clustering, the UIDs of the clustered embeddings and their cluster assignments.
+// NOTE: the neighbours missing from the first pass, e.g. the embeddings added during clustering, are ignored.
+func StreamDBSCAN(ctx context.Context, batches Batches, nbs Neighbours, opts *v1.ClusterOptions) (*v1.Clustering, []string, []Assignment, error) {
+	seed := time.Now().UnixNano()
+	if opts.Seed != nil {
+		seed = *opts.Seed
+	}
+	metric := v1.Euclidean
+	if opts.Metric != "" {
+		metric = opts.Metric
+	}
+	dist, err := search.Distance(metric)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	minPts := opts.MinPoints
+	if minPts == 0 {
+		minPts = DefaultMinPoints
+	}
+
+	var (
+		uids  []string
+		index = make(map[string]int)
+		core  []bool
+	)
+	// NOTE: every pass makes up an equal share of the progress
+	pass, done := 0, 0
+	report := func(n int) {
+		done += n
+		v1.ReportProgress(ctx, (float64(pass)+float64(done)/float64(len(uids)))/streamPasses)
+	}
+	next := func() {
+		pass, done = pass+1, 0
+		v1.ReportProgress(ctx, float64(pass)/streamPasses)
+	}
+
+	// find the core points
+	if err := batches(ctx, func(embs []v1.Embedding) error {
+		isCore := make([]bool, len(embs))
+		if minPts > 1 {
+			res, err := nbs(ctx, embs, minPts-1)
+			if err != nil {
+				return err
+			}
+			for i := range embs {
+				isCore[i] = len(res[i])+1 >= minPts
+			}
+		} else {
+			for i := range isCore {
+				isCore[i] = true
+			}
+		}
+		for i, e := range embs {
+			index[e.UID] = len(uids)
+			uids = append(uids, e.UID)
+			core = append(core, isCore[i])
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+	if len(uids) == 0 {
+		return nil, nil, nil, errors.New("no embeddings to cluster")
+	}
+	next()
+
+	// connect the neighbouring core points
+	parents := make([]int, len(uids))
+	border := make([]int, len(uids))
+	for i := range parents {
+		parents[i], border[i] = i, -1
+	}
+	find := func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+		return i
+	}
+	if err := batches(ctx, func(embs []v1.Embedding) error {
+		cores := make([]v1.Embedding, 0, len(embs))
+		for _, e := range embs {
+			if i, ok := index[e.UID]; ok && core[i] {
+				cores = append(cores, e)
+			}
+		}
+		res, err := nbs(ctx, cores, 0)
+		if err != nil {
+			return err
+		}
+		for n, e := range cores {
+			i := index[e.UID]
+			for _, uid := range res[n] {
+				j, ok := index[uid]
+				if !ok {
+					continue
+				}
+				if core[j] {
+					if a, b := find(i), find(j); a != b {
+						parents[max(a, b)] = min(a, b)
+					}
+					continue
+				}
+				if border[j] < 0 {
+					border[j] = i
+				}
+			}
+		}
+		report(len(embs))
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+	next()
+
+	// NOTE: clusters are numbered in the order of their first core points
+	labels := make([]int, len(uids))
+	clusters := make(map[int]int)
+	for i := range labels {
+		labels[i] = v1.NoiseCluster
+		if !core[i] {
+			continue
+		}
+		root := find(i)
+		if _, ok := clusters[root]; !ok {
+			clusters[root] = len(clusters)
+		}
+		labels[i] = clusters[root]
+	}
+	for i, b := range border {
+		if b >= 0 {
+			labels[i] = labels[b]
+		}
+	}
+
+	k := len(clusters)
+	centroids := make([][]float64, k)
+	sizes := make([]int, k)
+	dim := -1
+	if err := batches(ctx, func(embs []v1.Embedding) error {
+		for _, e := range embs {
+			i, ok := index[e.UID]
+			if !ok {
+				continue
+			}
+			if dim < 0 {
+				dim = len(e.Values)
+				for c := range centroids {
+					centroids[c] = make([]float64, dim)
+				}
+			}
+			if len(e.Values) != dim {
+				return fmt.Errorf("embedding %s dimension mismatch: %d != %d", e.UID, len(e.Values), dim)
+			}
+			if l := labels[i]; l != v1.NoiseCluster {
+				sizes[l]++
+				for j, v := range e.Values {
+					centroids[l][j] += v
+				}
+			}
+		}
+		report(len(embs))
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+	for c, centroid := range centroids {
+		for j := range centroid {
+			centroid[j] /= float64(sizes[c])
+		}
+	}
+	next()
+
+	// NOTE: silhouette is computed on a sample of the embeddings
+	sample := sampleIndices(len(uids), SilhouetteSampleSize, rand.New(rand.NewSource(seed)))
+	sampled := make(map[int]int, len(sample))
+	for s, i := range sample {
+		sampled[i] = s
+	}
+	sampleData := make([][]float64, len(sample))
+
+	assignments := make([]Assignment, len(uids))
+	inertia := 0.0
+	if err := batches(ctx, func(embs []v1.Embedding) error {
+		for _, e := range embs {
+			i, ok := index[e.UID]
+			if !ok {
+				continue
+			}
+			if s, ok := sampled[i]; ok {
+				sampleData[s] = e.Values
+			}
+			a := Assignment{Cluster: labels[i]}
+			if l := labels[i]; l != v1.NoiseCluster {
+				a.Distance = dist(e.Values, centroids[l])
+				inertia += a.Distance * a.Distance
+			} else if k > 0 {
+				a.Distance = math.Inf(1)
+				for _, centroid := range centroids {
+					a.Distance = math.Min(a.Distance, dist(e.Values, centroid))
+				}
+			}
+			assignments[i] = a
+		}
+		report(len(embs))
+		return nil
+	}); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// NOTE: sampled embeddings removed during clustering are left out of the silhouette
+	sampleIdx := make([]int, 0, len(sample))
+	labelsSample := make([]int, 0, len(sample))
+	for s, vals := range sampleData {
+		if vals != nil {
+			sampleIdx = append(sampleIdx, s)
+			labelsSample = append(labelsSample, labels[sample[s]])
+		}
+	}
+	dists := pairwiseDistances(sampleData, sampleIdx, dist)
+
+	noise := 0
+	for _, l := range labels {
+		if l == v1.NoiseCluster {
+			noise++
+		}
+	}
+
+	clustering := &v1.Clustering{
+		Algorithm:  v1.DBSCAN,
+		K:          k,
+		Metric:     metric,
+		Noise:      noise,
+		Seed:       seed,
+		Inertia:    inertia,
+		Silhouette: silhouette(dists, labelsSample, k),
+		Centroids:  centroids,
+		Sizes:      sizes,
+	}
+
+	return clustering, uids, assignments, nil
+}
+
+// ScanNeighbours returns Neighbours which find the neighbours within eps distance measured
+// by metric by scanning all the embeddings iterated over by batches.
+// NOTE: every call scans all the embeddings so clustering is quadratic in their number.
+func ScanNeighbours(batches Batches, eps float64, metric v1.Metric) (Neighbours, error) {
+	if metric == "" {
+		metric = v1.Euclidean
+	}
+	dist, err := search.Distance(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, embs []v1.Embedding, limit int) ([][]string, error) {
+		res := make([][]string, len(embs))
+		if len(embs) == 0 {
+			return res, nil
+		}
+		err := batches(ctx, func(batch []v1.Embedding) error {
+			for i, e := range embs {
+				for _, nb := range batch {
+					if limit > 0 && len(res[i]) >= limit {
+						break
+					}
+					if nb.UID != e.UID && len(nb.Values) == len(e.Values) && dist(e.Values, nb.Values) <= eps {
+						res[i] = append(res[i], nb.UID)
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}, nil
+}
@@ -0,0 +1,121 @@
+package cluster
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// streamBatches returns Batches iterating over embs in batches of the given size.
+func streamBatches(embs []v1.Embedding, size int) Batches {
+	return func(ctx context.Context, fn func([]v1.Embedding) error) error {
+		for lo := 0; lo < len(embs); lo += size {
+			if err := fn(embs[lo:min(lo+size, len(embs))]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestStreamDBSCAN(t *testing.T) {
+	centers := [][]float64{{0, 0, 0}, {10, 0, 0}, {0, 10, 10}}
+	embs := append(blobs(centers, 30, 1),
+		v1.Embedding{Values: []float64{50, 50, 50}},
+		v1.Embedding{Values: []float64{-50, 50, -50}},
+	)
+	for i := range embs {
+		embs[i].UID = strconv.Itoa(i)
+	}
+	seed := int64(1)
+
+	for _, metric := range []v1.Metric{v1.Euclidean, v1.Cosine} {
+		t.Run(string(metric), func(t *testing.T) {
+			eps := 1.0
+			if metric == v1.Cosine {
+				eps = 0.01
+			}
+			opts := &v1.ClusterOptions{Algorithm: v1.DBSCAN, Metric: metric, Eps: eps, Seed: &seed}
+
+			exp, expAssignments, err := Cluster(context.TODO(), embs, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			batches := streamBatches(embs, 7)
+			nbs, err := ScanNeighbours(batches, eps, metric)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, uids, assignments, err := StreamDBSCAN(context.TODO(), batches, nbs, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.K != exp.K || res.Noise != exp.Noise || res.Metric != exp.Metric {
+				t.Fatalf("expected %d clusters, %d noise, metric %s, got: %d, %d, %s", exp.K, exp.Noise, exp.Metric, res.K, res.Noise, res.Metric)
+			}
+			if len(uids) != len(embs) {
+				t.Fatalf("expected %d clustered embeddings, got: %d", len(embs), len(uids))
+			}
+			for i, uid := range uids {
+				if uid != embs[i].UID {
+					t.Fatalf("embedding %d: expected UID: %s, got: %s", i, embs[i].UID, uid)
+				}
+				if assignments[i].Cluster != expAssignments[i].Cluster {
+					t.Fatalf("embedding %d: expected cluster: %d, got: %d", i, expAssignments[i].Cluster, assignments[i].Cluster)
+				}
+				if math.Abs(assignments[i].Distance-expAssignments[i].Distance) > 1e-9 {
+					t.Fatalf("embedding %d: expected distance: %v, got: %v", i, expAssignments[i].Distance, assignments[i].Distance)
+				}
+			}
+			for c := range exp.Sizes {
+				if res.Sizes[c] != exp.Sizes[c] {
+					t.Fatalf("cluster %d: expected size: %d, got: %d", c, exp.Sizes[c], res.Sizes[c])
+				}
+			}
+			if math.Abs(res.Inertia-exp.Inertia) > 1e-9 || math.Abs(res.Silhouette-exp.Silhouette) > 1e-9 {
+				t.Fatalf("expected inertia %v, silhouette %v, got: %v, %v", exp.Inertia, exp.Silhouette, res.Inertia, res.Silhouette)
+			}
+		})
+	}
+
+	t.Run("Limit", func(t *testing.T) {
+		nbs, err := ScanNeighbours(streamBatches(embs, 7), 1, v1.Euclidean)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := nbs(context.TODO(), embs[:1], 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res[0]) != 3 {
+			t.Fatalf("expected %d neighbours, got: %v", 3, res[0])
+		}
+		for _, uid := range res[0] {
+			if uid == embs[0].UID {
+				t.Fatal("unexpected neighbour: the embedding itself")
+			}
+		}
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		batches := streamBatches(nil, 7)
+		nbs, err := ScanNeighbours(batches, 1, v1.Euclidean)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := &v1.ClusterOptions{Algorithm: v1.DBSCAN, Eps: 1}
+		if _, _, _, err := StreamDBSCAN(context.TODO(), batches, nbs, opts); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := ScanNeighbours(streamBatches(embs, 7), 1, "foo"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"gonum.org/v1/gonum/floats"
+)
+
+// SemanticAxes computes the projection of the given embeddings onto the semantic axes set in opts.
+// It returns a new slice of embeddings of the same size as the original embeddings,
+// but with the given dimension dropped to the given dimension.
+func SemanticAxes(ctx context.Context, embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	opts, err := ResolveAnchors(embs, opts)
+	if err != nil {
+		return nil, err
+	}
+	model, err := FitAxes(embs, projDim, opts)
+	if err != nil {
+		return nil, err
+	}
+	projs, err := Extend(Models{projDim: model}, nil, nil, embs)
+	if err != nil {
+		return nil, err
+	}
+	return projs[projDim], nil
+}
+
+// ResolveAnchors returns a copy of opts with the values of the axes anchors
+// given by UID set to the values of the matching embeddings.
+// It returns error if any of the anchor embeddings is not found in embs.
+func ResolveAnchors(embs []v1.Embedding, opts *v1.ProjectionOptions) (*v1.ProjectionOptions, error) {
+	o := *opts
+	o.Axes = make([]v1.Axis, len(opts.Axes))
+	copy(o.Axes, opts.Axes)
+
+	uids := make(map[string][]float64)
+	for i := range o.Axes {
+		for _, a := range []*v1.Anchor{&o.Axes[i].Negative, &o.Axes[i].Positive} {
+			if a.UID != "" && len(a.Values) == 0 {
+				uids[a.UID] = nil
+			}
+		}
+	}
+	if len(uids) == 0 {
+		return &o, nil
+	}
+	for _, e := range embs {
+		if _, ok := uids[e.UID]; ok {
+			uids[e.UID] = e.Values
+		}
+	}
+	for i := range o.Axes {
+		for _, a := range []*v1.Anchor{&o.Axes[i].Negative, &o.Axes[i].Positive} {
+			if a.UID == "" || len(a.Values) > 0 {
+				continue
+			}
+			if uids[a.UID] == nil {
+				return nil, fmt.Errorf("anchor embedding %s not found", a.UID)
+			}
+			a.Values = uids[a.UID]
+		}
+	}
+	return &o, nil
+}
+
+// FitAxes returns the linear model which projects embeddings onto the unit vectors
+// of the first dim semantic axes set in opts. The anchors of the axes must have their values set.
+func FitAxes(embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) (*Model, error) {
+	dim := projDim.Size()
+	if len(opts.Axes) < dim {
+		return nil, fmt.Errorf("insufficient axes: %d, needs at least: %d", len(opts.Axes), dim)
+	}
+
+	embDim := len(embs[0].Values)
+	model := &Model{
+		Projection: v1.Axes,
+		Dim:        projDim,
+		Components: make([][]float64, dim),
+		Size:       len(embs),
+	}
+	for i, axis := range opts.Axes[:dim] {
+		neg, pos := axis.Negative.Values, axis.Positive.Values
+		if len(neg) != embDim || len(pos) != embDim {
+			return nil, fmt.Errorf("invalid axis %d anchor dimension, expected: %d", i, embDim)
+		}
+		c := make([]float64, embDim)
+		for j := range c {
+			c[j] = pos[j] - neg[j]
+		}
+		norm := floats.Norm(c, 2)
+		if norm == 0 {
+			return nil, fmt.Errorf("degenerate axis %d: identical anchors", i)
+		}
+		for j := range c {
+			c[j] /= norm
+		}
+		model.Components[i] = c
+	}
+
+	return model, nil
+}
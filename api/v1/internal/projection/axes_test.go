@@ -0,0 +1,97 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestSemanticAxes(t *testing.T) {
+	embs := make([]v1.Embedding, 50)
+	for i := range embs {
+		x := float64(i)
+		embs[i] = v1.Embedding{
+			UID:    fmt.Sprintf("e%d", i),
+			Values: []float64{x, 2 * x, -x, 1},
+		}
+	}
+	axes := []v1.Axis{
+		{
+			Name:     "x",
+			Negative: v1.Anchor{Values: []float64{0, 0, 0, 0}},
+			Positive: v1.Anchor{Values: []float64{2, 0, 0, 0}},
+		},
+		{
+			Name:     "y",
+			Negative: v1.Anchor{UID: "e0"},
+			Positive: v1.Anchor{UID: "e1"},
+		},
+	}
+	seed := int64(1)
+
+	t.Run("Scalar", func(t *testing.T) {
+		opts := Options(&v1.ProjectionOptions{Seed: &seed, Axes: axes, Dims: []v1.Dim{v1.Dim1D, v1.Dim2D}})
+		// NOTE: landmarks make sure anchors are resolved against all the embeddings
+		opts.Landmarks = 10
+
+		res, _, err := Compute(context.TODO(), embs, v1.Axes, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Options.Axes[1].Negative.Values != nil {
+			t.Fatal("expected unresolved anchor options")
+		}
+
+		norm := math.Sqrt(6)
+		for i, e := range res.Embeddings[v1.Dim2D] {
+			x := float64(i)
+			// NOTE: e1 - e0 = [1, 2, -1, 0]
+			exp := []float64{x, (x + 4*x + x) / norm}
+			for k := range exp {
+				if math.Abs(e.Values[k]-exp[k]) > 1e-9 {
+					t.Fatalf("expected projection %d: %v, got: %v", i, exp, e.Values)
+				}
+			}
+		}
+		for i, e := range res.Embeddings[v1.Dim1D] {
+			if got := e.Values[0]; math.Abs(got-float64(i)) > 1e-9 {
+				t.Fatalf("expected projection %d: %v, got: %v", i, float64(i), got)
+			}
+		}
+	})
+
+	t.Run("Project", func(t *testing.T) {
+		res, err := SemanticAxes(context.TODO(), embs, v1.Dim2D, Options(&v1.ProjectionOptions{Axes: axes}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != len(embs) {
+			t.Fatalf("expected projections: %d, got: %d", len(embs), len(res))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			dim  v1.Dim
+			axes []v1.Axis
+		}{
+			{"InsufficientAxes", v1.Dim3D, axes},
+			{"MissingAnchor", v1.Dim1D, []v1.Axis{{Negative: v1.Anchor{UID: "foo"}, Positive: v1.Anchor{UID: "e1"}}}},
+			{"Degenerate", v1.Dim1D, []v1.Axis{{Negative: v1.Anchor{UID: "e1"}, Positive: v1.Anchor{UID: "e1"}}}},
+			{"AnchorDim", v1.Dim1D, []v1.Axis{{Negative: v1.Anchor{Values: []float64{1}}, Positive: v1.Anchor{UID: "e1"}}}},
+		}
+
+		for _, tc := range testCases {
+			tc := tc
+			t.Run(tc.name, func(t *testing.T) {
+				if _, err := SemanticAxes(context.TODO(), embs, tc.dim, Options(&v1.ProjectionOptions{Axes: tc.axes})); err == nil {
+					t.Fatal("expected error")
+				}
+			})
+		}
+	})
+}
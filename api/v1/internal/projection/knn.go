@@ -0,0 +1,421 @@
+package projection
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"gonum.org/v1/gonum/floats"
+)
+
+const (
+	// knnExactThreshold is the maximum number of embeddings
+	// whose nearest neighbours are searched exactly.
+	knnExactThreshold = 2048
+	// rpTrees is the number of random projection trees
+	// used to search the approximate nearest neighbours.
+	rpTrees = 10
+	// rpLeafSize is the minimum size of the random projection tree leaves.
+	rpLeafSize = 64
+	// rpRefineNeighbours is the number of the nearest neighbours whose
+	// neighbours refine the approximate nearest neighbours.
+	rpRefineNeighbours = 10
+)
+
+// nearestNeighbours returns indices and euclidean distances of the k nearest
+// neighbours of every embedding in embs sorted by their distance.
+// The neighbours of at most knnExactThreshold embeddings are searched exactly,
+// the neighbours of larger collections are approximated.
+// It returns error if ctx is cancelled before the search finishes.
+func nearestNeighbours(ctx context.Context, embs []v1.Embedding, k int, rnd *rand.Rand) ([][]int, [][]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	if len(embs) > knnExactThreshold {
+		return approxNearestNeighbours(ctx, embs, k, rnd)
+	}
+	return exactNearestNeighbours(ctx, embs, k, rnd)
+}
+
+// exactNearestNeighbours searches the nearest neighbours concurrently using a vantage-point tree.
+func exactNearestNeighbours(ctx context.Context, embs []v1.Embedding, k int, rnd *rand.Rand) ([][]int, [][]float64, error) {
+	n := len(embs)
+	idx := make([][]int, n)
+	dist := make([][]float64, n)
+
+	t := newVPTree(embs, rnd)
+
+	err := parallel(ctx, n, func(_, lo, hi int) {
+		for i := lo; i < hi; i++ {
+			idx[i], dist[i] = t.search(i, k)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return idx, dist, nil
+}
+
+// approxNearestNeighbours searches the nearest neighbours concurrently among
+// the embeddings which share the leaves of a forest of random projection trees.
+// See: https://arxiv.org/abs/1509.06957
+func approxNearestNeighbours(ctx context.Context, embs []v1.Embedding, k int, rnd *rand.Rand) ([][]int, [][]float64, error) {
+	n := len(embs)
+	idx := make([][]int, n)
+	dist := make([][]float64, n)
+
+	leafSize := max(rpLeafSize, 2*k)
+	forest := newRPForest(embs, leafSize, rnd)
+
+	err := parallel(ctx, n, func(_, lo, hi int) {
+		cands := make([]int, 0, rpTrees*leafSize)
+		for i := lo; i < hi; i++ {
+			cands = cands[:0]
+			for _, t := range forest {
+				cands = append(cands, t.leaf(i)...)
+			}
+			idx[i], dist[i] = nearest(embs[i].Values, i, embs, cands, k)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// NOTE: neighbours of the neighbours are likely neighbours, too.
+	// The refined neighbours are stored separately so the search doesn't
+	// depend on the order in which the embeddings are processed.
+	refIdx := make([][]int, n)
+	refDist := make([][]float64, n)
+	err = parallel(ctx, n, func(_, lo, hi int) {
+		cands := make([]int, 0, k*(rpRefineNeighbours+1))
+		for i := lo; i < hi; i++ {
+			cands = append(cands[:0], idx[i]...)
+			for _, j := range idx[i][:min(rpRefineNeighbours, len(idx[i]))] {
+				cands = append(cands, idx[j]...)
+			}
+			refIdx[i], refDist[i] = nearest(embs[i].Values, i, embs, cands, k)
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return refIdx, refDist, nil
+}
+
+// nearest returns the indices of the k candidate embeddings cands nearest to vals
+// along with their distances. The exclude-th embedding is never returned.
+// cands may contain duplicates. It searches all the embeddings if there are
+// fewer than k candidates.
+func nearest(vals []float64, exclude int, embs []v1.Embedding, cands []int, k int) ([]int, []float64) {
+	sort.Ints(cands)
+	uniq := cands[:0]
+	for x, j := range cands {
+		if j != exclude && (x == 0 || j != cands[x-1]) {
+			uniq = append(uniq, j)
+		}
+	}
+	if len(uniq) < k {
+		uniq = make([]int, 0, len(embs))
+		for j := range embs {
+			if j != exclude {
+				uniq = append(uniq, j)
+			}
+		}
+	}
+
+	dists := make([]float64, len(uniq))
+	for x, j := range uniq {
+		dists[x] = euclidean(vals, embs[j].Values)
+	}
+	order := make([]int, len(uniq))
+	for x := range order {
+		order[x] = x
+	}
+	sort.SliceStable(order, func(x, y int) bool { return dists[order[x]] < dists[order[y]] })
+
+	k = min(k, len(uniq))
+	idx := make([]int, k)
+	dist := make([]float64, k)
+	for x := 0; x < k; x++ {
+		idx[x], dist[x] = uniq[order[x]], dists[order[x]]
+	}
+
+	return idx, dist
+}
+
+// rpForest is a forest of random projection trees.
+type rpForest []*rpTree
+
+// newRPForest builds a new forest of rpTrees random projection trees of embs.
+func newRPForest(embs []v1.Embedding, leafSize int, rnd *rand.Rand) rpForest {
+	forest := make(rpForest, rpTrees)
+	// NOTE: every tree gets its own source so the forest is reproducible
+	seeds := make([]int64, rpTrees)
+	for t := range seeds {
+		seeds[t] = rnd.Int63()
+	}
+
+	var wg sync.WaitGroup
+	for t := range forest {
+		wg.Add(1)
+		go func(t int) {
+			defer wg.Done()
+			forest[t] = newRPTree(embs, leafSize, rand.New(rand.NewSource(seeds[t])))
+		}(t)
+	}
+	wg.Wait()
+
+	return forest
+}
+
+// query appends the embeddings which share the leaves with vals to cands.
+func (f rpForest) query(cands []int, vals []float64) []int {
+	for _, t := range f {
+		cands = append(cands, t.query(vals)...)
+	}
+	return cands
+}
+
+// rpTree is a random projection tree.
+// It splits the embeddings by hyperplanes equidistant from two random embeddings.
+type rpTree struct {
+	nodes  []rpNode
+	leafOf []int32
+}
+
+// rpNode is a node of the random projection tree.
+type rpNode struct {
+	// normal of the splitting hyperplane.
+	// It's nil if the node was split randomly.
+	normal []float64
+	// offset of the splitting hyperplane.
+	offset float64
+	// above is the subtree above the hyperplane.
+	above int
+	// below is the subtree below the hyperplane.
+	below int
+	// items of the leaf node.
+	items []int
+}
+
+// newRPTree builds a new random projection tree of embs
+// whose leaves contain at most leafSize embeddings.
+func newRPTree(embs []v1.Embedding, leafSize int, rnd *rand.Rand) *rpTree {
+	t := &rpTree{leafOf: make([]int32, len(embs))}
+	items := make([]int, len(embs))
+	for i := range items {
+		items[i] = i
+	}
+	t.split(embs, items, leafSize, rnd)
+	return t
+}
+
+func (t *rpTree) split(embs []v1.Embedding, items []int, leafSize int, rnd *rand.Rand) int {
+	node := len(t.nodes)
+	t.nodes = append(t.nodes, rpNode{})
+
+	if len(items) <= leafSize {
+		t.nodes[node].items = items
+		for _, i := range items {
+			t.leafOf[i] = int32(node)
+		}
+		return node
+	}
+
+	a := embs[items[rnd.Intn(len(items))]].Values
+	b := embs[items[rnd.Intn(len(items))]].Values
+	normal := make([]float64, len(a))
+	offset := 0.0
+	for d := range normal {
+		normal[d] = a[d] - b[d]
+		offset += (a[d]*a[d] - b[d]*b[d]) / 2
+	}
+
+	lo := 0
+	for x, i := range items {
+		if floats.Dot(embs[i].Values, normal) > offset {
+			items[lo], items[x] = items[x], items[lo]
+			lo++
+		}
+	}
+	// NOTE: duplicate embeddings can't be split by a hyperplane
+	if lo == 0 || lo == len(items) {
+		rnd.Shuffle(len(items), func(x, y int) { items[x], items[y] = items[y], items[x] })
+		lo = len(items) / 2
+		normal = nil
+	}
+
+	// NOTE: t.nodes may be reallocated by the recursive calls
+	above := t.split(embs, items[:lo], leafSize, rnd)
+	below := t.split(embs, items[lo:], leafSize, rnd)
+	t.nodes[node].normal, t.nodes[node].offset = normal, offset
+	t.nodes[node].above, t.nodes[node].below = above, below
+
+	return node
+}
+
+// leaf returns the embeddings in the leaf of the i-th embedding.
+func (t *rpTree) leaf(i int) []int {
+	return t.nodes[t.leafOf[i]].items
+}
+
+// query returns the embeddings in the leaf vals falls into.
+// NOTE: randomly split nodes are always descended above.
+func (t *rpTree) query(vals []float64) []int {
+	n := 0
+	for t.nodes[n].items == nil {
+		node := t.nodes[n]
+		if node.normal == nil || floats.Dot(vals, node.normal) > node.offset {
+			n = node.above
+			continue
+		}
+		n = node.below
+	}
+	return t.nodes[n].items
+}
+
+// vpTree is a vantage-point tree for exact euclidean k-NN search.
+// See: https://dl.acm.org/doi/10.5555/313559.313789
+type vpTree struct {
+	embs  []v1.Embedding
+	nodes []vpNode
+	root  int
+}
+
+// vpNode is a node of the vantage-point tree.
+type vpNode struct {
+	// index of the vantage point embedding.
+	index int
+	// threshold is the median distance from the vantage point.
+	threshold float64
+	// inside is the subtree closer than threshold, -1 if empty.
+	inside int
+	// outside is the subtree further than threshold, -1 if empty.
+	outside int
+}
+
+// newVPTree builds a new vantage-point tree of embs.
+// The vantage points are picked randomly using rnd.
+func newVPTree(embs []v1.Embedding, rnd *rand.Rand) *vpTree {
+	t := &vpTree{
+		embs:  embs,
+		nodes: make([]vpNode, 0, len(embs)),
+	}
+	items := make([]int, len(embs))
+	for i := range items {
+		items[i] = i
+	}
+	t.root = t.build(items, make([]float64, len(embs)), rnd)
+	return t
+}
+
+func (t *vpTree) build(items []int, dists []float64, rnd *rand.Rand) int {
+	if len(items) == 0 {
+		return -1
+	}
+
+	v := rnd.Intn(len(items))
+	items[0], items[v] = items[v], items[0]
+
+	node := len(t.nodes)
+	t.nodes = append(t.nodes, vpNode{index: items[0], inside: -1, outside: -1})
+	if len(items) == 1 {
+		return node
+	}
+
+	rest := items[1:]
+	vp := t.embs[items[0]].Values
+	for _, i := range rest {
+		dists[i] = euclidean(vp, t.embs[i].Values)
+	}
+	sort.Slice(rest, func(x, y int) bool { return dists[rest[x]] < dists[rest[y]] })
+
+	mid := len(rest) / 2
+	// NOTE: t.nodes may be reallocated by the recursive calls
+	t.nodes[node].threshold = dists[rest[mid]]
+	inside := t.build(rest[:mid], dists, rnd)
+	outside := t.build(rest[mid:], dists, rnd)
+	t.nodes[node].inside, t.nodes[node].outside = inside, outside
+
+	return node
+}
+
+// search returns the k nearest neighbours of the i-th embedding.
+func (t *vpTree) search(i, k int) ([]int, []float64) {
+	h := make(neighbourHeap, 0, k+1)
+	tau := math.Inf(1)
+	q := t.embs[i].Values
+
+	var visit func(n int)
+	visit = func(n int) {
+		if n < 0 {
+			return
+		}
+		node := t.nodes[n]
+		d := euclidean(q, t.embs[node.index].Values)
+		if node.index != i && d < tau {
+			heap.Push(&h, neighbour{index: node.index, dist: d})
+			if h.Len() > k {
+				heap.Pop(&h)
+			}
+			if h.Len() == k {
+				tau = h[0].dist
+			}
+		}
+		// NOTE: tau may shrink while visiting the first subtree
+		if d < node.threshold {
+			if d-tau <= node.threshold {
+				visit(node.inside)
+			}
+			if d+tau >= node.threshold {
+				visit(node.outside)
+			}
+			return
+		}
+		if d+tau >= node.threshold {
+			visit(node.outside)
+		}
+		if d-tau <= node.threshold {
+			visit(node.inside)
+		}
+	}
+	visit(t.root)
+
+	idx := make([]int, h.Len())
+	dist := make([]float64, h.Len())
+	for j := len(idx) - 1; j >= 0; j-- {
+		nb := heap.Pop(&h).(neighbour)
+		idx[j], dist[j] = nb.index, nb.dist
+	}
+
+	return idx, dist
+}
+
+// neighbour is a k-NN search candidate.
+type neighbour struct {
+	index int
+	dist  float64
+}
+
+// neighbourHeap is a max-heap of neighbours ordered by their distance.
+type neighbourHeap []neighbour
+
+func (h neighbourHeap) Len() int           { return len(h) }
+func (h neighbourHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h neighbourHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *neighbourHeap) Push(x any) { *h = append(*h, x.(neighbour)) }
+
+func (h *neighbourHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
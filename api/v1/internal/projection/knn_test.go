@@ -0,0 +1,94 @@
+package projection
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestNearestNeighbours(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	embs := make([]v1.Embedding, 500)
+	for i := range embs {
+		vals := make([]float64, 5)
+		for j := range vals {
+			vals[j] = rnd.NormFloat64()
+		}
+		embs[i] = v1.Embedding{Values: vals}
+	}
+
+	k := 7
+	idx, dist, err := nearestNeighbours(context.TODO(), embs, k, rnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range embs {
+		exp := make([]int, 0, len(embs)-1)
+		for j := range embs {
+			if j != i {
+				exp = append(exp, j)
+			}
+		}
+		sort.Slice(exp, func(x, y int) bool {
+			return euclidean(embs[i].Values, embs[exp[x]].Values) < euclidean(embs[i].Values, embs[exp[y]].Values)
+		})
+		if len(idx[i]) != k || len(dist[i]) != k {
+			t.Fatalf("expected %d neighbours of %d, got: %d", k, i, len(idx[i]))
+		}
+		for j := 0; j < k; j++ {
+			if idx[i][j] != exp[j] {
+				t.Fatalf("expected neighbour %d of %d: %d, got: %d", j, i, exp[j], idx[i][j])
+			}
+			if d := euclidean(embs[i].Values, embs[exp[j]].Values); dist[i][j] != d {
+				t.Fatalf("expected distance %d of %d: %v, got: %v", j, i, d, dist[i][j])
+			}
+		}
+	}
+
+	t.Run("Approximate", func(t *testing.T) {
+		embs := make([]v1.Embedding, knnExactThreshold+1000)
+		for i := range embs {
+			vals := make([]float64, 10)
+			for j := range vals {
+				vals[j] = rnd.NormFloat64()
+			}
+			embs[i] = v1.Embedding{Values: vals}
+		}
+
+		idx, _, err := nearestNeighbours(context.TODO(), embs, k, rnd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exact, _, err := exactNearestNeighbours(context.TODO(), embs, k, rnd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		found := 0
+		for i := range embs {
+			for _, j := range idx[i] {
+				for _, e := range exact[i] {
+					if j == e {
+						found++
+					}
+				}
+			}
+		}
+		if recall := float64(found) / float64(len(embs)*k); recall < 0.9 {
+			t.Fatalf("expected recall of at least 0.9, got: %v", recall)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		if _, _, err := nearestNeighbours(ctx, embs, k, rnd); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
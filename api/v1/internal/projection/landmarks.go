@@ -0,0 +1,150 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"golang.org/x/exp/maps"
+)
+
+// DefaultLandmarks is the default maximum number of embeddings projected by
+// the projection algorithm. Projections of the remaining embeddings are
+// interpolated from the projections of the landmark embeddings.
+const DefaultLandmarks = 10000
+
+// MaxLandmarks returns the maximum number of landmarks set in opts.
+func MaxLandmarks(opts *v1.ProjectionOptions) int {
+	if opts != nil && opts.Landmarks > 0 {
+		return opts.Landmarks
+	}
+	return DefaultLandmarks
+}
+
+// SampleLandmarks returns sorted indices of landmarks sampled from n embeddings
+// using the seed set in opts. It returns nil if all embeddings are landmarks.
+func SampleLandmarks(n int, opts *v1.ProjectionOptions) []int {
+	m := MaxLandmarks(opts)
+	if n <= m {
+		return nil
+	}
+	rnd := rand.New(rand.NewSource(*opts.Seed))
+	idx := rnd.Perm(n)[:m]
+	sort.Ints(idx)
+	return idx
+}
+
+// Interpolator projects embeddings using the fitted models.
+// Linear models apply the fitted transform. Non-linear models place every embedding
+// at the inverse distance weighted average of the projections of its nearest
+// neighbours found among the reference embeddings.
+type Interpolator struct {
+	models Models
+	refs   []v1.Embedding
+	projs  map[v1.Dim][]v1.Embedding
+	forest rpForest
+}
+
+// NewInterpolator returns a new interpolator of the models m fitted on the reference
+// embeddings refs whose projections are projs. The nearest neighbours among large
+// references are approximated using random projection trees seeded with seed.
+func NewInterpolator(m Models, refs []v1.Embedding, projs map[v1.Dim][]v1.Embedding, seed int64) (*Interpolator, error) {
+	linear := true
+	for dim, model := range m {
+		if model.Linear() {
+			continue
+		}
+		linear = false
+		if len(refs) != len(projs[dim]) {
+			return nil, fmt.Errorf("mismatched %s projections: %d, embeddings: %d", dim, len(projs[dim]), len(refs))
+		}
+	}
+
+	ip := &Interpolator{
+		models: m,
+		refs:   refs,
+		projs:  projs,
+	}
+	if !linear && len(refs) > knnExactThreshold {
+		ip.forest = newRPForest(refs, max(rpLeafSize, 2*DefaultNeighbours), rand.New(rand.NewSource(seed)))
+	}
+
+	return ip, nil
+}
+
+// Project returns the projections of embs keyed by projection dimension.
+// It returns error if ctx is cancelled before all embeddings have been projected.
+func (ip *Interpolator) Project(ctx context.Context, embs []v1.Embedding) (map[v1.Dim][]v1.Embedding, error) {
+	res := make(map[v1.Dim][]v1.Embedding, len(ip.models))
+	for dim := range ip.models {
+		res[dim] = make([]v1.Embedding, len(embs))
+	}
+
+	var (
+		mu      sync.Mutex
+		projErr error
+	)
+	err := parallel(ctx, len(embs), func(_, lo, hi int) {
+		var cands []int
+		for i := lo; i < hi; i++ {
+			e := embs[i]
+			var nbs []int
+			for dim, model := range ip.models {
+				var vals []float64
+				if model.Linear() {
+					var err error
+					vals, err = model.Transform(e.Values)
+					if err != nil {
+						mu.Lock()
+						projErr = err
+						mu.Unlock()
+						return
+					}
+				} else {
+					if nbs == nil {
+						nbs, cands = ip.neighbours(cands, e.Values)
+					}
+					nbEmbs := make([]v1.Embedding, 0, len(nbs))
+					nbProjs := make([][]float64, 0, len(nbs))
+					for _, j := range nbs {
+						nbEmbs = append(nbEmbs, ip.refs[j])
+						nbProjs = append(nbProjs, ip.projs[dim][j].Values)
+					}
+					vals = Place(e.Values, nbEmbs, nbProjs)
+				}
+				metadata := map[string]any{}
+				if e.Metadata != nil {
+					metadata = maps.Clone(e.Metadata)
+				}
+				metadata["projection"] = model.Projection
+				res[dim][i] = v1.Embedding{
+					UID:      e.UID,
+					Values:   vals,
+					Metadata: metadata,
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if projErr != nil {
+		return nil, projErr
+	}
+
+	return res, nil
+}
+
+// neighbours returns the indices of the nearest reference embeddings of vals.
+// It reuses cands for the candidate neighbours and returns them for reuse.
+func (ip *Interpolator) neighbours(cands []int, vals []float64) ([]int, []int) {
+	if ip.forest == nil {
+		return Nearest(vals, ip.refs, DefaultNeighbours), cands
+	}
+	cands = ip.forest.query(cands[:0], vals)
+	idx, _ := nearest(vals, -1, ip.refs, cands, DefaultNeighbours)
+	return idx, cands
+}
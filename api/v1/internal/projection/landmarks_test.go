@@ -0,0 +1,80 @@
+package projection
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestSampleLandmarks(t *testing.T) {
+	seed := int64(1)
+	opts := &v1.ProjectionOptions{Seed: &seed, Landmarks: 10}
+
+	if idx := SampleLandmarks(10, opts); idx != nil {
+		t.Fatalf("expected no landmarks, got: %v", idx)
+	}
+
+	idx := SampleLandmarks(100, opts)
+	if len(idx) != opts.Landmarks {
+		t.Fatalf("expected landmarks: %d, got: %d", opts.Landmarks, len(idx))
+	}
+	if !sort.IntsAreSorted(idx) {
+		t.Fatalf("expected sorted landmarks, got: %v", idx)
+	}
+	if again := SampleLandmarks(100, opts); !reflect.DeepEqual(idx, again) {
+		t.Fatalf("expected landmarks: %v, got: %v", idx, again)
+	}
+
+	if m := MaxLandmarks(nil); m != DefaultLandmarks {
+		t.Fatalf("expected max landmarks: %d, got: %d", DefaultLandmarks, m)
+	}
+}
+
+func TestInterpolator(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	// NOTE: enough references to approximate the nearest neighbours
+	refs := make([]v1.Embedding, knnExactThreshold+100)
+	projs := map[v1.Dim][]v1.Embedding{v1.Dim2D: make([]v1.Embedding, len(refs))}
+	for i := range refs {
+		vals := []float64{rnd.Float64(), rnd.Float64(), rnd.Float64()}
+		refs[i] = v1.Embedding{Values: vals}
+		projs[v1.Dim2D][i] = v1.Embedding{Values: vals[:2]}
+	}
+	models := Models{v1.Dim2D: {Projection: v1.TSNE, Dim: v1.Dim2D, Size: len(refs)}}
+
+	ip, err := NewInterpolator(models, refs, projs, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ip.Project(context.TODO(), refs[:10])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range res[v1.Dim2D] {
+		if !reflect.DeepEqual(r.Values, projs[v1.Dim2D][i].Values) {
+			t.Fatalf("expected projection: %v, got: %v", projs[v1.Dim2D][i].Values, r.Values)
+		}
+		if r.Metadata["projection"] != v1.TSNE {
+			t.Fatalf("expected projection: %v, got: %v", v1.TSNE, r.Metadata["projection"])
+		}
+	}
+
+	t.Run("Mismatched", func(t *testing.T) {
+		if _, err := NewInterpolator(models, refs[:1], projs, 1); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		if _, err := ip.Project(ctx, refs); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
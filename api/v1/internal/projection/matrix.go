@@ -0,0 +1,68 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// ProjectMatrix computes the projection of the given embeddings using the projection matrix set in opts.
+// It returns a new slice of embeddings of the same size as the original embeddings,
+// but with the given dimension dropped to the given dimension.
+func ProjectMatrix(ctx context.Context, embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	model, err := FitMatrix(embs, projDim, opts)
+	if err != nil {
+		return nil, err
+	}
+	projs, err := Extend(Models{projDim: model}, nil, nil, embs)
+	if err != nil {
+		return nil, err
+	}
+	return projs[projDim], nil
+}
+
+// FitMatrix returns the linear model which projects embeddings
+// using the first dim columns of the projection matrix set in opts.
+func FitMatrix(embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) (*Model, error) {
+	if opts == nil || opts.Matrix == nil {
+		return nil, errors.New("missing projection matrix")
+	}
+	m := opts.Matrix
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	dim := projDim.Size()
+	if cols := len(m.Values[0]); cols < dim {
+		return nil, fmt.Errorf("insufficient matrix columns: %d, needs at least: %d", cols, dim)
+	}
+	if embDim := len(embs[0].Values); embDim != len(m.Values) {
+		return nil, fmt.Errorf("invalid matrix rows: %d, expected embedding dimension: %d", len(m.Values), embDim)
+	}
+
+	model := &Model{
+		Projection: v1.Matrix,
+		Dim:        projDim,
+		Components: make([][]float64, dim),
+		Size:       len(embs),
+	}
+	if m.Mean != nil {
+		model.Mean = make([]float64, len(m.Mean))
+		copy(model.Mean, m.Mean)
+	}
+	// NOTE: model components are the columns of the matrix
+	for i := range model.Components {
+		c := make([]float64, len(m.Values))
+		for j, row := range m.Values {
+			c[j] = row[i]
+		}
+		model.Components[i] = c
+	}
+
+	return model, nil
+}
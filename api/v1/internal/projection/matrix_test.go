@@ -0,0 +1,83 @@
+package projection
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestProjectMatrix(t *testing.T) {
+	embs := make([]v1.Embedding, 20)
+	for i := range embs {
+		x := float64(i)
+		embs[i] = v1.Embedding{
+			Values: []float64{x, 2 * x, -x},
+		}
+	}
+	m := &v1.ProjectionMatrix{
+		Values: [][]float64{
+			{1, 0, 0},
+			{0, 0, 1},
+			{0, 1, 0},
+		},
+		Mean: []float64{1, 1, 1},
+	}
+
+	t.Run("Linear", func(t *testing.T) {
+		opts := Options(&v1.ProjectionOptions{Matrix: m, Dims: []v1.Dim{v1.Dim2D, v1.Dim3D}})
+		res, models, err := Compute(context.TODO(), embs, v1.Matrix, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !models[v1.Dim3D].Linear() {
+			t.Fatal("expected linear model")
+		}
+		for i, e := range res.Embeddings[v1.Dim3D] {
+			x := float64(i)
+			exp := []float64{x - 1, -x - 1, 2*x - 1}
+			for k := range exp {
+				if math.Abs(e.Values[k]-exp[k]) > 1e-9 {
+					t.Fatalf("expected projection %d: %v, got: %v", i, exp, e.Values)
+				}
+			}
+		}
+		for i, e := range res.Embeddings[v1.Dim2D] {
+			if len(e.Values) != 2 || e.Values[1] != res.Embeddings[v1.Dim3D][i].Values[1] {
+				t.Fatalf("expected projection %d using the first 2 columns, got: %v", i, e.Values)
+			}
+		}
+	})
+
+	t.Run("Project", func(t *testing.T) {
+		res, err := ProjectMatrix(context.TODO(), embs, v1.Dim2D, Options(&v1.ProjectionOptions{Matrix: m}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != len(embs) {
+			t.Fatalf("expected projections: %d, got: %d", len(embs), len(res))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		testCases := []struct {
+			name   string
+			dim    v1.Dim
+			matrix *v1.ProjectionMatrix
+		}{
+			{name: "Missing", dim: v1.Dim2D},
+			{name: "Rows", dim: v1.Dim2D, matrix: &v1.ProjectionMatrix{Values: [][]float64{{1, 0}, {0, 1}}}},
+			{name: "Columns", dim: v1.Dim3D, matrix: &v1.ProjectionMatrix{Values: [][]float64{{1, 0}, {0, 1}, {0, 0}}}},
+			{name: "Mean", dim: v1.Dim2D, matrix: &v1.ProjectionMatrix{Values: [][]float64{{1, 0}, {0, 1}, {0, 0}}, Mean: []float64{1}}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				if _, err := FitMatrix(embs, tc.dim, &v1.ProjectionOptions{Matrix: tc.matrix}); err == nil {
+					t.Fatal("expected error")
+				}
+			})
+		}
+	})
+}
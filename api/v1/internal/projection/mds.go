@@ -0,0 +1,217 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/internal/search"
+	"golang.org/x/exp/maps"
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	// mdsMetric is the default MDS metric.
+	mdsMetric = v1.Euclidean
+	// mdsLandmarks is the maximum number of embeddings
+	// the classical MDS eigendecomposition is computed for.
+	// The remaining embeddings are placed by landmark MDS triangulation.
+	mdsLandmarks = 1000
+)
+
+// MDS computes classical multidimensional scaling projection of the given embeddings into the given dimension.
+// The embeddings are compared by opts.Metric: euclidean or cosine distances are preserved by double centering
+// their squares whereas dot products are preserved by scaling their Gram matrix directly.
+// Larger collections are projected using landmark MDS.
+// It returns a new slice of embeddings of the same size as the original embeddings,
+// but with the given dimension dropped to the given dimension.
+// See: https://en.wikipedia.org/wiki/Multidimensional_scaling#Classical_multidimensional_scaling
+func MDS(ctx context.Context, embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	dim := projDim.Size()
+
+	if embDim := len(embs[0].Values); embDim <= dim {
+		return nil, fmt.Errorf("insufficient embedding dimension: %d, needs at least: %d", embDim, dim+1)
+	}
+
+	metric := mdsMetric
+	if opts.Metric != "" {
+		metric = opts.Metric
+	}
+	kernel, centered, err := mdsKernel(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: landmarks are all the embeddings unless there are too many of them
+	landmarks := make([]int, len(embs))
+	for i := range landmarks {
+		landmarks[i] = i
+	}
+	if len(embs) > mdsLandmarks {
+		rnd := rand.New(rand.NewSource(*opts.Seed))
+		landmarks = rnd.Perm(len(embs))[:mdsLandmarks]
+		sort.Ints(landmarks)
+	}
+
+	m := len(landmarks)
+	b := mat.NewSymDense(m, nil)
+	if err := parallel(ctx, m, func(_, lo, hi int) {
+		for i := lo; i < hi; i++ {
+			// NOTE: the distance of the embeddings to themselves is zero
+			start := i
+			if centered {
+				start++
+			}
+			for j := start; j < m; j++ {
+				b.SetSym(i, j, kernel(embs[landmarks[i]].Values, embs[landmarks[j]].Values))
+			}
+		}
+	}); err != nil {
+		return nil, err
+	}
+	v1.ReportProgress(ctx, 0.3)
+
+	// NOTE: the kernel of the remaining embeddings is centered by the landmark means
+	means := make([]float64, m)
+	if centered {
+		means = doubleCenter(b)
+	}
+	vals, vecs, err := classicalMDS(b, dim)
+	if err != nil {
+		return nil, err
+	}
+	v1.ReportProgress(ctx, 0.6)
+
+	// NOTE: landmark MDS triangulates embeddings using the pseudoinverse
+	// of the landmark coordinates and the kernel of the embeddings and the landmarks.
+	// See: de Silva, Tenenbaum: Global versus local methods in nonlinear dimensionality reduction
+	pinv := make([][]float64, dim)
+	for k := 0; k < dim; k++ {
+		pinv[k] = make([]float64, m)
+		if vals[k] <= 0 {
+			continue
+		}
+		s := math.Sqrt(vals[k])
+		for j := 0; j < m; j++ {
+			pinv[k][j] = vecs.At(j, k) / s
+		}
+	}
+
+	coords := make([][]float64, len(embs))
+	for l, i := range landmarks {
+		coords[i] = make([]float64, dim)
+		for k := 0; k < dim; k++ {
+			if vals[k] > 0 {
+				coords[i][k] = vecs.At(l, k) * math.Sqrt(vals[k])
+			}
+		}
+	}
+	if err := parallel(ctx, len(embs), func(_, lo, hi int) {
+		delta := make([]float64, m)
+		for i := lo; i < hi; i++ {
+			if coords[i] != nil {
+				continue
+			}
+			for j, l := range landmarks {
+				delta[j] = kernel(embs[i].Values, embs[l].Values) - means[j]
+			}
+			coords[i] = make([]float64, dim)
+			for k := 0; k < dim; k++ {
+				for j := range delta {
+					coords[i][k] += pinv[k][j] * delta[j]
+				}
+			}
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	mdss := make([]v1.Embedding, 0, len(embs))
+	for i := range embs {
+		metadata := map[string]any{}
+		if embs[i].Metadata != nil {
+			metadata = maps.Clone(embs[i].Metadata)
+		}
+		metadata["projection"] = v1.MDS
+		mdss = append(mdss, v1.Embedding{
+			UID:      embs[i].UID,
+			Values:   coords[i],
+			Metadata: metadata,
+		})
+	}
+
+	return mdss, nil
+}
+
+// doubleCenter double centers the symmetric matrix b in place and returns its original column means.
+func doubleCenter(b *mat.SymDense) []float64 {
+	n := b.SymmetricDim()
+
+	means := make([]float64, n)
+	total := 0.0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			means[i] += b.At(i, j)
+		}
+		total += means[i]
+		means[i] /= float64(n)
+	}
+	total /= float64(n * n)
+
+	for i := 0; i < n; i++ {
+		for j := i; j < n; j++ {
+			b.SetSym(i, j, b.At(i, j)-means[i]-means[j]+total)
+		}
+	}
+
+	return means
+}
+
+// classicalMDS returns the dim largest eigenvalues of the kernel matrix b
+// along with the matrix of the corresponding eigenvectors.
+// If there are fewer than dim eigenvalues the missing eigenvalues are zero.
+func classicalMDS(b *mat.SymDense, dim int) ([]float64, *mat.Dense, error) {
+	n := b.SymmetricDim()
+
+	var eig mat.EigenSym
+	if ok := eig.Factorize(b, true); !ok {
+		return nil, nil, errors.New("failed mds eigendecomposition")
+	}
+	var ev mat.Dense
+	eig.VectorsTo(&ev)
+	// NOTE: eigenvalues are sorted in ascending order
+	eigVals := eig.Values(nil)
+
+	vals := make([]float64, dim)
+	vecs := mat.NewDense(n, dim, nil)
+	for k := 0; k < dim && k < n; k++ {
+		vals[k] = eigVals[n-1-k]
+		vecs.SetCol(k, mat.Col(nil, n-1-k, &ev))
+	}
+
+	return vals, vecs, nil
+}
+
+// mdsKernel returns the kernel of classical MDS measured by metric and reports whether it must be
+// double centered. The kernel of the euclidean and cosine distances is minus half of their squares
+// which is double centered into the Gram matrix of the embeddings centered at their mean.
+// The kernel of the dot product is the Gram matrix of the embeddings themselves.
+func mdsKernel(metric v1.Metric) (func(x, y []float64) float64, bool, error) {
+	if metric == v1.Dot {
+		dot, _, err := search.Scorer(metric)
+		return dot, false, err
+	}
+
+	dist, err := search.Distance(metric)
+	if err != nil {
+		return nil, false, err
+	}
+	return func(x, y []float64) float64 {
+		d := dist(x, y)
+		return -0.5 * d * d
+	}, true, nil
+}
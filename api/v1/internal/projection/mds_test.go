@@ -0,0 +1,122 @@
+package projection
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"gonum.org/v1/gonum/floats"
+)
+
+// planar returns n random embeddings which lie in a 2D plane of a 4D space.
+func planar(n int, rnd *rand.Rand) []v1.Embedding {
+	embs := make([]v1.Embedding, n)
+	for i := range embs {
+		x, y := rnd.NormFloat64(), rnd.NormFloat64()
+		embs[i] = v1.Embedding{Values: []float64{x + y, x - y, 2 * x, 0.5 * y}}
+	}
+	return embs
+}
+
+func TestMDS(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	seed := int64(1)
+
+	testCases := []struct {
+		name string
+		size int
+	}{
+		{"Classical", 50},
+		{"Landmarks", mdsLandmarks + 100},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			embs := planar(tc.size, rnd)
+			opts := Options(&v1.ProjectionOptions{Seed: &seed, Metric: v1.Euclidean})
+
+			res, err := MDS(context.TODO(), embs, v1.Dim2D, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != len(embs) {
+				t.Fatalf("expected %d projections, got: %d", len(embs), len(res))
+			}
+			if res[0].Metadata["projection"] != v1.MDS {
+				t.Fatalf("expected projection: %v, got: %v", v1.MDS, res[0].Metadata["projection"])
+			}
+			// NOTE: planar embeddings are recovered exactly up to rotation
+			for i := 0; i < 20; i++ {
+				x, y := rnd.Intn(len(embs)), rnd.Intn(len(embs))
+				exp := euclidean(embs[x].Values, embs[y].Values)
+				if got := euclidean(res[x].Values, res[y].Values); math.Abs(got-exp) > 1e-6 {
+					t.Fatalf("expected distance: %v, got: %v", exp, got)
+				}
+			}
+		})
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name+"/Dot", func(t *testing.T) {
+			embs := planar(tc.size, rnd)
+			opts := Options(&v1.ProjectionOptions{Seed: &seed, Metric: v1.Dot})
+
+			res, err := MDS(context.TODO(), embs, v1.Dim2D, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// NOTE: dot products of planar embeddings are recovered exactly
+			for i := 0; i < 20; i++ {
+				x, y := rnd.Intn(len(embs)), rnd.Intn(len(embs))
+				exp := floats.Dot(embs[x].Values, embs[y].Values)
+				if got := floats.Dot(res[x].Values, res[y].Values); math.Abs(got-exp) > 1e-6 {
+					t.Fatalf("expected dot product: %v, got: %v", exp, got)
+				}
+			}
+		})
+	}
+
+	for _, metric := range []v1.Metric{v1.Cosine, v1.Dot} {
+		metric := metric
+		t.Run(string(metric), func(t *testing.T) {
+			embs := planar(20, rnd)
+			opts := Options(&v1.ProjectionOptions{Seed: &seed, Metric: metric})
+
+			res, err := MDS(context.TODO(), embs, v1.Dim3D, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range res {
+				if len(r.Values) != 3 {
+					t.Fatalf("expected dimension: 3, got: %d", len(r.Values))
+				}
+				for _, v := range r.Values {
+					if math.IsNaN(v) || math.IsInf(v, 0) {
+						t.Fatalf("expected finite values, got: %v", r.Values)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("InvalidMetric", func(t *testing.T) {
+		opts := Options(&v1.ProjectionOptions{Metric: "foo"})
+		if _, err := MDS(context.TODO(), planar(10, rnd), v1.Dim2D, opts); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+
+	t.Run("Single", func(t *testing.T) {
+		res, err := MDS(context.TODO(), planar(1, rnd), v1.Dim3D, Options(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != 1 || len(res[0].Values) != 3 {
+			t.Fatalf("expected single 3D projection, got: %v", res)
+		}
+	})
+}
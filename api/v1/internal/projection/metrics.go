@@ -0,0 +1,102 @@
+package projection
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+const (
+	// DefaultMetricsK is the default neighbourhood size of the projection metrics.
+	DefaultMetricsK = 10
+	// DefaultMetricsSamples is the maximum number of embeddings whose
+	// neighbourhoods are evaluated when computing the projection metrics.
+	// NOTE: evaluating the neighbourhood of every embedding scales
+	// quadratically with the number of embeddings.
+	DefaultMetricsSamples = 1000
+)
+
+// Evaluate computes the quality metrics of projections projs of embeddings embs
+// for the neighbourhood of size k. Neighbourhoods of at most DefaultMetricsSamples
+// embeddings, sampled using rnd, are evaluated. It returns nil if there are not
+// enough embeddings to compute the metrics.
+// See: https://lvdmaaten.github.io/publications/papers/TR_Dimensionality_Reduction_Review_2009.pdf
+func Evaluate(ctx context.Context, embs, projs []v1.Embedding, k int, rnd *rand.Rand) (*v1.ProjectionMetrics, error) {
+	n := len(embs)
+	// NOTE: trustworthiness and continuity are only defined for k < (2n - 1) / 3
+	if maxK := (2*n - 2) / 3; k > maxK {
+		k = maxK
+	}
+	if k < 1 || len(projs) != n {
+		return nil, nil
+	}
+
+	samples := make([]int, n)
+	for i := range samples {
+		samples[i] = i
+	}
+	if n > DefaultMetricsSamples {
+		rnd.Shuffle(n, func(i, j int) { samples[i], samples[j] = samples[j], samples[i] })
+		samples = samples[:DefaultMetricsSamples]
+	}
+
+	var trust, cont, knn float64
+	for _, i := range samples {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		embRanks := ranks(i, embs)
+		projRanks := ranks(i, projs)
+
+		shared := 0
+		for j := 0; j < n; j++ {
+			if j == i {
+				continue
+			}
+			embNb, projNb := embRanks[j] <= k, projRanks[j] <= k
+			switch {
+			case embNb && projNb:
+				shared++
+			case projNb:
+				trust += float64(embRanks[j] - k)
+			case embNb:
+				cont += float64(projRanks[j] - k)
+			}
+		}
+		knn += float64(shared) / float64(k)
+	}
+
+	s := float64(len(samples))
+	norm := 2 / (s * float64(k) * float64(2*n-3*k-1))
+
+	return &v1.ProjectionMetrics{
+		K:               k,
+		Trustworthiness: 1 - norm*trust,
+		Continuity:      1 - norm*cont,
+		KNNPreservation: knn / s,
+	}, nil
+}
+
+// ranks returns the ranks of the euclidean distances of all embeddings embs
+// from the i-th embedding. The nearest embedding has rank 1, the i-th rank 0.
+func ranks(i int, embs []v1.Embedding) []int {
+	dists := make([]float64, len(embs))
+	idx := make([]int, 0, len(embs)-1)
+	for j := range embs {
+		if j == i {
+			continue
+		}
+		dists[j] = sqEuclidean(embs[i].Values, embs[j].Values)
+		idx = append(idx, j)
+	}
+	// NOTE: ties are broken by index so the ranks are deterministic
+	sort.SliceStable(idx, func(x, y int) bool { return dists[idx[x]] < dists[idx[y]] })
+
+	res := make([]int, len(embs))
+	for r, j := range idx {
+		res[j] = r + 1
+	}
+	return res
+}
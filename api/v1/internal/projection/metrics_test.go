@@ -0,0 +1,80 @@
+package projection
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestEvaluate(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	embs := make([]v1.Embedding, 30)
+	for i := range embs {
+		vals := make([]float64, 3)
+		for j := range vals {
+			vals[j] = rnd.NormFloat64()
+		}
+		embs[i] = v1.Embedding{Values: vals}
+	}
+
+	t.Run("Identity", func(t *testing.T) {
+		m, err := Evaluate(context.TODO(), embs, embs, DefaultMetricsK, rnd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.K != DefaultMetricsK {
+			t.Fatalf("expected k: %d, got: %d", DefaultMetricsK, m.K)
+		}
+		for name, v := range map[string]float64{
+			"trustworthiness":  m.Trustworthiness,
+			"continuity":       m.Continuity,
+			"knn_preservation": m.KNNPreservation,
+		} {
+			if math.Abs(v-1) > 1e-9 {
+				t.Fatalf("expected %s: 1, got: %v", name, v)
+			}
+		}
+	})
+
+	t.Run("Random", func(t *testing.T) {
+		projs := make([]v1.Embedding, len(embs))
+		for i := range projs {
+			projs[i] = v1.Embedding{Values: []float64{rnd.Float64(), rnd.Float64()}}
+		}
+		m, err := Evaluate(context.TODO(), embs, projs, DefaultMetricsK, rnd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, v := range map[string]float64{
+			"trustworthiness":  m.Trustworthiness,
+			"continuity":       m.Continuity,
+			"knn_preservation": m.KNNPreservation,
+		} {
+			if v < 0 || v >= 1 {
+				t.Fatalf("expected %s in [0, 1), got: %v", name, v)
+			}
+		}
+	})
+
+	t.Run("TooFew", func(t *testing.T) {
+		m, err := Evaluate(context.TODO(), embs[:1], embs[:1], DefaultMetricsK, rnd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != nil {
+			t.Fatalf("expected no metrics, got: %v", m)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+		if _, err := Evaluate(ctx, embs, embs, DefaultMetricsK, rnd); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
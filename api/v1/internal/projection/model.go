@@ -0,0 +1,191 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+const (
+	// DefaultStaleness is the default ratio of embeddings added
+	// since the last fit which triggers refitting of the model.
+	DefaultStaleness = 0.25
+	// DefaultNeighbours is the default number of nearest neighbours
+	// used to place new embeddings into non-linear projections.
+	DefaultNeighbours = 10
+)
+
+// Model is a fitted projection model.
+// It allows to project new embeddings without recomputing
+// projections of all the embeddings it was fitted on.
+type Model struct {
+	// Projection algorithm.
+	Projection v1.Projection `json:"projection"`
+	// Dim is projection dimension.
+	Dim v1.Dim `json:"dim"`
+	// Mean of the embeddings the model was fitted on.
+	// It's only set for centered linear models.
+	Mean []float64 `json:"mean,omitempty"`
+	// Components of the linear model.
+	// Each component is a projection axis.
+	Components [][]float64 `json:"components,omitempty"`
+	// Scale of the components of the linear model.
+	Scale []float64 `json:"scale,omitempty"`
+	// Variance is the ratio of the variance explained by each component.
+	Variance []float64 `json:"variance,omitempty"`
+	// Size is the number of embeddings the model was fitted on.
+	Size int `json:"size"`
+	// Added is the number of embeddings added since the model was fitted.
+	Added int `json:"added"`
+}
+
+// Linear returns true if the model is linear.
+func (m *Model) Linear() bool {
+	return len(m.Components) > 0
+}
+
+// Transform projects vals using the linear model.
+func (m *Model) Transform(vals []float64) ([]float64, error) {
+	if !m.Linear() {
+		return nil, fmt.Errorf("non-linear model: %v", m.Projection)
+	}
+	if len(vals) != len(m.Components[0]) {
+		return nil, fmt.Errorf("invalid embedding dimension: %d, expected: %d", len(vals), len(m.Components[0]))
+	}
+	res := make([]float64, len(m.Components))
+	for i, c := range m.Components {
+		for j, v := range vals {
+			if m.Mean != nil {
+				v -= m.Mean[j]
+			}
+			res[i] += v * c[j]
+		}
+		if m.Scale != nil && m.Scale[i] > 0 {
+			res[i] /= m.Scale[i]
+		}
+	}
+	return res, nil
+}
+
+// Models are projection models keyed by projection dimension.
+type Models map[v1.Dim]*Model
+
+// Stale returns true if models must be refitted before projecting
+// new embeddings with the given projection p and options opts.
+// Models are stale if they were fitted with a different projection,
+// the given options differ from the options the models were fitted with
+// or if the ratio of embeddings added since the last fit exceeds the staleness.
+func (m Models) Stale(p v1.Projection, fitOpts, opts *v1.ProjectionOptions) bool {
+	if len(m) == 0 || fitOpts == nil {
+		return true
+	}
+
+	if opts != nil {
+		o := *opts
+		// NOTE: no seed means any seed and no dims mean the fitted dims
+		if o.Seed == nil {
+			o.Seed = fitOpts.Seed
+		}
+		if len(o.Dims) == 0 {
+			o.Dims = fitOpts.Dims
+		}
+		if !reflect.DeepEqual(&o, fitOpts) {
+			return true
+		}
+	}
+
+	staleness := DefaultStaleness
+	if fitOpts.Staleness > 0 {
+		staleness = fitOpts.Staleness
+	}
+
+	for _, model := range m {
+		if model.Projection != p {
+			return true
+		}
+		if model.Size == 0 || float64(model.Added)/float64(model.Size) > staleness {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Added records the number of embeddings added to all models.
+func (m Models) Added(n int) {
+	for _, model := range m {
+		model.Added += n
+	}
+}
+
+// Extend projects new embeddings embs using the fitted models m.
+// Linear models apply the fitted transform. Non-linear models place every new embedding
+// at the inverse distance weighted average of the projections of its nearest neighbours
+// found among refs, which are the embeddings the projections projs were computed for.
+// It returns the projections of embs keyed by projection dimension.
+func Extend(m Models, refs []v1.Embedding, projs map[v1.Dim][]v1.Embedding, embs []v1.Embedding) (map[v1.Dim][]v1.Embedding, error) {
+	// NOTE: the seed only affects the neighbour search among large references
+	ip, err := NewInterpolator(m, refs, projs, 0)
+	if err != nil {
+		return nil, err
+	}
+	return ip.Project(context.Background(), embs)
+}
+
+// Nearest returns the indices of the k nearest neighbours
+// of vals in embs sorted by their euclidean distance.
+func Nearest(vals []float64, embs []v1.Embedding, k int) []int {
+	idx := make([]int, 0, len(embs))
+	dists := make([]float64, len(embs))
+	for i, e := range embs {
+		if len(e.Values) != len(vals) {
+			continue
+		}
+		dists[i] = sqEuclidean(vals, e.Values)
+		idx = append(idx, i)
+	}
+	sort.SliceStable(idx, func(x, y int) bool { return dists[idx[x]] < dists[idx[y]] })
+	if k < len(idx) {
+		idx = idx[:k]
+	}
+	return idx
+}
+
+// Place returns the projection of vals computed as the inverse distance weighted
+// average of projections nbProjs of its nearest neighbours nbs in the original space.
+// If vals is identical to any of the neighbours, it returns that neighbour's projection.
+func Place(vals []float64, nbs []v1.Embedding, nbProjs [][]float64) []float64 {
+	if len(nbProjs) == 0 {
+		return nil
+	}
+
+	res := make([]float64, len(nbProjs[0]))
+	total := 0.0
+	for i, nb := range nbs {
+		d := euclidean(vals, nb.Values)
+		if d == 0 {
+			copy(res, nbProjs[i])
+			return res
+		}
+		w := 1 / d
+		for j, v := range nbProjs[i] {
+			res[j] += w * v
+		}
+		total += w
+	}
+	for j := range res {
+		res[j] /= total
+	}
+	// NOTE: this should never happen, but let's be safe
+	for j := range res {
+		if math.IsNaN(res[j]) {
+			res[j] = 0
+		}
+	}
+
+	return res
+}
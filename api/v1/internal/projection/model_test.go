@@ -0,0 +1,109 @@
+package projection
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestModelTransform(t *testing.T) {
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+		{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+		{Values: []float64{4.0, 3.0, 2.0, 1.0}},
+		{Values: []float64{3.0, 4.0, 1.0, 2.0}},
+	}
+
+	for _, opts := range []*v1.ProjectionOptions{
+		{},
+		{Center: true},
+		{Center: true, Whiten: true},
+	} {
+		model, err := FitPCA(embs, v1.Dim2D, Options(opts))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !model.Linear() {
+			t.Fatal("expected linear model")
+		}
+
+		projs, err := PCA(context.TODO(), embs, v1.Dim2D, Options(opts))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := range embs {
+			vals, err := model.Transform(embs[i].Values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for j := range vals {
+				if math.Abs(vals[j]-projs[i].Values[j]) > 1e-9 {
+					t.Fatalf("expected: %v, got: %v", projs[i].Values, vals)
+				}
+			}
+		}
+	}
+
+	if _, err := (&Model{Projection: v1.TSNE}).Transform([]float64{1.0}); err == nil {
+		t.Fatal("expected non-linear model error")
+	}
+}
+
+func TestModelsStale(t *testing.T) {
+	seed := int64(1)
+	fitOpts := &v1.ProjectionOptions{Seed: &seed}
+
+	models := func(added int) Models {
+		return Models{
+			v1.Dim2D: {Projection: v1.TSNE, Dim: v1.Dim2D, Size: 10, Added: added},
+		}
+	}
+
+	testCases := []struct {
+		name    string
+		models  Models
+		p       v1.Projection
+		fitOpts *v1.ProjectionOptions
+		opts    *v1.ProjectionOptions
+		exp     bool
+	}{
+		{name: "Empty", models: Models{}, p: v1.TSNE, fitOpts: fitOpts, exp: true},
+		{name: "NoOptions", models: models(0), p: v1.TSNE, exp: true},
+		{name: "Fresh", models: models(2), p: v1.TSNE, fitOpts: fitOpts, exp: false},
+		{name: "AnySeed", models: models(2), p: v1.TSNE, fitOpts: fitOpts, opts: &v1.ProjectionOptions{}, exp: false},
+		{name: "Projection", models: models(0), p: v1.PCA, fitOpts: fitOpts, exp: true},
+		{name: "Options", models: models(0), p: v1.TSNE, fitOpts: fitOpts, opts: &v1.ProjectionOptions{Perplexity: 10}, exp: true},
+		{name: "AnyDims", models: models(2), p: v1.TSNE, fitOpts: &v1.ProjectionOptions{Seed: &seed, Dims: []v1.Dim{v1.Dim2D}}, opts: &v1.ProjectionOptions{}, exp: false},
+		{name: "Dims", models: models(2), p: v1.TSNE, fitOpts: &v1.ProjectionOptions{Seed: &seed, Dims: []v1.Dim{v1.Dim2D}}, opts: &v1.ProjectionOptions{Dims: []v1.Dim{v1.Dim1D}}, exp: true},
+		{name: "Staleness", models: models(3), p: v1.TSNE, fitOpts: fitOpts, exp: true},
+		{name: "CustomStaleness", models: models(3), p: v1.TSNE, fitOpts: &v1.ProjectionOptions{Seed: &seed, Staleness: 0.5}, exp: false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if stale := tc.models.Stale(tc.p, tc.fitOpts, tc.opts); stale != tc.exp {
+				t.Fatalf("expected stale: %v, got: %v", tc.exp, stale)
+			}
+		})
+	}
+}
+
+func TestPlace(t *testing.T) {
+	nbs := []v1.Embedding{
+		{Values: []float64{0.0, 0.0}},
+		{Values: []float64{2.0, 0.0}},
+	}
+	nbProjs := [][]float64{{0.0}, {1.0}}
+
+	if vals := Place([]float64{1.0, 0.0}, nbs, nbProjs); math.Abs(vals[0]-0.5) > 1e-9 {
+		t.Fatalf("expected: %v, got: %v", 0.5, vals[0])
+	}
+
+	if vals := Place([]float64{2.0, 0.0}, nbs, nbProjs); vals[0] != 1.0 {
+		t.Fatalf("expected: %v, got: %v", 1.0, vals[0])
+	}
+}
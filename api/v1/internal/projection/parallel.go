@@ -0,0 +1,51 @@
+package projection
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelChunk is the number of items a goroutine processes at a time.
+// NOTE: the chunks don't depend on the number of CPUs, so the results
+// aggregated per chunk are the same on any machine.
+const parallelChunk = 256
+
+// chunks returns the number of chunks the range [0, n) is split into.
+func chunks(n int) int {
+	return (n + parallelChunk - 1) / parallelChunk
+}
+
+// parallel calls fn for the chunks of the range [0, n) concurrently.
+// fn receives the index of the chunk along with its bounds.
+// It returns error if ctx is cancelled before all the chunks have been processed.
+func parallel(ctx context.Context, n int, fn func(chunk, lo, hi int)) error {
+	total := chunks(n)
+	workers := runtime.GOMAXPROCS(0)
+	if workers > total {
+		workers = total
+	}
+
+	var (
+		next atomic.Int64
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				c := int(next.Add(1) - 1)
+				if c >= total || ctx.Err() != nil {
+					return
+				}
+				lo := c * parallelChunk
+				fn(c, lo, min(lo+parallelChunk, n))
+			}
+		}()
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
@@ -0,0 +1,140 @@
+package projection
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"golang.org/x/exp/maps"
+	"gonum.org/v1/gonum/mat"
+)
+
+// Procrustes aligns the projections embs to the reference projections ref using orthogonal Procrustes analysis.
+// Projections are matched by the value of their metadata key; values which occur more than once
+// in either of the projections are ambiguous and ignored. The rotation, reflection, scaling and translation
+// are fitted on the matched projections and applied to all the projections embs.
+// It returns the alignment with the aligned projections embs, the number of the matched projections and
+// the disparity of the standardised matched projections.
+// See: https://en.wikipedia.org/wiki/Procrustes_analysis
+func Procrustes(ref, embs []v1.Embedding, key string) (*v1.Alignment, error) {
+	if len(ref) == 0 || len(embs) == 0 {
+		return nil, errors.New("no projections to align")
+	}
+	dim := len(ref[0].Values)
+	if len(embs[0].Values) != dim {
+		return nil, fmt.Errorf("projection dimension mismatch: %d != %d", dim, len(embs[0].Values))
+	}
+
+	refIdx := matchIndex(ref, key)
+	embIdx := matchIndex(embs, key)
+	pairs := make([][2]int, 0, len(embIdx))
+	for val, j := range embIdx {
+		if i, ok := refIdx[val]; ok && i >= 0 && j >= 0 {
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+	if len(pairs) < 2 {
+		return nil, fmt.Errorf("insufficient matched projections: %d, needs at least: 2", len(pairs))
+	}
+
+	n := len(pairs)
+	x := mat.NewDense(n, dim, nil)
+	y := mat.NewDense(n, dim, nil)
+	for r, p := range pairs {
+		x.SetRow(r, ref[p[0]].Values)
+		y.SetRow(r, embs[p[1]].Values)
+	}
+	muX, normX := standardise(x)
+	muY, normY := standardise(y)
+	if normX == 0 || normY == 0 {
+		return nil, errors.New("matched projections are degenerate")
+	}
+
+	// NOTE: the rotation R minimising ||Y*R - X|| is U*V^T
+	// where U*S*V^T is the SVD of Y^T*X and the scale is trace(S).
+	var m mat.Dense
+	m.Mul(y.T(), x)
+	var svd mat.SVD
+	if ok := svd.Factorize(&m, mat.SVDFull); !ok {
+		return nil, errors.New("failed procrustes svd")
+	}
+	var u, v, rot mat.Dense
+	svd.UTo(&u)
+	svd.VTo(&v)
+	rot.Mul(&u, v.T())
+	scale := 0.0
+	for _, s := range svd.Values(nil) {
+		scale += s
+	}
+
+	aligned := make([]v1.Embedding, 0, len(embs))
+	row := mat.NewVecDense(dim, nil)
+	var res mat.VecDense
+	for _, e := range embs {
+		for k := 0; k < dim; k++ {
+			row.SetVec(k, (e.Values[k]-muY[k])/normY)
+		}
+		res.MulVec(rot.T(), row)
+		values := make([]float64, dim)
+		for k := range values {
+			values[k] = scale*res.AtVec(k)*normX + muX[k]
+		}
+		metadata := map[string]any{}
+		if e.Metadata != nil {
+			metadata = maps.Clone(e.Metadata)
+		}
+		aligned = append(aligned, v1.Embedding{
+			UID:      e.UID,
+			Values:   values,
+			Metadata: metadata,
+		})
+	}
+
+	return &v1.Alignment{
+		Key:        key,
+		Matched:    n,
+		Disparity:  math.Max(0, 1-scale*scale),
+		Embeddings: aligned,
+	}, nil
+}
+
+// matchIndex indexes embs by the value of their metadata key.
+// Values which occur more than once are indexed with -1.
+func matchIndex(embs []v1.Embedding, key string) map[string]int {
+	idx := make(map[string]int, len(embs))
+	for i, e := range embs {
+		val, ok := e.Metadata[key]
+		if !ok || val == nil {
+			continue
+		}
+		k := fmt.Sprint(val)
+		if _, ok := idx[k]; ok {
+			idx[k] = -1
+			continue
+		}
+		idx[k] = i
+	}
+	return idx
+}
+
+// standardise centers the columns of m in place and scales m to the unit Frobenius norm.
+// It returns the column means and the Frobenius norm of the centered matrix.
+func standardise(m *mat.Dense) ([]float64, float64) {
+	r, c := m.Dims()
+	means := make([]float64, c)
+	for j := 0; j < c; j++ {
+		for i := 0; i < r; i++ {
+			means[j] += m.At(i, j)
+		}
+		means[j] /= float64(r)
+		for i := 0; i < r; i++ {
+			m.Set(i, j, m.At(i, j)-means[j])
+		}
+	}
+	norm := mat.Norm(m, 2)
+	if norm > 0 {
+		m.Scale(1/norm, m)
+	}
+	return means, norm
+}
@@ -0,0 +1,100 @@
+package projection
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestProcrustes(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	ref := make([]v1.Embedding, 20)
+	for i := range ref {
+		ref[i] = v1.Embedding{
+			UID:      fmt.Sprintf("ref%d", i),
+			Values:   []float64{rnd.NormFloat64(), rnd.NormFloat64()},
+			Metadata: map[string]any{v1.LabelMetaKey: fmt.Sprintf("l%d", i)},
+		}
+	}
+
+	// NOTE: embs are rotated, flipped, scaled and translated ref in reverse order
+	theta := 0.7
+	embs := make([]v1.Embedding, len(ref))
+	for i, e := range ref {
+		x, y := e.Values[0], -e.Values[1]
+		embs[len(ref)-1-i] = v1.Embedding{
+			UID: fmt.Sprintf("emb%d", i),
+			Values: []float64{
+				3*(x*math.Cos(theta)-y*math.Sin(theta)) + 5,
+				3*(x*math.Sin(theta)+y*math.Cos(theta)) - 2,
+			},
+			Metadata: e.Metadata,
+		}
+	}
+
+	t.Run("Aligned", func(t *testing.T) {
+		res, err := Procrustes(ref, embs, v1.LabelMetaKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Matched != len(ref) {
+			t.Fatalf("expected matched: %d, got: %d", len(ref), res.Matched)
+		}
+		if res.Disparity > 1e-9 {
+			t.Fatalf("expected zero disparity, got: %v", res.Disparity)
+		}
+		for i, e := range res.Embeddings {
+			r := ref[len(ref)-1-i]
+			for k := range e.Values {
+				if math.Abs(e.Values[k]-r.Values[k]) > 1e-6 {
+					t.Fatalf("expected aligned %s: %v, got: %v", e.UID, r.Values, e.Values)
+				}
+			}
+		}
+	})
+
+	t.Run("Noise", func(t *testing.T) {
+		noisy := make([]v1.Embedding, len(embs))
+		for i, e := range embs {
+			noisy[i] = v1.Embedding{
+				Values:   []float64{e.Values[0] + rnd.NormFloat64(), e.Values[1] + rnd.NormFloat64()},
+				Metadata: e.Metadata,
+			}
+		}
+		res, err := Procrustes(ref, noisy, v1.LabelMetaKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Disparity <= 0 || res.Disparity >= 1 {
+			t.Fatalf("expected disparity in (0, 1), got: %v", res.Disparity)
+		}
+	})
+
+	t.Run("Ambiguous", func(t *testing.T) {
+		dup := append([]v1.Embedding{}, embs...)
+		dup[0] = v1.Embedding{Values: dup[0].Values, Metadata: dup[1].Metadata}
+		res, err := Procrustes(ref, dup, v1.LabelMetaKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := len(ref) - 2; res.Matched != exp {
+			t.Fatalf("expected matched: %d, got: %d", exp, res.Matched)
+		}
+		if len(res.Embeddings) != len(dup) {
+			t.Fatalf("expected aligned: %d, got: %d", len(dup), len(res.Embeddings))
+		}
+	})
+
+	t.Run("Insufficient", func(t *testing.T) {
+		if _, err := Procrustes(ref, embs, "foo"); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, err := Procrustes(ref, []v1.Embedding{{Values: []float64{1, 2, 3}}}, v1.LabelMetaKey); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -1,28 +1,58 @@
 package projection
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math"
+	"math/rand"
+	"time"
 
-	"github.com/danaugrs/go-tsne/tsne"
 	v1 "github.com/milosgajdos/embeviz/api/v1"
 	"golang.org/x/exp/maps"
+	"gonum.org/v1/gonum/floats"
 	"gonum.org/v1/gonum/mat"
 	"gonum.org/v1/gonum/stat"
 )
 
-var projDimToNum = map[v1.Dim]int{
-	v1.Dim2D: 2,
-	v1.Dim3D: 3,
-}
-
 // PCA computes PCA vectors and projects the original embeddings to the given dimension.
 // It returns a new slice of embeddings of the same size as the origin al embeddings,
 // but with the given dimension dropped to the given dimension.
-func PCA(embs []v1.Embedding, projDim v1.Dim) ([]v1.Embedding, error) {
-	dim := projDimToNum[projDim]
+func PCA(ctx context.Context, embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	model, err := FitPCA(embs, projDim, opts)
+	if err != nil {
+		return nil, err
+	}
 	pcas := make([]v1.Embedding, 0, len(embs))
 
+	for i := range embs {
+		vals, err := model.Transform(embs[i].Values)
+		if err != nil {
+			return nil, err
+		}
+		metadata := map[string]any{}
+		if embs[i].Metadata != nil {
+			metadata = maps.Clone(embs[i].Metadata)
+		}
+		metadata["projection"] = v1.PCA
+		pcas = append(pcas, v1.Embedding{
+			UID:      embs[i].UID,
+			Values:   vals,
+			Metadata: metadata,
+		})
+	}
+
+	return pcas, nil
+}
+
+// FitPCA computes PCA vectors of the given embeddings and returns
+// the linear model which projects embeddings to the given dimension.
+func FitPCA(embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) (*Model, error) {
+	dim := projDim.Size()
+
 	if embDim := len(embs[0].Values); embDim <= dim {
 		return nil, fmt.Errorf("insufficient embedding dimension: %d, needs at least: %d", embDim, dim+1)
 	}
@@ -31,7 +61,7 @@ func PCA(embs []v1.Embedding, projDim v1.Dim) ([]v1.Embedding, error) {
 	for i, e := range embs {
 		mx.SetRow(i, e.Values)
 	}
-	r, _ := mx.Dims()
+	r, c := mx.Dims()
 	// Keep extending matrix until we have enough
 	// data to compute the PCA
 	for r < dim {
@@ -40,7 +70,7 @@ func PCA(embs []v1.Embedding, projDim v1.Dim) ([]v1.Embedding, error) {
 		vals := make([]float64, len(embs[0].Values))
 		copy(vals, embs[0].Values)
 		mx = mx.Grow(1, 0).(*mat.Dense)
-		mx.SetRow(1, vals)
+		mx.SetRow(r, vals)
 		r, _ = mx.Dims()
 	}
 	var pc stat.PC
@@ -48,106 +78,267 @@ func PCA(embs []v1.Embedding, projDim v1.Dim) ([]v1.Embedding, error) {
 	if !ok {
 		return nil, errors.New("failed pca")
 	}
-	var proj mat.Dense
+
 	var vec mat.Dense
 	pc.VectorsTo(&vec)
-	proj.Mul(mx, vec.Slice(0, len(embs[0].Values), 0, dim))
 
-	for i := range embs {
-		metadata := map[string]any{}
-		if embs[i].Metadata != nil {
-			metadata = maps.Clone(embs[i].Metadata)
+	model := &Model{
+		Projection: v1.PCA,
+		Dim:        projDim,
+		Components: make([][]float64, dim),
+		Size:       len(embs),
+	}
+	for i := 0; i < dim; i++ {
+		model.Components[i] = mat.Col(nil, i, &vec)
+	}
+
+	if opts.Center {
+		model.Mean = make([]float64, c)
+		for j := 0; j < c; j++ {
+			model.Mean[j] = stat.Mean(mat.Col(nil, j, mx), nil)
 		}
-		metadata["projection"] = v1.PCA
-		pcas = append(pcas, v1.Embedding{
-			Values:   proj.RawRowView(i),
-			Metadata: metadata,
-		})
 	}
 
-	return pcas, nil
+	vars := pc.VarsTo(nil)
+	total := floats.Sum(vars)
+	model.Variance = make([]float64, dim)
+	for j := 0; j < dim && j < len(vars) && total > 0; j++ {
+		model.Variance[j] = vars[j] / total
+	}
+
+	if opts.Whiten {
+		model.Scale = make([]float64, dim)
+		for j := 0; j < dim && j < len(vars); j++ {
+			// NOTE: zero variance components can't be whitened
+			if vars[j] > 0 {
+				model.Scale[j] = math.Sqrt(vars[j])
+			}
+		}
+	}
+
+	return model, nil
 }
 
-// TSNE calculates tsne projecion of the given embeddings into the given dimension.
-// It returns a new slice of embeddings of the same size as the origin al embeddings,
-// but with the given dimension dropped to the given dimension.
-func TSNE(embs []v1.Embedding, projDim v1.Dim) ([]v1.Embedding, error) {
-	dim := projDimToNum[projDim]
-	tsnes := make([]v1.Embedding, 0, len(embs))
+// Options returns a copy of opts with the random seed set.
+// If opts is nil it returns default options.
+func Options(opts *v1.ProjectionOptions) *v1.ProjectionOptions {
+	o := &v1.ProjectionOptions{}
+	if opts != nil {
+		*o = *opts
+	}
+	if o.Seed == nil {
+		seed := time.Now().UnixNano()
+		o.Seed = &seed
+	}
+	return o
+}
 
-	if embDim := len(embs[0].Values); embDim <= dim {
-		return nil, fmt.Errorf("insufficient embedding dimension: %d, needs at least: %d", embDim, dim+1)
+// Dims returns a copy of opts which requests the given projection dimensions supported by p
+// unless opts already request specific dimensions. If opts is nil it returns default options.
+func Dims(opts *v1.ProjectionOptions, p v1.Projection, dims []v1.Dim) *v1.ProjectionOptions {
+	o := &v1.ProjectionOptions{}
+	if opts != nil {
+		*o = *opts
+	}
+	if len(o.Dims) > 0 || len(dims) == 0 {
+		return o
+	}
+	projector, ok := v1.GetProjector(p)
+	if !ok {
+		return o
+	}
+	for _, dim := range dims {
+		if projector.Algorithm().Supports(dim) {
+			o.Dims = append(o.Dims, dim)
+		}
+	}
+	return o
+}
+
+// Compute computes p projections for embeddings embs and returns them.
+// The projections are computed for all the dimensions requested in opts.
+// It returns the options used to compute the projections along with the projections
+// and the fitted models which can be used to project new embeddings.
+// If there are more embeddings than the maximum number of landmarks, the projections
+// are computed for the sampled landmarks and interpolated for the remaining embeddings.
+// It returns error if ctx is cancelled before the projections have been computed.
+func Compute(ctx context.Context, embs []v1.Embedding, p v1.Projection, opts *v1.ProjectionOptions) (*v1.Projections, Models, error) {
+	opts = Options(opts)
+	projector, err := lookup(p, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(embs) == 0 {
+		embeddings := make(map[v1.Dim][]v1.Embedding)
+		for _, dim := range opts.Dimensions() {
+			embeddings[dim] = []v1.Embedding{}
+		}
+		return &v1.Projections{
+			Projection: p,
+			Options:    opts,
+			Embeddings: embeddings,
+		}, Models{}, nil
 	}
 
-	// NOTE: these are somewhat randomly picked hyperparams YMMV
-	perplexity, learningRate := float64(300), float64(300)
-	if dim == 3 {
-		perplexity, learningRate = float64(500), float64(500)
+	// NOTE: the resolved options are only used to compute the projections
+	// so the returned options are the options the caller requested.
+	fitOpts := opts
+	if r, ok := projector.(resolver); ok {
+		if fitOpts, err = r.Resolve(embs, opts); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	mx := mat.NewDense(len(embs), len(embs[0].Values), nil)
-	for i, e := range embs {
-		mx.SetRow(i, e.Values)
+	landmarks := SampleLandmarks(len(embs), opts)
+	if landmarks == nil {
+		embeddings, models, err := compute(ctx, embs, p, fitOpts)
+		if err != nil {
+			return nil, nil, err
+		}
+		metrics, err := evaluate(ctx, embs, embeddings, models, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &v1.Projections{
+			Projection: p,
+			Options:    opts,
+			Metrics:    metrics,
+			Embeddings: embeddings,
+		}, models, nil
 	}
 
-	t := tsne.NewTSNE(dim, perplexity, learningRate, 300, true)
-	resMat := t.EmbedData(mx, nil)
-	d := mat.DenseCopyOf(resMat)
+	refs := make([]v1.Embedding, 0, len(landmarks))
+	rest := make([]v1.Embedding, 0, len(embs)-len(landmarks))
+	for i, l := 0, 0; i < len(embs); i++ {
+		if l < len(landmarks) && landmarks[l] == i {
+			refs = append(refs, embs[i])
+			l++
+			continue
+		}
+		rest = append(rest, embs[i])
+	}
 
-	for i := range embs {
-		metadata := map[string]any{}
-		if embs[i].Metadata != nil {
-			metadata = maps.Clone(embs[i].Metadata)
+	refProjs, models, err := compute(v1.ProgressRange(ctx, 0, 0.9), refs, p, fitOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+	// NOTE: the metrics of the landmarks are a proxy
+	// for the metrics of the interpolated projections.
+	metrics, err := evaluate(ctx, refs, refProjs, models, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	ip, err := NewInterpolator(models, refs, refProjs, *opts.Seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	restProjs, err := ip.Project(ctx, rest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	embeddings := make(map[v1.Dim][]v1.Embedding, len(refProjs))
+	for dim := range refProjs {
+		dimProjs := make([]v1.Embedding, 0, len(embs))
+		for i, l, r := 0, 0, 0; i < len(embs); i++ {
+			if l < len(landmarks) && landmarks[l] == i {
+				dimProjs = append(dimProjs, refProjs[dim][l])
+				l++
+				continue
+			}
+			dimProjs = append(dimProjs, restProjs[dim][r])
+			r++
 		}
-		metadata["projection"] = v1.TSNE
-		tsnes = append(tsnes, v1.Embedding{
-			Values:   d.RawRowView(i),
-			Metadata: metadata,
-		})
+		embeddings[dim] = dimProjs
+	}
+	for _, model := range models {
+		model.Size = len(embs)
 	}
 
-	return tsnes, nil
+	return &v1.Projections{
+		Projection: p,
+		Options:    opts,
+		Metrics:    metrics,
+		Embeddings: embeddings,
+	}, models, nil
 }
 
-// Compute computes p projections (2D and 3D) for embeddings embs and returns them.
-func Compute(embs []v1.Embedding, p v1.Projection) (map[v1.Dim][]v1.Embedding, error) {
-	if len(embs) == 0 {
-		return map[v1.Dim][]v1.Embedding{
-			v1.Dim2D: {},
-			v1.Dim3D: {},
-		}, nil
-	}
-	var (
-		err    error
-		proj2D []v1.Embedding
-		proj3D []v1.Embedding
-	)
-	// Calculate projection
-	switch p {
-	case v1.PCA:
-		proj2D, err = PCA(embs, v1.Dim2D)
-		if err != nil {
-			return nil, err
+// compute computes p projections of embs and returns them along with the fitted models.
+// Projectors which fit linear models project embs using the fitted models.
+func compute(ctx context.Context, embs []v1.Embedding, p v1.Projection, opts *v1.ProjectionOptions) (map[v1.Dim][]v1.Embedding, Models, error) {
+	projector, err := lookup(p, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dims := opts.Dimensions()
+	models := make(Models, len(dims))
+
+	if f, ok := projector.(fitter); ok {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
 		}
-		proj3D, err = PCA(embs, v1.Dim3D)
-		if err != nil {
-			return nil, err
+		for _, dim := range dims {
+			model, err := f.Fit(embs, dim, opts)
+			if err != nil {
+				return nil, nil, err
+			}
+			models[dim] = model
 		}
-	case v1.TSNE:
-		proj2D, err = TSNE(embs, v1.Dim2D)
+		projs, err := Extend(models, nil, nil, embs)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		proj3D, err = TSNE(embs, v1.Dim3D)
+		return projs, models, nil
+	}
+
+	projs := make(map[v1.Dim][]v1.Embedding, len(dims))
+	for i, dim := range dims {
+		lo, hi := float64(i)/float64(len(dims)), float64(i+1)/float64(len(dims))
+		dimProjs, err := projector.Project(v1.ProgressRange(ctx, lo, hi), embs, dim, opts)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if len(dimProjs) != len(embs) {
+			return nil, nil, fmt.Errorf("invalid %s %s projections: %d, expected: %d", p, dim, len(dimProjs), len(embs))
 		}
-	default:
+		projs[dim] = dimProjs
+		models[dim] = &Model{Projection: p, Dim: dim, Size: len(embs)}
+	}
+
+	return projs, models, nil
+}
+
+// lookup returns the projector of p projections.
+// It returns error if the projector does not support the dimensions requested in opts.
+func lookup(p v1.Projection, opts *v1.ProjectionOptions) (v1.Projector, error) {
+	projector, ok := v1.GetProjector(p)
+	if !ok {
 		return nil, fmt.Errorf("invalid projection: %v", p)
+	}
+	for _, dim := range opts.Dimensions() {
+		if !projector.Algorithm().Supports(dim) {
+			return nil, fmt.Errorf("unsupported %s dimension: %v", p, dim)
+		}
+	}
+	return projector, nil
+}
 
+// evaluate computes the quality metrics of projections of embs computed by models.
+func evaluate(ctx context.Context, embs []v1.Embedding, projs map[v1.Dim][]v1.Embedding, models Models, opts *v1.ProjectionOptions) (map[v1.Dim]*v1.ProjectionMetrics, error) {
+	rnd := rand.New(rand.NewSource(*opts.Seed))
+	metrics := make(map[v1.Dim]*v1.ProjectionMetrics, len(projs))
+	// NOTE: dimensions are evaluated in order so the sampling is deterministic
+	for _, dim := range opts.Dimensions() {
+		m, err := Evaluate(ctx, embs, projs[dim], DefaultMetricsK, rnd)
+		if err != nil {
+			return nil, err
+		}
+		if m == nil {
+			continue
+		}
+		m.ExplainedVariance = models[dim].Variance
+		metrics[dim] = m
 	}
-	return map[v1.Dim][]v1.Embedding{
-		v1.Dim2D: proj2D,
-		v1.Dim3D: proj3D,
-	}, nil
+	return metrics, nil
 }
@@ -0,0 +1,156 @@
+package projection
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestCompute(t *testing.T) {
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+		{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+		{Values: []float64{4.0, 3.0, 2.0, 1.0}},
+		{Values: []float64{3.0, 4.0, 1.0, 2.0}},
+	}
+
+	for _, p := range []v1.Projection{v1.PCA, v1.TSNE, v1.UMAP, v1.MDS, v1.Random} {
+		p := p
+		t.Run(string(p), func(t *testing.T) {
+			var progress float64
+			ctx := v1.WithProgress(context.Background(), func(p float64) { progress = p })
+
+			projs, models, err := Compute(ctx, embs, p, &v1.ProjectionOptions{MaxIterations: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, dim := range []v1.Dim{v1.Dim2D, v1.Dim3D} {
+				if len(projs.Embeddings[dim]) != len(embs) {
+					t.Fatalf("expected %s projections: %d, got: %d", dim, len(embs), len(projs.Embeddings[dim]))
+				}
+				if models[dim] == nil || models[dim].Projection != p {
+					t.Fatalf("expected %s model: %v, got: %v", dim, p, models[dim])
+				}
+			}
+			if !models[v1.Dim2D].Linear() && (progress <= 0.5 || progress > 1) {
+				t.Fatalf("expected progress in (0.5, 1], got: %v", progress)
+			}
+		})
+
+		t.Run(string(p)+"Cancelled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			if _, _, err := Compute(ctx, embs, p, nil); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestComputeDims(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	embs := make([]v1.Embedding, 20)
+	for i := range embs {
+		vals := make([]float64, 16)
+		for j := range vals {
+			vals[j] = rnd.Float64()
+		}
+		embs[i] = v1.Embedding{Values: vals}
+	}
+
+	dims := []v1.Dim{v1.Dim1D, v1.NewDim(10)}
+	for _, p := range []v1.Projection{v1.PCA, v1.UMAP, v1.MDS, v1.Random} {
+		p := p
+		t.Run(string(p), func(t *testing.T) {
+			projs, models, err := Compute(context.Background(), embs, p, &v1.ProjectionOptions{MaxIterations: 10, Dims: dims})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(projs.Embeddings) != len(dims) || len(models) != len(dims) {
+				t.Fatalf("expected projections of %d dims, got: %d", len(dims), len(projs.Embeddings))
+			}
+			for _, dim := range dims {
+				if len(projs.Embeddings[dim]) != len(embs) {
+					t.Fatalf("expected %s projections: %d, got: %d", dim, len(embs), len(projs.Embeddings[dim]))
+				}
+				for _, e := range projs.Embeddings[dim] {
+					if len(e.Values) != dim.Size() {
+						t.Fatalf("expected %s projection size: %d, got: %d", dim, dim.Size(), len(e.Values))
+					}
+				}
+				if projs.Metrics[dim] == nil {
+					t.Fatalf("expected %s metrics", dim)
+				}
+			}
+		})
+	}
+
+	t.Run("Unsupported", func(t *testing.T) {
+		if _, _, err := Compute(context.Background(), embs, v1.TSNE, &v1.ProjectionOptions{Dims: dims}); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, _, err := Compute(context.Background(), nil, v1.PCA, &v1.ProjectionOptions{Dims: []v1.Dim{"foo"}}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestComputeLandmarks(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	embs := make([]v1.Embedding, 60)
+	for i := range embs {
+		embs[i] = v1.Embedding{
+			UID:    string(rune('a' + i)),
+			Values: []float64{rnd.Float64(), rnd.Float64(), rnd.Float64(), rnd.Float64()},
+		}
+	}
+
+	seed := int64(1)
+	opts := &v1.ProjectionOptions{Seed: &seed, MaxIterations: 10, Landmarks: 20}
+
+	for _, p := range []v1.Projection{v1.PCA, v1.TSNE} {
+		p := p
+		t.Run(string(p), func(t *testing.T) {
+			projs, models, err := Compute(context.Background(), embs, p, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			landmarks := SampleLandmarks(len(embs), opts)
+			refs := make([]v1.Embedding, 0, len(landmarks))
+			for _, i := range landmarks {
+				refs = append(refs, embs[i])
+			}
+			refProjs, _, err := Compute(context.Background(), refs, p, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, dim := range []v1.Dim{v1.Dim2D, v1.Dim3D} {
+				if len(projs.Embeddings[dim]) != len(embs) {
+					t.Fatalf("expected %s projections: %d, got: %d", dim, len(embs), len(projs.Embeddings[dim]))
+				}
+				for i, e := range projs.Embeddings[dim] {
+					if e.UID != embs[i].UID {
+						t.Fatalf("expected %s projection UID: %s, got: %s", dim, embs[i].UID, e.UID)
+					}
+				}
+				// landmarks are projected by the algorithm
+				for l, i := range landmarks {
+					if got, exp := projs.Embeddings[dim][i].Values, refProjs.Embeddings[dim][l].Values; !reflect.DeepEqual(got, exp) {
+						t.Fatalf("expected %s landmark projection: %v, got: %v", dim, exp, got)
+					}
+				}
+				if models[dim].Size != len(embs) {
+					t.Fatalf("expected %s model size: %d, got: %d", dim, len(embs), models[dim].Size)
+				}
+			}
+		})
+	}
+}
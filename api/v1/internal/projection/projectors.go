@@ -0,0 +1,274 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func init() {
+	for _, p := range []v1.Projector{pcaProjector{}, tsneProjector{}, umapProjector{}, mdsProjector{}, randomProjector{}, axesProjector{}, matrixProjector{}} {
+		if err := v1.RegisterProjector(p); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// fitter is implemented by projectors which fit linear models.
+type fitter interface {
+	// Fit fits the model which projects embs to the given dimension.
+	Fit(embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) (*Model, error)
+}
+
+// resolver is implemented by projectors which resolve
+// their options against all the projected embeddings.
+type resolver interface {
+	// Resolve returns the options the embeddings embs are projected with.
+	Resolve(embs []v1.Embedding, opts *v1.ProjectionOptions) (*v1.ProjectionOptions, error)
+}
+
+// commonParams are the params of all the projection algorithms.
+var commonParams = []v1.ProjectionParam{
+	{
+		Name:        "seed",
+		Type:        "integer",
+		Description: "Seed of the random number generator. If not set, a random seed is picked and recorded.",
+	},
+	{
+		Name:        "staleness",
+		Type:        "number",
+		Description: "Ratio of embeddings added since the projections have been computed which triggers recomputing them.",
+		Default:     DefaultStaleness,
+	},
+	{
+		Name:        "landmarks",
+		Type:        "integer",
+		Description: "Maximum number of embeddings projected by the algorithm. Projections of the remaining embeddings are interpolated.",
+		Default:     DefaultLandmarks,
+	},
+}
+
+type pcaProjector struct{}
+
+func (pcaProjector) Algorithm() v1.ProjectionAlgorithm {
+	return v1.ProjectionAlgorithm{
+		Name:        v1.PCA,
+		Description: "Principal component analysis",
+		Params: append([]v1.ProjectionParam{
+			{
+				Name:        "center",
+				Type:        "boolean",
+				Description: "Center the data before projecting it.",
+				Default:     false,
+			},
+			{
+				Name:        "whiten",
+				Type:        "boolean",
+				Description: "Scale the principal components to unit variance.",
+				Default:     false,
+			},
+		}, commonParams...),
+	}
+}
+
+func (pcaProjector) Project(ctx context.Context, embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	return PCA(ctx, embs, dim, opts)
+}
+
+func (pcaProjector) Fit(embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) (*Model, error) {
+	return FitPCA(embs, dim, opts)
+}
+
+type tsneProjector struct{}
+
+func (tsneProjector) Algorithm() v1.ProjectionAlgorithm {
+	return v1.ProjectionAlgorithm{
+		Name:        v1.TSNE,
+		Description: "Barnes-Hut t-distributed stochastic neighbor embedding",
+		// NOTE: space-partitioning trees grow exponentially with the dimension
+		MaxDim: 3,
+		Params: append([]v1.ProjectionParam{
+			{
+				Name:        "perplexity",
+				Type:        "number",
+				Description: "Effective number of nearest neighbours of every embedding, at most a third of the other embeddings. Defaults to 500 in 3D and is reduced for small collections.",
+				Default:     tsnePerplexity,
+			},
+			{
+				Name:        "learning_rate",
+				Type:        "number",
+				Description: "Learning rate of the gradient descent. 3D projections and larger collections use larger default rate.",
+				Default:     tsneLearningRate,
+			},
+			{
+				Name:        "max_iterations",
+				Type:        "integer",
+				Description: "Number of the gradient descent iterations.",
+				Default:     tsneIterations,
+			},
+		}, commonParams...),
+	}
+}
+
+func (tsneProjector) Project(ctx context.Context, embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	return TSNE(ctx, embs, dim, opts)
+}
+
+type umapProjector struct{}
+
+func (umapProjector) Algorithm() v1.ProjectionAlgorithm {
+	return v1.ProjectionAlgorithm{
+		Name:        v1.UMAP,
+		Description: "Uniform manifold approximation and projection",
+		Params: append([]v1.ProjectionParam{
+			{
+				Name:        "learning_rate",
+				Type:        "number",
+				Description: "Learning rate of the layout optimization.",
+				Default:     umapLearningRate,
+			},
+			{
+				Name:        "max_iterations",
+				Type:        "integer",
+				Description: "Number of the layout optimization epochs. Larger collections use fewer default epochs.",
+				Default:     umapSmallEpochs,
+			},
+		}, commonParams...),
+	}
+}
+
+func (umapProjector) Project(ctx context.Context, embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	return UMAP(ctx, embs, dim, opts)
+}
+
+type mdsProjector struct{}
+
+func (mdsProjector) Algorithm() v1.ProjectionAlgorithm {
+	return v1.ProjectionAlgorithm{
+		Name:        v1.MDS,
+		Description: "Classical multidimensional scaling",
+		Params: append([]v1.ProjectionParam{
+			{
+				Name:        "metric",
+				Type:        "string",
+				Description: "Dissimilarity metric of the embeddings: cosine, dot or euclidean.",
+				Default:     mdsMetric,
+			},
+		}, commonParams...),
+	}
+}
+
+func (mdsProjector) Project(ctx context.Context, embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	return MDS(ctx, embs, dim, opts)
+}
+
+func (mdsProjector) ValidateOptions(opts *v1.ProjectionOptions) error {
+	if opts.Metric != "" && !opts.Metric.Valid() {
+		return fmt.Errorf("invalid metric: %v", opts.Metric)
+	}
+	return nil
+}
+
+type randomProjector struct{}
+
+func (randomProjector) Algorithm() v1.ProjectionAlgorithm {
+	return v1.ProjectionAlgorithm{
+		Name:        v1.Random,
+		Description: "Johnson-Lindenstrauss random projection",
+		Params:      commonParams,
+	}
+}
+
+func (randomProjector) Project(ctx context.Context, embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	return RandomProjection(ctx, embs, dim, opts)
+}
+
+func (randomProjector) Fit(embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) (*Model, error) {
+	return FitRandom(embs, dim, opts)
+}
+
+type axesProjector struct{}
+
+func (axesProjector) Algorithm() v1.ProjectionAlgorithm {
+	return v1.ProjectionAlgorithm{
+		Name:        v1.Axes,
+		Description: "Scalar projection onto semantic axes defined by pairs of anchors",
+		Params: append([]v1.ProjectionParam{
+			{
+				Name:        "axes",
+				Type:        "array",
+				Description: "Semantic axes given by negative and positive anchors. Anchors are texts, embedding UIDs or embedding values.",
+			},
+		}, commonParams...),
+	}
+}
+
+func (axesProjector) Project(ctx context.Context, embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	return SemanticAxes(ctx, embs, dim, opts)
+}
+
+func (axesProjector) Fit(embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) (*Model, error) {
+	return FitAxes(embs, dim, opts)
+}
+
+func (axesProjector) Resolve(embs []v1.Embedding, opts *v1.ProjectionOptions) (*v1.ProjectionOptions, error) {
+	return ResolveAnchors(embs, opts)
+}
+
+func (axesProjector) ValidateOptions(opts *v1.ProjectionOptions) error {
+	if len(opts.Axes) == 0 {
+		return fmt.Errorf("invalid axes: no axes")
+	}
+	for i, axis := range opts.Axes {
+		if !axis.Negative.Valid() || !axis.Positive.Valid() {
+			return fmt.Errorf("invalid axis %d: missing anchor", i)
+		}
+	}
+	for _, dim := range opts.Dims {
+		if dim.Size() > len(opts.Axes) {
+			return fmt.Errorf("invalid axes: %s projection needs %d axes, got: %d", dim, dim.Size(), len(opts.Axes))
+		}
+	}
+	return nil
+}
+
+type matrixProjector struct{}
+
+func (matrixProjector) Algorithm() v1.ProjectionAlgorithm {
+	return v1.ProjectionAlgorithm{
+		Name:        v1.Matrix,
+		Description: "Linear projection using the uploaded projection matrix",
+		Params: append([]v1.ProjectionParam{
+			{
+				Name:        "matrix",
+				Type:        "object",
+				Description: "Projection matrix with a row per embedding dimension and an optional mean. Defaults to the uploaded provider matrix.",
+			},
+		}, commonParams...),
+	}
+}
+
+func (matrixProjector) Project(ctx context.Context, embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	return ProjectMatrix(ctx, embs, dim, opts)
+}
+
+func (matrixProjector) Fit(embs []v1.Embedding, dim v1.Dim, opts *v1.ProjectionOptions) (*Model, error) {
+	return FitMatrix(embs, dim, opts)
+}
+
+func (matrixProjector) ValidateOptions(opts *v1.ProjectionOptions) error {
+	// NOTE: the uploaded provider matrix is used if opts do not carry any
+	if opts.Matrix == nil {
+		return nil
+	}
+	if err := opts.Matrix.Validate(); err != nil {
+		return err
+	}
+	for _, dim := range opts.Dims {
+		if cols := len(opts.Matrix.Values[0]); dim.Size() > cols {
+			return fmt.Errorf("invalid matrix: %s projection needs %d columns, got: %d", dim, dim.Size(), cols)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// RandomProjection computes Johnson-Lindenstrauss random projection of the given embeddings into the given dimension.
+// It returns a new slice of embeddings of the same size as the original embeddings,
+// but with the given dimension dropped to the given dimension.
+func RandomProjection(ctx context.Context, embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	model, err := FitRandom(embs, projDim, opts)
+	if err != nil {
+		return nil, err
+	}
+	projs, err := Extend(Models{projDim: model}, nil, nil, embs)
+	if err != nil {
+		return nil, err
+	}
+	return projs[projDim], nil
+}
+
+// FitRandom returns the linear model which projects embeddings to the given dimension
+// using a random matrix with entries drawn from the normal distribution N(0, 1/dim).
+// The matrix is generated from opts.Seed so the same seed always yields the same projection.
+// See: https://en.wikipedia.org/wiki/Johnson%E2%80%93Lindenstrauss_lemma
+func FitRandom(embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) (*Model, error) {
+	dim := projDim.Size()
+
+	embDim := len(embs[0].Values)
+	if embDim <= dim {
+		return nil, fmt.Errorf("insufficient embedding dimension: %d, needs at least: %d", embDim, dim+1)
+	}
+
+	rnd := rand.New(rand.NewSource(*opts.Seed))
+	scale := 1 / math.Sqrt(float64(dim))
+
+	model := &Model{
+		Projection: v1.Random,
+		Dim:        projDim,
+		Components: make([][]float64, dim),
+		Size:       len(embs),
+	}
+	for i := range model.Components {
+		model.Components[i] = make([]float64, embDim)
+		for j := range model.Components[i] {
+			model.Components[i][j] = rnd.NormFloat64() * scale
+		}
+	}
+
+	return model, nil
+}
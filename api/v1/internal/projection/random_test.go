@@ -0,0 +1,65 @@
+package projection
+
+import (
+	"context"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestRandomProjection(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	embs := make([]v1.Embedding, 10)
+	for i := range embs {
+		vals := make([]float64, 16)
+		for j := range vals {
+			vals[j] = rnd.NormFloat64()
+		}
+		embs[i] = v1.Embedding{Values: vals}
+	}
+
+	seed, other := int64(1), int64(2)
+	opts := Options(&v1.ProjectionOptions{Seed: &seed})
+
+	res2D, err := RandomProjection(context.TODO(), embs, v1.Dim2D, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res3D, err := RandomProjection(context.TODO(), embs, v1.Dim3D, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range embs {
+		if res2D[i].Metadata["projection"] != v1.Random {
+			t.Fatalf("expected projection: %v, got: %v", v1.Random, res2D[i].Metadata["projection"])
+		}
+		if len(res2D[i].Values) != 2 || len(res3D[i].Values) != 3 {
+			t.Fatalf("expected 2D and 3D projections, got: %v, %v", res2D[i].Values, res3D[i].Values)
+		}
+	}
+
+	again, err := RandomProjection(context.TODO(), embs, v1.Dim2D, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res2D, again) {
+		t.Fatal("expected reproducible projections")
+	}
+
+	diff, err := RandomProjection(context.TODO(), embs, v1.Dim2D, Options(&v1.ProjectionOptions{Seed: &other}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(res2D, diff) {
+		t.Fatal("expected different seeds to yield different projections")
+	}
+
+	t.Run("InsufficientDim", func(t *testing.T) {
+		if _, err := RandomProjection(context.TODO(), []v1.Embedding{{Values: []float64{1, 2}}}, v1.Dim2D, opts); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
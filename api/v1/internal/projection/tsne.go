@@ -0,0 +1,551 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"golang.org/x/exp/maps"
+	"gonum.org/v1/gonum/floats"
+)
+
+// NOTE: the perplexity, learning rate and iterations keep the defaults of the original
+// t-SNE projections, which use larger perplexity and learning rate in 3D.
+const (
+	tsnePerplexity     = 300.0
+	tsneLearningRate   = 300.0
+	tsne3DPerplexity   = 500.0
+	tsne3DLearningRate = 500.0
+	tsneIterations     = 300
+)
+
+// NOTE: these mirror the defaults of the reference Barnes-Hut t-SNE implementation.
+// See: https://github.com/lvdmaaten/bhtsne
+const (
+	tsneTheta              = 0.5
+	tsneExaggeration       = 12.0
+	tsneExaggerationIters  = 250
+	tsneInitialMomentum    = 0.5
+	tsneFinalMomentum      = 0.8
+	tsneMinGain            = 0.01
+	tsneInitScale          = 1e-4
+	tsnePerplexityIters    = 200
+	tsnePerplexityTol      = 1e-5
+	tsneNeighboursProgress = 0.1
+	// spMaxDepth limits the depth of the space-partitioning tree.
+	// NOTE: points closer than the deepest cells are kept in the same leaf.
+	spMaxDepth = 32
+)
+
+// TSNE calculates tsne projecion of the given embeddings into the given dimension.
+// It returns a new slice of embeddings of the same size as the origin al embeddings,
+// but with the given dimension dropped to the given dimension.
+// It uses the Barnes-Hut approximation of the gradient on a sparse k-NN graph
+// of the input affinities rather than the exact gradient over all pairs of embeddings.
+// It returns error if the perplexity set in opts needs more neighbours than there are embeddings.
+// The computation stops early and returns error if ctx is cancelled.
+// If ctx observes the intermediate layouts they are reported along with their KL divergence
+// and the last reported layout is returned if ctx is stopped while it's being optimized.
+// See: https://jmlr.org/papers/v15/vandermaaten14a.html
+func TSNE(ctx context.Context, embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	dim := projDim.Size()
+	tsnes := make([]v1.Embedding, 0, len(embs))
+
+	if embDim := len(embs[0].Values); embDim <= dim {
+		return nil, fmt.Errorf("insufficient embedding dimension: %d, needs at least: %d", embDim, dim+1)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	n := len(embs)
+	rnd := rand.New(rand.NewSource(*opts.Seed))
+
+	perplexity, learningRate := tsnePerplexity, tsneLearningRate
+	if dim == 3 {
+		perplexity, learningRate = tsne3DPerplexity, tsne3DLearningRate
+	}
+	// NOTE: the k-NN graph of 3*perplexity neighbours must fit the embeddings
+	// so the default perplexity is reduced for small collections.
+	maxPerplexity := float64(n-1) / 3
+	perplexity = math.Min(perplexity, maxPerplexity)
+	if opts.Perplexity > 0 {
+		if opts.Perplexity > maxPerplexity {
+			return nil, v1.Errorf(v1.EINVALID, "invalid perplexity %v: %d embeddings allow at most %v", opts.Perplexity, n, maxPerplexity)
+		}
+		perplexity = opts.Perplexity
+	}
+	// NOTE: large collections need a larger learning rate to converge.
+	// See: https://www.nature.com/articles/s41467-019-13056-x
+	learningRate = math.Max(learningRate, float64(n)/tsneExaggeration)
+	if opts.LearningRate > 0 {
+		learningRate = opts.LearningRate
+	}
+	iters := tsneIterations
+	if opts.MaxIterations > 0 {
+		iters = opts.MaxIterations
+	}
+
+	layout := make([]float64, n*dim)
+	for i := range layout {
+		layout[i] = rnd.NormFloat64() * tsneInitScale
+	}
+
+	if n > 1 {
+		k := max(1, min(n-1, int(3*perplexity)))
+		idx, dist, err := nearestNeighbours(ctx, embs, k, rnd)
+		if err != nil {
+			return nil, err
+		}
+		p, err := tsneAffinities(ctx, idx, dist, perplexity)
+		if err != nil {
+			return nil, err
+		}
+		v1.ReportProgress(ctx, tsneNeighboursProgress)
+
+		step := func(iter int, kl float64) {
+			projs := make([]v1.Embedding, n)
+			for i := range projs {
+				projs[i] = v1.Embedding{
+					UID:    embs[i].UID,
+					Values: append([]float64(nil), layout[i*dim:(i+1)*dim]...),
+				}
+			}
+			v1.ReportStep(ctx, &v1.ProjectionStep{
+				Projection:   v1.TSNE,
+				Dim:          projDim,
+				Iteration:    iter,
+				Iterations:   iters,
+				KLDivergence: kl,
+				Embeddings:   projs,
+			})
+		}
+
+		ctx := v1.ProgressRange(ctx, tsneNeighboursProgress, 1)
+		if err := optimizeTSNE(ctx, layout, dim, p, learningRate, iters, step); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range embs {
+		metadata := map[string]any{}
+		if embs[i].Metadata != nil {
+			metadata = maps.Clone(embs[i].Metadata)
+		}
+		metadata["projection"] = v1.TSNE
+		tsnes = append(tsnes, v1.Embedding{
+			UID:      embs[i].UID,
+			Values:   layout[i*dim : (i+1)*dim : (i+1)*dim],
+			Metadata: metadata,
+		})
+	}
+
+	return tsnes, nil
+}
+
+// affinities is a sparse symmetric matrix of the t-SNE input affinities.
+// The matrix is stored in the compressed sparse row format.
+type affinities struct {
+	rows []int
+	cols []int32
+	vals []float64
+}
+
+// tsneAffinities computes the joint probabilities of the embeddings given
+// the indices idx and distances dist of their nearest neighbours.
+// It returns error if ctx is cancelled before the affinities have been computed.
+func tsneAffinities(ctx context.Context, idx [][]int, dist [][]float64, perplexity float64) (*affinities, error) {
+	n := len(idx)
+
+	cond := make([][]float64, n)
+	err := parallel(ctx, n, func(_, lo, hi int) {
+		for i := lo; i < hi; i++ {
+			cond[i] = conditionalP(dist[i], perplexity)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: symmetrize the conditional probabilities: p_ij = p_j|i + p_i|j
+	type entry struct {
+		col int32
+		val float64
+	}
+	counts := make([]int, n)
+	for i := range idx {
+		counts[i] += len(idx[i])
+		for _, j := range idx[i] {
+			counts[j]++
+		}
+	}
+	entries := make([][]entry, n)
+	for i := range entries {
+		entries[i] = make([]entry, 0, counts[i])
+	}
+	for i := range idx {
+		for x, j := range idx[i] {
+			entries[i] = append(entries[i], entry{col: int32(j), val: cond[i][x]})
+			entries[j] = append(entries[j], entry{col: int32(i), val: cond[i][x]})
+		}
+	}
+
+	err = parallel(ctx, n, func(_, lo, hi int) {
+		for i := lo; i < hi; i++ {
+			row := entries[i]
+			sort.Slice(row, func(x, y int) bool { return row[x].col < row[y].col })
+			merged := row[:0]
+			for _, e := range row {
+				if l := len(merged); l > 0 && merged[l-1].col == e.col {
+					merged[l-1].val += e.val
+					continue
+				}
+				merged = append(merged, e)
+			}
+			entries[i] = merged
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &affinities{rows: make([]int, n+1)}
+	for i, row := range entries {
+		p.rows[i+1] = p.rows[i] + len(row)
+	}
+	p.cols = make([]int32, 0, p.rows[n])
+	p.vals = make([]float64, 0, p.rows[n])
+	for _, row := range entries {
+		for _, e := range row {
+			p.cols = append(p.cols, e.col)
+			p.vals = append(p.vals, e.val)
+		}
+	}
+	if sum := floats.Sum(p.vals); sum > 0 {
+		floats.Scale(1/sum, p.vals)
+	}
+
+	return p, nil
+}
+
+// conditionalP returns the conditional probabilities of the neighbours
+// at the given distances whose entropy matches the given perplexity.
+func conditionalP(dists []float64, perplexity float64) []float64 {
+	p := make([]float64, len(dists))
+	if len(dists) == 0 {
+		return p
+	}
+
+	// NOTE: the squared distances are shifted by the distance
+	// of the nearest neighbour to avoid numerical underflow.
+	d2 := make([]float64, len(dists))
+	for j, d := range dists {
+		d2[j] = d*d - dists[0]*dists[0]
+	}
+
+	target := math.Log(perplexity)
+	beta, lo, hi := 1.0, 0.0, math.Inf(1)
+	sum := 0.0
+	for iter := 0; iter < tsnePerplexityIters; iter++ {
+		sum = 0.0
+		dsum := 0.0
+		for j, d := range d2 {
+			p[j] = math.Exp(-beta * d)
+			sum += p[j]
+			dsum += d * p[j]
+		}
+		h := math.Log(sum) + beta*dsum/sum
+		if math.Abs(h-target) < tsnePerplexityTol {
+			break
+		}
+		if h > target {
+			lo = beta
+			if math.IsInf(hi, 1) {
+				beta *= 2
+			} else {
+				beta = (lo + hi) / 2
+			}
+		} else {
+			hi = beta
+			beta = (lo + hi) / 2
+		}
+	}
+	floats.Scale(1/sum, p)
+
+	return p
+}
+
+// optimizeTSNE optimizes the layout of points of the given dimension stored
+// in the layout slice row by row using the gradient descent with momentum.
+// If ctx observes the intermediate layouts, step is called with the iteration
+// and the KL divergence of the layout every v1.StepInterval iterations.
+// The optimization ends at the next reported layout if ctx is stopped while it's running.
+// It returns error if ctx is cancelled before the optimization finishes.
+func optimizeTSNE(ctx context.Context, layout []float64, dim int, p *affinities, learningRate float64, iters int, step func(int, float64)) error {
+	n := len(layout) / dim
+	every := v1.StepInterval(ctx)
+	// NOTE: optimizations started after the stop was requested have no reported layout to keep
+	stoppable := !v1.Stopped(ctx)
+
+	var (
+		attr   = make([]float64, n*dim)
+		rep    = make([]float64, n*dim)
+		update = make([]float64, n*dim)
+		gains  = make([]float64, n*dim)
+		sumQ   = make([]float64, chunks(n))
+	)
+	for i := range gains {
+		gains[i] = 1.0
+	}
+	exaggerationIters := min(tsneExaggerationIters, iters/4)
+
+	for iter := 0; iter < iters; iter++ {
+		v1.ReportProgress(ctx, float64(iter)/float64(iters))
+
+		exaggeration, momentum := 1.0, tsneFinalMomentum
+		if iter < exaggerationIters {
+			exaggeration, momentum = tsneExaggeration, tsneInitialMomentum
+		}
+
+		tree := newSPTree(layout, dim)
+		err := parallel(ctx, n, func(c, lo, hi int) {
+			sumQ[c] = 0
+			for i := lo; i < hi; i++ {
+				yi := layout[i*dim : (i+1)*dim]
+				a, r := attr[i*dim:(i+1)*dim], rep[i*dim:(i+1)*dim]
+				for d := range a {
+					a[d], r[d] = 0, 0
+				}
+				for e := p.rows[i]; e < p.rows[i+1]; e++ {
+					j := int(p.cols[e])
+					yj := layout[j*dim : (j+1)*dim]
+					f := exaggeration * p.vals[e] / (1 + sqEuclidean(yi, yj))
+					for d := range a {
+						a[d] += f * (yi[d] - yj[d])
+					}
+				}
+				sumQ[c] += tree.repulsion(0, i, r)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		z := floats.Sum(sumQ)
+
+		if every > 0 && ((iter+1)%every == 0 || iter == iters-1) {
+			step(iter+1, klDivergence(layout, dim, p, z))
+			if stoppable && v1.Stopped(ctx) {
+				return nil
+			}
+		}
+
+		for x := range layout {
+			grad := attr[x] - rep[x]/z
+			if (grad > 0) != (update[x] > 0) {
+				gains[x] += 0.2
+			} else {
+				gains[x] *= 0.8
+			}
+			gains[x] = math.Max(gains[x], tsneMinGain)
+			update[x] = momentum*update[x] - learningRate*gains[x]*grad
+			layout[x] += update[x]
+		}
+
+		// NOTE: the cost is invariant to translation so we keep the layout centered
+		for d := 0; d < dim; d++ {
+			mean := 0.0
+			for i := 0; i < n; i++ {
+				mean += layout[i*dim+d]
+			}
+			mean /= float64(n)
+			for i := 0; i < n; i++ {
+				layout[i*dim+d] -= mean
+			}
+		}
+	}
+
+	return nil
+}
+
+// klDivergence returns the KL divergence of the input affinities p and the output affinities
+// of the layout of points of the given dimension normalized by z.
+// NOTE: the divergence only sums over the sparse input affinities.
+func klDivergence(layout []float64, dim int, p *affinities, z float64) float64 {
+	kl := 0.0
+	for i := 0; i < len(p.rows)-1; i++ {
+		yi := layout[i*dim : (i+1)*dim]
+		for e := p.rows[i]; e < p.rows[i+1]; e++ {
+			j := int(p.cols[e])
+			q := 1 / (1 + sqEuclidean(yi, layout[j*dim:(j+1)*dim])) / z
+			kl += p.vals[e] * math.Log(math.Max(p.vals[e], math.SmallestNonzeroFloat64)/math.Max(q, math.SmallestNonzeroFloat64))
+		}
+	}
+	return kl
+}
+
+// spTree is a space-partitioning tree (a quadtree in 2D, an octree in 3D)
+// used to approximate the t-SNE repulsive forces using the Barnes-Hut algorithm.
+type spTree struct {
+	dim    int
+	layout []float64
+	nodes  []spNode
+	// cells stores the center, the half width and
+	// the center of mass of every node of the tree.
+	cells []float64
+}
+
+// spNode is a cell of the space-partitioning tree.
+type spNode struct {
+	// size is the number of points in the cell.
+	size int
+	// point is the point of the leaf cell, -1 if the cell is empty.
+	point int
+	// child is the index of the first child cell, 0 if the cell is a leaf.
+	child int
+	// depth of the cell in the tree.
+	depth int
+	// width is the maximum width of the cell.
+	width float64
+}
+
+// newSPTree builds a new space-partitioning tree of the layout
+// of points of the given dimension stored in the slice row by row.
+func newSPTree(layout []float64, dim int) *spTree {
+	n := len(layout) / dim
+	t := &spTree{
+		dim:    dim,
+		layout: layout,
+		nodes:  make([]spNode, 0, 2*n),
+		cells:  make([]float64, 0, 2*n*3*dim),
+	}
+
+	center, half := make([]float64, dim), make([]float64, dim)
+	for d := 0; d < dim; d++ {
+		for i := 0; i < n; i++ {
+			center[d] += layout[i*dim+d]
+		}
+		center[d] /= float64(n)
+		for i := 0; i < n; i++ {
+			half[d] = math.Max(half[d], math.Abs(layout[i*dim+d]-center[d]))
+		}
+		half[d] += 1e-5
+	}
+	t.addNode(center, half, 0)
+
+	for i := 0; i < n; i++ {
+		t.insert(i)
+	}
+
+	return t
+}
+
+func (t *spTree) point(i int) []float64 { return t.layout[i*t.dim : (i+1)*t.dim] }
+
+func (t *spTree) center(n int) []float64 { return t.cells[3*n*t.dim : (3*n+1)*t.dim] }
+
+func (t *spTree) half(n int) []float64 { return t.cells[(3*n+1)*t.dim : (3*n+2)*t.dim] }
+
+func (t *spTree) com(n int) []float64 { return t.cells[(3*n+2)*t.dim : (3*n+3)*t.dim] }
+
+func (t *spTree) addNode(center, half []float64, depth int) {
+	t.nodes = append(t.nodes, spNode{point: -1, depth: depth, width: 2 * floats.Max(half)})
+	t.cells = append(t.cells, center...)
+	t.cells = append(t.cells, half...)
+	t.cells = append(t.cells, make([]float64, t.dim)...)
+}
+
+// quadrant returns the index of the child cell of the n-th cell containing y.
+func (t *spTree) quadrant(n int, y []float64) int {
+	q := 0
+	for d, c := range t.center(n) {
+		if y[d] > c {
+			q |= 1 << d
+		}
+	}
+	return q
+}
+
+func (t *spTree) insert(i int) {
+	y := t.point(i)
+	n := 0
+	for {
+		com, size := t.com(n), float64(t.nodes[n].size)
+		for d := range com {
+			com[d] = (com[d]*size + y[d]) / (size + 1)
+		}
+		t.nodes[n].size++
+
+		if t.nodes[n].child == 0 {
+			if t.nodes[n].point < 0 {
+				t.nodes[n].point = i
+				return
+			}
+			// NOTE: duplicate points and the points of the deepest cells stay in the same leaf
+			if t.nodes[n].depth >= spMaxDepth || floats.Equal(t.point(t.nodes[n].point), y) {
+				return
+			}
+			t.subdivide(n)
+		}
+		n = t.nodes[n].child + t.quadrant(n, y)
+	}
+}
+
+// subdivide splits the n-th leaf cell and moves its point to the matching child cell.
+func (t *spTree) subdivide(n int) {
+	first := len(t.nodes)
+	center, half := make([]float64, t.dim), make([]float64, t.dim)
+	for q := 0; q < 1<<t.dim; q++ {
+		for d := 0; d < t.dim; d++ {
+			half[d] = t.half(n)[d] / 2
+			center[d] = t.center(n)[d] - half[d]
+			if q&(1<<d) != 0 {
+				center[d] = t.center(n)[d] + half[d]
+			}
+		}
+		t.addNode(center, half, t.nodes[n].depth+1)
+	}
+
+	point := t.nodes[n].point
+	child := first + t.quadrant(n, t.point(point))
+	// NOTE: the size of n has already been incremented by the point being inserted
+	t.nodes[child].point = point
+	t.nodes[child].size = t.nodes[n].size - 1
+	copy(t.com(child), t.point(point))
+
+	t.nodes[n].point = -1
+	t.nodes[n].child = first
+}
+
+// repulsion adds the repulsive forces acting on the i-th point exerted by the points
+// of the n-th cell to rep and returns their contribution to the normalization term.
+func (t *spTree) repulsion(n, i int, rep []float64) float64 {
+	node := t.nodes[n]
+	size := node.size
+	if node.child == 0 && node.point == i {
+		size--
+	}
+	if size <= 0 {
+		return 0
+	}
+
+	y, com := t.point(i), t.com(n)
+	dist := sqEuclidean(y, com)
+	if node.child == 0 || node.width*node.width < tsneTheta*tsneTheta*dist {
+		q := 1 / (1 + dist)
+		sum := float64(size) * q
+		f := sum * q
+		for d := range rep {
+			rep[d] += f * (y[d] - com[d])
+		}
+		return sum
+	}
+
+	sum := 0.0
+	for q := 0; q < 1<<t.dim; q++ {
+		sum += t.repulsion(node.child+q, i, rep)
+	}
+	return sum
+}
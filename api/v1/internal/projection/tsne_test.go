@@ -0,0 +1,261 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestConditionalP(t *testing.T) {
+	dists := []float64{1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5}
+	perplexity := 5.0
+
+	p := conditionalP(dists, perplexity)
+
+	sum, h := 0.0, 0.0
+	for _, v := range p {
+		sum += v
+		if v > 0 {
+			h -= v * math.Log(v)
+		}
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("expected probabilities to sum to 1, got: %v", sum)
+	}
+	if got := math.Exp(h); math.Abs(got-perplexity) > 1e-3 {
+		t.Fatalf("expected perplexity: %v, got: %v", perplexity, got)
+	}
+}
+
+func TestTSNE(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	clusters, size := 3, 100
+	embs := make([]v1.Embedding, 0, clusters*size)
+	for c := 0; c < clusters; c++ {
+		for i := 0; i < size; i++ {
+			vals := make([]float64, 8)
+			for j := range vals {
+				vals[j] = rnd.NormFloat64() * 0.1
+			}
+			vals[c] += 5
+			embs = append(embs, v1.Embedding{Values: vals})
+		}
+	}
+	// NOTE: duplicate embeddings must not break the space-partitioning tree
+	embs = append(embs, embs[0], embs[0])
+
+	seed := int64(1)
+	opts := Options(&v1.ProjectionOptions{Seed: &seed, MaxIterations: 500})
+
+	for _, dim := range []v1.Dim{v1.Dim2D, v1.Dim3D} {
+		dim := dim
+		t.Run(string(dim), func(t *testing.T) {
+			res, err := TSNE(context.TODO(), embs, dim, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != len(embs) {
+				t.Fatalf("expected %d projections, got: %d", len(embs), len(res))
+			}
+			for _, r := range res {
+				if len(r.Values) != dim.Size() {
+					t.Fatalf("expected dimension: %d, got: %d", dim.Size(), len(r.Values))
+				}
+				if r.Metadata["projection"] != v1.TSNE {
+					t.Fatalf("expected projection: %v, got: %v", v1.TSNE, r.Metadata["projection"])
+				}
+				for _, v := range r.Values {
+					if math.IsNaN(v) || math.IsInf(v, 0) {
+						t.Fatalf("expected finite values, got: %v", r.Values)
+					}
+				}
+			}
+
+			m, err := Evaluate(context.TODO(), embs, res, DefaultMetricsK, rnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Trustworthiness < 0.9 {
+				t.Fatalf("expected trustworthiness of at least 0.9, got: %v", m.Trustworthiness)
+			}
+
+			// every point should be closer to the centroid
+			// of its own cluster than to any other centroid
+			centroids := make([][]float64, clusters)
+			for c := 0; c < clusters; c++ {
+				centroids[c] = make([]float64, dim.Size())
+				for _, r := range res[c*size : (c+1)*size] {
+					for j, v := range r.Values {
+						centroids[c][j] += v / float64(size)
+					}
+				}
+			}
+			for i, r := range res[:clusters*size] {
+				own := i / size
+				for c := range centroids {
+					if c == own {
+						continue
+					}
+					if euclidean(r.Values, centroids[c]) < euclidean(r.Values, centroids[own]) {
+						t.Fatalf("point %d is closer to cluster %d than to its own cluster %d", i, c, own)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("Reproducible", func(t *testing.T) {
+		opts := Options(&v1.ProjectionOptions{Seed: &seed, MaxIterations: 50})
+		a, err := TSNE(context.TODO(), embs, v1.Dim2D, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := TSNE(context.TODO(), embs, v1.Dim2D, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(a, b) {
+			t.Fatal("expected identical projections for the same seed")
+		}
+	})
+
+	t.Run("Steps", func(t *testing.T) {
+		iters, every := 300, 50
+		opts := Options(&v1.ProjectionOptions{Seed: &seed, MaxIterations: iters})
+
+		steps := []*v1.ProjectionStep{}
+		ctx := v1.WithSteps(context.TODO(), every, func(step *v1.ProjectionStep) {
+			steps = append(steps, step)
+		})
+		res, err := TSNE(ctx, embs, v1.Dim2D, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if exp := iters / every; len(steps) != exp {
+			t.Fatalf("expected steps: %d, got: %d", exp, len(steps))
+		}
+		for i, step := range steps {
+			if exp := (i + 1) * every; step.Iteration != exp || step.Iterations != iters || step.Dim != v1.Dim2D {
+				t.Fatalf("unexpected step %d: %d/%d %s", i, step.Iteration, step.Iterations, step.Dim)
+			}
+			if len(step.Embeddings) != len(embs) {
+				t.Fatalf("expected step projections: %d, got: %d", len(embs), len(step.Embeddings))
+			}
+			if step.KLDivergence <= 0 || math.IsNaN(step.KLDivergence) {
+				t.Fatalf("expected positive KL divergence, got: %v", step.KLDivergence)
+			}
+		}
+		if first, last := steps[0].KLDivergence, steps[len(steps)-1].KLDivergence; last >= first {
+			t.Fatalf("expected KL divergence to decrease, got: %v -> %v", first, last)
+		}
+
+		// NOTE: reporting steps must not change the projections
+		exp, err := TSNE(context.TODO(), embs, v1.Dim2D, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res, exp) {
+			t.Fatal("expected identical projections with and without steps")
+		}
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		iters, every := 300, 50
+		opts := Options(&v1.ProjectionOptions{Seed: &seed, MaxIterations: iters})
+
+		stop := make(chan struct{})
+		var once sync.Once
+		steps := []*v1.ProjectionStep{}
+		ctx := v1.WithSteps(context.TODO(), every, func(step *v1.ProjectionStep) {
+			steps = append(steps, step)
+			if len(steps) == 2 {
+				once.Do(func() { close(stop) })
+			}
+		})
+		res, err := TSNE(v1.WithStop(ctx, stop), embs, v1.Dim2D, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(steps) != 2 {
+			t.Fatalf("expected steps: %d, got: %d", 2, len(steps))
+		}
+		last := steps[len(steps)-1]
+		for i, r := range res {
+			if !reflect.DeepEqual(r.Values, last.Embeddings[i].Values) {
+				t.Fatalf("expected the last step projection: %v, got: %v", last.Embeddings[i].Values, r.Values)
+			}
+		}
+
+		// NOTE: the stop requested before the optimization starts does not cut it short
+		steps = steps[:0]
+		if _, err := TSNE(v1.WithStop(ctx, stop), embs, v1.Dim2D, opts); err != nil {
+			t.Fatal(err)
+		}
+		if exp := iters / every; len(steps) != exp {
+			t.Fatalf("expected steps: %d, got: %d", exp, len(steps))
+		}
+	})
+
+	t.Run("Single", func(t *testing.T) {
+		res, err := TSNE(context.TODO(), embs[:1], v1.Dim2D, Options(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != 1 {
+			t.Fatalf("expected 1 projection, got: %d", len(res))
+		}
+	})
+
+	t.Run("Perplexity", func(t *testing.T) {
+		// NOTE: the default perplexity is reduced for small collections
+		if _, err := TSNE(context.TODO(), embs[:10], v1.Dim2D, Options(&v1.ProjectionOptions{Seed: &seed, MaxIterations: 10})); err != nil {
+			t.Fatal(err)
+		}
+		opts := Options(&v1.ProjectionOptions{Seed: &seed, Perplexity: 4, MaxIterations: 10})
+		if _, err := TSNE(context.TODO(), embs[:10], v1.Dim2D, opts); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("InsufficientDim", func(t *testing.T) {
+		if _, err := TSNE(context.TODO(), []v1.Embedding{{Values: []float64{1, 2}}}, v1.Dim2D, Options(nil)); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+// BenchmarkTSNE measures how the t-SNE gradient descent scales with the number of embeddings.
+func BenchmarkTSNE(b *testing.B) {
+	seed := int64(1)
+	opts := Options(&v1.ProjectionOptions{Seed: &seed, Perplexity: 30, MaxIterations: 100})
+
+	for _, n := range []int{1000, 2000, 4000} {
+		rnd := rand.New(rand.NewSource(1))
+		embs := make([]v1.Embedding, n)
+		for i := range embs {
+			vals := make([]float64, 32)
+			for j := range vals {
+				vals[j] = rnd.NormFloat64()
+			}
+			vals[i%8] += 5
+			embs[i] = v1.Embedding{Values: vals}
+		}
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := TSNE(context.TODO(), embs, v1.Dim2D, opts); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
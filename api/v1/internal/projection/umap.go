@@ -0,0 +1,411 @@
+package projection
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"golang.org/x/exp/maps"
+	"gonum.org/v1/gonum/mat"
+	"gonum.org/v1/gonum/stat"
+)
+
+// NOTE: these mirror the defaults of the reference UMAP implementation.
+// See: https://umap-learn.readthedocs.io/en/latest/parameters.html
+const (
+	umapNeighbors      = 15
+	umapMinDist        = 0.1
+	umapSpread         = 1.0
+	umapLearningRate   = 1.0
+	umapNegativeRate   = 5
+	umapSmallEpochs    = 500
+	umapLargeEpochs    = 200
+	umapLargeThreshold = 10000
+	umapInitScale      = 10.0
+)
+
+// UMAP calculates UMAP projection of the given embeddings into the given dimension.
+// It returns a new slice of embeddings of the same size as the origin al embeddings,
+// but with the given dimension dropped to the given dimension.
+// See: https://arxiv.org/abs/1802.03426
+func UMAP(ctx context.Context, embs []v1.Embedding, projDim v1.Dim, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	dim := projDim.Size()
+	umaps := make([]v1.Embedding, 0, len(embs))
+
+	if embDim := len(embs[0].Values); embDim <= dim {
+		return nil, fmt.Errorf("insufficient embedding dimension: %d, needs at least: %d", embDim, dim+1)
+	}
+
+	rnd := rand.New(rand.NewSource(*opts.Seed))
+
+	epochs := umapSmallEpochs
+	if len(embs) > umapLargeThreshold {
+		epochs = umapLargeEpochs
+	}
+	if opts.MaxIterations > 0 {
+		epochs = opts.MaxIterations
+	}
+	learningRate := umapLearningRate
+	if opts.LearningRate > 0 {
+		learningRate = opts.LearningRate
+	}
+
+	a, b := findABParams(umapSpread, umapMinDist)
+	graph, err := fuzzySimplicialSet(ctx, embs, umapNeighbors, rnd)
+	if err != nil {
+		return nil, err
+	}
+	layout := umapInit(embs, dim, rnd)
+	if err := optimizeLayout(ctx, layout, graph, a, b, learningRate, epochs, rnd); err != nil {
+		return nil, err
+	}
+
+	for i := range embs {
+		metadata := map[string]any{}
+		if embs[i].Metadata != nil {
+			metadata = maps.Clone(embs[i].Metadata)
+		}
+		metadata["projection"] = v1.UMAP
+		umaps = append(umaps, v1.Embedding{
+			UID:      embs[i].UID,
+			Values:   layout[i],
+			Metadata: metadata,
+		})
+	}
+
+	return umaps, nil
+}
+
+// edge is a weighted edge of the fuzzy simplicial set.
+type edge struct {
+	head   int
+	tail   int
+	weight float64
+}
+
+// fuzzySimplicialSet builds the symmetric fuzzy k-NN graph of embs.
+// It returns error if ctx is cancelled before the graph has been built.
+func fuzzySimplicialSet(ctx context.Context, embs []v1.Embedding, k int, rnd *rand.Rand) ([]edge, error) {
+	n := len(embs)
+	if k > n-1 {
+		k = n - 1
+	}
+	if k < 1 {
+		return nil, nil
+	}
+
+	knnIdx, knnDist, err := nearestNeighbours(ctx, embs, k, rnd)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: we use a map for symmetrization so
+	// we don't have to allocate n*n dense matrix.
+	weights := make(map[[2]int]float64, n*k)
+	target := math.Log2(float64(k))
+
+	for i := 0; i < n; i++ {
+		rho, sigma := smoothKNNDist(knnDist[i], target)
+		for j, nb := range knnIdx[i] {
+			w := 1.0
+			if d := knnDist[i][j] - rho; d > 0 {
+				w = math.Exp(-d / sigma)
+			}
+			weights[[2]int{i, nb}] = w
+		}
+	}
+
+	// fuzzy union: a + b - a*b
+	sym := make(map[[2]int]float64, 2*len(weights))
+	for key, w := range weights {
+		wt := weights[[2]int{key[1], key[0]}]
+		u := w + wt - w*wt
+		sym[key] = u
+		sym[[2]int{key[1], key[0]}] = u
+	}
+
+	edges := make([]edge, 0, len(sym))
+	for key, w := range sym {
+		edges = append(edges, edge{head: key[0], tail: key[1], weight: w})
+	}
+	// NOTE: map iteration order is random; sort the
+	// edges so the optimization is reproducible.
+	sort.Slice(edges, func(x, y int) bool {
+		if edges[x].head != edges[y].head {
+			return edges[x].head < edges[y].head
+		}
+		return edges[x].tail < edges[y].tail
+	})
+
+	return edges, nil
+}
+
+// smoothKNNDist finds rho and sigma for the given neighbour distances
+// such that sum(exp(-(d - rho)/sigma)) == target.
+func smoothKNNDist(dists []float64, target float64) (float64, float64) {
+	const (
+		iters = 64
+		tol   = 1e-5
+		minK  = 1e-3
+	)
+
+	rho := 0.0
+	for _, d := range dists {
+		if d > 0 {
+			rho = d
+			break
+		}
+	}
+
+	lo, hi, mid := 0.0, math.Inf(1), 1.0
+	for n := 0; n < iters; n++ {
+		psum := 0.0
+		for _, d := range dists {
+			if dd := d - rho; dd > 0 {
+				psum += math.Exp(-dd / mid)
+			} else {
+				psum += 1.0
+			}
+		}
+		if math.Abs(psum-target) < tol {
+			break
+		}
+		if psum > target {
+			hi = mid
+			mid = (lo + hi) / 2
+		} else {
+			lo = mid
+			if math.IsInf(hi, 1) {
+				mid *= 2
+			} else {
+				mid = (lo + hi) / 2
+			}
+		}
+	}
+
+	mean := stat.Mean(dists, nil)
+	if mid < minK*mean {
+		mid = minK * mean
+	}
+	if mid == 0 {
+		mid = minK
+	}
+
+	return rho, mid
+}
+
+// umapInit initializes the low dimensional layout.
+// It uses PCA of the embeddings scaled to [-umapInitScale, umapInitScale]
+// and falls back to uniform random init if the PCA can't be computed.
+func umapInit(embs []v1.Embedding, dim int, rnd *rand.Rand) [][]float64 {
+	n := len(embs)
+	layout := make([][]float64, n)
+
+	randomInit := func() [][]float64 {
+		for i := range layout {
+			layout[i] = make([]float64, dim)
+			for j := range layout[i] {
+				layout[i][j] = rnd.Float64()*2*umapInitScale - umapInitScale
+			}
+		}
+		return layout
+	}
+
+	if n <= dim {
+		return randomInit()
+	}
+
+	mx := mat.NewDense(n, len(embs[0].Values), nil)
+	for i, e := range embs {
+		mx.SetRow(i, e.Values)
+	}
+	var pc stat.PC
+	if ok := pc.PrincipalComponents(mx, nil); !ok {
+		return randomInit()
+	}
+	var vec, proj mat.Dense
+	pc.VectorsTo(&vec)
+	_, c := vec.Dims()
+	if c < dim {
+		return randomInit()
+	}
+	proj.Mul(mx, vec.Slice(0, len(embs[0].Values), 0, dim))
+
+	maxAbs := 0.0
+	for i := 0; i < n; i++ {
+		for j := 0; j < dim; j++ {
+			maxAbs = math.Max(maxAbs, math.Abs(proj.At(i, j)))
+		}
+	}
+	if maxAbs == 0 {
+		return randomInit()
+	}
+
+	for i := 0; i < n; i++ {
+		layout[i] = make([]float64, dim)
+		for j := 0; j < dim; j++ {
+			// NOTE: a tiny bit of noise breaks ties between identical points
+			layout[i][j] = proj.At(i, j)/maxAbs*umapInitScale + rnd.NormFloat64()*1e-4
+		}
+	}
+
+	return layout
+}
+
+// optimizeLayout optimizes the layout using stochastic gradient descent
+// with negative sampling as described in the UMAP paper.
+// It returns error if ctx is cancelled before the optimization finishes.
+func optimizeLayout(ctx context.Context, layout [][]float64, edges []edge, a, b, learningRate float64, epochs int, rnd *rand.Rand) error {
+	if len(edges) == 0 {
+		return nil
+	}
+
+	n, dim := len(layout), len(layout[0])
+
+	maxWeight := 0.0
+	for _, e := range edges {
+		maxWeight = math.Max(maxWeight, e.weight)
+	}
+
+	epochsPerSample := make([]float64, len(edges))
+	for i, e := range edges {
+		epochsPerSample[i] = -1
+		if samples := e.weight / maxWeight * float64(epochs); samples > 0 {
+			epochsPerSample[i] = float64(epochs) / samples
+		}
+	}
+	epochOfNextSample := make([]float64, len(edges))
+	copy(epochOfNextSample, epochsPerSample)
+	epochsPerNegSample := make([]float64, len(edges))
+	epochOfNextNegSample := make([]float64, len(edges))
+	for i := range edges {
+		epochsPerNegSample[i] = epochsPerSample[i] / umapNegativeRate
+		epochOfNextNegSample[i] = epochsPerNegSample[i]
+	}
+
+	clip := func(v float64) float64 {
+		return math.Max(-4, math.Min(4, v))
+	}
+
+	for epoch := 1; epoch <= epochs; epoch++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		v1.ReportProgress(ctx, float64(epoch-1)/float64(epochs))
+		alpha := learningRate * (1 - float64(epoch-1)/float64(epochs))
+		for i, e := range edges {
+			if epochsPerSample[i] < 0 || epochOfNextSample[i] > float64(epoch) {
+				continue
+			}
+			cur, other := layout[e.head], layout[e.tail]
+
+			distSq := sqEuclidean(cur, other)
+			gradCoeff := 0.0
+			if distSq > 0 {
+				gradCoeff = -2 * a * b * math.Pow(distSq, b-1)
+				gradCoeff /= a*math.Pow(distSq, b) + 1
+			}
+			for d := 0; d < dim; d++ {
+				grad := clip(gradCoeff*(cur[d]-other[d])) * alpha
+				cur[d] += grad
+				other[d] -= grad
+			}
+			epochOfNextSample[i] += epochsPerSample[i]
+
+			negSamples := int((float64(epoch) - epochOfNextNegSample[i]) / epochsPerNegSample[i])
+			for s := 0; s < negSamples; s++ {
+				k := rnd.Intn(n)
+				if k == e.head {
+					continue
+				}
+				other := layout[k]
+				distSq := sqEuclidean(cur, other)
+				gradCoeff := 0.0
+				if distSq > 0 {
+					gradCoeff = 2 * b
+					gradCoeff /= (0.001 + distSq) * (a*math.Pow(distSq, b) + 1)
+				}
+				for d := 0; d < dim; d++ {
+					grad := 4.0
+					if gradCoeff > 0 {
+						grad = clip(gradCoeff * (cur[d] - other[d]))
+					}
+					cur[d] += grad * alpha
+				}
+			}
+			epochOfNextNegSample[i] += float64(negSamples) * epochsPerNegSample[i]
+		}
+	}
+
+	return nil
+}
+
+// findABParams fits the a and b parameters of the curve 1/(1+a*x^(2b))
+// to the exponential decay of the given spread and minDist.
+func findABParams(spread, minDist float64) (float64, float64) {
+	const samples = 300
+
+	xs := make([]float64, samples)
+	ys := make([]float64, samples)
+	for i := range xs {
+		xs[i] = spread * 3 * float64(i) / float64(samples-1)
+		if xs[i] < minDist {
+			ys[i] = 1.0
+		} else {
+			ys[i] = math.Exp(-(xs[i] - minDist) / spread)
+		}
+	}
+
+	// NOTE: Gauss-Newton on the squared residuals
+	a, b := 1.0, 1.0
+	for iter := 0; iter < 100; iter++ {
+		var jtj [2][2]float64
+		var jtr [2]float64
+		for i, x := range xs {
+			if x == 0 {
+				continue
+			}
+			x2b := math.Pow(x, 2*b)
+			den := 1 + a*x2b
+			f := 1 / den
+			r := ys[i] - f
+			da := -x2b / (den * den)
+			db := -a * x2b * 2 * math.Log(x) / (den * den)
+			jtj[0][0] += da * da
+			jtj[0][1] += da * db
+			jtj[1][1] += db * db
+			jtr[0] += da * r
+			jtr[1] += db * r
+		}
+		jtj[1][0] = jtj[0][1]
+		det := jtj[0][0]*jtj[1][1] - jtj[0][1]*jtj[1][0]
+		if det == 0 {
+			break
+		}
+		stepA := (jtj[1][1]*jtr[0] - jtj[0][1]*jtr[1]) / det
+		stepB := (jtj[0][0]*jtr[1] - jtj[1][0]*jtr[0]) / det
+		a += stepA
+		b += stepB
+		if math.Abs(stepA) < 1e-8 && math.Abs(stepB) < 1e-8 {
+			break
+		}
+	}
+
+	return a, b
+}
+
+func euclidean(x, y []float64) float64 {
+	return math.Sqrt(sqEuclidean(x, y))
+}
+
+func sqEuclidean(x, y []float64) float64 {
+	sum := 0.0
+	for i := range x {
+		d := x[i] - y[i]
+		sum += d * d
+	}
+	return sum
+}
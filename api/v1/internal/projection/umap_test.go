@@ -0,0 +1,87 @@
+package projection
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestFindABParams(t *testing.T) {
+	// NOTE: reference values for spread=1.0, min_dist=0.1
+	expA, expB := 1.577, 0.895
+
+	a, b := findABParams(1.0, 0.1)
+	if math.Abs(a-expA) > 1e-2 || math.Abs(b-expB) > 1e-2 {
+		t.Errorf("expected a=%v, b=%v, got a=%v, b=%v", expA, expB, a, b)
+	}
+}
+
+func TestUMAP(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	clusters, size := 3, 20
+	embs := make([]v1.Embedding, 0, clusters*size)
+	for c := 0; c < clusters; c++ {
+		for i := 0; i < size; i++ {
+			vals := make([]float64, 8)
+			for j := range vals {
+				vals[j] = rnd.NormFloat64() * 0.1
+			}
+			vals[c] += 5
+			embs = append(embs, v1.Embedding{Values: vals})
+		}
+	}
+
+	for _, dim := range []v1.Dim{v1.Dim2D, v1.Dim3D} {
+		dim := dim
+		t.Run(string(dim), func(t *testing.T) {
+			res, err := UMAP(context.TODO(), embs, dim, Options(nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != len(embs) {
+				t.Fatalf("expected %d projections, got: %d", len(embs), len(res))
+			}
+			for _, r := range res {
+				if len(r.Values) != dim.Size() {
+					t.Fatalf("expected dimension: %d, got: %d", dim.Size(), len(r.Values))
+				}
+				if r.Metadata["projection"] != v1.UMAP {
+					t.Fatalf("expected projection: %v, got: %v", v1.UMAP, r.Metadata["projection"])
+				}
+			}
+
+			// every point should be closer to the centroid
+			// of its own cluster than to any other centroid
+			centroids := make([][]float64, clusters)
+			for c := 0; c < clusters; c++ {
+				centroids[c] = make([]float64, dim.Size())
+				for _, r := range res[c*size : (c+1)*size] {
+					for j, v := range r.Values {
+						centroids[c][j] += v / float64(size)
+					}
+				}
+			}
+			for i, r := range res {
+				own := i / size
+				for c := range centroids {
+					if c == own {
+						continue
+					}
+					if euclidean(r.Values, centroids[c]) < euclidean(r.Values, centroids[own]) {
+						t.Fatalf("point %d is closer to cluster %d than to its own cluster %d", i, c, own)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("InsufficientDim", func(t *testing.T) {
+		if _, err := UMAP(context.TODO(), []v1.Embedding{{Values: []float64{1, 2}}}, v1.Dim2D, Options(nil)); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
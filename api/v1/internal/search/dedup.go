@@ -0,0 +1,92 @@
+package search
+
+import v1 "github.com/milosgajdos/embeviz/api/v1"
+
+// Duplicate returns the index of the first embedding of embs which duplicates e under the policy p.
+// It returns -1 if none of embs duplicates e.
+func Duplicate(p *v1.DedupPolicy, e v1.Embedding, embs []v1.Embedding) int {
+	for i := range embs {
+		if duplicates(p, e, embs[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Duplicates returns the groups of indices of embs which duplicate each other under the policy p.
+// Embeddings are grouped transitively; embeddings without duplicates are not grouped.
+func Duplicates(p *v1.DedupPolicy, embs []v1.Embedding) [][]int {
+	parents := make([]int, len(embs))
+	for i := range parents {
+		parents[i] = i
+	}
+	find := func(i int) int {
+		for parents[i] != i {
+			parents[i] = parents[parents[i]]
+			i = parents[i]
+		}
+		return i
+	}
+
+	switch p.Mode {
+	case v1.DedupText:
+		// NOTE: text hashes are grouped without comparing all the pairs
+		first := make(map[string]int, len(embs))
+		for i, e := range embs {
+			h, ok := e.Metadata[v1.TextHashMetaKey].(string)
+			if !ok || h == "" {
+				continue
+			}
+			if j, ok := first[h]; ok {
+				parents[find(i)] = find(j)
+				continue
+			}
+			first[h] = i
+		}
+	case v1.DedupVector:
+		for i := range embs {
+			for j := i + 1; j < len(embs); j++ {
+				if duplicates(p, embs[i], embs[j]) {
+					parents[find(j)] = find(i)
+				}
+			}
+		}
+	}
+
+	var groups [][]int
+	index := make(map[int]int)
+	for i := range embs {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+
+	dups := groups[:0]
+	for _, g := range groups {
+		if len(g) > 1 {
+			dups = append(dups, g)
+		}
+	}
+	return dups
+}
+
+// duplicates returns true if a and b are duplicates under the policy p.
+func duplicates(p *v1.DedupPolicy, a, b v1.Embedding) bool {
+	switch p.Mode {
+	case v1.DedupText:
+		h, ok := a.Metadata[v1.TextHashMetaKey].(string)
+		return ok && h != "" && h == b.Metadata[v1.TextHashMetaKey]
+	case v1.DedupVector:
+		if len(a.Values) != len(b.Values) {
+			return false
+		}
+		cosine, _, _ := Scorer(v1.Cosine)
+		return cosine(a.Values, b.Values) >= p.MinSimilarity()
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"slices"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestDuplicate(t *testing.T) {
+	embs := []v1.Embedding{
+		{Values: []float64{1, 0}, Metadata: map[string]any{v1.TextHashMetaKey: v1.TextHash("foo")}},
+		{Values: []float64{0, 1}, Metadata: map[string]any{v1.TextHashMetaKey: v1.TextHash("bar")}},
+	}
+
+	testCases := []struct {
+		name   string
+		policy *v1.DedupPolicy
+		emb    v1.Embedding
+		exp    int
+	}{
+		{
+			name:   "Off",
+			policy: &v1.DedupPolicy{Mode: v1.DedupOff},
+			emb:    v1.Embedding{Values: []float64{1, 0}, Metadata: map[string]any{v1.TextHashMetaKey: v1.TextHash("foo")}},
+			exp:    -1,
+		},
+		{
+			name:   "Text",
+			policy: &v1.DedupPolicy{Mode: v1.DedupText},
+			emb:    v1.Embedding{Values: []float64{1, 1}, Metadata: map[string]any{v1.TextHashMetaKey: v1.TextHash("bar")}},
+			exp:    1,
+		},
+		{
+			name:   "TextMissing",
+			policy: &v1.DedupPolicy{Mode: v1.DedupText},
+			emb:    v1.Embedding{Values: []float64{1, 0}},
+			exp:    -1,
+		},
+		{
+			name:   "Vector",
+			policy: &v1.DedupPolicy{Mode: v1.DedupVector},
+			emb:    v1.Embedding{Values: []float64{2, 0.01}},
+			exp:    0,
+		},
+		{
+			name:   "VectorThreshold",
+			policy: &v1.DedupPolicy{Mode: v1.DedupVector, Threshold: 0.99},
+			emb:    v1.Embedding{Values: []float64{1, 0.5}},
+			exp:    -1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Duplicate(tc.policy, tc.emb, embs); got != tc.exp {
+				t.Fatalf("expected duplicate: %d, got: %d", tc.exp, got)
+			}
+		})
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	t.Run("Text", func(t *testing.T) {
+		embs := []v1.Embedding{
+			{Metadata: map[string]any{v1.TextHashMetaKey: v1.TextHash("foo")}},
+			{Metadata: map[string]any{v1.TextHashMetaKey: v1.TextHash("bar")}},
+			{Metadata: map[string]any{v1.TextHashMetaKey: v1.TextHash("foo")}},
+			{},
+			{Metadata: map[string]any{v1.TextHashMetaKey: v1.TextHash("foo")}},
+		}
+		groups := Duplicates(&v1.DedupPolicy{Mode: v1.DedupText}, embs)
+		if len(groups) != 1 || !slices.Equal(groups[0], []int{0, 2, 4}) {
+			t.Fatalf("unexpected groups: %v", groups)
+		}
+	})
+
+	t.Run("Vector", func(t *testing.T) {
+		embs := []v1.Embedding{
+			{Values: []float64{1, 0}},
+			{Values: []float64{0, 1}},
+			{Values: []float64{0, 2}},
+			{Values: []float64{2, 0.01}},
+			{Values: []float64{1, 1}},
+		}
+		groups := Duplicates(&v1.DedupPolicy{Mode: v1.DedupVector}, embs)
+		if len(groups) != 2 || !slices.Equal(groups[0], []int{0, 3}) || !slices.Equal(groups[1], []int{1, 2}) {
+			t.Fatalf("unexpected groups: %v", groups)
+		}
+	})
+}
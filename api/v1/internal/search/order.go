@@ -0,0 +1,104 @@
+package search
+
+import (
+	"context"
+	"math"
+)
+
+// Order returns the order of data in which the data clustered together are adjacent.
+// Data are clustered by the average linkage hierarchical clustering with their dissimilarities
+// measured by dissim and ordered by the leaves of the clustering dendrogram.
+// NOTE: average linkage only compares dissimilarities so they don't need to be distances.
+// See: https://en.wikipedia.org/wiki/Nearest-neighbor_chain_algorithm
+func Order(ctx context.Context, data [][]float64, dissim func(x, y []float64) float64) ([]int, error) {
+	n := len(data)
+	if n < 3 {
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+		return order, nil
+	}
+
+	dists := make([][]float64, n)
+	for i := range dists {
+		dists[i] = make([]float64, n)
+	}
+	for i := range data {
+		for j := i + 1; j < n; j++ {
+			d := dissim(data[i], data[j])
+			dists[i][j], dists[j][i] = d, d
+		}
+	}
+
+	// NOTE: nodes of the dendrogram: leaves are the data, node n+i is the i-th merge.
+	children := make([][2]int, 0, n-1)
+	nodes := make([]int, n)
+	sizes := make([]int, n)
+	active := make([]bool, n)
+	for i := range nodes {
+		nodes[i], sizes[i], active[i] = i, 1, true
+	}
+
+	var chain []int
+	for remaining := n; remaining > 1; {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(chain) == 0 {
+			for i := range active {
+				if active[i] {
+					chain = append(chain, i)
+					break
+				}
+			}
+		}
+
+		a := chain[len(chain)-1]
+		b, best := -1, math.Inf(1)
+		// NOTE: ties prefer the previous cluster in the chain so the chain terminates
+		if len(chain) > 1 {
+			b = chain[len(chain)-2]
+			best = dists[a][b]
+		}
+		for i := range active {
+			if active[i] && i != a && dists[a][i] < best {
+				b, best = i, dists[a][i]
+			}
+		}
+
+		if len(chain) < 2 || b != chain[len(chain)-2] {
+			chain = append(chain, b)
+			continue
+		}
+		chain = chain[:len(chain)-2]
+
+		// merge b into a updating the average linkage distances
+		for i := range active {
+			if active[i] && i != a && i != b {
+				d := (float64(sizes[a])*dists[a][i] + float64(sizes[b])*dists[b][i]) / float64(sizes[a]+sizes[b])
+				dists[a][i], dists[i][a] = d, d
+			}
+		}
+		children = append(children, [2]int{nodes[a], nodes[b]})
+		nodes[a] = n + len(children) - 1
+		sizes[a] += sizes[b]
+		active[b] = false
+		remaining--
+	}
+
+	order := make([]int, 0, n)
+	stack := []int{n + len(children) - 1}
+	for len(stack) > 0 {
+		nd := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if nd < n {
+			order = append(order, nd)
+			continue
+		}
+		c := children[nd-n]
+		stack = append(stack, c[1], c[0])
+	}
+
+	return order, nil
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestOrder(t *testing.T) {
+	centers := [][]float64{{0, 0}, {10, 0}, {0, 10}}
+	rnd := rand.New(rand.NewSource(1))
+
+	// NOTE: shuffle the blobs so their embeddings are not adjacent
+	perm := rnd.Perm(10 * len(centers))
+	data := make([][]float64, len(perm))
+	blob := make([]int, len(perm))
+	for i, p := range perm {
+		c := centers[p/10]
+		data[i] = []float64{c[0] + rnd.NormFloat64(), c[1] + rnd.NormFloat64()}
+		blob[i] = p / 10
+	}
+
+	dist, err := Distance(v1.Euclidean)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := Order(context.TODO(), data, dist)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sorted := append([]int(nil), order...)
+	sort.Ints(sorted)
+	for i, o := range sorted {
+		if i != o {
+			t.Fatalf("expected permutation of data, got: %v", order)
+		}
+	}
+
+	// NOTE: the blobs must be contiguous in the order
+	changes := 0
+	for i := 1; i < len(order); i++ {
+		if blob[order[i]] != blob[order[i-1]] {
+			changes++
+		}
+	}
+	if changes != len(centers)-1 {
+		t.Fatalf("expected %d blob changes in the order, got: %d", len(centers)-1, changes)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	if _, err := Order(ctx, data, dist); err == nil {
+		t.Fatal("expected error")
+	}
+}
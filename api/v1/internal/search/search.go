@@ -0,0 +1,155 @@
+// Package search implements nearest neighbour search of embeddings.
+package search
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// Scorer returns the function scoring the similarity of embeddings measured by metric
+// and reports whether higher scores mean more similar embeddings.
+// Cosine and dot scores are similarities; euclidean scores are distances.
+func Scorer(metric v1.Metric) (func(x, y []float64) float64, bool, error) {
+	switch metric {
+	case v1.Cosine:
+		return func(x, y []float64) float64 {
+			var xy, xx, yy float64
+			for i := range x {
+				xy += x[i] * y[i]
+				xx += x[i] * x[i]
+				yy += y[i] * y[i]
+			}
+			if xx == 0 || yy == 0 {
+				return 0
+			}
+			return xy / math.Sqrt(xx*yy)
+		}, true, nil
+	case v1.Dot:
+		return func(x, y []float64) float64 {
+			var xy float64
+			for i := range x {
+				xy += x[i] * y[i]
+			}
+			return xy
+		}, true, nil
+	case v1.Euclidean:
+		return func(x, y []float64) float64 {
+			var d float64
+			for i := range x {
+				d += (x[i] - y[i]) * (x[i] - y[i])
+			}
+			return math.Sqrt(d)
+		}, false, nil
+	}
+	return nil, false, fmt.Errorf("unsupported metric: %v", metric)
+}
+
+// Distance returns the function measuring the distance of embeddings by metric.
+// Cosine distance is one minus the cosine similarity. Dot product does not induce
+// a distance of its own so it's rejected rather than aliased to the euclidean distance.
+func Distance(metric v1.Metric) (func(x, y []float64) float64, error) {
+	switch metric {
+	case v1.Cosine:
+		cosine, _, _ := Scorer(v1.Cosine)
+		// NOTE: zero embeddings have zero cosine similarity
+		return func(x, y []float64) float64 {
+			return math.Max(0, 1-cosine(x, y))
+		}, nil
+	case v1.Euclidean:
+		euclidean, _, _ := Scorer(v1.Euclidean)
+		return euclidean, nil
+	case v1.Dot:
+		return nil, fmt.Errorf("unsupported metric: %v is not a distance", metric)
+	}
+	return nil, fmt.Errorf("unsupported metric: %v", metric)
+}
+
+// TopK keeps the k best scored embeddings pushed into it.
+type TopK struct {
+	k int
+	h *scoredHeap
+}
+
+// NewTopK returns TopK keeping k embeddings with the highest scores
+// or with the lowest scores if higher is false.
+func NewTopK(k int, higher bool) *TopK {
+	better := func(a, b float64) bool { return a > b }
+	if !higher {
+		better = func(a, b float64) bool { return a < b }
+	}
+	return &TopK{
+		k: k,
+		h: &scoredHeap{better: better},
+	}
+}
+
+// Push pushes the embedding e with the given score.
+func (t *TopK) Push(e v1.Embedding, score float64) {
+	if t.k <= 0 {
+		return
+	}
+	se := v1.ScoredEmbedding{Embedding: e, Score: score}
+	if t.h.Len() < t.k {
+		heap.Push(t.h, se)
+		return
+	}
+	if t.h.better(score, t.h.embs[0].Score) {
+		t.h.embs[0] = se
+		heap.Fix(t.h, 0)
+	}
+}
+
+// Embeddings returns the kept embeddings sorted from the best score.
+func (t *TopK) Embeddings() []v1.ScoredEmbedding {
+	embs := make([]v1.ScoredEmbedding, len(t.h.embs))
+	copy(embs, t.h.embs)
+	sort.SliceStable(embs, func(i, j int) bool {
+		return t.h.better(embs[i].Score, embs[j].Score)
+	})
+	return embs
+}
+
+// Nearest returns k embeddings embs nearest to vals measured by metric.
+// Embeddings with the skip UID are skipped unless skip is empty.
+func Nearest(embs []v1.Embedding, vals []float64, k int, metric v1.Metric, skip string) ([]v1.ScoredEmbedding, error) {
+	score, higher, err := Scorer(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	top := NewTopK(k, higher)
+	for _, e := range embs {
+		if skip != "" && e.UID == skip {
+			continue
+		}
+		if len(e.Values) != len(vals) {
+			return nil, fmt.Errorf("embedding dimension mismatch: %d != %d", len(vals), len(e.Values))
+		}
+		top.Push(e, score(vals, e.Values))
+	}
+
+	return top.Embeddings(), nil
+}
+
+// scoredHeap keeps the worst scored embedding on top.
+type scoredHeap struct {
+	embs   []v1.ScoredEmbedding
+	better func(a, b float64) bool
+}
+
+func (h scoredHeap) Len() int           { return len(h.embs) }
+func (h scoredHeap) Less(i, j int) bool { return h.better(h.embs[j].Score, h.embs[i].Score) }
+func (h scoredHeap) Swap(i, j int)      { h.embs[i], h.embs[j] = h.embs[j], h.embs[i] }
+
+func (h *scoredHeap) Push(x any) { h.embs = append(h.embs, x.(v1.ScoredEmbedding)) }
+
+func (h *scoredHeap) Pop() any {
+	n := len(h.embs)
+	x := h.embs[n-1]
+	h.embs = h.embs[:n-1]
+	return x
+}
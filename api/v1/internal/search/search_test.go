@@ -0,0 +1,103 @@
+package search
+
+import (
+	"math"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestNearest(t *testing.T) {
+	embs := []v1.Embedding{
+		{UID: "a", Values: []float64{1, 0}},
+		{UID: "b", Values: []float64{10, 1}},
+		{UID: "c", Values: []float64{0, 1}},
+		{UID: "d", Values: []float64{2, 0.1}},
+	}
+	vals := []float64{1, 0}
+
+	testCases := []struct {
+		metric v1.Metric
+		skip   string
+		exp    []string
+	}{
+		// NOTE: dot product prefers long vectors
+		{metric: v1.Dot, exp: []string{"b", "d"}},
+		{metric: v1.Cosine, exp: []string{"a", "d"}},
+		{metric: v1.Euclidean, exp: []string{"a", "d"}},
+		{metric: v1.Euclidean, skip: "a", exp: []string{"d", "c"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.metric), func(t *testing.T) {
+			res, err := Nearest(embs, vals, 2, tc.metric, tc.skip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != len(tc.exp) {
+				t.Fatalf("expected %d embeddings, got: %d", len(tc.exp), len(res))
+			}
+			for i, uid := range tc.exp {
+				if res[i].UID != uid {
+					t.Fatalf("embedding %d: %s, expected: %s", i, res[i].UID, uid)
+				}
+			}
+		})
+	}
+
+	t.Run("Scores", func(t *testing.T) {
+		res, err := Nearest(embs, vals, len(embs), v1.Cosine, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res[0].Score != 1 || res[len(res)-1].Score != 0 {
+			t.Fatalf("unexpected cosine scores: %v, %v", res[0].Score, res[len(res)-1].Score)
+		}
+		res, err = Nearest(embs, vals, 1, v1.Euclidean, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := math.Sqrt(1 + 0.01); math.Abs(res[0].Score-exp) > 1e-12 {
+			t.Fatalf("expected euclidean distance: %v, got: %v", exp, res[0].Score)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := Nearest(embs, []float64{1}, 2, v1.Dot, ""); err == nil {
+			t.Fatal("expected error")
+		}
+		if _, err := Nearest(embs, vals, 2, "foo", ""); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestDistance(t *testing.T) {
+	testCases := []struct {
+		metric v1.Metric
+		x, y   []float64
+		exp    float64
+	}{
+		{metric: v1.Euclidean, x: []float64{0, 0}, y: []float64{3, 4}, exp: 5},
+		{metric: v1.Cosine, x: []float64{1, 0}, y: []float64{0, 2}, exp: 1},
+		{metric: v1.Cosine, x: []float64{1, 1}, y: []float64{2, 2}, exp: 0},
+		{metric: v1.Cosine, x: []float64{0, 0}, y: []float64{1, 1}, exp: 1},
+	}
+
+	for _, tc := range testCases {
+		dist, err := Distance(tc.metric)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := dist(tc.x, tc.y); math.Abs(got-tc.exp) > 1e-12 {
+			t.Fatalf("%s distance of %v and %v: %v, expected: %v", tc.metric, tc.x, tc.y, got, tc.exp)
+		}
+	}
+
+	// NOTE: dot product is a similarity rather than a distance
+	for _, metric := range []v1.Metric{v1.Dot, "foo"} {
+		if _, err := Distance(metric); err == nil {
+			t.Fatalf("expected %q error", metric)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+// Similarities returns the pairwise similarity matrix of embs measured by metric.
+// If reorder is true, embs are ordered by their hierarchical clustering.
+func Similarities(ctx context.Context, embs []v1.Embedding, metric v1.Metric, reorder bool) (*v1.Similarity, error) {
+	score, higher, err := Scorer(metric)
+	if err != nil {
+		return nil, err
+	}
+	for i, e := range embs {
+		if len(e.Values) != len(embs[0].Values) {
+			return nil, fmt.Errorf("embedding %d dimension mismatch: %d != %d", i, len(e.Values), len(embs[0].Values))
+		}
+	}
+
+	order := make([]int, len(embs))
+	for i := range order {
+		order[i] = i
+	}
+	if reorder {
+		data := make([][]float64, len(embs))
+		for i, e := range embs {
+			data[i] = e.Values
+		}
+		// NOTE: the similarities are negated into dissimilarities
+		dissim := score
+		if higher {
+			dissim = func(x, y []float64) float64 { return -score(x, y) }
+		}
+		if order, err = Order(ctx, data, dissim); err != nil {
+			return nil, err
+		}
+	}
+
+	sim := &v1.Similarity{
+		Metric:    metric,
+		Reordered: reorder,
+		UIDs:      make([]string, len(embs)),
+		Labels:    make([]string, len(embs)),
+		Values:    make([][]float64, len(embs)),
+	}
+	for i, idx := range order {
+		sim.UIDs[i] = embs[idx].UID
+		sim.Labels[i], _ = embs[idx].Metadata[v1.LabelMetaKey].(string)
+		sim.Values[i] = make([]float64, len(embs))
+	}
+	for i, a := range order {
+		for j := i; j < len(order); j++ {
+			s := score(embs[a].Values, embs[order[j]].Values)
+			sim.Values[i][j], sim.Values[j][i] = s, s
+		}
+	}
+
+	return sim, nil
+}
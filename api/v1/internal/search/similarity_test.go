@@ -0,0 +1,64 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func TestSimilarities(t *testing.T) {
+	// NOTE: a and c point the same way as do b and d
+	embs := []v1.Embedding{
+		{UID: "a", Values: []float64{1, 0.1}, Metadata: map[string]any{v1.LabelMetaKey: "a"}},
+		{UID: "b", Values: []float64{0.1, 1}},
+		{UID: "c", Values: []float64{2, 0.1}},
+		{UID: "d", Values: []float64{0.1, 2}},
+	}
+
+	sim, err := Similarities(context.TODO(), embs, v1.Cosine, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range embs {
+		if sim.UIDs[i] != embs[i].UID {
+			t.Fatalf("expected uid %d: %s, got: %s", i, embs[i].UID, sim.UIDs[i])
+		}
+		if d := sim.Values[i][i]; d < 1-1e-12 {
+			t.Fatalf("expected unit self similarity, got: %v", d)
+		}
+		for j := range embs {
+			if sim.Values[i][j] != sim.Values[j][i] {
+				t.Fatalf("expected symmetric similarities, got: %v", sim.Values)
+			}
+		}
+	}
+	if sim.Labels[0] != "a" || sim.Labels[1] != "" {
+		t.Fatalf("unexpected labels: %v", sim.Labels)
+	}
+
+	sim, err = Similarities(context.TODO(), embs, v1.Cosine, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sim.Reordered {
+		t.Fatal("expected reordered similarities")
+	}
+	// NOTE: the embeddings pointing the same way must be adjacent
+	pos := make(map[string]int, len(sim.UIDs))
+	for i, uid := range sim.UIDs {
+		pos[uid] = i
+	}
+	for _, pair := range [][2]string{{"a", "c"}, {"b", "d"}} {
+		if d := pos[pair[0]] - pos[pair[1]]; d != 1 && d != -1 {
+			t.Fatalf("expected %s and %s adjacent, got order: %v", pair[0], pair[1], sim.UIDs)
+		}
+	}
+	if s := sim.Values[pos["a"]][pos["c"]]; s < 0.99 {
+		t.Fatalf("expected similar a and c, got: %v", s)
+	}
+
+	if _, err := Similarities(context.TODO(), append(embs, v1.Embedding{Values: []float64{1}}), v1.Cosine, false); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"context"
+	"time"
+)
+
+// JobStatus is the status of a job.
+type JobStatus string
+
+const (
+	// JobPending is a job waiting for a free worker.
+	JobPending JobStatus = "pending"
+	// JobRunning is a job being run by a worker.
+	JobRunning JobStatus = "running"
+	// JobDone is a job which finished successfully.
+	JobDone JobStatus = "done"
+	// JobFailed is a job which finished with error.
+	JobFailed JobStatus = "failed"
+	// JobCancelled is a job which has been cancelled.
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Finished returns true if the job with status s has finished.
+func (s JobStatus) Finished() bool {
+	return s == JobDone || s == JobFailed || s == JobCancelled
+}
+
+// JobKind is the kind of a job.
+type JobKind string
+
+const (
+	// ProjectionsJob computes provider projections.
+	ProjectionsJob JobKind = "projections"
+	// ViewJob computes provider view projections.
+	ViewJob JobKind = "view"
+	// ClustersJob clusters provider embeddings.
+	ClustersJob JobKind = "clusters"
+	// OutliersJob detects outliers of provider embeddings.
+	OutliersJob JobKind = "outliers"
+	// SimilarityJob computes pairwise similarities of provider embeddings.
+	SimilarityJob JobKind = "similarity"
+)
+
+// Job is an asynchronous task.
+type Job struct {
+	// UID of the job.
+	UID string `json:"id"`
+	// Kind of the job.
+	Kind JobKind `json:"kind"`
+	// Provider UID the job runs for.
+	Provider string `json:"provider"`
+	// Status of the job.
+	Status JobStatus `json:"status"`
+	// Progress of the job in the range [0, 1].
+	Progress float64 `json:"progress"`
+	// Error is set if the job has failed.
+	Error string `json:"error,omitempty"`
+	// Result is set if the job has finished successfully and it reported any.
+	Result any `json:"result,omitempty"`
+	// CreatedAt is the time the job was created.
+	CreatedAt time.Time `json:"created_at"`
+	// UpdatedAt is the time the job was last updated.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JobFunc is run by the job workers.
+// ctx is cancelled when the job is cancelled.
+type JobFunc func(ctx context.Context) error
+
+// JobsService manages asynchronous jobs.
+type JobsService interface {
+	// AddJob schedules a new job of the given kind for the given provider and returns it.
+	AddJob(ctx context.Context, kind JobKind, provider string, run JobFunc) (*Job, error)
+	// GetJobByUID returns the job with the given uid.
+	GetJobByUID(ctx context.Context, uid string) (*Job, error)
+	// CancelJob cancels the job with the given uid and returns it.
+	CancelJob(ctx context.Context, uid string) (*Job, error)
+}
+
+type progressKey struct{}
+
+// WithProgress returns a copy of ctx which reports progress to fn.
+func WithProgress(ctx context.Context, fn func(float64)) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// ReportProgress reports progress p in the range [0, 1] if ctx tracks progress.
+func ReportProgress(ctx context.Context, p float64) {
+	if fn, ok := ctx.Value(progressKey{}).(func(float64)); ok {
+		fn(p)
+	}
+}
+
+type resultKey struct{}
+
+// WithResult returns a copy of ctx which reports the job result to fn.
+func WithResult(ctx context.Context, fn func(any)) context.Context {
+	return context.WithValue(ctx, resultKey{}, fn)
+}
+
+// ReportResult reports the job result res if ctx tracks it.
+func ReportResult(ctx context.Context, res any) {
+	if fn, ok := ctx.Value(resultKey{}).(func(any)); ok {
+		fn(res)
+	}
+}
+
+// ProgressRange returns a copy of ctx which maps progress reported
+// in the range [0, 1] to the range [lo, hi] of the progress of ctx.
+func ProgressRange(ctx context.Context, lo, hi float64) context.Context {
+	return WithProgress(ctx, func(p float64) {
+		ReportProgress(ctx, lo+p*(hi-lo))
+	})
+}
@@ -0,0 +1,230 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+const (
+	// DefaultWorkers is the default number of job workers.
+	DefaultWorkers = 2
+	// DefaultTTL is the default time finished jobs are retained for.
+	DefaultTTL = time.Hour
+)
+
+var (
+	ErrClosed = errors.New("ErrClosed")
+)
+
+// job is a scheduled job.
+type job struct {
+	v1.Job
+	run    v1.JobFunc
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// JobsService runs jobs in a bounded pool of workers.
+type JobsService struct {
+	// TTL is the time finished jobs are retained for.
+	TTL time.Duration
+
+	mu     sync.RWMutex
+	jobs   map[string]*job
+	closed bool
+
+	// sem bounds the number of concurrently running jobs.
+	sem    chan struct{}
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewJobsService creates an instance of JobsService which
+// runs at most the given number of jobs concurrently.
+// If workers is not positive, DefaultWorkers is used.
+func NewJobsService(workers int) (*JobsService, error) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &JobsService{
+		TTL:    DefaultTTL,
+		jobs:   make(map[string]*job),
+		sem:    make(chan struct{}, workers),
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// AddJob schedules a new job and returns it.
+// Only a single unfinished job can exist for any given provider.
+// nolint:revive
+func (s *JobsService) AddJob(ctx context.Context, kind v1.JobKind, provider string, run v1.JobFunc) (*v1.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrClosed)
+	}
+
+	for _, j := range s.jobs {
+		if j.Provider == provider && !j.Status.Finished() {
+			return nil, v1.Errorf(v1.ECONFLICT, "job %s already scheduled for provider %s", j.UID, provider)
+		}
+	}
+
+	now := time.Now().UTC()
+	j := &job{
+		Job: v1.Job{
+			UID:       uuid.NewString(),
+			Kind:      kind,
+			Provider:  provider,
+			Status:    v1.JobPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		run: run,
+	}
+	j.ctx, j.cancel = context.WithCancel(s.ctx)
+	s.jobs[j.UID] = j
+
+	s.wg.Add(1)
+	go s.work(j)
+
+	res := j.Job
+	return &res, nil
+}
+
+// GetJobByUID returns the job with the given uid.
+// nolint:revive
+func (s *JobsService) GetJobByUID(ctx context.Context, uid string) (*v1.Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	j, ok := s.jobs[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "job %s not found", uid)
+	}
+	res := j.Job
+	return &res, nil
+}
+
+// CancelJob cancels the job with the given uid and returns it.
+// Pending jobs are cancelled immediately, running jobs
+// are cancelled once their job function returns.
+// nolint:revive
+func (s *JobsService) CancelJob(ctx context.Context, uid string) (*v1.Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "job %s not found", uid)
+	}
+	if j.Status.Finished() {
+		return nil, v1.Errorf(v1.ECONFLICT, "job %s already %s", uid, j.Status)
+	}
+
+	j.cancel()
+	if j.Status == v1.JobPending {
+		j.Status = v1.JobCancelled
+		j.UpdatedAt = time.Now().UTC()
+	}
+
+	res := j.Job
+	return &res, nil
+}
+
+// Close cancels all unfinished jobs and waits for the workers to return.
+func (s *JobsService) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	s.cancel()
+	s.wg.Wait()
+
+	return nil
+}
+
+// work waits for a free worker and runs the job.
+// Finished jobs are removed once they have been retained for TTL.
+func (s *JobsService) work(j *job) {
+	defer s.wg.Done()
+	defer s.expire(j)
+	defer j.cancel()
+
+	select {
+	case <-j.ctx.Done():
+		s.finish(j, j.ctx.Err())
+		return
+	case s.sem <- struct{}{}:
+	}
+	defer func() { <-s.sem }()
+
+	s.mu.Lock()
+	// NOTE: the job might have been cancelled while acquiring the worker
+	if j.Status != v1.JobPending {
+		s.mu.Unlock()
+		return
+	}
+	j.Status = v1.JobRunning
+	j.UpdatedAt = time.Now().UTC()
+	s.mu.Unlock()
+
+	ctx := v1.WithProgress(j.ctx, func(p float64) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		j.Progress = p
+		j.UpdatedAt = time.Now().UTC()
+	})
+	ctx = v1.WithResult(ctx, func(res any) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		j.Result = res
+	})
+
+	s.finish(j, j.run(ctx))
+}
+
+// finish records the result of the job.
+func (s *JobsService) finish(j *job, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if j.Status.Finished() {
+		return
+	}
+
+	switch {
+	case j.ctx.Err() != nil:
+		j.Status = v1.JobCancelled
+		j.Result = nil
+	case err != nil:
+		j.Status = v1.JobFailed
+		j.Error = err.Error()
+		j.Result = nil
+	default:
+		j.Status = v1.JobDone
+		j.Progress = 1
+	}
+	j.UpdatedAt = time.Now().UTC()
+}
+
+// expire removes the finished job j once it has been retained for TTL.
+func (s *JobsService) expire(j *job) {
+	s.mu.RLock()
+	ttl := s.TTL
+	s.mu.RUnlock()
+
+	time.AfterFunc(ttl, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.jobs, j.UID)
+	})
+}
@@ -0,0 +1,239 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+)
+
+func MustJobsService(t *testing.T, workers int) *JobsService {
+	s, err := NewJobsService(workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func MustWaitJob(t *testing.T, s *JobsService, uid string) *v1.Job {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := s.GetJobByUID(context.TODO(), uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status.Finished() {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", uid)
+	return nil
+}
+
+func TestAddJob(t *testing.T) {
+	t.Run("Done", func(t *testing.T) {
+		s := MustJobsService(t, 1)
+
+		job, err := s.AddJob(context.TODO(), v1.ProjectionsJob, "foo", func(ctx context.Context) error {
+			v1.ReportProgress(ctx, 0.5)
+			v1.ReportResult(ctx, "foo")
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != v1.JobPending && job.Status != v1.JobRunning {
+			t.Fatalf("expected status: %s, got: %s", v1.JobPending, job.Status)
+		}
+
+		job = MustWaitJob(t, s, job.UID)
+		if job.Status != v1.JobDone || job.Progress != 1 {
+			t.Fatalf("expected status: %s, progress: 1, got: %s, %v", v1.JobDone, job.Status, job.Progress)
+		}
+		if job.Result != "foo" {
+			t.Fatalf("expected result: foo, got: %v", job.Result)
+		}
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		s := MustJobsService(t, 1)
+
+		job, err := s.AddJob(context.TODO(), v1.ProjectionsJob, "foo", func(ctx context.Context) error {
+			v1.ReportResult(ctx, "foo")
+			return errors.New("foo")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job = MustWaitJob(t, s, job.UID)
+		if job.Status != v1.JobFailed || job.Error != "foo" {
+			t.Fatalf("expected status: %s, error: foo, got: %s, %s", v1.JobFailed, job.Status, job.Error)
+		}
+		if job.Result != nil {
+			t.Fatalf("expected no result, got: %v", job.Result)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		s := MustJobsService(t, 1)
+		s.TTL = 10 * time.Millisecond
+
+		job, err := s.AddJob(context.TODO(), v1.ProjectionsJob, "foo", func(ctx context.Context) error {
+			v1.ReportResult(ctx, "foo")
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: finished jobs are removed even if no new jobs are added
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if _, err := s.GetJobByUID(context.TODO(), job.UID); v1.ErrorCode(err) == v1.ENOTFOUND {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("job %s was not removed", job.UID)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		s := MustJobsService(t, 1)
+
+		done := make(chan struct{})
+		defer close(done)
+
+		if _, err := s.AddJob(context.TODO(), v1.ProjectionsJob, "foo", func(ctx context.Context) error {
+			<-done
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := s.AddJob(context.TODO(), v1.ProjectionsJob, "foo", func(ctx context.Context) error { return nil })
+		if code := v1.ErrorCode(err); code != v1.ECONFLICT {
+			t.Fatalf("expected error: %s, got: %s", v1.ECONFLICT, code)
+		}
+	})
+
+	t.Run("Workers", func(t *testing.T) {
+		workers := 2
+		s := MustJobsService(t, workers)
+
+		var running, maxRunning atomic.Int32
+		uids := []string{}
+		for _, p := range []string{"foo", "bar", "car", "dar", "ear"} {
+			job, err := s.AddJob(context.TODO(), v1.ProjectionsJob, p, func(ctx context.Context) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					m := maxRunning.Load()
+					if n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			uids = append(uids, job.UID)
+		}
+
+		for _, uid := range uids {
+			MustWaitJob(t, s, uid)
+		}
+
+		if m := maxRunning.Load(); m > int32(workers) {
+			t.Fatalf("expected at most %d running jobs, got: %d", workers, m)
+		}
+	})
+}
+
+func TestGetJobByUID(t *testing.T) {
+	s := MustJobsService(t, 1)
+
+	_, err := s.GetJobByUID(context.TODO(), "foo")
+	if code := v1.ErrorCode(err); code != v1.ENOTFOUND {
+		t.Fatalf("expected error: %s, got: %s", v1.ENOTFOUND, code)
+	}
+}
+
+func TestCancelJob(t *testing.T) {
+	t.Run("Running", func(t *testing.T) {
+		s := MustJobsService(t, 1)
+
+		started := make(chan struct{})
+		job, err := s.AddJob(context.TODO(), v1.ProjectionsJob, "foo", func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-started
+
+		if _, err := s.CancelJob(context.TODO(), job.UID); err != nil {
+			t.Fatal(err)
+		}
+
+		job = MustWaitJob(t, s, job.UID)
+		if job.Status != v1.JobCancelled {
+			t.Fatalf("expected status: %s, got: %s", v1.JobCancelled, job.Status)
+		}
+
+		if _, err := s.CancelJob(context.TODO(), job.UID); v1.ErrorCode(err) != v1.ECONFLICT {
+			t.Fatalf("expected error: %s, got: %v", v1.ECONFLICT, err)
+		}
+	})
+
+	t.Run("Pending", func(t *testing.T) {
+		s := MustJobsService(t, 1)
+
+		done := make(chan struct{})
+		defer close(done)
+
+		if _, err := s.AddJob(context.TODO(), v1.ProjectionsJob, "foo", func(ctx context.Context) error {
+			<-done
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		var ran atomic.Bool
+		job, err := s.AddJob(context.TODO(), v1.ProjectionsJob, "bar", func(ctx context.Context) error {
+			ran.Store(true)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job, err = s.CancelJob(context.TODO(), job.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != v1.JobCancelled {
+			t.Fatalf("expected status: %s, got: %s", v1.JobCancelled, job.Status)
+		}
+		if ran.Load() {
+			t.Fatal("cancelled job must not run")
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		s := MustJobsService(t, 1)
+
+		if _, err := s.CancelJob(context.TODO(), "foo"); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
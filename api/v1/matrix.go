@@ -0,0 +1,47 @@
+package v1
+
+import "fmt"
+
+// ProjectionMatrix is a user-supplied linear projection, e.g. a basis computed offline by LDA or a probe classifier.
+// Embeddings are projected by centering them with the optional mean and multiplying them by the matrix.
+type ProjectionMatrix struct {
+	// Version of the matrix.
+	// It's incremented every time the matrix is replaced.
+	Version int `json:"version"`
+	// Name of the matrix.
+	Name string `json:"name,omitempty"`
+	// Values of the matrix with a row per embedding dimension
+	// and a column per projection axis, e.g. dim x 2 or dim x 3.
+	Values [][]float64 `json:"values"`
+	// Mean is subtracted from the embeddings before they're projected.
+	Mean []float64 `json:"mean,omitempty"`
+}
+
+// Validate returns error if the matrix is empty, its rows are ragged
+// or if its mean does not match the embedding dimension.
+func (m *ProjectionMatrix) Validate() error {
+	if len(m.Values) == 0 || len(m.Values[0]) == 0 {
+		return fmt.Errorf("invalid matrix: no values")
+	}
+	cols := len(m.Values[0])
+	if cols > MaxDim {
+		return fmt.Errorf("invalid matrix: %d columns exceed max dimension: %d", cols, MaxDim)
+	}
+	for i, row := range m.Values {
+		if len(row) != cols {
+			return fmt.Errorf("invalid matrix: row %d has %d columns, expected: %d", i, len(row), cols)
+		}
+	}
+	if m.Mean != nil && len(m.Mean) != len(m.Values) {
+		return fmt.Errorf("invalid matrix: mean dimension %d, expected: %d", len(m.Mean), len(m.Values))
+	}
+	return nil
+}
+
+// Dim returns the projection dimension of the matrix.
+func (m *ProjectionMatrix) Dim() Dim {
+	if len(m.Values) == 0 {
+		return ""
+	}
+	return NewDim(len(m.Values[0]))
+}
@@ -0,0 +1,30 @@
+package v1
+
+import "testing"
+
+func TestProjectionMatrixValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		matrix ProjectionMatrix
+		valid  bool
+	}{
+		{name: "Valid", matrix: ProjectionMatrix{Values: [][]float64{{1, 0}, {0, 1}, {0, 0}}}, valid: true},
+		{name: "Mean", matrix: ProjectionMatrix{Values: [][]float64{{1, 0}, {0, 1}}, Mean: []float64{1, 1}}, valid: true},
+		{name: "Empty", matrix: ProjectionMatrix{}},
+		{name: "Ragged", matrix: ProjectionMatrix{Values: [][]float64{{1, 0}, {0}}}},
+		{name: "InvalidMean", matrix: ProjectionMatrix{Values: [][]float64{{1, 0}, {0, 1}}, Mean: []float64{1}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.matrix.Validate(); (err == nil) != tc.valid {
+				t.Fatalf("expected valid: %v, got error: %v", tc.valid, err)
+			}
+		})
+	}
+
+	m := ProjectionMatrix{Values: [][]float64{{1, 0, 0}, {0, 1, 0}}}
+	if dim := m.Dim(); dim != Dim3D {
+		t.Fatalf("expected dim: %s, got: %s", Dim3D, dim)
+	}
+}
@@ -2,11 +2,16 @@ package memory
 
 import (
 	"context"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/internal/cluster"
 	"github.com/milosgajdos/embeviz/api/v1/internal/paging"
 	"github.com/milosgajdos/embeviz/api/v1/internal/projection"
+	"github.com/milosgajdos/embeviz/api/v1/internal/search"
+	"golang.org/x/exp/maps"
 )
 
 const (
@@ -16,6 +21,20 @@ const (
 	emb = "embs"
 	// projection keyspace
 	proj = "proj"
+	// projection options keyspace
+	opts = "opts"
+	// projection models keyspace
+	model = "model"
+	// projection metrics keyspace
+	metrics = "metrics"
+	// active projection keyspace
+	active = "active"
+	// views keyspace
+	views = "views"
+	// projection matrix keyspace
+	matrix = "matrix"
+	// dedup policy keyspace
+	dedup = "dedup"
 )
 
 // ProvidersService is an in-memory store for embeddings providers.
@@ -48,10 +67,7 @@ func (p *ProvidersService) AddProvider(ctx context.Context, name string, md map[
 	p.db.store[uid] = map[string]any{
 		meta: provider,
 		emb:  []v1.Embedding{},
-		proj: map[v1.Dim][]v1.Embedding{
-			v1.Dim2D: {},
-			v1.Dim3D: {},
-		},
+		proj: map[string][]v1.Embedding{},
 	}
 	return provider, nil
 }
@@ -121,7 +137,7 @@ func (p *ProvidersService) GetProviderEmbeddings(ctx context.Context, uid string
 
 // GetProviderProjections fetches a specific provider embeddings projection.
 // nolint:revive
-func (p *ProvidersService) GetProviderProjections(ctx context.Context, uid string, filter v1.ProviderFilter) (map[v1.Dim][]v1.Embedding, v1.Page, error) {
+func (p *ProvidersService) GetProviderProjections(ctx context.Context, uid string, filter v1.ProviderFilter) (*v1.Projections, v1.Page, error) {
 	p.db.RLock()
 	defer p.db.RUnlock()
 	count := 0
@@ -137,59 +153,343 @@ func (p *ProvidersService) GetProviderProjections(ctx context.Context, uid strin
 	if !ok {
 		offset = 0
 	}
+	// NOTE: unless requested otherwise we return the last computed projections
+	prj := v1.PCA
+	if a, ok := provider[active].(v1.Projection); ok {
+		prj = a
+	}
+	if filter.Projection != nil {
+		prj = *filter.Projection
+	}
+
+	// NOTE: options and metrics are not set until the projections have been computed
+	projOpts, _ := provider[opts].(map[v1.Projection]*v1.ProjectionOptions)
+	projMetrics, _ := provider[metrics].(map[v1.Projection]map[v1.Dim]*v1.ProjectionMetrics)
+
+	dims := projOpts[prj].Dimensions()
 	if dim := filter.Dim; dim != nil {
-		if *dim != v1.Dim2D && *dim != v1.Dim3D {
+		if !slices.Contains(dims, *dim) {
 			return nil, v1.Page{Count: &count},
 				v1.Errorf(v1.EINVALID, "invalid dimension %v for provider %q", *dim, uid)
 		}
+		dims = []v1.Dim{*dim}
+	}
 
-		projStore := provider[proj].(map[v1.Dim][]v1.Embedding)
-		newProjections := getDimProjections(projStore, *dim)
+	projStore := provider[proj].(map[string][]v1.Embedding)
+	res := make(map[v1.Dim][]v1.Embedding, len(dims))
+	for _, dim := range dims {
+		newProjections := getDimProjections(projStore, prj, dim)
 		count = len(newProjections)
-
-		return map[v1.Dim][]v1.Embedding{
-			*filter.Dim: paging.ApplyOffsetLimit(newProjections, offset, filter.Limit).([]v1.Embedding),
-		}, v1.Page{Count: &count}, nil
+		res[dim] = paging.ApplyOffsetLimit(newProjections, offset, filter.Limit).([]v1.Embedding)
 	}
 
-	projections := provider[proj].(map[v1.Dim][]v1.Embedding)
-	newProjections2D := getDimProjections(projections, v1.Dim2D)
-	newProjections3D := getDimProjections(projections, v1.Dim3D)
-	count = len(newProjections2D)
+	var resMetrics map[v1.Dim]*v1.ProjectionMetrics
+	if m, ok := projMetrics[prj]; ok {
+		resMetrics = make(map[v1.Dim]*v1.ProjectionMetrics, len(dims))
+		for _, dim := range dims {
+			if dm, ok := m[dim]; ok {
+				resMetrics[dim] = dm
+			}
+		}
+	}
 
-	return map[v1.Dim][]v1.Embedding{
-		v1.Dim2D: paging.ApplyOffsetLimit(newProjections2D, offset, filter.Limit).([]v1.Embedding),
-		v1.Dim3D: paging.ApplyOffsetLimit(newProjections3D, offset, filter.Limit).([]v1.Embedding),
+	return &v1.Projections{
+		Projection: prj,
+		Options:    projOpts[prj],
+		Metrics:    resMetrics,
+		Embeddings: res,
 	}, v1.Page{Count: &count}, nil
 }
 
 // UpdateProviderEmbeddings updates embeddings of a specific provider.
-// nolint:revive
-func (p *ProvidersService) UpdateProviderEmbeddings(ctx context.Context, uid string, embeds []v1.Embedding, prj v1.Projection) ([]v1.Embedding, error) {
+// Embeddings without UID are assigned a new UID.
+// Duplicates are handled according to the provider dedup policy and
+// the stored embeddings they duplicate are returned in their place.
+// NOTE: the stale projection is recomputed without holding the lock and
+// the update fails with conflict if the embeddings have been updated in the meantime.
+func (p *ProvidersService) UpdateProviderEmbeddings(ctx context.Context, uid string, embeds []v1.Embedding, prj v1.Projection, prjOpts *v1.ProjectionOptions) ([]v1.Embedding, error) {
+	// NOTE: embeddings are assigned new UIDs unless they have one
+	embeds = slices.Clone(embeds)
+	for i := range embeds {
+		if embeds[i].UID == "" {
+			embeds[i].UID = uuid.NewString()
+		}
+	}
+
 	p.db.Lock()
-	defer p.db.Unlock()
 	if p.db.Closed {
+		p.db.Unlock()
 		return nil, ErrDBClosed
 	}
-
 	provider, ok := p.db.store[uid]
 	if !ok {
+		p.db.Unlock()
 		return nil, v1.Errorf(v1.ENOTFOUND, "provider %s not found", uid)
 	}
-	embs := provider[emb].([]v1.Embedding)
-	newEmbs := make([]v1.Embedding, len(embs))
-	copy(newEmbs, embs)
-	newEmbs = append(newEmbs, embeds...)
+	u := newUpdate(provider, embeds)
+
+	// NOTE: models and options are not set until the projections have been computed
+	projModels, _ := provider[model].(map[v1.Projection]projection.Models)
+	projOpts, _ := provider[opts].(map[v1.Projection]*v1.ProjectionOptions)
+	if len(u.added) == 0 || !projModels[prj].Stale(prj, projOpts[prj], prjOpts) {
+		defer p.db.Unlock()
+		return u.apply(provider, prj, nil, nil)
+	}
+	prjOpts = projection.Dims(prjOpts, prj, providerDims(provider))
+	p.db.Unlock()
+
+	prjs, models, err := projection.Compute(ctx, u.newEmbs, prj, prjOpts)
+	if err != nil {
+		return nil, err
+	}
 
-	prjs, err := projection.Compute(newEmbs, prj)
+	p.db.Lock()
+	defer p.db.Unlock()
+
+	provider, err = p.getUnchanged(uid, u.embs)
 	if err != nil {
 		return nil, err
 	}
+	return u.apply(provider, prj, prjs, models)
+}
+
+// update is an update of the provider embeddings.
+type update struct {
+	// embs are the stored embeddings.
+	embs []v1.Embedding
+	// newEmbs are the stored embeddings with the merged metadata followed by the added embeddings.
+	newEmbs []v1.Embedding
+	// added are the added embeddings.
+	added []v1.Embedding
+	// res indexes the embeddings returned by the update into newEmbs.
+	res []int
+	// merges is the metadata merged into the stored embeddings keyed by their index.
+	merges map[int]map[string]any
+}
+
+// newUpdate returns the update adding the embeddings embeds to the provider
+// embeddings according to the provider dedup policy.
+func newUpdate(provider map[string]any, embeds []v1.Embedding) *update {
+	u := &update{
+		embs: provider[emb].([]v1.Embedding),
+		res:  make([]int, len(embeds)),
+	}
+	u.newEmbs = make([]v1.Embedding, len(u.embs), len(u.embs)+len(embeds))
+	copy(u.newEmbs, u.embs)
+
+	// NOTE: res indexes either the stored embeddings or the added ones offset by len(embs)
+	if policy, ok := provider[dedup].(*v1.DedupPolicy); ok && policy.Mode != v1.DedupOff {
+		u.added, u.res, u.merges = dedupEmbeddings(policy, u.embs, embeds)
+	} else {
+		u.added = embeds
+		for i := range u.res {
+			u.res[i] = len(u.embs) + i
+		}
+	}
+	mergeMetadata(u.newEmbs, u.merges)
+	u.newEmbs = append(u.newEmbs, u.added...)
+
+	return u
+}
+
+// apply applies the update to the provider and returns the embeddings returned by the update.
+// The added embeddings are projected using the fitted models of all the stored projections
+// except for the projection prj if its projections prjs and models have been recomputed.
+// NOTE: it must be called with the lock held.
+func (u *update) apply(provider map[string]any, prj v1.Projection, prjs *v1.Projections, models projection.Models) ([]v1.Embedding, error) {
+	result := make([]v1.Embedding, len(u.res))
+	for i, j := range u.res {
+		result[i] = u.newEmbs[j]
+	}
+
+	projStore := provider[proj].(map[string][]v1.Embedding)
+	if len(u.added) == 0 {
+		// NOTE: nothing has been added so the projections don't need updating
+		newProjStore := make(map[string][]v1.Embedding, len(projStore))
+		for key, projs := range projStore {
+			newProjStore[key] = slices.Clone(projs)
+			mergeMetadata(newProjStore[key], u.merges)
+		}
+		provider[emb] = u.newEmbs
+		provider[proj] = newProjStore
+		return result, nil
+	}
+
+	projModels, _ := provider[model].(map[v1.Projection]projection.Models)
+	projOpts, _ := provider[opts].(map[v1.Projection]*v1.ProjectionOptions)
+	projMetrics, _ := provider[metrics].(map[v1.Projection]map[v1.Dim]*v1.ProjectionMetrics)
+
+	newProjStore := make(map[string][]v1.Embedding, len(projStore))
+	for p, models := range projModels {
+		if p == prj && prjs != nil {
+			continue
+		}
+		dimProjs := make(map[v1.Dim][]v1.Embedding, len(models))
+		for dim := range models {
+			dimProjs[dim] = projStore[v1.ProjectionKey(p, dim)]
+		}
+		extProjs, err := projection.Extend(models, u.embs, dimProjs, u.added)
+		if err != nil {
+			return nil, err
+		}
+		for dim := range models {
+			key := v1.ProjectionKey(p, dim)
+			newProjStore[key] = make([]v1.Embedding, len(dimProjs[dim]), len(dimProjs[dim])+len(u.added))
+			copy(newProjStore[key], dimProjs[dim])
+			newProjStore[key] = append(newProjStore[key], extProjs[dim]...)
+		}
+	}
+
+	newModels := make(map[v1.Projection]projection.Models, len(projModels)+1)
+	newOpts := make(map[v1.Projection]*v1.ProjectionOptions, len(projOpts)+1)
+	newMetrics := make(map[v1.Projection]map[v1.Dim]*v1.ProjectionMetrics, len(projMetrics)+1)
+	for p := range projModels {
+		newModels[p], newOpts[p], newMetrics[p] = projModels[p], projOpts[p], projMetrics[p]
+	}
+
+	if prjs != nil {
+		for dim, dimProjs := range prjs.Embeddings {
+			newProjStore[v1.ProjectionKey(prj, dim)] = dimProjs
+		}
+		newModels[prj], newOpts[prj], newMetrics[prj] = models, prjs.Options, prjs.Metrics
+	}
+	for key := range newProjStore {
+		mergeMetadata(newProjStore[key], u.merges)
+	}
+
+	// NOTE: we only record the added embeddings once nothing can fail
+	for p, models := range projModels {
+		if p != prj || prjs == nil {
+			models.Added(len(u.added))
+		}
+	}
+
+	provider[emb] = u.newEmbs
+	provider[proj] = newProjStore
+	provider[opts] = newOpts
+	provider[model] = newModels
+	provider[metrics] = newMetrics
+	provider[active] = prj
+
+	return result, nil
+}
+
+// dedupEmbeddings applies the dedup policy to the embeddings embeds duplicating
+// either the stored embeddings embs or the preceding embeddings in embeds.
+// It returns the embeddings to add, the indices of the embeddings returned in place of embeds
+// into embs followed by the added embeddings and the metadata merged into the stored embeddings.
+func dedupEmbeddings(policy *v1.DedupPolicy, embs, embeds []v1.Embedding) ([]v1.Embedding, []int, map[int]map[string]any) {
+	added := make([]v1.Embedding, 0, len(embeds))
+	res := make([]int, 0, len(embeds))
+	merges := make(map[int]map[string]any)
+
+	for _, e := range embeds {
+		if i := search.Duplicate(policy, e, embs); i >= 0 {
+			if policy.Action == v1.DedupMerge {
+				if merges[i] == nil {
+					merges[i] = make(map[string]any, len(e.Metadata))
+				}
+				maps.Copy(merges[i], e.Metadata)
+			}
+			res = append(res, i)
+			continue
+		}
+		if i := search.Duplicate(policy, e, added); i >= 0 {
+			if policy.Action == v1.DedupMerge {
+				md := make(map[string]any, len(added[i].Metadata)+len(e.Metadata))
+				maps.Copy(md, added[i].Metadata)
+				maps.Copy(md, e.Metadata)
+				added[i].Metadata = md
+			}
+			res = append(res, len(embs)+i)
+			continue
+		}
+		added = append(added, e)
+		res = append(res, len(embs)+len(added)-1)
+	}
+
+	return added, res, merges
+}
+
+// getEmbeddings returns the embeddings of the provider with the given uid.
+// NOTE: the returned embeddings are shared with the store and must not be modified.
+func (p *ProvidersService) getEmbeddings(uid string) ([]v1.Embedding, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	return provider[emb].([]v1.Embedding), nil
+}
+
+// getProjectionInput returns the embeddings and the projection dimensions of the provider with the given uid.
+// NOTE: the returned embeddings are shared with the store and must not be modified.
+func (p *ProvidersService) getProjectionInput(uid string) ([]v1.Embedding, []v1.Dim, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	return provider[emb].([]v1.Embedding), providerDims(provider), nil
+}
+
+// getUnchanged returns the provider with the given uid if its stored embeddings are still embs.
+// NOTE: it must be called with the lock held.
+func (p *ProvidersService) getUnchanged(uid string, embs []v1.Embedding) (map[string]any, error) {
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	// NOTE: the stored embeddings are replaced rather than modified on every update
+	stored := provider[emb].([]v1.Embedding)
+	if len(stored) != len(embs) || len(embs) > 0 && &stored[0] != &embs[0] {
+		return nil, v1.Errorf(v1.ECONFLICT, "embeddings of provider %q have changed", uid)
+	}
+	return provider, nil
+}
 
-	provider[emb] = newEmbs
-	provider[proj] = prjs
+// setMetadata merges the metadata md into the metadata of the provider embeddings
+// and their projections keyed by the embedding index.
+// NOTE: the stored slices and metadata maps are replaced rather than modified
+// as they may be shared with the results returned to the callers.
+func setMetadata(provider map[string]any, md map[int]map[string]any) {
+	embs := slices.Clone(provider[emb].([]v1.Embedding))
+	mergeMetadata(embs, md)
+	provider[emb] = embs
 
-	return embeds, nil
+	// NOTE: projections are stored in the same order as embeddings
+	projStore := provider[proj].(map[string][]v1.Embedding)
+	newProjStore := make(map[string][]v1.Embedding, len(projStore))
+	for key, projs := range projStore {
+		newProjStore[key] = slices.Clone(projs)
+		mergeMetadata(newProjStore[key], md)
+	}
+	provider[proj] = newProjStore
+}
+
+// mergeMetadata merges the metadata md into the metadata of the embeddings embs keyed by their index.
+// The values of the existing metadata keys are overwritten.
+func mergeMetadata(embs []v1.Embedding, md map[int]map[string]any) {
+	for i, m := range md {
+		merged := make(map[string]any, len(embs[i].Metadata)+len(m))
+		maps.Copy(merged, embs[i].Metadata)
+		maps.Copy(merged, m)
+		embs[i].Metadata = merged
+	}
 }
 
 // DropProviderEmbeddings drops all embeddings for the provider with the given uid.
@@ -208,13 +508,282 @@ func (p *ProvidersService) DropProviderEmbeddings(ctx context.Context, uid strin
 		return v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
 	}
 	provider[emb] = []v1.Embedding{}
-	provider[proj] = map[v1.Dim][]v1.Embedding{}
+	provider[proj] = map[string][]v1.Embedding{}
+	delete(provider, opts)
+	delete(provider, model)
+	delete(provider, metrics)
+	delete(provider, active)
+	delete(provider, views)
 	return nil
 }
 
 // ComputeProviderProjections recomputes all projections from scratch for the provider with the given UID.
+// NOTE: the projections are computed without holding the lock and the computation
+// fails with conflict if the embeddings have been updated in the meantime.
+func (p *ProvidersService) ComputeProviderProjections(ctx context.Context, uid string, prj v1.Projection, prjOpts *v1.ProjectionOptions) error {
+	embs, dims, err := p.getProjectionInput(uid)
+	if err != nil {
+		return err
+	}
+	prjOpts = projection.Dims(prjOpts, prj, dims)
+
+	prjs, models, err := projection.Compute(ctx, embs, prj, prjOpts)
+	if err != nil {
+		return err
+	}
+
+	p.db.Lock()
+	defer p.db.Unlock()
+
+	provider, err := p.getUnchanged(uid, embs)
+	if err != nil {
+		return err
+	}
+
+	projStore := provider[proj].(map[string][]v1.Embedding)
+	projModels, ok := provider[model].(map[v1.Projection]projection.Models)
+	if !ok {
+		projModels = make(map[v1.Projection]projection.Models)
+		provider[model] = projModels
+	}
+	// NOTE: the projections may have been computed for different dimensions
+	for dim := range projModels[prj] {
+		delete(projStore, v1.ProjectionKey(prj, dim))
+	}
+	for dim, dimProjs := range prjs.Embeddings {
+		projStore[v1.ProjectionKey(prj, dim)] = dimProjs
+	}
+	projModels[prj] = models
+
+	projOpts, ok := provider[opts].(map[v1.Projection]*v1.ProjectionOptions)
+	if !ok {
+		projOpts = make(map[v1.Projection]*v1.ProjectionOptions)
+		provider[opts] = projOpts
+	}
+	projOpts[prj] = prjs.Options
+
+	projMetrics, ok := provider[metrics].(map[v1.Projection]map[v1.Dim]*v1.ProjectionMetrics)
+	if !ok {
+		projMetrics = make(map[v1.Projection]map[v1.Dim]*v1.ProjectionMetrics)
+		provider[metrics] = projMetrics
+	}
+	projMetrics[prj] = prjs.Metrics
+
+	provider[active] = prj
+
+	return nil
+}
+
+// ComputeProviderView projects the embeddings matching filter and stores the projections in the named view.
+// NOTE: the embeddings are projected without holding the lock and the computation
+// fails with conflict if the embeddings have been updated in the meantime.
+func (p *ProvidersService) ComputeProviderView(ctx context.Context, uid, name string, filter *v1.EmbeddingsFilter, prj v1.Projection, prjOpts *v1.ProjectionOptions) error {
+	embs, dims, err := p.getProjectionInput(uid)
+	if err != nil {
+		return err
+	}
+
+	matched := embs
+	if filter != nil {
+		matched = make([]v1.Embedding, 0, len(embs))
+		for _, e := range embs {
+			if filter.Match(e.Metadata) {
+				matched = append(matched, e)
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return v1.Errorf(v1.EINVALID, "no embeddings of provider %s match the view %s filter", uid, name)
+	}
+
+	prjOpts = projection.Dims(prjOpts, prj, dims)
+	prjs, _, err := projection.Compute(ctx, matched, prj, prjOpts)
+	if err != nil {
+		return err
+	}
+
+	p.db.Lock()
+	defer p.db.Unlock()
+
+	provider, err := p.getUnchanged(uid, embs)
+	if err != nil {
+		return err
+	}
+
+	provViews, ok := provider[views].(map[string]*v1.View)
+	if !ok {
+		provViews = make(map[string]*v1.View)
+		provider[views] = provViews
+	}
+	provViews[name] = &v1.View{
+		Name:       name,
+		Filter:     filter,
+		Projection: prj,
+		Options:    prjs.Options,
+		Metrics:    prjs.Metrics,
+		Count:      len(matched),
+		Embeddings: prjs.Embeddings,
+	}
+
+	return nil
+}
+
+// GetProviderViews returns the views of the provider with the given uid sorted by their name.
+// nolint:revive
+func (p *ProvidersService) GetProviderViews(ctx context.Context, uid string) ([]*v1.View, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+
+	provViews, _ := provider[views].(map[string]*v1.View)
+	res := make([]*v1.View, 0, len(provViews))
+	for _, view := range provViews {
+		v := *view
+		v.Embeddings = nil
+		res = append(res, &v)
+	}
+	slices.SortFunc(res, func(a, b *v1.View) int { return strings.Compare(a.Name, b.Name) })
+
+	return res, nil
+}
+
+// GetProviderView returns the named view of the provider with the given uid.
+// nolint:revive
+func (p *ProvidersService) GetProviderView(ctx context.Context, uid, name string, filter v1.ProviderFilter) (*v1.View, v1.Page, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	count := 0
+	if p.db.Closed {
+		return nil, v1.Page{Count: &count}, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Page{Count: &count}, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	provViews, _ := provider[views].(map[string]*v1.View)
+	view, ok := provViews[name]
+	if !ok {
+		return nil, v1.Page{Count: &count}, v1.Errorf(v1.ENOTFOUND, "view %q of provider %q not found", name, uid)
+	}
+
+	dims := view.Options.Dimensions()
+	if dim := filter.Dim; dim != nil {
+		if !slices.Contains(dims, *dim) {
+			return nil, v1.Page{Count: &count},
+				v1.Errorf(v1.EINVALID, "invalid dimension %v for view %q", *dim, name)
+		}
+		dims = []v1.Dim{*dim}
+	}
+	offset, ok := filter.Offset.(int)
+	if !ok {
+		offset = 0
+	}
+
+	res := *view
+	res.Embeddings = make(map[v1.Dim][]v1.Embedding, len(dims))
+	res.Metrics = make(map[v1.Dim]*v1.ProjectionMetrics, len(dims))
+	for _, dim := range dims {
+		dimProjs := make([]v1.Embedding, len(view.Embeddings[dim]))
+		copy(dimProjs, view.Embeddings[dim])
+		count = len(dimProjs)
+		res.Embeddings[dim] = paging.ApplyOffsetLimit(dimProjs, offset, filter.Limit).([]v1.Embedding)
+		if m, ok := view.Metrics[dim]; ok {
+			res.Metrics[dim] = m
+		}
+	}
+
+	return &res, v1.Page{Count: &count}, nil
+}
+
+// DropProviderView drops the named view of the provider with the given uid.
+// nolint:revive
+func (p *ProvidersService) DropProviderView(ctx context.Context, uid, name string) error {
+	p.db.Lock()
+	defer p.db.Unlock()
+	if p.db.Closed {
+		return v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	provViews, _ := provider[views].(map[string]*v1.View)
+	if _, ok := provViews[name]; !ok {
+		return v1.Errorf(v1.ENOTFOUND, "view %q of provider %q not found", name, uid)
+	}
+	delete(provViews, name)
+
+	return nil
+}
+
+// UpdateProviderMatrix stores a new version of the projection matrix m of the provider with the given uid.
+// nolint:revive
+func (p *ProvidersService) UpdateProviderMatrix(ctx context.Context, uid string, m *v1.ProjectionMatrix) (*v1.ProjectionMatrix, error) {
+	p.db.Lock()
+	defer p.db.Unlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	version := 0
+	if cur, ok := provider[matrix].(*v1.ProjectionMatrix); ok {
+		version = cur.Version
+	}
+	if m.Version != 0 && m.Version != version {
+		return nil, v1.Errorf(v1.ECONFLICT, "matrix version %d of provider %q does not match: %d", m.Version, uid, version)
+	}
+
+	newMatrix := *m
+	newMatrix.Version = version + 1
+	provider[matrix] = &newMatrix
+
+	res := newMatrix
+	return &res, nil
+}
+
+// GetProviderMatrix returns the projection matrix of the provider with the given uid.
+// nolint:revive
+func (p *ProvidersService) GetProviderMatrix(ctx context.Context, uid string) (*v1.ProjectionMatrix, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	m, ok := provider[matrix].(*v1.ProjectionMatrix)
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "matrix of provider %q not found", uid)
+	}
+
+	res := *m
+	return &res, nil
+}
+
+// DropProviderMatrix drops the projection matrix of the provider with the given uid.
+// NOTE: the projections computed using the matrix are kept until they're recomputed.
 // nolint:revive
-func (p *ProvidersService) ComputeProviderProjections(ctx context.Context, uid string, prj v1.Projection) error {
+func (p *ProvidersService) DropProviderMatrix(ctx context.Context, uid string) error {
 	p.db.Lock()
 	defer p.db.Unlock()
 	if p.db.Closed {
@@ -223,22 +792,403 @@ func (p *ProvidersService) ComputeProviderProjections(ctx context.Context, uid s
 
 	provider, ok := p.db.store[uid]
 	if !ok {
-		return v1.Errorf(v1.ENOTFOUND, "provider %s not found", uid)
+		return v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	if _, ok := provider[matrix]; !ok {
+		return v1.Errorf(v1.ENOTFOUND, "matrix of provider %q not found", uid)
+	}
+	delete(provider, matrix)
+
+	return nil
+}
+
+// ClusterProviderEmbeddings clusters embeddings of the provider with the given uid
+// and stores the cluster assignments in the metadata of the embeddings and their projections.
+// NOTE: the embeddings are clustered without holding the lock and the clustering
+// fails with conflict if the embeddings have been updated in the meantime.
+func (p *ProvidersService) ClusterProviderEmbeddings(ctx context.Context, uid string, clusterOpts *v1.ClusterOptions) (*v1.Clustering, error) {
+	embs, err := p.getEmbeddings(uid)
+	if err != nil {
+		return nil, err
+	}
+	if err := clusterOpts.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	clustering, assignments, err := cluster.Cluster(ctx, embs, clusterOpts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, v1.Errorf(v1.EINVALID, "Cluster error: %v", err)
+	}
+
+	md := make(map[int]map[string]any, len(embs))
+	for i := range embs {
+		md[i] = make(map[string]any)
+		assignments[i].Metadata(md[i])
+	}
+
+	p.db.Lock()
+	defer p.db.Unlock()
+
+	provider, err := p.getUnchanged(uid, embs)
+	if err != nil {
+		return nil, err
+	}
+	setMetadata(provider, md)
+
+	return clustering, nil
+}
+
+// DetectProviderOutliers scores embeddings of the provider with the given uid for outlierness
+// and stores the scores in the metadata of the embeddings and their projections.
+// NOTE: the embeddings are scored without holding the lock and the detection
+// fails with conflict if the embeddings have been updated in the meantime.
+// nolint:revive
+func (p *ProvidersService) DetectProviderOutliers(ctx context.Context, uid string, outlierOpts *v1.OutlierOptions) (*v1.Outliers, error) {
+	embs, err := p.getEmbeddings(uid)
+	if err != nil {
+		return nil, err
+	}
+	if err := outlierOpts.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	scores, outliers, err := cluster.Outliers(ctx, embs, outlierOpts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, v1.Errorf(v1.EINVALID, "Outliers error: %v", err)
+	}
+
+	top := v1.DefaultOutlierTop
+	if outlierOpts.Top > 0 {
+		top = outlierOpts.Top
+	}
+	md := make(map[int]map[string]any, len(embs))
+	for i := range embs {
+		md[i] = map[string]any{v1.OutlierMetaKey: scores[i]}
+	}
+
+	p.db.Lock()
+	defer p.db.Unlock()
+
+	provider, err := p.getUnchanged(uid, embs)
+	if err != nil {
+		return nil, err
+	}
+	setMetadata(provider, md)
+
+	topK := search.NewTopK(top, true)
+	for i, e := range provider[emb].([]v1.Embedding) {
+		topK.Push(e, scores[i])
+	}
+	outliers.Embeddings = topK.Embeddings()
+
+	return outliers, nil
+}
+
+// PlaceProviderEmbeddings places embeddings embs into the stored projections of the provider
+// with the given uid using the fitted projection models without storing them.
+// nolint:revive
+func (p *ProvidersService) PlaceProviderEmbeddings(ctx context.Context, uid string, embs []v1.Embedding, filter v1.ProviderFilter) (*v1.Projections, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	prj := v1.PCA
+	if a, ok := provider[active].(v1.Projection); ok {
+		prj = a
+	}
+	if filter.Projection != nil {
+		prj = *filter.Projection
+	}
+
+	projModels, _ := provider[model].(map[v1.Projection]projection.Models)
+	projOpts, _ := provider[opts].(map[v1.Projection]*v1.ProjectionOptions)
+	models, ok := projModels[prj]
+	if !ok || len(models) == 0 {
+		return nil, v1.Errorf(v1.EINVALID, "no %s projections of provider %q", prj, uid)
+	}
+	if dim := filter.Dim; dim != nil {
+		m, ok := models[*dim]
+		if !ok {
+			return nil, v1.Errorf(v1.EINVALID, "invalid dimension %v for provider %q", *dim, uid)
+		}
+		models = projection.Models{*dim: m}
+	}
+
+	projStore := provider[proj].(map[string][]v1.Embedding)
+	dimProjs := make(map[v1.Dim][]v1.Embedding, len(models))
+	for dim := range models {
+		dimProjs[dim] = projStore[v1.ProjectionKey(prj, dim)]
+	}
+	projs, err := projection.Extend(models, provider[emb].([]v1.Embedding), dimProjs, embs)
+	if err != nil {
+		return nil, v1.Errorf(v1.EINTERNAL, "Extend error: %v", err)
+	}
+
+	return &v1.Projections{
+		Projection: prj,
+		Options:    projOpts[prj],
+		Embeddings: projs,
+	}, nil
+}
+
+// AlignProviderProjections aligns the projections of the provider with the given uid
+// to the projections of the reference provider ref using Procrustes analysis.
+// nolint:revive
+func (p *ProvidersService) AlignProviderProjections(ctx context.Context, uid, ref string, prj v1.Projection, dim v1.Dim, key string) (*v1.Alignment, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	projs := make([][]v1.Embedding, 0, 2)
+	for _, id := range []string{ref, uid} {
+		provider, ok := p.db.store[id]
+		if !ok {
+			return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", id)
+		}
+		projOpts, _ := provider[opts].(map[v1.Projection]*v1.ProjectionOptions)
+		if o, ok := projOpts[prj]; !ok || !slices.Contains(o.Dimensions(), dim) {
+			return nil, v1.Errorf(v1.EINVALID, "no %s %s projections of provider %q", prj, dim, id)
+		}
+		projs = append(projs, getDimProjections(provider[proj].(map[string][]v1.Embedding), prj, dim))
+	}
+
+	alignment, err := projection.Procrustes(projs[0], projs[1], key)
+	if err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "Procrustes error: %v", err)
+	}
+	alignment.Reference = ref
+	alignment.Projection = prj
+	alignment.Dim = dim
+
+	return alignment, nil
+}
+
+// SearchProviderEmbeddings returns k embeddings of the provider with the given uid
+// most similar to the given embedding values. Embeddings are searched by brute force
+// and scored by their dot product with vals which matches the default qdrant distance.
+// nolint:revive
+func (p *ProvidersService) SearchProviderEmbeddings(ctx context.Context, uid string, vals []float64, k int) ([]v1.ScoredEmbedding, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	if k <= 0 {
+		return nil, v1.Errorf(v1.EINVALID, "invalid k: %d", k)
+	}
+
+	embs, err := search.Nearest(provider[emb].([]v1.Embedding), vals, k, v1.Dot, "")
+	if err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "Search error: %v", err)
+	}
+
+	return embs, nil
+}
+
+// GetProviderEmbeddingNeighbours returns k embeddings of the provider with the given uid
+// nearest to its embedding with the given eid measured by metric.
+// nolint:revive
+func (p *ProvidersService) GetProviderEmbeddingNeighbours(ctx context.Context, uid, eid string, k int, metric v1.Metric) ([]v1.ScoredEmbedding, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	if k <= 0 {
+		return nil, v1.Errorf(v1.EINVALID, "invalid k: %d", k)
 	}
+	if !metric.Valid() {
+		return nil, v1.Errorf(v1.EINVALID, "invalid metric: %v", metric)
+	}
+
 	embs := provider[emb].([]v1.Embedding)
+	idx := slices.IndexFunc(embs, func(e v1.Embedding) bool { return e.UID == eid })
+	if eid == "" || idx < 0 {
+		return nil, v1.Errorf(v1.ENOTFOUND, "embedding %q of provider %q not found", eid, uid)
+	}
 
-	prjs, err := projection.Compute(embs, prj)
+	nbrs, err := search.Nearest(embs, embs[idx].Values, k, metric, eid)
 	if err != nil {
-		return err
+		return nil, v1.Errorf(v1.EINVALID, "Search error: %v", err)
 	}
 
-	provider[proj] = prjs
+	return nbrs, nil
+}
 
-	return nil
+// GetProviderSimilarities returns the pairwise similarity matrix of the embeddings
+// of the provider with the given uid selected either by their UIDs or by the request filter.
+// NOTE: the similarities are computed without holding the lock.
+// nolint:revive
+func (p *ProvidersService) GetProviderSimilarities(ctx context.Context, uid string, req *v1.SimilarityRequest) (*v1.Similarity, error) {
+	embs, err := p.getEmbeddings(uid)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	var selected []v1.Embedding
+	if len(req.UIDs) > 0 {
+		idx := make(map[string]int, len(embs))
+		for i, e := range embs {
+			idx[e.UID] = i
+		}
+		for _, eid := range req.UIDs {
+			i, ok := idx[eid]
+			if !ok {
+				return nil, v1.Errorf(v1.ENOTFOUND, "embedding %q of provider %q not found", eid, uid)
+			}
+			selected = append(selected, embs[i])
+		}
+	} else {
+		for _, e := range embs {
+			if req.Filter.Match(e.Metadata) {
+				selected = append(selected, e)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil, v1.Errorf(v1.EINVALID, "no embeddings of provider %q match the filter", uid)
+	}
+	if len(selected) > v1.MaxSimilarityEmbeddings {
+		return nil, v1.Errorf(v1.EINVALID, "%d embeddings of provider %q match the filter, max: %d", len(selected), uid, v1.MaxSimilarityEmbeddings)
+	}
+
+	metric := v1.DefaultSimilarityMetric
+	if req.Metric != "" {
+		metric = req.Metric
+	}
+	sim, err := search.Similarities(ctx, selected, metric, req.Reorder)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, v1.Errorf(v1.EINVALID, "Similarity error: %v", err)
+	}
+
+	return sim, nil
+}
+
+// UpdateProviderDedup sets the dedup policy of the provider with the given uid.
+// nolint:revive
+func (p *ProvidersService) UpdateProviderDedup(ctx context.Context, uid string, policy *v1.DedupPolicy) (*v1.DedupPolicy, error) {
+	p.db.Lock()
+	defer p.db.Unlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	newPolicy := *policy
+	provider[dedup] = &newPolicy
+
+	res := newPolicy
+	return &res, nil
+}
+
+// GetProviderDedup returns the dedup policy of the provider with the given uid.
+// nolint:revive
+func (p *ProvidersService) GetProviderDedup(ctx context.Context, uid string) (*v1.DedupPolicy, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	policy, ok := provider[dedup].(*v1.DedupPolicy)
+	if !ok {
+		return &v1.DedupPolicy{Mode: v1.DedupOff}, nil
+	}
+
+	res := *policy
+	return &res, nil
+}
+
+// GetProviderDuplicates returns the groups of duplicate embeddings
+// of the provider with the given uid detected using the given policy.
+// nolint:revive
+func (p *ProvidersService) GetProviderDuplicates(ctx context.Context, uid string, policy *v1.DedupPolicy) (*v1.Duplicates, error) {
+	p.db.RLock()
+	defer p.db.RUnlock()
+	if p.db.Closed {
+		return nil, v1.Errorf(v1.EINTERNAL, "%v", ErrDBClosed)
+	}
+
+	provider, ok := p.db.store[uid]
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "provider %q not found", uid)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+	if policy.Mode == v1.DedupOff {
+		return nil, v1.Errorf(v1.EINVALID, "invalid mode: %v", policy.Mode)
+	}
+
+	embs := provider[emb].([]v1.Embedding)
+	dups := &v1.Duplicates{
+		Mode:   policy.Mode,
+		Groups: [][]v1.Embedding{},
+	}
+	if policy.Mode == v1.DedupVector {
+		dups.Threshold = policy.MinSimilarity()
+	}
+	for _, g := range search.Duplicates(policy, embs) {
+		group := make([]v1.Embedding, 0, len(g))
+		for _, i := range g {
+			group = append(group, embs[i])
+		}
+		dups.Groups = append(dups.Groups, group)
+	}
+
+	return dups, nil
+}
+
+// providerDims returns the projection dimensions set in the provider metadata.
+func providerDims(provider map[string]any) []v1.Dim {
+	dims, _ := provider[meta].(*v1.Provider).Metadata[v1.DimsMetaKey].([]v1.Dim)
+	return dims
 }
 
-func getDimProjections(projections map[v1.Dim][]v1.Embedding, dim v1.Dim) []v1.Embedding {
-	newProjections := make([]v1.Embedding, len(projections[dim]))
-	copy(newProjections, projections[dim])
+func getDimProjections(projections map[string][]v1.Embedding, prj v1.Projection, dim v1.Dim) []v1.Embedding {
+	dimProjections := projections[v1.ProjectionKey(prj, dim)]
+	newProjections := make([]v1.Embedding, len(dimProjections))
+	copy(newProjections, dimProjections)
 	return newProjections
 }
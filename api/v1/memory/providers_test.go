@@ -2,9 +2,13 @@ package memory
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	v1 "github.com/milosgajdos/embeviz/api/v1"
 )
@@ -259,9 +263,9 @@ func TestGetProviderProjections(t *testing.T) {
 		proj2D = append(proj2D, v1.Embedding{})
 		proj3D = append(proj3D, v1.Embedding{})
 	}
-	ps.db.store[p.UID][proj] = map[v1.Dim][]v1.Embedding{
-		v1.Dim2D: proj2D,
-		v1.Dim3D: proj3D,
+	ps.db.store[p.UID][proj] = map[string][]v1.Embedding{
+		v1.ProjectionKey(v1.PCA, v1.Dim2D): proj2D,
+		v1.ProjectionKey(v1.PCA, v1.Dim3D): proj3D,
 	}
 
 	dim2D := v1.Dim2D
@@ -299,7 +303,7 @@ func TestGetProviderProjections(t *testing.T) {
 
 			// NOTE: we seed the data so we expect the filter seeds to be retrurned
 			// and they are guaranteed to have some projections
-			pxRes := len(px[v1.Dim2D])
+			pxRes := len(px.Embeddings[v1.Dim2D])
 
 			if tc.expRes != pxRes {
 				t.Errorf("expected results: %d, got: %d", tc.expRes, pxRes)
@@ -339,7 +343,7 @@ func TestUpdateProviderEmbeddings(t *testing.T) {
 			{Values: []float64{1.0, 2.0, 3.0, 4.0}},
 		}
 
-		ex, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA)
+		ex, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil)
 		if err != nil {
 			t.Fatalf("expected error: %s", err)
 		}
@@ -351,10 +355,308 @@ func TestUpdateProviderEmbeddings(t *testing.T) {
 		}
 	})
 
+	t.Run("Options", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		p, err := ps.AddProvider(context.TODO(), "foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		embs := []v1.Embedding{
+			{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+			{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+		}
+
+		seed := int64(10)
+		opts := &v1.ProjectionOptions{Seed: &seed, Center: true}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(px.Options, opts) {
+			t.Fatalf("expected options: %v, got: %v", opts, px.Options)
+		}
+
+		// NOTE: the seed must be recorded if it's not provided
+		if err := ps.ComputeProviderProjections(context.TODO(), p.UID, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+		px, _, err = ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if px.Options == nil || px.Options.Seed == nil {
+			t.Fatalf("expected recorded seed, got: %v", px.Options)
+		}
+	})
+
+	t.Run("Incremental", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		p, err := ps.AddProvider(context.TODO(), "foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		embs := []v1.Embedding{
+			{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+			{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+			{Values: []float64{4.0, 3.0, 2.0, 1.0}},
+			{Values: []float64{3.0, 4.0, 1.0, 2.0}},
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: a single embedding does not exceed the default staleness
+		newEmbs := []v1.Embedding{
+			{Values: []float64{1.0, 1.0, 1.0, 1.0}},
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, newEmbs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+		newPx, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for dim, dimProjs := range px.Embeddings {
+			if exp, got := len(dimProjs)+len(newEmbs), len(newPx.Embeddings[dim]); exp != got {
+				t.Fatalf("expected %s projections: %d, got: %d", dim, exp, got)
+			}
+			for i := range dimProjs {
+				if !reflect.DeepEqual(dimProjs[i].Values, newPx.Embeddings[dim][i].Values) {
+					t.Fatalf("expected %s projection: %v, got: %v", dim, dimProjs[i].Values, newPx.Embeddings[dim][i].Values)
+				}
+			}
+		}
+	})
+
+	t.Run("SideBySide", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		p, err := ps.AddProvider(context.TODO(), "foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		embs := []v1.Embedding{
+			{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+			{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+			{Values: []float64{4.0, 3.0, 2.0, 1.0}},
+			{Values: []float64{3.0, 4.0, 1.0, 2.0}},
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+		pcaPx, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts := &v1.ProjectionOptions{Perplexity: 1, MaxIterations: 10}
+		if err := ps.ComputeProviderProjections(context.TODO(), p.UID, v1.TSNE, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: the last computed projections are returned by default
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if px.Projection != v1.TSNE {
+			t.Fatalf("expected projection: %s, got: %s", v1.TSNE, px.Projection)
+		}
+
+		pca := v1.PCA
+		px, _, err = ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{Projection: &pca})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(px, pcaPx) {
+			t.Fatalf("expected projections: %v, got: %v", pcaPx, px)
+		}
+
+		// new embeddings extend all the stored projections
+		newEmbs := []v1.Embedding{
+			{Values: []float64{1.0, 1.0, 1.0, 1.0}},
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, newEmbs, v1.TSNE, opts); err != nil {
+			t.Fatal(err)
+		}
+		for _, prj := range []v1.Projection{v1.PCA, v1.TSNE} {
+			prj := prj
+			px, _, err = ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{Projection: &prj})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for dim, dimProjs := range px.Embeddings {
+				if exp, got := len(embs)+len(newEmbs), len(dimProjs); exp != got {
+					t.Fatalf("expected %s/%s projections: %d, got: %d", prj, dim, exp, got)
+				}
+			}
+		}
+	})
+
+	t.Run("Dims", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		p, err := ps.AddProvider(context.TODO(), "foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		embs := []v1.Embedding{
+			{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+			{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+			{Values: []float64{4.0, 3.0, 2.0, 1.0}},
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		dims := []v1.Dim{v1.Dim1D, v1.Dim3D}
+		if err := ps.ComputeProviderProjections(context.TODO(), p.UID, v1.PCA, &v1.ProjectionOptions{Dims: dims}); err != nil {
+			t.Fatal(err)
+		}
+
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(px.Embeddings) != len(dims) {
+			t.Fatalf("expected projections of %d dims, got: %d", len(dims), len(px.Embeddings))
+		}
+		for _, dim := range dims {
+			if got := len(px.Embeddings[dim]); got != len(embs) {
+				t.Fatalf("expected %s projections: %d, got: %d", dim, len(embs), got)
+			}
+		}
+		// NOTE: recomputing projections drops the projections of other dimensions
+		if _, ok := ps.db.store[p.UID][proj].(map[string][]v1.Embedding)[v1.ProjectionKey(v1.PCA, v1.Dim2D)]; ok {
+			t.Fatalf("unexpected %s projections", v1.Dim2D)
+		}
+
+		dim := v1.Dim2D
+		if _, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{Dim: &dim}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %v, got: %v", v1.EINVALID, err)
+		}
+
+		// NOTE: providers can configure the projection dimensions
+		p, err = ps.AddProvider(context.TODO(), "bar", map[string]any{v1.DimsMetaKey: []v1.Dim{v1.Dim1D, v1.NewDim(10)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.TSNE, &v1.ProjectionOptions{MaxIterations: 10}); err != nil {
+			t.Fatal(err)
+		}
+		px, _, err = ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// t-SNE does not support 10D projections
+		if len(px.Embeddings) != 1 || len(px.Embeddings[v1.Dim1D]) != len(embs) {
+			t.Fatalf("expected %s projections only, got: %v", v1.Dim1D, px.Embeddings)
+		}
+	})
+
+	t.Run("Metrics", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		p, err := ps.AddProvider(context.TODO(), "foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		embs := []v1.Embedding{
+			{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+			{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+			{Values: []float64{4.0, 3.0, 2.0, 1.0}},
+			{Values: []float64{3.0, 4.0, 1.0, 2.0}},
+			{Values: []float64{1.0, 1.0, 1.0, 1.0}},
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		dim := v1.Dim2D
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{Dim: &dim})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(px.Metrics) != 1 {
+			t.Fatalf("expected metrics for %d dims, got: %d", 1, len(px.Metrics))
+		}
+		m, ok := px.Metrics[dim]
+		if !ok {
+			t.Fatalf("expected %s metrics", dim)
+		}
+		if m.K < 1 {
+			t.Fatalf("expected positive neighbourhood size, got: %d", m.K)
+		}
+		if exp, got := 2, len(m.ExplainedVariance); exp != got {
+			t.Fatalf("expected explained variance of %d components, got: %d", exp, got)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		p, err := ps.AddProvider(context.TODO(), "foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		embs := []v1.Embedding{
+			{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+			{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+			{Values: []float64{4.0, 3.0, 2.0, 1.0}},
+			{Values: []float64{3.0, 4.0, 1.0, 2.0}},
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: the embeddings are updated while they're being projected
+		// which must not block as the projections are computed without the lock.
+		update := func() context.Context {
+			var once sync.Once
+			return v1.WithProgress(context.TODO(), func(float64) {
+				once.Do(func() {
+					if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs[:1], v1.PCA, nil); err != nil {
+						t.Error(err)
+					}
+				})
+			})
+		}
+		if _, err := ps.UpdateProviderEmbeddings(update(), p.UID, embs[:1], v1.MDS, nil); v1.ErrorCode(err) != v1.ECONFLICT {
+			t.Fatalf("expected error: %s, got: %v", v1.ECONFLICT, err)
+		}
+		if err := ps.ComputeProviderProjections(update(), p.UID, v1.MDS, nil); v1.ErrorCode(err) != v1.ECONFLICT {
+			t.Fatalf("expected error: %s, got: %v", v1.ECONFLICT, err)
+		}
+
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if px.Projection != v1.PCA {
+			t.Fatalf("expected projection: %s, got: %s", v1.PCA, px.Projection)
+		}
+	})
+
 	t.Run("NotFound", func(t *testing.T) {
 		ps := MustProvidersService(t, DSN)
 
-		_, err := ps.UpdateProviderEmbeddings(context.TODO(), "fooUID", []v1.Embedding{}, v1.PCA)
+		_, err := ps.UpdateProviderEmbeddings(context.TODO(), "fooUID", []v1.Embedding{}, v1.PCA, nil)
 		if v1.ErrorCode(err) != v1.ENOTFOUND {
 			t.Fatalf("expected error: %s, got: %s", v1.ENOTFOUND, v1.ErrorCode(err))
 		}
@@ -397,11 +699,11 @@ func TestDropProviderEmbeddings(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if dim2D := len(px[v1.Dim2D]); dim2D != 0 {
+		if dim2D := len(px.Embeddings[v1.Dim2D]); dim2D != 0 {
 			t.Fatalf("expected no projections, got: %d", dim2D)
 		}
 
-		if dim3D := len(px[v1.Dim3D]); dim3D != 0 {
+		if dim3D := len(px.Embeddings[v1.Dim3D]); dim3D != 0 {
 			t.Fatalf("expected no projections, got: %d", dim3D)
 		}
 
@@ -441,3 +743,1122 @@ func TestDropProviderEmbeddings(t *testing.T) {
 		}
 	})
 }
+
+func TestProviderViews(t *testing.T) {
+	embs := []v1.Embedding{
+		{UID: "a", Values: []float64{1.0, 2.0, 3.0, 4.0}, Metadata: map[string]any{v1.LabelMetaKey: "foo: text", v1.RawLabelMetaKey: "foo", "n": 1}},
+		{UID: "b", Values: []float64{2.0, 1.0, 4.0, 3.0}, Metadata: map[string]any{v1.LabelMetaKey: "foo: text", v1.RawLabelMetaKey: "foo", "n": 2}},
+		{UID: "c", Values: []float64{4.0, 3.0, 2.0, 1.0}, Metadata: map[string]any{v1.LabelMetaKey: "foo: text", v1.RawLabelMetaKey: "foo", "n": 2}},
+		{UID: "d", Values: []float64{3.0, 4.0, 1.0, 2.0}, Metadata: map[string]any{v1.LabelMetaKey: "bar: text", v1.RawLabelMetaKey: "bar", "n": 2}},
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		p, err := ps.AddProvider(context.TODO(), "foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		filter := &v1.EmbeddingsFilter{Label: "foo", Metadata: map[string]any{"n": 2.0}}
+		if err := ps.ComputeProviderView(context.TODO(), p.UID, "foo", filter, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+		if err := ps.ComputeProviderView(context.TODO(), p.UID, "all", nil, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		views, err := ps.GetProviderViews(context.TODO(), p.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(views) != 2 || views[0].Name != "all" || views[1].Name != "foo" {
+			t.Fatalf("unexpected views: %v", views)
+		}
+		if views[0].Count != len(embs) || views[1].Count != 2 {
+			t.Fatalf("unexpected view counts: %d, %d", views[0].Count, views[1].Count)
+		}
+		for _, view := range views {
+			if view.Embeddings != nil {
+				t.Fatalf("unexpected %s view embeddings", view.Name)
+			}
+		}
+
+		dim := v1.Dim2D
+		view, page, err := ps.GetProviderView(context.TODO(), p.UID, "foo", v1.ProviderFilter{Dim: &dim})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(view.Embeddings) != 1 || *page.Count != 2 {
+			t.Fatalf("expected 2 %s view projections, got: %v", dim, view.Embeddings)
+		}
+		for _, e := range view.Embeddings[dim] {
+			if e.UID != "b" && e.UID != "c" {
+				t.Fatalf("unexpected view embedding: %s", e.UID)
+			}
+		}
+
+		// NOTE: views do not replace the provider projections
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{Dim: &dim})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(px.Embeddings[dim]); got != len(embs) {
+			t.Fatalf("expected %s projections: %d, got: %d", dim, len(embs), got)
+		}
+
+		if err := ps.DropProviderView(context.TODO(), p.UID, "foo"); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := ps.GetProviderView(context.TODO(), p.UID, "foo", v1.ProviderFilter{}); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+
+		if err := ps.DropProviderEmbeddings(context.TODO(), p.UID); err != nil {
+			t.Fatal(err)
+		}
+		views, err = ps.GetProviderViews(context.TODO(), p.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(views) != 0 {
+			t.Fatalf("expected no views, got: %d", len(views))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		p, err := ps.AddProvider(context.TODO(), "foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		filter := &v1.EmbeddingsFilter{Label: "baz"}
+		if err := ps.ComputeProviderView(context.TODO(), p.UID, "baz", filter, v1.PCA, nil); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+
+		opts := &v1.ProjectionOptions{Dims: []v1.Dim{v1.Dim2D}}
+		if err := ps.ComputeProviderView(context.TODO(), p.UID, "foo", nil, v1.PCA, opts); err != nil {
+			t.Fatal(err)
+		}
+		dim := v1.Dim3D
+		if _, _, err := ps.GetProviderView(context.TODO(), p.UID, "foo", v1.ProviderFilter{Dim: &dim}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		p, err := ps.AddProvider(context.TODO(), "foo", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: the embeddings are updated while the view is being projected
+		var once sync.Once
+		ctx := v1.WithProgress(context.TODO(), func(float64) {
+			once.Do(func() {
+				if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs[:1], v1.PCA, nil); err != nil {
+					t.Error(err)
+				}
+			})
+		})
+		if err := ps.ComputeProviderView(ctx, p.UID, "foo", nil, v1.MDS, nil); v1.ErrorCode(err) != v1.ECONFLICT {
+			t.Fatalf("expected error: %s, got: %v", v1.ECONFLICT, err)
+		}
+		if _, _, err := ps.GetProviderView(context.TODO(), p.UID, "foo", v1.ProviderFilter{}); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		ps := MustProvidersService(t, DSN)
+
+		if err := ps.ComputeProviderView(context.TODO(), "fooUID", "foo", nil, v1.PCA, nil); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+		if _, err := ps.GetProviderViews(context.TODO(), "fooUID"); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+		if err := ps.DropProviderView(context.TODO(), "fooUID", "foo"); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestAlignProviderProjections(t *testing.T) {
+	ps := MustProvidersService(t, DSN)
+
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 2.0, 3.0, 4.0}, Metadata: map[string]any{v1.LabelMetaKey: "a"}},
+		{Values: []float64{2.0, 1.0, 4.0, 3.0}, Metadata: map[string]any{v1.LabelMetaKey: "b"}},
+		{Values: []float64{4.0, 3.0, 2.0, 1.0}, Metadata: map[string]any{v1.LabelMetaKey: "c"}},
+		{Values: []float64{3.0, 4.0, 1.0, 2.0}, Metadata: map[string]any{v1.LabelMetaKey: "d"}},
+		{Values: []float64{1.0, 1.0, 1.0, 1.0}, Metadata: map[string]any{v1.LabelMetaKey: "e"}},
+	}
+	// NOTE: flipped embeddings yield flipped projections
+	flipped := make([]v1.Embedding, len(embs))
+	for i, e := range embs {
+		values := make([]float64, len(e.Values))
+		for k, v := range e.Values {
+			values[k] = -2 * v
+		}
+		flipped[len(embs)-1-i] = v1.Embedding{Values: values, Metadata: e.Metadata}
+	}
+
+	ref, err := ps.AddProvider(context.TODO(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.UpdateProviderEmbeddings(context.TODO(), ref.UID, embs, v1.PCA, nil); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ps.AddProvider(context.TODO(), "bar", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, flipped, v1.PCA, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		res, err := ps.AlignProviderProjections(context.TODO(), p.UID, ref.UID, v1.PCA, v1.Dim2D, v1.LabelMetaKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Matched != len(embs) {
+			t.Fatalf("expected matched: %d, got: %d", len(embs), res.Matched)
+		}
+		if res.Disparity > 1e-9 {
+			t.Fatalf("expected zero disparity, got: %v", res.Disparity)
+		}
+		if len(res.Embeddings) != len(flipped) {
+			t.Fatalf("expected aligned projections: %d, got: %d", len(flipped), len(res.Embeddings))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := ps.AlignProviderProjections(context.TODO(), p.UID, ref.UID, v1.PCA, v1.Dim2D, "foo"); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+		if _, err := ps.AlignProviderProjections(context.TODO(), p.UID, ref.UID, v1.TSNE, v1.Dim2D, v1.LabelMetaKey); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.AlignProviderProjections(context.TODO(), p.UID, "fooUID", v1.PCA, v1.Dim2D, v1.LabelMetaKey); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestPlaceProviderEmbeddings(t *testing.T) {
+	ps := MustProvidersService(t, DSN)
+
+	p, err := ps.AddProvider(context.TODO(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+		{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+		{Values: []float64{4.0, 3.0, 2.0, 1.0}},
+		{Values: []float64{3.0, 4.0, 1.0, 2.0}},
+	}
+	if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+		t.Fatal(err)
+	}
+	opts := &v1.ProjectionOptions{Perplexity: 1, MaxIterations: 10}
+	if err := ps.ComputeProviderProjections(context.TODO(), p.UID, v1.TSNE, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := []v1.Embedding{{Values: stored[1].Values}}
+
+	// NOTE: the query matches a stored embedding so it must be placed onto its projection
+	for _, prj := range []v1.Projection{v1.PCA, v1.TSNE} {
+		t.Run(string(prj), func(t *testing.T) {
+			px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{Projection: &prj})
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := ps.PlaceProviderEmbeddings(context.TODO(), p.UID, query, v1.ProviderFilter{Projection: &prj})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Projection != prj {
+				t.Fatalf("expected projection: %s, got: %s", prj, res.Projection)
+			}
+			for dim, dimProjs := range px.Embeddings {
+				if len(res.Embeddings[dim]) != 1 {
+					t.Fatalf("expected 1 %s projection, got: %d", dim, len(res.Embeddings[dim]))
+				}
+				// NOTE: projections are stored in the same order as embeddings
+				for k, v := range dimProjs[1].Values {
+					if math.Abs(res.Embeddings[dim][0].Values[k]-v) > 1e-9 {
+						t.Fatalf("expected %s projection: %v, got: %v", dim, dimProjs[1].Values, res.Embeddings[dim][0].Values)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("Active", func(t *testing.T) {
+		dim := v1.Dim2D
+		res, err := ps.PlaceProviderEmbeddings(context.TODO(), p.UID, query, v1.ProviderFilter{Dim: &dim})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Projection != v1.TSNE {
+			t.Fatalf("expected projection: %s, got: %s", v1.TSNE, res.Projection)
+		}
+		if len(res.Embeddings) != 1 || len(res.Embeddings[dim]) != 1 {
+			t.Fatalf("expected a single %s projection, got: %v", dim, res.Embeddings)
+		}
+	})
+
+	t.Run("NotStored", func(t *testing.T) {
+		res, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != len(embs) {
+			t.Fatalf("expected embeddings: %d, got: %d", len(embs), len(res))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		prj := v1.UMAP
+		if _, err := ps.PlaceProviderEmbeddings(context.TODO(), p.UID, query, v1.ProviderFilter{Projection: &prj}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.PlaceProviderEmbeddings(context.TODO(), "fooUID", query, v1.ProviderFilter{}); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestProviderMatrix(t *testing.T) {
+	ps := MustProvidersService(t, DSN)
+
+	p, err := ps.AddProvider(context.TODO(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &v1.ProjectionMatrix{
+		Name:   "foo",
+		Values: [][]float64{{1, 0}, {0, 1}, {0, 0}, {0, 0}},
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.GetProviderMatrix(context.TODO(), p.UID); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+		if _, err := ps.UpdateProviderMatrix(context.TODO(), "fooUID", m); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+		if err := ps.DropProviderMatrix(context.TODO(), p.UID); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+
+	t.Run("Versions", func(t *testing.T) {
+		res, err := ps.UpdateProviderMatrix(context.TODO(), p.UID, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Version != 1 {
+			t.Fatalf("expected version: 1, got: %d", res.Version)
+		}
+
+		// NOTE: the replaced version must match the stored version
+		if _, err := ps.UpdateProviderMatrix(context.TODO(), p.UID, &v1.ProjectionMatrix{Version: 2, Values: m.Values}); v1.ErrorCode(err) != v1.ECONFLICT {
+			t.Fatalf("expected error: %s, got: %v", v1.ECONFLICT, err)
+		}
+		if res, err = ps.UpdateProviderMatrix(context.TODO(), p.UID, &v1.ProjectionMatrix{Version: 1, Name: "bar", Values: m.Values}); err != nil {
+			t.Fatal(err)
+		}
+		if res.Version != 2 {
+			t.Fatalf("expected version: 2, got: %d", res.Version)
+		}
+
+		got, err := ps.GetProviderMatrix(context.TODO(), p.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Version != 2 || got.Name != "bar" {
+			t.Fatalf("unexpected matrix: %#v", got)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := ps.UpdateProviderMatrix(context.TODO(), p.UID, &v1.ProjectionMatrix{}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("Projection", func(t *testing.T) {
+		embs := []v1.Embedding{
+			{Values: []float64{1.0, 2.0, 3.0, 4.0}},
+			{Values: []float64{2.0, 1.0, 4.0, 3.0}},
+		}
+		cur, err := ps.GetProviderMatrix(context.TODO(), p.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := &v1.ProjectionOptions{Matrix: cur, Dims: []v1.Dim{v1.Dim2D}}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.Matrix, opts); err != nil {
+			t.Fatal(err)
+		}
+
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if px.Projection != v1.Matrix || px.Options.Matrix.Version != cur.Version {
+			t.Fatalf("unexpected projection: %s, options: %#v", px.Projection, px.Options)
+		}
+		for i, e := range px.Embeddings[v1.Dim2D] {
+			if !reflect.DeepEqual(e.Values, embs[i].Values[:2]) {
+				t.Fatalf("expected projection: %v, got: %v", embs[i].Values[:2], e.Values)
+			}
+		}
+
+		// NOTE: the matrix is not dropped along with the embeddings
+		if err := ps.DropProviderEmbeddings(context.TODO(), p.UID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.GetProviderMatrix(context.TODO(), p.UID); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		if err := ps.DropProviderMatrix(context.TODO(), p.UID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.GetProviderMatrix(context.TODO(), p.UID); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestClusterProviderEmbeddings(t *testing.T) {
+	ps := MustProvidersService(t, DSN)
+
+	p, err := ps.AddProvider(context.TODO(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embs := []v1.Embedding{
+		{Values: []float64{0.0, 0.0, 0.0, 0.1}, Metadata: map[string]any{"label": "a"}},
+		{Values: []float64{0.1, 0.0, 0.0, 0.0}, Metadata: map[string]any{"label": "b"}},
+		{Values: []float64{5.0, 5.0, 5.0, 5.1}, Metadata: map[string]any{"label": "c"}},
+		{Values: []float64{5.1, 5.0, 5.0, 5.0}, Metadata: map[string]any{"label": "d"}},
+	}
+	if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		before, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		seed := int64(1)
+		res, err := ps.ClusterProviderEmbeddings(context.TODO(), p.UID, &v1.ClusterOptions{K: 2, Seed: &seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Centroids) != 2 || !reflect.DeepEqual(res.Sizes, []int{2, 2}) {
+			t.Fatalf("unexpected clusters: %v, sizes: %v", res.Centroids, res.Sizes)
+		}
+
+		stored, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, e := range stored {
+			c, ok := e.Metadata[v1.ClusterMetaKey]
+			if !ok {
+				t.Fatalf("embedding %d missing cluster metadata: %v", i, e.Metadata)
+			}
+			if c != stored[i/2*2].Metadata[v1.ClusterMetaKey] {
+				t.Fatalf("embedding %d cluster: %v, expected: %v", i, c, stored[i/2*2].Metadata[v1.ClusterMetaKey])
+			}
+			if _, ok := e.Metadata[v1.ClusterDistanceMetaKey]; !ok {
+				t.Fatalf("embedding %d missing cluster distance metadata: %v", i, e.Metadata)
+			}
+			// NOTE: the embeddings returned earlier must not change under their callers
+			if _, ok := before[i].Metadata[v1.ClusterMetaKey]; ok {
+				t.Fatalf("embedding %d returned before clustering was modified: %v", i, before[i].Metadata)
+			}
+			// NOTE: projections can be coloured by cluster
+			for dim, dimProjs := range px.Embeddings {
+				if dimProjs[i].Metadata[v1.ClusterMetaKey] != c {
+					t.Fatalf("%s projection %d cluster: %v, expected: %v", dim, i, dimProjs[i].Metadata[v1.ClusterMetaKey], c)
+				}
+			}
+		}
+	})
+
+	t.Run("Noise", func(t *testing.T) {
+		q, err := ps.AddProvider(context.TODO(), "bar", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outlier := v1.Embedding{Values: []float64{50.0, -50.0, 50.0, -50.0}}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), q.UID, append(append([]v1.Embedding{}, embs...), outlier), v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := ps.ClusterProviderEmbeddings(context.TODO(), q.UID, &v1.ClusterOptions{Algorithm: v1.DBSCAN, Eps: 1, MinPoints: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.K != 2 || res.Noise != 1 {
+			t.Fatalf("expected 2 clusters and 1 noise embedding, got: %d clusters, %d noise", res.K, res.Noise)
+		}
+
+		stored, _, err := ps.GetProviderEmbeddings(context.TODO(), q.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, e := range stored {
+			exp := any(stored[i/2*2].Metadata[v1.ClusterMetaKey])
+			if i == len(embs) {
+				exp = v1.NoiseCluster
+			}
+			if c := e.Metadata[v1.ClusterMetaKey]; c != exp {
+				t.Fatalf("embedding %d cluster: %v, expected: %v", i, c, exp)
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := ps.ClusterProviderEmbeddings(context.TODO(), p.UID, &v1.ClusterOptions{K: len(embs) + 1}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+		if _, err := ps.ClusterProviderEmbeddings(context.TODO(), p.UID, &v1.ClusterOptions{}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		q, err := ps.AddProvider(context.TODO(), "baz", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), q.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: the embeddings are updated while they're being clustered
+		ctx := v1.WithProgress(context.TODO(), func(float64) {
+			if _, err := ps.UpdateProviderEmbeddings(context.TODO(), q.UID, embs[:1], v1.PCA, nil); err != nil {
+				t.Error(err)
+			}
+		})
+		if _, err := ps.ClusterProviderEmbeddings(ctx, q.UID, &v1.ClusterOptions{K: 2}); v1.ErrorCode(err) != v1.ECONFLICT {
+			t.Fatalf("expected error: %s, got: %v", v1.ECONFLICT, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.ClusterProviderEmbeddings(context.TODO(), "fooUID", &v1.ClusterOptions{K: 2}); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestSearchProviderEmbeddings(t *testing.T) {
+	ps := MustProvidersService(t, DSN)
+
+	p, err := ps.AddProvider(context.TODO(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 0.0, 0.0, 0.0}, Metadata: map[string]any{"label": "x"}},
+		{Values: []float64{0.0, 1.0, 0.0, 0.0}, Metadata: map[string]any{"label": "y"}},
+		{Values: []float64{0.0, 0.0, 1.0, 0.0}, Metadata: map[string]any{"label": "z"}},
+		{Values: []float64{0.7, 0.7, 0.0, 0.0}, Metadata: map[string]any{"label": "xy"}},
+	}
+	if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		res, err := ps.SearchProviderEmbeddings(context.TODO(), p.UID, []float64{1.0, 0.1, 0.0, 0.0}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != 2 {
+			t.Fatalf("expected 2 results, got: %d", len(res))
+		}
+		for i, label := range []string{"x", "xy"} {
+			if res[i].Metadata["label"] != label {
+				t.Fatalf("result %d label: %v, expected: %v", i, res[i].Metadata["label"], label)
+			}
+		}
+		if res[0].Score < res[1].Score {
+			t.Fatalf("expected results sorted by score, got: %v, %v", res[0].Score, res[1].Score)
+		}
+
+		all, err := ps.SearchProviderEmbeddings(context.TODO(), p.UID, []float64{1.0, 0.1, 0.0, 0.0}, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != len(embs) {
+			t.Fatalf("expected %d results, got: %d", len(embs), len(all))
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := ps.SearchProviderEmbeddings(context.TODO(), p.UID, []float64{1.0}, 2); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+		if _, err := ps.SearchProviderEmbeddings(context.TODO(), p.UID, []float64{1.0, 0.0, 0.0, 0.0}, 0); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.SearchProviderEmbeddings(context.TODO(), "fooUID", []float64{1.0, 0.0, 0.0, 0.0}, 2); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestGetProviderEmbeddingNeighbours(t *testing.T) {
+	ps := MustProvidersService(t, DSN)
+
+	p, err := ps.AddProvider(context.TODO(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 0.0, 0.0, 0.0}},
+		{Values: []float64{10.0, 1.0, 0.0, 0.0}},
+		{Values: []float64{0.0, 1.0, 0.0, 0.0}},
+		{Values: []float64{2.0, 0.1, 0.0, 0.0}},
+	}
+	stored, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range stored {
+		if e.UID == "" {
+			t.Fatalf("embedding %d missing UID", i)
+		}
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		testCases := []struct {
+			metric v1.Metric
+			exp    []int
+		}{
+			{metric: v1.Cosine, exp: []int{3, 1}},
+			{metric: v1.Dot, exp: []int{1, 3}},
+			{metric: v1.Euclidean, exp: []int{3, 2}},
+		}
+		for _, tc := range testCases {
+			nbrs, err := ps.GetProviderEmbeddingNeighbours(context.TODO(), p.UID, stored[0].UID, 2, tc.metric)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, idx := range tc.exp {
+				if nbrs[i].UID != stored[idx].UID {
+					t.Fatalf("%s neighbour %d: %s, expected: %s", tc.metric, i, nbrs[i].UID, stored[idx].UID)
+				}
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := ps.GetProviderEmbeddingNeighbours(context.TODO(), p.UID, stored[0].UID, 2, "foo"); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+		if _, err := ps.GetProviderEmbeddingNeighbours(context.TODO(), p.UID, stored[0].UID, 0, v1.Cosine); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.GetProviderEmbeddingNeighbours(context.TODO(), "fooUID", stored[0].UID, 2, v1.Cosine); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+		if _, err := ps.GetProviderEmbeddingNeighbours(context.TODO(), p.UID, "fooUID", 2, v1.Cosine); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestGetProviderSimilarities(t *testing.T) {
+	ps := MustProvidersService(t, DSN)
+
+	p, err := ps.AddProvider(context.TODO(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 0.0, 0.0, 0.0}, Metadata: map[string]any{"group": "x"}},
+		{Values: []float64{0.0, 1.0, 0.0, 0.0}, Metadata: map[string]any{"group": "y"}},
+		{Values: []float64{2.0, 0.0, 0.0, 0.0}, Metadata: map[string]any{"group": "x"}},
+		{Values: []float64{0.0, 0.0, 1.0, 0.0}},
+	}
+	stored, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("UIDs", func(t *testing.T) {
+		req := &v1.SimilarityRequest{UIDs: []string{stored[2].UID, stored[0].UID, stored[1].UID}}
+		sim, err := ps.GetProviderSimilarities(context.TODO(), p.UID, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sim.UIDs, req.UIDs) {
+			t.Fatalf("expected uids: %v, got: %v", req.UIDs, sim.UIDs)
+		}
+		if sim.Metric != v1.DefaultSimilarityMetric {
+			t.Fatalf("expected metric: %s, got: %s", v1.DefaultSimilarityMetric, sim.Metric)
+		}
+		if sim.Values[0][1] != 1 || sim.Values[0][2] != 0 {
+			t.Fatalf("unexpected similarities: %v", sim.Values)
+		}
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		req := &v1.SimilarityRequest{Filter: &v1.EmbeddingsFilter{Metadata: map[string]any{"group": "x"}}, Metric: v1.Euclidean}
+		sim, err := ps.GetProviderSimilarities(context.TODO(), p.UID, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := []string{stored[0].UID, stored[2].UID}; !reflect.DeepEqual(sim.UIDs, exp) {
+			t.Fatalf("expected uids: %v, got: %v", exp, sim.UIDs)
+		}
+		if sim.Values[0][1] != 1 || sim.Values[0][0] != 0 {
+			t.Fatalf("unexpected distances: %v", sim.Values)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := ps.GetProviderSimilarities(context.TODO(), p.UID, &v1.SimilarityRequest{}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+		req := &v1.SimilarityRequest{Filter: &v1.EmbeddingsFilter{Label: "foo"}}
+		if _, err := ps.GetProviderSimilarities(context.TODO(), p.UID, req); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		req := &v1.SimilarityRequest{UIDs: []string{stored[0].UID}}
+		if _, err := ps.GetProviderSimilarities(context.TODO(), "fooUID", req); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+		req = &v1.SimilarityRequest{UIDs: []string{stored[0].UID, "fooUID"}}
+		if _, err := ps.GetProviderSimilarities(context.TODO(), p.UID, req); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestDetectProviderOutliers(t *testing.T) {
+	ps := MustProvidersService(t, DSN)
+
+	p, err := ps.AddProvider(context.TODO(), "foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embs := []v1.Embedding{
+		{Values: []float64{0.0, 0.0, 0.0, 0.1}},
+		{Values: []float64{0.1, 0.0, 0.0, 0.0}},
+		{Values: []float64{0.0, 0.1, 0.0, 0.0}},
+		{Values: []float64{0.0, 0.0, 0.1, 0.0}},
+		{Values: []float64{5.0, 5.0, 5.0, 5.0}},
+	}
+	stored, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		res, err := ps.DetectProviderOutliers(context.TODO(), p.UID, &v1.OutlierOptions{Method: v1.KNN, K: 2, Top: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Embeddings) != 1 || res.Embeddings[0].UID != stored[4].UID {
+			t.Fatalf("expected top outlier: %s, got: %v", stored[4].UID, res.Embeddings)
+		}
+
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		all, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, e := range all {
+			score, ok := e.Metadata[v1.OutlierMetaKey].(float64)
+			if !ok {
+				t.Fatalf("embedding %d missing outlier score: %v", i, e.Metadata)
+			}
+			if i < 4 && score >= res.Embeddings[0].Score {
+				t.Fatalf("embedding %d score %v exceeds the top outlier score: %v", i, score, res.Embeddings[0].Score)
+			}
+			// NOTE: projections can be coloured by outlier score
+			for dim, dimProjs := range px.Embeddings {
+				if dimProjs[i].Metadata[v1.OutlierMetaKey] != score {
+					t.Fatalf("%s projection %d score: %v, expected: %v", dim, i, dimProjs[i].Metadata[v1.OutlierMetaKey], score)
+				}
+			}
+		}
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		// NOTE: run with -race to detect the metadata of the returned embeddings
+		// being modified while the callers are encoding them.
+		done := make(chan struct{})
+		errs := make(chan error, 1)
+		go func() {
+			defer close(errs)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				embs, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+				if err != nil {
+					errs <- err
+					return
+				}
+				px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+				if err != nil {
+					errs <- err
+					return
+				}
+				if _, err := json.Marshal(embs); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := json.Marshal(px); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+
+		for start := time.Now(); time.Since(start) < 100*time.Millisecond; {
+			if _, err := ps.DetectProviderOutliers(context.TODO(), p.UID, &v1.OutlierOptions{K: 2}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		close(done)
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		q, err := ps.AddProvider(context.TODO(), "bar", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), q.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// NOTE: the embeddings are updated while they're being scored
+		var once sync.Once
+		ctx := v1.WithProgress(context.TODO(), func(float64) {
+			once.Do(func() {
+				if _, err := ps.UpdateProviderEmbeddings(context.TODO(), q.UID, embs[:1], v1.PCA, nil); err != nil {
+					t.Error(err)
+				}
+			})
+		})
+		if _, err := ps.DetectProviderOutliers(ctx, q.UID, &v1.OutlierOptions{K: 2}); v1.ErrorCode(err) != v1.ECONFLICT {
+			t.Fatalf("expected error: %s, got: %v", v1.ECONFLICT, err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := ps.DetectProviderOutliers(context.TODO(), p.UID, &v1.OutlierOptions{Method: "foo"}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.DetectProviderOutliers(context.TODO(), "fooUID", &v1.OutlierOptions{}); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestProviderDedup(t *testing.T) {
+	ps := MustProvidersService(t, DSN)
+
+	textEmb := func(text string, vals []float64, md map[string]any) v1.Embedding {
+		metadata := map[string]any{v1.TextHashMetaKey: v1.TextHash(text)}
+		for k, v := range md {
+			metadata[k] = v
+		}
+		return v1.Embedding{Values: vals, Metadata: metadata}
+	}
+	count := func(t *testing.T, uid string) int {
+		embs, _, err := ps.GetProviderEmbeddings(context.TODO(), uid, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		px, _, err := ps.GetProviderProjections(context.TODO(), uid, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for dim, dimProjs := range px.Embeddings {
+			if len(dimProjs) != len(embs) {
+				t.Fatalf("expected %d %s projections, got: %d", len(embs), dim, len(dimProjs))
+			}
+		}
+		return len(embs)
+	}
+
+	t.Run("Off", func(t *testing.T) {
+		p, err := ps.AddProvider(context.TODO(), "off", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		policy, err := ps.GetProviderDedup(context.TODO(), p.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy.Mode != v1.DedupOff {
+			t.Fatalf("expected mode: %s, got: %s", v1.DedupOff, policy.Mode)
+		}
+
+		embs := []v1.Embedding{
+			textEmb("foo", []float64{1, 2, 3, 4}, nil),
+			textEmb("foo", []float64{1, 2, 3, 4}, nil),
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+		if c := count(t, p.UID); c != 2 {
+			t.Fatalf("expected embeddings: 2, got: %d", c)
+		}
+	})
+
+	t.Run("TextSkip", func(t *testing.T) {
+		p, err := ps.AddProvider(context.TODO(), "skip", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderDedup(context.TODO(), p.UID, &v1.DedupPolicy{Mode: v1.DedupText}); err != nil {
+			t.Fatal(err)
+		}
+
+		embs := []v1.Embedding{
+			textEmb("foo", []float64{1, 2, 3, 4}, nil),
+			textEmb("bar", []float64{2, 1, 4, 3}, nil),
+			textEmb("baz", []float64{4, 3, 2, 1}, nil),
+			textEmb("foo", []float64{3, 4, 1, 2}, nil),
+		}
+		res, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != len(embs) || res[3].UID != res[0].UID {
+			t.Fatalf("expected the duplicate in place of embedding: %v", res)
+		}
+		if c := count(t, p.UID); c != 3 {
+			t.Fatalf("expected embeddings: 3, got: %d", c)
+		}
+
+		dup, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, []v1.Embedding{textEmb("bar", []float64{1, 1, 1, 1}, nil)}, v1.PCA, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dup) != 1 || dup[0].UID != res[1].UID {
+			t.Fatalf("expected the stored duplicate: %s, got: %v", res[1].UID, dup)
+		}
+		if c := count(t, p.UID); c != 3 {
+			t.Fatalf("expected embeddings: 3, got: %d", c)
+		}
+	})
+
+	t.Run("TextMerge", func(t *testing.T) {
+		p, err := ps.AddProvider(context.TODO(), "merge", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderDedup(context.TODO(), p.UID, &v1.DedupPolicy{Mode: v1.DedupText, Action: v1.DedupMerge}); err != nil {
+			t.Fatal(err)
+		}
+
+		embs := []v1.Embedding{
+			textEmb("foo", []float64{1, 2, 3, 4}, map[string]any{v1.LabelMetaKey: "a"}),
+			textEmb("bar", []float64{2, 1, 4, 3}, nil),
+			textEmb("baz", []float64{4, 3, 2, 1}, nil),
+		}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		dup := textEmb("foo", []float64{1, 2, 3, 4}, map[string]any{v1.ColorMetaKey: "red"})
+		res, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, []v1.Embedding{dup}, v1.PCA, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != 1 || res[0].Metadata[v1.LabelMetaKey] != "a" || res[0].Metadata[v1.ColorMetaKey] != "red" {
+			t.Fatalf("expected merged duplicate, got: %v", res)
+		}
+		if c := count(t, p.UID); c != 3 {
+			t.Fatalf("expected embeddings: 3, got: %d", c)
+		}
+
+		px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for dim, dimProjs := range px.Embeddings {
+			if dimProjs[0].Metadata[v1.ColorMetaKey] != "red" {
+				t.Fatalf("expected merged %s projection metadata, got: %v", dim, dimProjs[0].Metadata)
+			}
+		}
+	})
+
+	t.Run("Vector", func(t *testing.T) {
+		p, err := ps.AddProvider(context.TODO(), "vector", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderDedup(context.TODO(), p.UID, &v1.DedupPolicy{Mode: v1.DedupVector, Threshold: 0.999}); err != nil {
+			t.Fatal(err)
+		}
+
+		embs := []v1.Embedding{
+			{Values: []float64{1, 2, 3, 4}},
+			{Values: []float64{2, 1, 4, 3}},
+			{Values: []float64{4, 3, 2, 1}},
+			{Values: []float64{2, 4, 6, 8}},
+		}
+		res, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res[3].UID != res[0].UID {
+			t.Fatalf("expected the duplicate in place of embedding: %v", res)
+		}
+		if c := count(t, p.UID); c != 3 {
+			t.Fatalf("expected embeddings: 3, got: %d", c)
+		}
+
+		// NOTE: the policy is not derived from the embeddings so it survives dropping them
+		if err := ps.DropProviderEmbeddings(context.TODO(), p.UID); err != nil {
+			t.Fatal(err)
+		}
+		policy, err := ps.GetProviderDedup(context.TODO(), p.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy.Mode != v1.DedupVector || policy.Threshold != 0.999 {
+			t.Fatalf("unexpected policy: %#v", policy)
+		}
+	})
+
+	t.Run("Duplicates", func(t *testing.T) {
+		p, err := ps.AddProvider(context.TODO(), "duplicates", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		embs := []v1.Embedding{
+			textEmb("foo", []float64{1, 2, 3, 4}, nil),
+			textEmb("bar", []float64{2, 1, 4, 3}, nil),
+			textEmb("foo", []float64{4, 3, 2, 1}, nil),
+			textEmb("baz", []float64{2, 4, 6, 8}, nil),
+		}
+		stored, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, v1.PCA, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dups, err := ps.GetProviderDuplicates(context.TODO(), p.UID, &v1.DedupPolicy{Mode: v1.DedupText})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dups.Groups) != 1 || len(dups.Groups[0]) != 2 || dups.Groups[0][0].UID != stored[0].UID || dups.Groups[0][1].UID != stored[2].UID {
+			t.Fatalf("unexpected text duplicates: %v", dups.Groups)
+		}
+
+		dups, err = ps.GetProviderDuplicates(context.TODO(), p.UID, &v1.DedupPolicy{Mode: v1.DedupVector})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dups.Threshold != v1.DefaultDedupThreshold {
+			t.Fatalf("expected threshold: %v, got: %v", v1.DefaultDedupThreshold, dups.Threshold)
+		}
+		if len(dups.Groups) != 1 || len(dups.Groups[0]) != 2 || dups.Groups[0][0].UID != stored[0].UID || dups.Groups[0][1].UID != stored[3].UID {
+			t.Fatalf("unexpected vector duplicates: %v", dups.Groups)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		p, err := ps.AddProvider(context.TODO(), "invalid", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ps.UpdateProviderDedup(context.TODO(), p.UID, &v1.DedupPolicy{Mode: "foo"}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+		if _, err := ps.GetProviderDuplicates(context.TODO(), p.UID, &v1.DedupPolicy{Mode: v1.DedupOff}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.GetProviderDedup(context.TODO(), "fooUID"); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+		if _, err := ps.UpdateProviderDedup(context.TODO(), "fooUID", &v1.DedupPolicy{Mode: v1.DedupText}); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+		if _, err := ps.GetProviderDuplicates(context.TODO(), "fooUID", &v1.DedupPolicy{Mode: v1.DedupText}); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package v1
+
+import "fmt"
+
+// OutlierMethod is the method of outlier detection.
+type OutlierMethod string
+
+const (
+	// LOF scores embeddings by their local density relative to the density of their neighbours.
+	// https://en.wikipedia.org/wiki/Local_outlier_factor
+	LOF OutlierMethod = "lof"
+	// KNN scores embeddings by the distance to their k-th nearest neighbour.
+	KNN OutlierMethod = "knn"
+)
+
+const (
+	// OutlierMetaKey is the metadata key of the embedding outlier score.
+	OutlierMetaKey = "outlier_score"
+)
+
+const (
+	// DefaultOutlierNeighbours is the default number of neighbours used to score outliers.
+	DefaultOutlierNeighbours = 20
+	// DefaultOutlierTop is the default number of the top outliers returned.
+	DefaultOutlierTop = 10
+	// MaxOutlierTop is the maximum number of the top outliers returned.
+	MaxOutlierTop = 1000
+)
+
+// OutlierOptions are options of outlier detection.
+type OutlierOptions struct {
+	// Method of outlier detection.
+	// It defaults to LOF.
+	Method OutlierMethod `json:"method,omitempty"`
+	// K is the number of neighbours.
+	// It defaults to DefaultOutlierNeighbours.
+	K int `json:"k,omitempty"`
+	// Metric measuring the distance of embeddings.
+	// It defaults to Euclidean. Dot product is not a distance so it is not supported.
+	Metric Metric `json:"metric,omitempty"`
+	// Top is the number of the top outliers returned.
+	// It defaults to DefaultOutlierTop.
+	Top int `json:"top,omitempty"`
+}
+
+// Validate returns error if the options are invalid.
+func (o *OutlierOptions) Validate() error {
+	switch o.Method {
+	case "", LOF, KNN:
+	default:
+		return fmt.Errorf("invalid method: %v", o.Method)
+	}
+	if o.K < 0 {
+		return fmt.Errorf("invalid k: %d", o.K)
+	}
+	if o.Top < 0 || o.Top > MaxOutlierTop {
+		return fmt.Errorf("invalid top: %d, must be at most: %d", o.Top, MaxOutlierTop)
+	}
+	if o.Metric != "" && !o.Metric.Valid() {
+		return fmt.Errorf("invalid metric: %v", o.Metric)
+	}
+	// NOTE: dot product is a similarity rather than a distance
+	if o.Metric == Dot {
+		return fmt.Errorf("invalid metric: %v is not a distance", o.Metric)
+	}
+	return nil
+}
+
+// Outliers are the results of outlier detection.
+type Outliers struct {
+	// Method of outlier detection.
+	Method OutlierMethod `json:"method"`
+	// K is the number of neighbours.
+	K int `json:"k"`
+	// Metric measuring the distance of embeddings.
+	Metric Metric `json:"metric"`
+	// Embeddings with the highest outlier scores sorted from the highest score.
+	Embeddings []ScoredEmbedding `json:"embeddings"`
+}
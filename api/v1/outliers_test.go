@@ -0,0 +1,27 @@
+package v1
+
+import "testing"
+
+func TestOutlierOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		opts  OutlierOptions
+		valid bool
+	}{
+		{name: "Default", opts: OutlierOptions{}, valid: true},
+		{name: "KNN", opts: OutlierOptions{Method: KNN, K: 5, Metric: Cosine, Top: 20}, valid: true},
+		{name: "Method", opts: OutlierOptions{Method: "foo"}},
+		{name: "K", opts: OutlierOptions{K: -1}},
+		{name: "Top", opts: OutlierOptions{Top: MaxOutlierTop + 1}},
+		{name: "Metric", opts: OutlierOptions{Metric: "foo"}},
+		{name: "DotMetric", opts: OutlierOptions{Metric: Dot}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.opts.Validate(); (err == nil) != tc.valid {
+				t.Fatalf("expected valid: %v, got error: %v", tc.valid, err)
+			}
+		})
+	}
+}
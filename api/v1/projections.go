@@ -0,0 +1,180 @@
+package v1
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ProjectionParam describes a parameter of a projection algorithm.
+type ProjectionParam struct {
+	// Name of the parameter.
+	// It's either the JSON name of a ProjectionOptions field
+	// or the name of an algorithm specific ProjectionOptions parameter.
+	Name string `json:"name"`
+	// Type of the parameter value: number, integer, boolean, string or array.
+	Type string `json:"type"`
+	// Description of the parameter.
+	Description string `json:"description,omitempty"`
+	// Default value of the parameter.
+	Default any `json:"default,omitempty"`
+}
+
+// ProjectionAlgorithm describes a projection algorithm.
+type ProjectionAlgorithm struct {
+	// Name of the algorithm.
+	Name Projection `json:"name"`
+	// Description of the algorithm.
+	Description string `json:"description,omitempty"`
+	// Params of the algorithm.
+	Params []ProjectionParam `json:"params,omitempty"`
+	// MaxDim is the maximum projection dimension supported by the algorithm.
+	// If it's not set the algorithm supports all the projection dimensions.
+	MaxDim int `json:"max_dim,omitempty"`
+}
+
+// Supports returns true if the algorithm supports projection dimension dim.
+func (a ProjectionAlgorithm) Supports(dim Dim) bool {
+	return dim.Valid() && (a.MaxDim == 0 || dim.Size() <= a.MaxDim)
+}
+
+// Projector computes embeddings projections.
+type Projector interface {
+	// Algorithm describes the projection algorithm.
+	Algorithm() ProjectionAlgorithm
+	// Project projects embeddings embs to the given dimension and returns the projections
+	// in the same order as embs. opts are never nil and always have the seed set.
+	// It returns error if ctx is cancelled before the projections have been computed.
+	Project(ctx context.Context, embs []Embedding, dim Dim, opts *ProjectionOptions) ([]Embedding, error)
+}
+
+// ProjectionValidator is implemented by projectors
+// which validate their projection options.
+type ProjectionValidator interface {
+	// ValidateOptions returns error if opts are invalid.
+	ValidateOptions(opts *ProjectionOptions) error
+}
+
+var projectors = struct {
+	sync.RWMutex
+	m map[Projection]Projector
+}{
+	m: make(map[Projection]Projector),
+}
+
+// RegisterProjector registers projector p under the name of its algorithm.
+// Names must be lowercase and must not contain /.
+// It returns error if the name is invalid or if it has already been registered.
+func RegisterProjector(p Projector) error {
+	name := p.Algorithm().Name
+	// NOTE: projections are stored under keys which use / as a separator
+	if name == "" || strings.Contains(string(name), "/") || strings.ToLower(string(name)) != string(name) {
+		return Errorf(EINVALID, "invalid projection name: %q", name)
+	}
+
+	projectors.Lock()
+	defer projectors.Unlock()
+
+	if _, ok := projectors.m[name]; ok {
+		return Errorf(ECONFLICT, "projection %q already registered", name)
+	}
+	projectors.m[name] = p
+
+	return nil
+}
+
+// GetProjector returns the projector registered under the given name.
+func GetProjector(name Projection) (Projector, bool) {
+	projectors.RLock()
+	defer projectors.RUnlock()
+
+	p, ok := projectors.m[name]
+	return p, ok
+}
+
+// Projectors returns all registered projectors sorted by their names.
+func Projectors() []Projector {
+	projectors.RLock()
+	defer projectors.RUnlock()
+
+	px := make([]Projector, 0, len(projectors.m))
+	for _, p := range projectors.m {
+		px = append(px, p)
+	}
+	sort.Slice(px, func(i, j int) bool {
+		return px[i].Algorithm().Name < px[j].Algorithm().Name
+	})
+
+	return px
+}
+
+// ProjectionStep is an intermediate layout of an iterative projection algorithm.
+type ProjectionStep struct {
+	// Projection algorithm computing the layout.
+	Projection Projection `json:"projection"`
+	// Dim of the layout.
+	Dim Dim `json:"dim"`
+	// Iteration of the algorithm the layout was computed in.
+	Iteration int `json:"iteration"`
+	// Iterations is the total number of iterations of the algorithm.
+	Iterations int `json:"iterations"`
+	// KLDivergence of the layout.
+	KLDivergence float64 `json:"kl_divergence"`
+	// Embeddings are the intermediate projections.
+	// NOTE: they only carry UIDs and values.
+	Embeddings []Embedding `json:"embeddings"`
+}
+
+type stepsKey struct{}
+
+// stepObserver receives intermediate projection layouts.
+type stepObserver struct {
+	every int
+	fn    func(*ProjectionStep)
+}
+
+// WithSteps returns a copy of ctx which reports the intermediate layouts
+// of iterative projection algorithms to fn every given number of iterations.
+func WithSteps(ctx context.Context, every int, fn func(*ProjectionStep)) context.Context {
+	return context.WithValue(ctx, stepsKey{}, stepObserver{every: max(1, every), fn: fn})
+}
+
+// StepInterval returns the number of iterations between the layouts reported to ctx.
+// It returns 0 if ctx does not observe the intermediate layouts.
+func StepInterval(ctx context.Context) int {
+	if o, ok := ctx.Value(stepsKey{}).(stepObserver); ok {
+		return o.every
+	}
+	return 0
+}
+
+// ReportStep reports the intermediate layout step if ctx observes the intermediate layouts.
+func ReportStep(ctx context.Context, step *ProjectionStep) {
+	if o, ok := ctx.Value(stepsKey{}).(stepObserver); ok {
+		o.fn(step)
+	}
+}
+
+type stopKey struct{}
+
+// WithStop returns a copy of ctx which asks iterative projection algorithms
+// to stop early once the stop channel is closed.
+// Unlike cancelling ctx, stopping keeps the last reported intermediate layout as the result.
+func WithStop(ctx context.Context, stop <-chan struct{}) context.Context {
+	return context.WithValue(ctx, stopKey{}, stop)
+}
+
+// Stopped returns true if ctx has been asked to stop early.
+func Stopped(ctx context.Context) bool {
+	stop, ok := ctx.Value(stopKey{}).(<-chan struct{})
+	if !ok {
+		return false
+	}
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"context"
+	"testing"
+)
+
+type testProjector struct {
+	name Projection
+}
+
+func (p testProjector) Algorithm() ProjectionAlgorithm {
+	return ProjectionAlgorithm{Name: p.name}
+}
+
+func (p testProjector) Project(_ context.Context, embs []Embedding, _ Dim, _ *ProjectionOptions) ([]Embedding, error) {
+	return embs, nil
+}
+
+func TestRegisterProjector(t *testing.T) {
+	for _, name := range []Projection{"zzz", "aaa"} {
+		if err := RegisterProjector(testProjector{name: name}); err != nil {
+			t.Fatalf("failed to register projector %s: %v", name, err)
+		}
+	}
+
+	if _, ok := GetProjector("aaa"); !ok {
+		t.Fatal("expected registered projector")
+	}
+	if _, ok := GetProjector("foo"); ok {
+		t.Fatal("unexpected projector")
+	}
+
+	px := Projectors()
+	for i := 1; i < len(px); i++ {
+		if px[i-1].Algorithm().Name >= px[i].Algorithm().Name {
+			t.Fatalf("expected sorted projectors, got: %s before %s", px[i-1].Algorithm().Name, px[i].Algorithm().Name)
+		}
+	}
+
+	t.Run("Conflict", func(t *testing.T) {
+		err := RegisterProjector(testProjector{name: "aaa"})
+		if code := ErrorCode(err); code != ECONFLICT {
+			t.Fatalf("expected error: %s, got: %s", ECONFLICT, code)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, name := range []Projection{"", "foo/bar", "Foo"} {
+			err := RegisterProjector(testProjector{name: name})
+			if code := ErrorCode(err); code != EINVALID {
+				t.Fatalf("expected %q error: %s, got: %s", name, EINVALID, code)
+			}
+		}
+	})
+}
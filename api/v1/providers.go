@@ -1,6 +1,12 @@
 package v1
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+)
 
 // Provider for embeddings.
 type Provider struct {
@@ -30,12 +36,40 @@ type Embedding struct {
 type Dim string
 
 const (
+	// Dim1D is 1D projection.
+	Dim1D Dim = "1D"
 	// Dim2D is 2D projection.
 	Dim2D Dim = "2D"
 	// Dim3D is 3D projection.
 	Dim3D Dim = "3D"
 )
 
+// MaxDim is the maximum projection dimension.
+const MaxDim = 64
+
+// DefaultDims are the projection dimensions computed by default.
+var DefaultDims = []Dim{Dim2D, Dim3D}
+
+// NewDim returns projection dimension of the given size, e.g. 10D.
+func NewDim(size int) Dim {
+	return Dim(strconv.Itoa(size) + "D")
+}
+
+// Size returns the number of components of the projection dimension.
+// It returns 0 if d is not a valid projection dimension.
+func (d Dim) Size() int {
+	n, err := strconv.Atoi(strings.TrimSuffix(string(d), "D"))
+	if err != nil || n < 1 || n > MaxDim || NewDim(n) != d {
+		return 0
+	}
+	return n
+}
+
+// Valid returns true if d is a valid projection dimension.
+func (d Dim) Valid() bool {
+	return d.Size() > 0
+}
+
 // Projection algorithm.
 type Projection string
 
@@ -46,12 +80,51 @@ const (
 	// PCA projection
 	// https://en.wikipedia.org/wiki/Principal_component_analysis
 	PCA Projection = "pca"
+	// UMAP projection
+	// https://umap-learn.readthedocs.io/en/latest/how_umap_works.html
+	UMAP Projection = "umap"
+	// MDS is classical multidimensional scaling projection
+	// https://en.wikipedia.org/wiki/Multidimensional_scaling#Classical_multidimensional_scaling
+	MDS Projection = "mds"
+	// Random is Johnson-Lindenstrauss random projection
+	// https://en.wikipedia.org/wiki/Random_projection
+	Random Projection = "random"
+	// Axes projects embeddings onto semantic axes defined by pairs of anchors
+	// https://en.wikipedia.org/wiki/Vector_projection#Scalar_projection
+	Axes Projection = "axes"
+	// Matrix projects embeddings using a user-supplied projection matrix
+	Matrix Projection = "matrix"
 )
 
+// Metric measures (dis)similarity of embeddings.
+type Metric string
+
+const (
+	// Cosine metric.
+	Cosine Metric = "cosine"
+	// Dot product metric.
+	Dot Metric = "dot"
+	// Euclidean metric.
+	Euclidean Metric = "euclidean"
+)
+
+// Valid returns true if m is a known metric.
+func (m Metric) Valid() bool {
+	return m == Cosine || m == Dot || m == Euclidean
+}
+
+// ProjectionKey returns the key projections p of dimension dim are stored under.
+// Keying projections by both algorithm and dimension, e.g. pca/2D, allows to store
+// projections computed by different algorithms side by side.
+func ProjectionKey(p Projection, dim Dim) string {
+	return string(p) + "/" + string(dim)
+}
+
 // ProviderFilter is used for filtering providers.
 type ProviderFilter struct {
 	// Filtering fields.
-	Dim *Dim `json:"dim"`
+	Dim        *Dim        `json:"dim"`
+	Projection *Projection `json:"projection"`
 	// Restrict to subset of range.
 	Offset any `json:"offset"`
 	Limit  int `json:"limit"`
@@ -60,16 +133,22 @@ type ProviderFilter struct {
 const (
 	ProjMetaKey  = "projection"
 	LabelMetaKey = "label"
+	ColorMetaKey = "color"
+	DimsMetaKey  = "dims"
+	// RawLabelMetaKey is the metadata key of the label supplied when embedding text.
+	// NOTE: LabelMetaKey stores the label joined with the embedded text chunk.
+	RawLabelMetaKey = "raw_label"
 )
 
 // EmbeddingsUpdate is used to fetch embeddings.
 // NOTE: we call this an Update because it updates the vector store.
 type EmbeddingsUpdate struct {
-	Text       string         `json:"text"`
-	Label      string         `json:"label"`
-	Projection Projection     `json:"projection"`
-	Chunking   *Chunking      `json:"chunking,omitempty"`
-	Metadata   map[string]any `json:"metadata,omitempty"`
+	Text       string             `json:"text"`
+	Label      string             `json:"label"`
+	Projection Projection         `json:"projection"`
+	Options    *ProjectionOptions `json:"options,omitempty"`
+	Chunking   *Chunking          `json:"chunking,omitempty"`
+	Metadata   map[string]any     `json:"metadata,omitempty"`
 }
 
 // Chunking splits input text into chunks if enabled.
@@ -93,9 +172,168 @@ type ChunkingInput struct {
 }
 
 // ProjectionsUpdate is used to recompute embedding projections.
+// If View is set, the projections of the embeddings matching
+// the Filter are stored in the view with the given name.
 type ProjectionsUpdate struct {
-	Projection Projection     `json:"projection"`
-	Metadata   map[string]any `json:"metadata,omitempty"`
+	Projection Projection         `json:"projection"`
+	Options    *ProjectionOptions `json:"options,omitempty"`
+	Metadata   map[string]any     `json:"metadata,omitempty"`
+	View       string             `json:"view,omitempty"`
+	Filter     *EmbeddingsFilter  `json:"filter,omitempty"`
+}
+
+// ProjectionQuery is used to place a query text into provider projections.
+type ProjectionQuery struct {
+	// Text of the query.
+	Text string `json:"text"`
+	// Label of the query.
+	Label string `json:"label,omitempty"`
+	// Projection to place the query into.
+	// If not set, the last computed projection is used.
+	Projection Projection `json:"projection,omitempty"`
+	// Dim of the projection to place the query into.
+	// If not set, the query is placed into all the projection dimensions.
+	Dim Dim `json:"dim,omitempty"`
+}
+
+// ProjectionOptions configure projection algorithms.
+// Options which are not set fall back to algorithm defaults.
+type ProjectionOptions struct {
+	// Perplexity of t-SNE.
+	Perplexity float64 `json:"perplexity,omitempty"`
+	// LearningRate of iterative algorithms (t-SNE, UMAP).
+	LearningRate float64 `json:"learning_rate,omitempty"`
+	// MaxIterations of iterative algorithms (t-SNE, UMAP).
+	MaxIterations int `json:"max_iterations,omitempty"`
+	// Seed of the random number generator.
+	// If not set, a random seed is picked and recorded.
+	Seed *int64 `json:"seed,omitempty"`
+	// Center the data before PCA projection.
+	Center bool `json:"center,omitempty"`
+	// Whiten scales PCA components to unit variance.
+	Whiten bool `json:"whiten,omitempty"`
+	// Metric of MDS: euclidean or cosine distances or dot products are preserved.
+	Metric Metric `json:"metric,omitempty"`
+	// Staleness is the ratio of embeddings added since the projections
+	// have been computed which triggers recomputing all projections.
+	Staleness float64 `json:"staleness,omitempty"`
+	// Landmarks is the maximum number of embeddings projected by the algorithm.
+	// Projections of larger collections are computed for a random sample
+	// of landmark embeddings and interpolated for the remaining embeddings.
+	Landmarks int `json:"landmarks,omitempty"`
+	// Params are algorithm specific parameters.
+	// Params named after the other options set those options
+	// and the remaining params are passed to the projection algorithm.
+	Params map[string]any `json:"params,omitempty"`
+	// Dims are the projection dimensions to compute.
+	// If not set, DefaultDims are computed.
+	Dims []Dim `json:"dims,omitempty"`
+	// Axes are the semantic axes of the axes projection.
+	Axes []Axis `json:"axes,omitempty"`
+	// Matrix of the matrix projection.
+	Matrix *ProjectionMatrix `json:"matrix,omitempty"`
+}
+
+// Dimensions returns the projection dimensions to compute.
+func (o *ProjectionOptions) Dimensions() []Dim {
+	if o == nil || len(o.Dims) == 0 {
+		return DefaultDims
+	}
+	return o.Dims
+}
+
+// optionNames are the JSON names of the projection options which can be set via params.
+var optionNames = func() map[string]struct{} {
+	names := make(map[string]struct{})
+	t := reflect.TypeOf(ProjectionOptions{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "params" {
+			names[name] = struct{}{}
+		}
+	}
+	return names
+}()
+
+// ApplyParams returns a copy of o with the options named by the keys of o.Params
+// set to their values, which take precedence over the values set in o.
+// The params which don't name any option are kept in the Params of the copy
+// for the projection algorithms which read them.
+// It returns EINVALID error if any param value does not match its option type.
+func (o *ProjectionOptions) ApplyParams() (*ProjectionOptions, error) {
+	if o == nil || len(o.Params) == 0 {
+		return o, nil
+	}
+
+	// NOTE: the options are overlaid in their JSON encoding so the params
+	// are decoded exactly like the options set in the requests.
+	fields := make(map[string]json.RawMessage)
+	b, err := json.Marshal(o)
+	if err != nil {
+		return nil, Errorf(EINTERNAL, "options encoding error: %v", err)
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, Errorf(EINTERNAL, "options decoding error: %v", err)
+	}
+	delete(fields, "params")
+
+	var params map[string]any
+	for name, val := range o.Params {
+		if _, ok := optionNames[name]; !ok {
+			if params == nil {
+				params = make(map[string]any)
+			}
+			params[name] = val
+			continue
+		}
+		if fields[name], err = json.Marshal(val); err != nil {
+			return nil, Errorf(EINVALID, "invalid param %s: %v", name, err)
+		}
+	}
+
+	if b, err = json.Marshal(fields); err != nil {
+		return nil, Errorf(EINTERNAL, "options encoding error: %v", err)
+	}
+	res := new(ProjectionOptions)
+	if err := json.Unmarshal(b, res); err != nil {
+		return nil, Errorf(EINVALID, "invalid params: %v", err)
+	}
+	res.Params = params
+
+	return res, nil
+}
+
+// Projections are embeddings projections.
+type Projections struct {
+	// Projection algorithm used to compute the projections.
+	Projection Projection `json:"projection"`
+	// Options used to compute the projections.
+	Options *ProjectionOptions `json:"options,omitempty"`
+	// Metrics of the projections keyed by projection dimension.
+	Metrics map[Dim]*ProjectionMetrics `json:"metrics,omitempty"`
+	// Embeddings projections keyed by projection dimension.
+	Embeddings map[Dim][]Embedding `json:"embeddings"`
+}
+
+// ProjectionMetrics measure how faithfully projections preserve
+// the neighbourhoods of the original embeddings. All the metrics
+// except for the explained variance are in the range [0, 1].
+// NOTE: metrics are computed when the projections are computed.
+type ProjectionMetrics struct {
+	// K is the neighbourhood size the metrics were computed for.
+	K int `json:"k"`
+	// Trustworthiness penalizes points which are neighbours
+	// in the projection but not in the original space.
+	Trustworthiness float64 `json:"trustworthiness"`
+	// Continuity penalizes points which are neighbours
+	// in the original space but not in the projection.
+	Continuity float64 `json:"continuity"`
+	// KNNPreservation is the mean ratio of the k nearest
+	// neighbours preserved by the projection.
+	KNNPreservation float64 `json:"knn_preservation"`
+	// ExplainedVariance is the ratio of the variance explained
+	// by each projection component. It's only set for PCA.
+	ExplainedVariance []float64 `json:"explained_variance,omitempty"`
 }
 
 // ProvidersService manages embedding providers.
@@ -109,11 +347,58 @@ type ProvidersService interface {
 	// GetProviderEmbeddings returns embeddings for the provider with the given uid.
 	GetProviderEmbeddings(ctx context.Context, uid string, filter ProviderFilter) ([]Embedding, Page, error)
 	// GetProviderProjections returns embeddings projections for the provider with the given uid.
-	GetProviderProjections(ctx context.Context, uid string, filter ProviderFilter) (map[Dim][]Embedding, Page, error)
+	GetProviderProjections(ctx context.Context, uid string, filter ProviderFilter) (*Projections, Page, error)
 	// UpdateProviderEmbeddings generates embeddings for the provider with the given uid.
-	UpdateProviderEmbeddings(ctx context.Context, uid string, update []Embedding, projection Projection) ([]Embedding, error)
+	// Duplicates of the stored embeddings are skipped or merged according to the provider dedup policy.
+	UpdateProviderEmbeddings(ctx context.Context, uid string, update []Embedding, projection Projection, opts *ProjectionOptions) ([]Embedding, error)
 	// DropProviderEmbeddings drops all provider embeddings from the store.
 	DropProviderEmbeddings(ctx context.Context, uid string) error
 	// ComputeProviderProjections drops existing projections and recomputes anew.
-	ComputeProviderProjections(ctx context.Context, uid string, projection Projection) error
+	ComputeProviderProjections(ctx context.Context, uid string, projection Projection, opts *ProjectionOptions) error
+	// ComputeProviderView projects the embeddings matching filter and stores the projections in the named view.
+	// If the view already exists it is replaced.
+	ComputeProviderView(ctx context.Context, uid, name string, filter *EmbeddingsFilter, projection Projection, opts *ProjectionOptions) error
+	// GetProviderViews returns the views of the provider with the given uid without their projections.
+	GetProviderViews(ctx context.Context, uid string) ([]*View, error)
+	// GetProviderView returns the named view of the provider with the given uid.
+	GetProviderView(ctx context.Context, uid, name string, filter ProviderFilter) (*View, Page, error)
+	// DropProviderView drops the named view of the provider with the given uid.
+	DropProviderView(ctx context.Context, uid, name string) error
+	// UpdateProviderMatrix stores a new version of the projection matrix of the provider with the given uid.
+	// Unless the version of m is zero, it must match the version of the stored matrix it replaces.
+	UpdateProviderMatrix(ctx context.Context, uid string, m *ProjectionMatrix) (*ProjectionMatrix, error)
+	// GetProviderMatrix returns the projection matrix of the provider with the given uid.
+	GetProviderMatrix(ctx context.Context, uid string) (*ProjectionMatrix, error)
+	// DropProviderMatrix drops the projection matrix of the provider with the given uid.
+	DropProviderMatrix(ctx context.Context, uid string) error
+	// ClusterProviderEmbeddings clusters embeddings of the provider with the given uid
+	// and stores the cluster assignments in the embeddings metadata.
+	ClusterProviderEmbeddings(ctx context.Context, uid string, opts *ClusterOptions) (*Clustering, error)
+	// DetectProviderOutliers scores embeddings of the provider with the given uid for outlierness,
+	// stores the scores in the embeddings metadata and returns the top outliers.
+	DetectProviderOutliers(ctx context.Context, uid string, opts *OutlierOptions) (*Outliers, error)
+	// PlaceProviderEmbeddings places embeddings into the stored projections of the provider with the given uid
+	// using the fitted projection models without storing them. Unless the filter requests
+	// a specific projection, the embeddings are placed into the last computed projections.
+	PlaceProviderEmbeddings(ctx context.Context, uid string, embs []Embedding, filter ProviderFilter) (*Projections, error)
+	// SearchProviderEmbeddings returns k embeddings of the provider with the given uid
+	// most similar to the given embedding values sorted by their similarity scores.
+	SearchProviderEmbeddings(ctx context.Context, uid string, vals []float64, k int) ([]ScoredEmbedding, error)
+	// GetProviderEmbeddingNeighbours returns k embeddings of the provider with the given uid
+	// nearest to its embedding with the given eid in the original vector space measured by metric.
+	GetProviderEmbeddingNeighbours(ctx context.Context, uid, eid string, k int, metric Metric) ([]ScoredEmbedding, error)
+	// GetProviderSimilarities returns the pairwise similarity matrix
+	// of the requested embeddings of the provider with the given uid.
+	GetProviderSimilarities(ctx context.Context, uid string, req *SimilarityRequest) (*Similarity, error)
+	// UpdateProviderDedup sets the dedup policy applied when updating embeddings of the provider with the given uid.
+	UpdateProviderDedup(ctx context.Context, uid string, policy *DedupPolicy) (*DedupPolicy, error)
+	// GetProviderDedup returns the dedup policy of the provider with the given uid.
+	// Dedup is off unless the policy has been set.
+	GetProviderDedup(ctx context.Context, uid string) (*DedupPolicy, error)
+	// GetProviderDuplicates returns the groups of duplicate embeddings
+	// of the provider with the given uid detected using the given policy.
+	GetProviderDuplicates(ctx context.Context, uid string, policy *DedupPolicy) (*Duplicates, error)
+	// AlignProviderProjections aligns the projections of the provider with the given uid
+	// to the projections of the reference provider ref using Procrustes analysis.
+	AlignProviderProjections(ctx context.Context, uid, ref string, projection Projection, dim Dim, key string) (*Alignment, error)
 }
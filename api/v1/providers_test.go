@@ -0,0 +1,77 @@
+package v1
+
+import "testing"
+
+func TestDim(t *testing.T) {
+	testCases := []struct {
+		dim  Dim
+		size int
+	}{
+		{Dim1D, 1},
+		{Dim2D, 2},
+		{Dim3D, 3},
+		{NewDim(10), 10},
+		{NewDim(MaxDim), MaxDim},
+		{NewDim(MaxDim + 1), 0},
+		{"0D", 0},
+		{"-1D", 0},
+		{"02D", 0},
+		{"2d", 0},
+		{"2", 0},
+		{"D", 0},
+		{"", 0},
+	}
+
+	for _, tc := range testCases {
+		if got := tc.dim.Size(); got != tc.size {
+			t.Errorf("expected %q size: %d, got: %d", tc.dim, tc.size, got)
+		}
+		if got := tc.dim.Valid(); got != (tc.size > 0) {
+			t.Errorf("expected %q valid: %v, got: %v", tc.dim, tc.size > 0, got)
+		}
+	}
+}
+
+func TestProjectionOptionsDimensions(t *testing.T) {
+	var opts *ProjectionOptions
+	if got := opts.Dimensions(); len(got) != len(DefaultDims) {
+		t.Fatalf("expected default dims: %v, got: %v", DefaultDims, got)
+	}
+
+	opts = &ProjectionOptions{Dims: []Dim{Dim1D, NewDim(10)}}
+	if got := opts.Dimensions(); len(got) != 2 || got[0] != Dim1D || got[1] != "10D" {
+		t.Fatalf("expected dims: %v, got: %v", opts.Dims, got)
+	}
+}
+
+func TestProjectionOptionsApplyParams(t *testing.T) {
+	var opts *ProjectionOptions
+	if got, err := opts.ApplyParams(); err != nil || got != nil {
+		t.Fatalf("expected nil options, got: %v, err: %v", got, err)
+	}
+
+	seed := int64(1)
+	opts = &ProjectionOptions{
+		Perplexity: 10,
+		Seed:       &seed,
+		Params:     map[string]any{"perplexity": 20.0, "seed": 2.0, "dims": []any{"2D"}, "factor": 2.0},
+	}
+	got, err := opts.ApplyParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Perplexity != 20 || *got.Seed != 2 || len(got.Dims) != 1 || got.Dims[0] != Dim2D {
+		t.Fatalf("expected options set by params, got: %#v", got)
+	}
+	if len(got.Params) != 1 || got.Params["factor"] != 2.0 {
+		t.Fatalf("expected params: %v, got: %v", map[string]any{"factor": 2.0}, got.Params)
+	}
+	if opts.Perplexity != 10 || *opts.Seed != 1 || len(opts.Params) != 4 {
+		t.Fatalf("expected unchanged options, got: %#v", opts)
+	}
+
+	opts = &ProjectionOptions{Params: map[string]any{"perplexity": "foo"}}
+	if _, err := opts.ApplyParams(); ErrorCode(err) != EINVALID {
+		t.Fatalf("expected error: %s, got: %v", EINVALID, err)
+	}
+}
@@ -0,0 +1,313 @@
+package qdrant
+
+import (
+	"context"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	pb "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc/metadata"
+)
+
+// migrationSuffix is appended to the name of the collection
+// the points are copied to while their collection is migrated.
+const migrationSuffix = "_migrate"
+
+// migration is a collection migration in progress.
+// It's stored once the points have been copied to the migration collection.
+type migration struct {
+	// Aliases of the migrated collection.
+	Aliases []string `json:"aliases"`
+}
+
+// Migrate migrates the provider collections which don't store the projections of all
+// the registered projection algorithms, e.g. the legacy collections which only store
+// the 2D and 3D named vectors or the collections created before new projectors were
+// registered. qdrant does not allow adding named vectors to existing collections so
+// the collections are recreated and their points are copied over.
+// The legacy projections were computed by unknown projection algorithms so they are
+// dropped and must be recomputed. Interrupted migrations are resumed.
+func (p *ProvidersService) Migrate(ctx context.Context) error {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	// NOTE: the aliases of the collections are deleted while they're
+	// being recreated so interrupted migrations must be resumed first.
+	uids, err := p.state.Providers(ctx, migrationStateKey)
+	if err != nil {
+		return err
+	}
+	for _, uid := range uids {
+		if err := p.finishMigration(ctx, uid); err != nil {
+			return err
+		}
+	}
+
+	resp, err := p.db.col.ListAliases(ctx, &pb.ListAliasesRequest{})
+	if err != nil {
+		return v1.Errorf(v1.EINTERNAL, "ListAliases error: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, a := range resp.Aliases {
+		if seen[a.CollectionName] {
+			continue
+		}
+		seen[a.CollectionName] = true
+		if err := p.migrate(ctx, a.CollectionName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrate migrates the collection with the given uid if it needs migrating.
+func (p *ProvidersService) migrate(ctx context.Context, uid string) error {
+	params, err := p.getVectorParams(ctx, uid)
+	if err != nil {
+		return err
+	}
+	newParams := migrationParams(params)
+	if newParams == nil {
+		return nil
+	}
+
+	// NOTE: the migration collection may contain
+	// a partial copy made by an interrupted migration.
+	tmp := uid + migrationSuffix
+	if err := p.dropCollection(ctx, tmp); err != nil {
+		return err
+	}
+	if err := p.createCollection(ctx, tmp, newParams); err != nil {
+		return err
+	}
+	if err := p.copyPoints(ctx, uid, tmp, newParams); err != nil {
+		return err
+	}
+
+	resp, err := p.db.col.ListCollectionAliases(ctx, &pb.ListCollectionAliasesRequest{CollectionName: uid})
+	if err != nil {
+		return v1.Errorf(v1.EINTERNAL, "ListCollectionAliases error: %v", err)
+	}
+	m := migration{Aliases: make([]string, 0, len(resp.Aliases))}
+	for _, a := range resp.Aliases {
+		m.Aliases = append(m.Aliases, a.AliasName)
+	}
+	if err := p.state.Put(ctx, uid, migrationStateKey, m); err != nil {
+		return err
+	}
+
+	return p.finishMigration(ctx, uid)
+}
+
+// finishMigration recreates the collection with the given uid from its migration collection.
+// It's a noop if no migration of the collection is in progress.
+func (p *ProvidersService) finishMigration(ctx context.Context, uid string) error {
+	m := migration{}
+	ok, err := p.state.Get(ctx, uid, migrationStateKey, &m)
+	if err != nil || !ok {
+		return err
+	}
+
+	tmp := uid + migrationSuffix
+	exists, err := p.collectionExists(ctx, tmp)
+	if err != nil {
+		return err
+	}
+	// NOTE: the migration collection is only dropped
+	// once the collection has been fully recreated.
+	if exists {
+		params, err := p.getVectorParams(ctx, tmp)
+		if err != nil {
+			return err
+		}
+		if err := p.dropCollection(ctx, uid); err != nil {
+			return err
+		}
+		if err := p.createCollection(ctx, uid, params); err != nil {
+			return err
+		}
+		if err := p.copyPoints(ctx, tmp, uid, params); err != nil {
+			return err
+		}
+
+		createAliases := make([]*pb.AliasOperations, 0, len(m.Aliases))
+		for _, alias := range m.Aliases {
+			createAliases = append(createAliases, &pb.AliasOperations{
+				Action: &pb.AliasOperations_CreateAlias{
+					CreateAlias: &pb.CreateAlias{
+						CollectionName: uid,
+						AliasName:      alias,
+					},
+				},
+			})
+		}
+		if _, err := p.db.col.UpdateAliases(ctx, &pb.ChangeAliases{Actions: createAliases}); err != nil {
+			return v1.Errorf(v1.EINTERNAL, "UpdateAliases error: %v", err)
+		}
+
+		if err := p.dropCollection(ctx, tmp); err != nil {
+			return err
+		}
+	}
+
+	return p.state.Delete(ctx, uid, migrationStateKey)
+}
+
+// collectionExists returns true if the collection with the given name exists.
+func (p *ProvidersService) collectionExists(ctx context.Context, name string) (bool, error) {
+	resp, err := p.db.col.List(ctx, &pb.ListCollectionsRequest{})
+	if err != nil {
+		return false, v1.Errorf(v1.EINTERNAL, "ListCollections error: %v", err)
+	}
+	for _, c := range resp.Collections {
+		if c.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// createCollection creates a new collection with the given name and vector params.
+func (p *ProvidersService) createCollection(ctx context.Context, name string, params map[string]*pb.VectorParams) error {
+	if _, err := p.db.col.Create(ctx, &pb.CreateCollection{
+		CollectionName: name,
+		VectorsConfig: &pb.VectorsConfig{
+			Config: &pb.VectorsConfig_ParamsMap{
+				ParamsMap: &pb.VectorParamsMap{
+					Map: params,
+				},
+			},
+		},
+		OptimizersConfig: &pb.OptimizersConfigDiff{
+			DefaultSegmentNumber: &defaultSegmentNumber,
+		},
+	}); err != nil {
+		return v1.Errorf(v1.EINTERNAL, "CreateCollection error: %v", err)
+	}
+	return nil
+}
+
+// dropCollection deletes the collection with the given name along with its aliases.
+// It's a noop if the collection does not exist.
+func (p *ProvidersService) dropCollection(ctx context.Context, name string) error {
+	exists, err := p.collectionExists(ctx, name)
+	if err != nil || !exists {
+		return err
+	}
+
+	resp, err := p.db.col.ListCollectionAliases(ctx, &pb.ListCollectionAliasesRequest{CollectionName: name})
+	if err != nil {
+		return v1.Errorf(v1.EINTERNAL, "ListCollectionAliases error: %v", err)
+	}
+	if len(resp.Aliases) > 0 {
+		deleteAliases := make([]*pb.AliasOperations, 0, len(resp.Aliases))
+		for _, alias := range resp.Aliases {
+			deleteAliases = append(deleteAliases, &pb.AliasOperations{
+				Action: &pb.AliasOperations_DeleteAlias{
+					DeleteAlias: &pb.DeleteAlias{AliasName: alias.AliasName},
+				},
+			})
+		}
+		if _, err := p.db.col.UpdateAliases(ctx, &pb.ChangeAliases{Actions: deleteAliases}); err != nil {
+			return v1.Errorf(v1.EINTERNAL, "DeleteAliases error: %v", err)
+		}
+	}
+
+	if _, err := p.db.col.Delete(ctx, &pb.DeleteCollection{CollectionName: name}); err != nil {
+		return v1.Errorf(v1.EINTERNAL, "DeleteCollection error: %v", err)
+	}
+	return nil
+}
+
+// copyPoints copies the points of the collection from to the collection to in batches.
+// Only the point vectors with the given params are copied.
+func (p *ProvidersService) copyPoints(ctx context.Context, from, to string, params map[string]*pb.VectorParams) error {
+	limit := uint32(scrollBatchSize)
+	req := &pb.ScrollPoints{
+		CollectionName: from,
+		WithVectors: &pb.WithVectorsSelector{
+			SelectorOptions: &pb.WithVectorsSelector_Enable{
+				Enable: true,
+			},
+		},
+		WithPayload: &pb.WithPayloadSelector{
+			SelectorOptions: &pb.WithPayloadSelector_Enable{
+				Enable: true,
+			},
+		},
+		Limit: &limit,
+	}
+
+	for {
+		resp, err := p.db.pts.Scroll(ctx, req)
+		if err != nil {
+			return v1.Errorf(v1.EINTERNAL, "Scroll error %v", err)
+		}
+
+		points := make([]*pb.PointStruct, 0, len(resp.GetResult()))
+		for _, pt := range resp.GetResult() {
+			points = append(points, migratePoint(pt, params))
+		}
+		if len(points) > 0 {
+			wait := true
+			if _, err := p.db.pts.Upsert(ctx, &pb.UpsertPoints{
+				CollectionName: to,
+				Wait:           &wait,
+				Points:         points,
+			}); err != nil {
+				return v1.Errorf(v1.EINTERNAL, "Upsert error %v", err)
+			}
+		}
+
+		if resp.NextPageOffset == nil {
+			break
+		}
+		req.Offset = resp.NextPageOffset
+	}
+
+	return nil
+}
+
+// migrationParams returns the vector params of the migrated collection with the given params
+// or nil if the collection does not need migrating. Migrated collections store the projections
+// of all the registered projection algorithms of the collection dims. Legacy projections are dropped.
+func migrationParams(params map[string]*pb.VectorParams) map[string]*pb.VectorParams {
+	dims := projDims(params)
+	if len(dims) == 0 {
+		dims = v1.DefaultDims
+	}
+
+	newParams := vectorParams(params[""].GetSize(), params[""].GetDistance(), dims)
+	if len(newParams) != len(params) {
+		return newParams
+	}
+	for name := range params {
+		if _, ok := newParams[name]; !ok {
+			return newParams
+		}
+	}
+	return nil
+}
+
+// migratePoint returns the point copying the point pt which only stores
+// the vectors with the given params and the payload of pt.
+func migratePoint(pt *pb.RetrievedPoint, params map[string]*pb.VectorParams) *pb.PointStruct {
+	vecs := make(map[string]*pb.Vector)
+	for name, vec := range pt.GetVectors().GetVectors().GetVectors() {
+		if _, ok := params[name]; ok {
+			vecs[name] = vec
+		}
+	}
+
+	return &pb.PointStruct{
+		Id: pt.Id,
+		Vectors: &pb.Vectors{
+			VectorsOptions: &pb.Vectors_Vectors{
+				Vectors: &pb.NamedVectors{
+					Vectors: vecs,
+				},
+			},
+		},
+		Payload: pt.Payload,
+	}
+}
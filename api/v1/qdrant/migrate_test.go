@@ -0,0 +1,188 @@
+package qdrant
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	pb "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestMigrationParams(t *testing.T) {
+	dist := pb.Distance_Cosine
+	current := vectorParams(4, dist, v1.DefaultDims)
+
+	t.Run("Current", func(t *testing.T) {
+		if params := migrationParams(current); params != nil {
+			t.Fatalf("expected no migration, got: %v", params)
+		}
+	})
+
+	t.Run("Legacy", func(t *testing.T) {
+		legacy := map[string]*pb.VectorParams{
+			"":   {Size: 4, Distance: dist},
+			"2D": {Size: 2, Distance: dist},
+			"3D": {Size: 3, Distance: dist},
+		}
+		if params := migrationParams(legacy); !reflect.DeepEqual(params, current) {
+			t.Fatalf("expected params: %v, got: %v", current, params)
+		}
+	})
+
+	t.Run("MissingProjection", func(t *testing.T) {
+		params := make(map[string]*pb.VectorParams, len(current))
+		for name, p := range current {
+			params[name] = p
+		}
+		delete(params, v1.ProjectionKey(v1.UMAP, v1.Dim2D))
+		if params := migrationParams(params); !reflect.DeepEqual(params, current) {
+			t.Fatalf("expected params: %v, got: %v", current, params)
+		}
+	})
+}
+
+func TestMigratePoint(t *testing.T) {
+	params := vectorParams(2, pb.Distance_Dot, []v1.Dim{v1.Dim2D})
+	key := v1.ProjectionKey(v1.PCA, v1.Dim2D)
+	payload := map[string]*pb.Value{
+		"foo": {Kind: &pb.Value_StringValue{StringValue: "bar"}},
+	}
+
+	pt := &pb.RetrievedPoint{
+		Id: pointID(uuid.NewString()),
+		Vectors: &pb.Vectors{
+			VectorsOptions: &pb.Vectors_Vectors{
+				Vectors: &pb.NamedVectors{
+					Vectors: map[string]*pb.Vector{
+						"":   {Data: []float32{1, 2}},
+						"2D": {Data: []float32{3, 4}},
+						key:  {Data: []float32{5, 6}},
+					},
+				},
+			},
+		},
+		Payload: payload,
+	}
+
+	res := migratePoint(pt, params)
+	if res.Id.GetUuid() != pt.Id.GetUuid() {
+		t.Fatalf("expected ID: %s, got: %s", pt.Id.GetUuid(), res.Id.GetUuid())
+	}
+	vecs := res.GetVectors().GetVectors()
+	if len(vecs.GetVectors()) != 2 {
+		t.Fatalf("expected %d vectors, got: %v", 2, vecs.GetVectors())
+	}
+	if vals := getVecVals(vecs, ""); !reflect.DeepEqual(vals, []float64{1, 2}) {
+		t.Fatalf("expected embedding values: %v, got: %v", []float64{1, 2}, vals)
+	}
+	if vals := getVecVals(vecs, key); !reflect.DeepEqual(vals, []float64{5, 6}) {
+		t.Fatalf("expected %s values: %v, got: %v", key, []float64{5, 6}, vals)
+	}
+	if !reflect.DeepEqual(res.Payload, payload) {
+		t.Fatalf("expected payload: %v, got: %v", payload, res.Payload)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	ps := MustProvidersService(t)
+	ctx := metadata.NewOutgoingContext(context.TODO(), ps.db.md)
+
+	// NOTE: legacy collections only store 2D and 3D projections
+	uid, name := uuid.NewString(), uuid.NewString()
+	legacy := map[string]*pb.VectorParams{
+		"":   {Size: TestVectorSize, Distance: defaultDistance},
+		"2D": {Size: 2, Distance: defaultDistance},
+		"3D": {Size: 3, Distance: defaultDistance},
+	}
+	if err := ps.createCollection(ctx, uid, legacy); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := ps.dropCollection(ctx, uid); err != nil {
+			t.Errorf("failed to drop collection: %v", err)
+		}
+		if err := ps.state.Drop(ctx, uid); err != nil {
+			t.Errorf("failed to drop state: %v", err)
+		}
+	})
+	if _, err := ps.db.col.UpdateAliases(ctx, &pb.ChangeAliases{
+		Actions: []*pb.AliasOperations{
+			{
+				Action: &pb.AliasOperations_CreateAlias{
+					CreateAlias: &pb.CreateAlias{
+						CollectionName: uid,
+						AliasName:      name,
+					},
+				},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	wait := true
+	points := []*pb.PointStruct{
+		{
+			Id: pointID(uuid.NewString()),
+			Vectors: &pb.Vectors{
+				VectorsOptions: &pb.Vectors_Vectors{
+					Vectors: &pb.NamedVectors{
+						Vectors: map[string]*pb.Vector{
+							"":   {Data: []float32{1, 0, 0, 0}},
+							"2D": {Data: []float32{1, 0}},
+							"3D": {Data: []float32{1, 0, 0}},
+						},
+					},
+				},
+			},
+			Payload: meta2Payload(map[string]any{v1.LabelMetaKey: "foo"}),
+		},
+	}
+	if _, err := ps.db.pts.Upsert(ctx, &pb.UpsertPoints{
+		CollectionName: uid,
+		Wait:           &wait,
+		Points:         points,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ps.Migrate(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := ps.getVectorParams(ctx, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if migrationParams(params) != nil {
+		t.Fatalf("expected migrated collection, got: %v", params)
+	}
+
+	p, err := ps.AddProvider(context.TODO(), name, map[string]any{"size": uint64(TestVectorSize)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.UID != uid {
+		t.Fatalf("expected UID: %s, got: %s", uid, p.UID)
+	}
+
+	embs, _, err := ps.GetProviderEmbeddings(context.TODO(), uid, v1.ProviderFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embs) != len(points) || embs[0].Metadata[v1.LabelMetaKey] != "foo" {
+		t.Fatalf("expected migrated embeddings, got: %v", embs)
+	}
+
+	if exists, err := ps.collectionExists(ctx, uid+migrationSuffix); err != nil || exists {
+		t.Fatalf("expected dropped migration collection, got: %v, %v", exists, err)
+	}
+
+	// NOTE: legacy projections are dropped so the projections must be recomputed
+	if err := ps.ComputeProviderProjections(context.TODO(), uid, v1.PCA, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -3,12 +3,17 @@ package qdrant
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/internal/cluster"
 	"github.com/milosgajdos/embeviz/api/v1/internal/paging"
 	"github.com/milosgajdos/embeviz/api/v1/internal/projection"
+	"github.com/milosgajdos/embeviz/api/v1/internal/search"
 	pb "github.com/qdrant/go-client/qdrant"
+	"golang.org/x/exp/maps"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -17,27 +22,50 @@ var (
 	defaultDistance             = pb.Distance_Dot
 )
 
+const (
+	// scrollBatchSize is the number of points fetched at once.
+	scrollBatchSize = 1000
+	// scrollIDsBatchSize is the number of point IDs fetched at once.
+	scrollIDsBatchSize = 10000
+	// payloadBatchSize is the number of point payloads updated at once.
+	payloadBatchSize = 1000
+	// dedupCandidates is the number of the nearest points checked for vector duplicates.
+	dedupCandidates = 10
+	// placeCandidates is the number of the nearest points the neighbours
+	// of the placed embeddings are re-ranked among.
+	placeCandidates = 4 * projection.DefaultNeighbours
+	// searchBatchSize is the number of searches run at once.
+	searchBatchSize = 100
+	// hdbscanMaxEmbeddings is the maximum number of embeddings clustered by HDBSCAN.
+	hdbscanMaxEmbeddings = 10000
+)
+
 var (
 	ErrMissingVectorSize     = errors.New("ErrMissingVectorSize")
 	ErrInvalidVectorSize     = errors.New("ErrInvalidVectorSize")
 	ErrInvalidVectorDistance = errors.New("ErrInvalidVectorDistance")
+	ErrInvalidProjectionDims = errors.New("ErrInvalidProjectionDims")
 )
 
 // ProvidersService allows to store data in qdrant vector store.
 type ProvidersService struct {
-	db *DB
+	db    *DB
+	state *state
 }
 
 // NewProvidersService creates an instance of ProvidersService and returns it.
 func NewProvidersService(db *DB) (*ProvidersService, error) {
 	return &ProvidersService{
-		db: db,
+		db:    db,
+		state: &state{db: db},
 	}, nil
 }
 
 // AddProvider creates a new provider and returns it.
 // It creates a new qdrant collection and raturns the new provider.
 // The collection name is the same as the UUID of the provider.
+// The collection stores projections of the dimensions set in the dims metadata
+// which must be []v1.Dim. If the dims are not set v1.DefaultDims are stored.
 func (p *ProvidersService) AddProvider(ctx context.Context, name string, md map[string]any) (*v1.Provider, error) {
 	size, ok := md["size"]
 	if !ok {
@@ -59,6 +87,19 @@ func (p *ProvidersService) AddProvider(ctx context.Context, name string, md map[
 		}
 	}
 
+	vecDims := v1.DefaultDims
+	if dims, ok := md[v1.DimsMetaKey]; ok {
+		vecDims, ok = dims.([]v1.Dim)
+		if !ok || len(vecDims) == 0 {
+			return nil, v1.Errorf(v1.EINVALID, "%v: %v", ErrInvalidProjectionDims, dims)
+		}
+		for _, dim := range vecDims {
+			if !dim.Valid() {
+				return nil, v1.Errorf(v1.EINVALID, "%v: %v", ErrInvalidProjectionDims, dims)
+			}
+		}
+	}
+
 	resp, err := p.db.col.ListAliases(ctx, &pb.ListAliasesRequest{})
 	if err != nil {
 		return nil, v1.Errorf(v1.EINTERNAL, "ListAliases error: %v", err)
@@ -80,37 +121,8 @@ func (p *ProvidersService) AddProvider(ctx context.Context, name string, md map[
 
 	uid := uuid.New().String()
 	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
-	_, err = p.db.col.Create(ctx, &pb.CreateCollection{
-		CollectionName: uid,
-		VectorsConfig: &pb.VectorsConfig{
-			Config: &pb.VectorsConfig_ParamsMap{
-				ParamsMap: &pb.VectorParamsMap{
-					Map: map[string]*pb.VectorParams{
-						// NOTE(milosgajdos): empty name vector
-						// is the "default" point vector.
-						"": {
-							Size:     vectorSize,
-							Distance: vectorDistance,
-						},
-						"2D": {
-							Size:     2,
-							Distance: vectorDistance,
-						},
-						"3D": {
-							Size:     3,
-							Distance: vectorDistance,
-						},
-					},
-				},
-			},
-		},
-		OptimizersConfig: &pb.OptimizersConfigDiff{
-			// https://qdrant.tech/documentation/concepts/optimizer/#merge-optimizer
-			DefaultSegmentNumber: &defaultSegmentNumber,
-		},
-	})
-	if err != nil {
-		return nil, v1.Errorf(v1.EINTERNAL, "CreateCollection error %v", err)
+	if err := p.createCollection(ctx, uid, vectorParams(vectorSize, vectorDistance, vecDims)); err != nil {
+		return nil, err
 	}
 
 	createAliases := []*pb.AliasOperations{
@@ -269,12 +281,60 @@ func (p *ProvidersService) GetProviderEmbeddings(ctx context.Context, uid string
 }
 
 // GetProviderProjections returns embeddings projections for the provider with the given uid.
-func (p *ProvidersService) GetProviderProjections(ctx context.Context, uid string, filter v1.ProviderFilter) (map[v1.Dim][]v1.Embedding, v1.Page, error) {
+// Unless the filter requests a specific projection, it returns the last computed projections.
+func (p *ProvidersService) GetProviderProjections(ctx context.Context, uid string, filter v1.ProviderFilter) (*v1.Projections, v1.Page, error) {
+	page := v1.Page{}
+
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	proj := v1.PCA
+	if _, err := p.state.Get(ctx, uid, activeStateKey, &proj); err != nil {
+		return nil, page, err
+	}
+	if filter.Projection != nil {
+		proj = *filter.Projection
+	}
+	if err := p.checkComplete(ctx, uid, proj); err != nil {
+		return nil, page, err
+	}
+
+	opts := new(v1.ProjectionOptions)
+	ok, err := p.state.Get(ctx, uid, projStateKey(optionsStateKey, proj), opts)
+	if err != nil {
+		return nil, page, err
+	}
+	if !ok {
+		opts = nil
+	}
+
+	dims := opts.Dimensions()
+	if opts == nil {
+		// NOTE: projections which have not been computed yet default to collection dims
+		vecParams, err := p.getVectorParams(ctx, uid)
+		if err != nil {
+			return nil, page, err
+		}
+		dims = projection.Dims(nil, proj, projDims(vecParams)).Dimensions()
+	}
+	if dim := filter.Dim; dim != nil {
+		if !slices.Contains(dims, *dim) {
+			return nil, page, v1.Errorf(v1.EINVALID, "invalid dimension %v for provider %q", *dim, uid)
+		}
+		dims = []v1.Dim{*dim}
+	}
+
+	vecNames := make([]string, 0, len(dims))
+	for _, dim := range dims {
+		vecNames = append(vecNames, v1.ProjectionKey(proj, dim))
+	}
+
 	req := &pb.ScrollPoints{
 		CollectionName: uid,
 		WithVectors: &pb.WithVectorsSelector{
-			SelectorOptions: &pb.WithVectorsSelector_Enable{
-				Enable: true,
+			SelectorOptions: &pb.WithVectorsSelector_Include{
+				Include: &pb.VectorsSelector{
+					Names: vecNames,
+				},
 			},
 		},
 		WithPayload: &pb.WithPayloadSelector{
@@ -291,10 +351,6 @@ func (p *ProvidersService) GetProviderProjections(ctx context.Context, uid strin
 		req.Limit = &limit
 	}
 
-	page := v1.Page{}
-
-	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
-
 	resp, err := p.db.pts.Scroll(ctx, req)
 	if err != nil {
 		return nil, page, v1.Errorf(v1.EINTERNAL, "Scroll error %v", err)
@@ -307,30 +363,24 @@ func (p *ProvidersService) GetProviderProjections(ctx context.Context, uid strin
 		page.Next = &next
 	}
 
-	if dim := filter.Dim; dim != nil {
-		if *dim != v1.Dim2D && *dim != v1.Dim3D {
-			return nil, page, v1.Errorf(v1.EINVALID, "invalid dimension %v for provider %q", *dim, uid)
-		}
-		projs := make([]v1.Embedding, len(points))
-
-		for _, p := range points {
-			// NOTE: we call GetVectors twice because we use
-			// NamedVectors so we need to dig in 2 levels down.
-			vecs := p.GetVectors().GetVectors()
-			if vecs != nil {
-				vals := getVecVals(vecs, string(*dim))
-				projs = append(projs, v1.Embedding{
-					UID:      p.Id.GetUuid(),
-					Values:   vals,
-					Metadata: payload2Meta(p.GetPayload()),
-				})
+	projMetrics := make(map[v1.Dim]*v1.ProjectionMetrics)
+	if _, err := p.state.Get(ctx, uid, projStateKey(metricsStateKey, proj), &projMetrics); err != nil {
+		return nil, page, err
+	}
+	var resMetrics map[v1.Dim]*v1.ProjectionMetrics
+	for _, dim := range dims {
+		if m, ok := projMetrics[dim]; ok {
+			if resMetrics == nil {
+				resMetrics = make(map[v1.Dim]*v1.ProjectionMetrics, len(dims))
 			}
+			resMetrics[dim] = m
 		}
-		return map[v1.Dim][]v1.Embedding{*filter.Dim: projs}, page, nil
 	}
 
-	res2DProjs := make([]v1.Embedding, 0, len(points))
-	res3DProjs := make([]v1.Embedding, 0, len(points))
+	res := make(map[v1.Dim][]v1.Embedding, len(dims))
+	for _, dim := range dims {
+		res[dim] = make([]v1.Embedding, 0, len(points))
+	}
 
 	for _, p := range points {
 		// NOTE: we call GetVectors twice because we use
@@ -338,179 +388,174 @@ func (p *ProvidersService) GetProviderProjections(ctx context.Context, uid strin
 		vecs := p.GetVectors().GetVectors()
 		// skip if no named vectors exist for this point
 		// this means there are no projections for this embedding.
-		if vecs != nil {
-			// 2D projections
-			proj2DVals := getVecVals(vecs, string(v1.Dim2D))
-			res2DProjs = append(res2DProjs, v1.Embedding{
-				UID:      p.Id.GetUuid(),
-				Values:   proj2DVals,
-				Metadata: payload2Meta(p.GetPayload()),
-			})
-			// 3D projections
-			proj3DVals := getVecVals(vecs, string(v1.Dim3D))
-			res3DProjs = append(res3DProjs, v1.Embedding{
+		if vecs == nil {
+			continue
+		}
+		for _, dim := range dims {
+			// NOTE: embeddings are stored without projections
+			// until the projections have been computed.
+			vals := getVecVals(vecs, v1.ProjectionKey(proj, dim))
+			if len(vals) == 0 {
+				continue
+			}
+			res[dim] = append(res[dim], v1.Embedding{
 				UID:      p.Id.GetUuid(),
-				Values:   proj3DVals,
+				Values:   vals,
 				Metadata: payload2Meta(p.GetPayload()),
 			})
 		}
 	}
-	return map[v1.Dim][]v1.Embedding{
-		v1.Dim2D: res2DProjs,
-		v1.Dim3D: res3DProjs,
+
+	return &v1.Projections{
+		Projection: proj,
+		Options:    opts,
+		Metrics:    resMetrics,
+		Embeddings: res,
 	}, page, nil
 }
 
 // UpdateProviderEmbeddings generates embeddings for the provider with the given uid.
-func (p *ProvidersService) UpdateProviderEmbeddings(ctx context.Context, uid string, embeds []v1.Embedding, proj v1.Projection) ([]v1.Embedding, error) {
+// Duplicates are handled according to the provider dedup policy and
+// the stored embeddings they duplicate are returned in their place.
+// The new embeddings are projected using the fitted models of all the stored projections.
+// The requested projection is recomputed from scratch if its models are stale.
+func (p *ProvidersService) UpdateProviderEmbeddings(ctx context.Context, uid string, embeds []v1.Embedding, proj v1.Projection, opts *v1.ProjectionOptions) ([]v1.Embedding, error) {
 	// fetch all points so we can compute projections
 	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
 
-	upsertPoints := make([]*pb.PointStruct, 0, len(embeds))
-	for _, e := range embeds {
-		// create a new embedding point
-		data := make([]float32, 0, len(e.Values))
-		for _, val := range e.Values {
-			data = append(data, float32(val))
-		}
-		pointUID := e.UID
-		if pointUID == "" {
-			pointUID = uuid.NewString()
-		}
-		md := make(map[string]*pb.Value)
-		for k, v := range e.Metadata {
-			// FIXME: v can be of any type
-			if stringVal, ok := v.(string); ok {
-				md[k] = &pb.Value{
-					Kind: &pb.Value_StringValue{
-						StringValue: stringVal,
-					},
-				}
-			}
-		}
-		upsertPoints = append(upsertPoints, &pb.PointStruct{
-			Id: &pb.PointId{
-				PointIdOptions: &pb.PointId_Uuid{
-					Uuid: pointUID,
-				},
-			},
-			Vectors: &pb.Vectors{
-				VectorsOptions: &pb.Vectors_Vectors{
-					Vectors: &pb.NamedVectors{
-						Vectors: map[string]*pb.Vector{
-							"":   {Data: data},
-							"2D": {Data: []float32{0, 0}},
-							"3D": {Data: []float32{0, 0, 0}},
-						},
-					},
-				},
-			},
-			Payload: md,
-		})
+	if _, err := p.getVectorParams(ctx, uid); err != nil {
+		return nil, err
 	}
 
-	// TODO: can we avoid upserting duplicate points ?
-	// duplicate meaning similar vectors or the same vectors i.e.
-	// that have the same data not necessarily the same UUID
-	waitUpsert := true
-	if _, err := p.db.pts.Upsert(ctx, &pb.UpsertPoints{
-		CollectionName: uid,
-		Wait:           &waitUpsert,
-		Points:         upsertPoints,
-	}); err != nil {
-		return nil, v1.Errorf(v1.EINTERNAL, "Upsert error %v", err)
+	policy, err := p.getDedupPolicy(ctx, uid)
+	if err != nil {
+		return nil, err
 	}
 
-	// Collect all embeddings and calculate projections.
-	// NOTE: tread carefully, as this can shit memory pants on large collections!
-	req := &pb.ScrollPoints{
-		CollectionName: uid,
-		WithVectors: &pb.WithVectorsSelector{
-			SelectorOptions: &pb.WithVectorsSelector_Enable{
-				Enable: true,
-			},
-		},
-		WithPayload: &pb.WithPayloadSelector{
-			SelectorOptions: &pb.WithPayloadSelector_Enable{
-				Enable: true,
-			},
-		},
+	embeds = slices.Clone(embeds)
+	for i := range embeds {
+		if embeds[i].UID == "" {
+			embeds[i].UID = uuid.NewString()
+		}
 	}
 
-	embs := []v1.Embedding{}
-
-	for {
-		resp, err := p.db.pts.Scroll(ctx, req)
-		if err != nil {
-			return nil, v1.Errorf(v1.EINTERNAL, "Scroll error %v", err)
+	var stored []v1.Embedding
+	if policy.Mode != v1.DedupOff {
+		if stored, err = p.getDuplicateCandidates(ctx, uid, policy, embeds); err != nil {
+			return nil, err
 		}
-		next := resp.NextPageOffset
+	}
 
-		for _, p := range resp.GetResult() {
-			vecs := p.GetVectors().GetVectors()
-			if vecs != nil {
-				vals := make([]float64, 0, len(vecs.Vectors[""].Data))
-				for _, val := range vecs.Vectors[""].Data {
-					vals = append(vals, float64(val))
-				}
-				embs = append(embs, v1.Embedding{
-					UID:      p.Id.GetUuid(),
-					Values:   vals,
-					Metadata: payload2Meta(p.GetPayload()),
-				})
+	// NOTE: embeddings are returned by their UIDs which are
+	// either the UIDs of the added embeddings or of their duplicates
+	resUIDs := make([]string, 0, len(embeds))
+	newEmbs := make([]v1.Embedding, 0, len(embeds))
+	var (
+		dups     []v1.Embedding
+		payloads []map[string]*pb.Value
+	)
+	for _, e := range embeds {
+		if policy.Mode == v1.DedupOff {
+			newEmbs = append(newEmbs, e)
+			resUIDs = append(resUIDs, e.UID)
+			continue
+		}
+		if i := search.Duplicate(policy, e, stored); i >= 0 {
+			resUIDs = append(resUIDs, stored[i].UID)
+			j := slices.IndexFunc(dups, func(d v1.Embedding) bool { return d.UID == stored[i].UID })
+			if j < 0 {
+				dups = append(dups, stored[i])
+				payloads = append(payloads, map[string]*pb.Value{})
+				j = len(dups) - 1
+			}
+			if policy.Action == v1.DedupMerge {
+				md := make(map[string]any, len(dups[j].Metadata)+len(e.Metadata))
+				maps.Copy(md, dups[j].Metadata)
+				maps.Copy(md, e.Metadata)
+				dups[j].Metadata = md
+				maps.Copy(payloads[j], meta2Payload(e.Metadata))
 			}
+			continue
 		}
-		// stop paging we're done
-		if next == nil {
-			break
+		if i := search.Duplicate(policy, e, newEmbs); i >= 0 {
+			resUIDs = append(resUIDs, newEmbs[i].UID)
+			if policy.Action == v1.DedupMerge {
+				md := make(map[string]any, len(newEmbs[i].Metadata)+len(e.Metadata))
+				maps.Copy(md, newEmbs[i].Metadata)
+				maps.Copy(md, e.Metadata)
+				newEmbs[i].Metadata = md
+			}
+			continue
 		}
-		req.Offset = next
+		newEmbs = append(newEmbs, e)
+		resUIDs = append(resUIDs, e.UID)
 	}
 
-	projs, err := projection.Compute(embs, proj)
-	if err != nil {
-		return nil, v1.Errorf(v1.EINTERNAL, "Compute error %v", err)
+	if policy.Action == v1.DedupMerge && len(dups) > 0 {
+		if err := p.setPayloads(ctx, uid, dups, payloads); err != nil {
+			return nil, err
+		}
 	}
 
-	pointVecs := make([]*pb.PointVectors, 0, len(embs))
-
-	for i, emb := range embs {
-		pv := &pb.PointVectors{
-			Id: &pb.PointId{
-				PointIdOptions: &pb.PointId_Uuid{
-					Uuid: emb.UID,
-				},
-			},
-		}
-		namedVecs := make(map[string]*pb.Vector)
-		for dim, dimProjs := range projs {
-			data := make([]float32, 0, len(dimProjs[i].Values))
-			for _, val := range dimProjs[i].Values {
-				data = append(data, float32(val))
-			}
-			namedVecs[string(dim)] = &pb.Vector{
-				Data: data,
-			}
-		}
-		pv.Vectors = &pb.Vectors{
-			VectorsOptions: &pb.Vectors_Vectors{
-				Vectors: &pb.NamedVectors{
-					Vectors: namedVecs,
-				},
-			},
+	res := make([]v1.Embedding, 0, len(resUIDs))
+	for _, eid := range resUIDs {
+		i := slices.IndexFunc(newEmbs, func(e v1.Embedding) bool { return e.UID == eid })
+		if i >= 0 {
+			res = append(res, newEmbs[i])
+			continue
 		}
-		pointVecs = append(pointVecs, pv)
+		i = slices.IndexFunc(dups, func(e v1.Embedding) bool { return e.UID == eid })
+		res = append(res, dups[i])
 	}
 
-	if _, err := p.db.pts.UpdateVectors(ctx, &pb.UpdatePointVectors{
+	if len(newEmbs) == 0 {
+		// NOTE: nothing has been added so the projections don't need updating
+		return res, nil
+	}
+
+	existing, err := p.existingPoints(ctx, uid, newEmbs)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: upserting points replaces all their vectors so we only upsert
+	// the embedding vectors and leave the projections to extendProjections
+	wait := true
+	if _, err := p.db.pts.UpdateBatch(ctx, &pb.UpdateBatchPoints{
 		CollectionName: uid,
-		Wait:           &waitUpsert,
-		Points:         pointVecs,
+		Wait:           &wait,
+		Operations:     embeddingsUpdate(newEmbs, existing),
 	}); err != nil {
-		return nil, v1.Errorf(v1.EINTERNAL, "UpdateVectors error %v", err)
+		return nil, v1.Errorf(v1.EINTERNAL, "UpdateBatch error: %v", err)
+	}
+
+	// NOTE: models and options are not set until the projections have been computed
+	projModels, projOpts, err := p.getProjectionsState(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	// project the new embeddings using the fitted models of all the stored projections
+	// unless the models of the requested projection are stale in which case we recompute it.
+	stale := projModels[proj].Stale(proj, projOpts[proj], opts)
+	if stale {
+		delete(projModels, proj)
+	}
+	if err := p.extendProjections(ctx, uid, projModels, newEmbs); err != nil {
+		return nil, err
+	}
+
+	if stale {
+		if err := p.computeProjections(ctx, uid, proj, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.state.Put(ctx, uid, activeStateKey, proj); err != nil {
+		return nil, err
 	}
 
-	return embeds, nil
+	return res, nil
 }
 
 // DropProviderEmbeddings drops all provider embeddings from the store
@@ -576,120 +621,1478 @@ func (p *ProvidersService) DropProviderEmbeddings(ctx context.Context, uid strin
 		return v1.Errorf(v1.EINTERNAL, "UpdateAliases error: %v", err)
 	}
 
+	// NOTE: projection matrix and dedup policy are not derived from the embeddings so we keep them
+	m := new(v1.ProjectionMatrix)
+	hasMatrix, err := p.state.Get(ctx, uid, matrixStateKey, m)
+	if err != nil {
+		return err
+	}
+	policy := new(v1.DedupPolicy)
+	hasPolicy, err := p.state.Get(ctx, uid, dedupStateKey, policy)
+	if err != nil {
+		return err
+	}
+	if err := p.state.Drop(ctx, uid); err != nil {
+		return err
+	}
+	if hasMatrix {
+		if err := p.state.Put(ctx, uid, matrixStateKey, m); err != nil {
+			return err
+		}
+	}
+	if hasPolicy {
+		return p.state.Put(ctx, uid, dedupStateKey, policy)
+	}
 	return nil
 }
 
 // ComputeProviderProjections recomputes all projections from scratch for the provider with the given UID.
-func (p *ProvidersService) ComputeProviderProjections(ctx context.Context, uid string, proj v1.Projection) error {
-	// NOTE: tread carefully, as this can shit memory pants on large collections!
-	// fetch all points so we can compute projections
+func (p *ProvidersService) ComputeProviderProjections(ctx context.Context, uid string, proj v1.Projection, opts *v1.ProjectionOptions) error {
 	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
 
-	req := &pb.ScrollPoints{
-		CollectionName: uid,
-		WithVectors: &pb.WithVectorsSelector{
-			SelectorOptions: &pb.WithVectorsSelector_Enable{
-				Enable: true,
-			},
-		},
-		WithPayload: &pb.WithPayloadSelector{
-			SelectorOptions: &pb.WithPayloadSelector_Enable{
-				Enable: true,
-			},
-		},
+	if err := p.computeProjections(ctx, uid, proj, opts); err != nil {
+		return err
 	}
 
-	embs := []v1.Embedding{}
+	return p.state.Put(ctx, uid, activeStateKey, proj)
+}
 
-	for {
-		resp, err := p.db.pts.Scroll(ctx, req)
-		if err != nil {
-			return v1.Errorf(v1.EINTERNAL, "Scroll error %v", err)
+// computeProjections computes proj projections of all the embeddings
+// of the provider with the given uid and stores them along with the
+// options and models used to compute them.
+// Projections of large collections are computed for sampled landmarks and
+// interpolated for the remaining embeddings which are fetched in batches.
+// If the computation fails after the vectors started being updated, the projection
+// stays marked incomplete until it's successfully recomputed.
+func (p *ProvidersService) computeProjections(ctx context.Context, uid string, proj v1.Projection, opts *v1.ProjectionOptions) error {
+	vecParams, err := p.getVectorParams(ctx, uid)
+	if err != nil {
+		return err
+	}
+	opts = projection.Options(projection.Dims(opts, proj, projDims(vecParams)))
+	for _, dim := range opts.Dimensions() {
+		if _, ok := vecParams[v1.ProjectionKey(proj, dim)]; !ok {
+			return v1.Errorf(v1.EINVALID, "invalid %s dimension %v for provider %q", proj, dim, uid)
 		}
-		next := resp.NextPageOffset
+	}
 
-		for _, p := range resp.GetResult() {
-			vecs := p.GetVectors().GetVectors()
-			if vecs != nil {
-				vals := make([]float64, 0, len(vecs.Vectors[""].Data))
-				for _, val := range vecs.Vectors[""].Data {
-					vals = append(vals, float64(val))
-				}
-				embs = append(embs, v1.Embedding{
-					UID:      p.Id.GetUuid(),
-					Values:   vals,
-					Metadata: payload2Meta(p.GetPayload()),
-				})
-			}
-		}
-		// stop paging we're done
-		if next == nil {
-			break
-		}
-		req.Offset = next
+	// NOTE: fetching point IDs is cheap even for large collections
+	ids, err := p.getPointIDs(ctx, uid)
+	if err != nil {
+		return err
 	}
 
-	projs, err := projection.Compute(embs, proj)
+	var refs []v1.Embedding
+	landmarks := projection.SampleLandmarks(len(ids), opts)
+	if landmarks == nil {
+		refs, err = p.getAllEmbeddings(ctx, uid, nil)
+	} else {
+		lids := make([]*pb.PointId, 0, len(landmarks))
+		for _, i := range landmarks {
+			lids = append(lids, ids[i])
+		}
+		refs, err = p.getPoints(ctx, uid, lids)
+	}
 	if err != nil {
-		return v1.Errorf(v1.EINTERNAL, "Compute error %v", err)
+		return err
 	}
+	v1.ReportProgress(ctx, 0.1)
 
-	pointVecs := make([]*pb.PointVectors, 0, len(embs))
+	projs, models, err := projection.Compute(v1.ProgressRange(ctx, 0.1, 0.7), refs, proj, opts)
+	if err != nil {
+		return v1.Errorf(v1.ErrorCode(err), "Compute error %v", err)
+	}
 
-	for i, emb := range embs {
-		pv := &pb.PointVectors{
-			Id: &pb.PointId{
-				PointIdOptions: &pb.PointId_Uuid{
-					Uuid: emb.UID,
-				},
-			},
-		}
-		namedVecs := make(map[string]*pb.Vector)
-		for dim, dimProjs := range projs {
-			data := make([]float32, 0, len(dimProjs[i].Values))
-			for _, val := range dimProjs[i].Values {
-				data = append(data, float32(val))
-			}
-			namedVecs[string(dim)] = &pb.Vector{
-				Data: data,
-			}
-		}
-		pv.Vectors = &pb.Vectors{
-			VectorsOptions: &pb.Vectors_Vectors{
-				Vectors: &pb.NamedVectors{
-					Vectors: namedVecs,
-				},
-			},
-		}
-		pointVecs = append(pointVecs, pv)
+	// NOTE: the vectors are updated in batches so the projection is marked incomplete
+	// until all of them and the state describing them have been stored.
+	if err := p.state.Put(ctx, uid, projStateKey(incompleteStateKey, proj), true); err != nil {
+		return err
 	}
 
 	waitUpsert := true
 	if _, err := p.db.pts.UpdateVectors(ctx, &pb.UpdatePointVectors{
 		CollectionName: uid,
 		Wait:           &waitUpsert,
-		Points:         pointVecs,
+		Points:         projPointVectors(proj, refs, projs.Embeddings),
 	}); err != nil {
 		return v1.Errorf(v1.EINTERNAL, "UpdateVectors error %v", err)
 	}
+	v1.ReportProgress(ctx, 0.7)
 
-	return nil
+	if landmarks != nil {
+		ip, err := projection.NewInterpolator(models, refs, projs.Embeddings, *opts.Seed)
+		if err != nil {
+			return v1.Errorf(v1.EINTERNAL, "Interpolator error %v", err)
+		}
+		isRef := make(map[string]struct{}, len(refs))
+		for _, r := range refs {
+			isRef[r.UID] = struct{}{}
+		}
+
+		done := 0
+		err = p.scrollEmbeddings(ctx, uid, nil, false, func(embs []v1.Embedding) error {
+			rest := make([]v1.Embedding, 0, len(embs))
+			for _, e := range embs {
+				if _, ok := isRef[e.UID]; !ok {
+					rest = append(rest, e)
+				}
+			}
+			if len(rest) > 0 {
+				restProjs, err := ip.Project(ctx, rest)
+				if err != nil {
+					return v1.Errorf(v1.EINTERNAL, "Project error %v", err)
+				}
+				if _, err := p.db.pts.UpdateVectors(ctx, &pb.UpdatePointVectors{
+					CollectionName: uid,
+					Wait:           &waitUpsert,
+					Points:         projPointVectors(proj, rest, restProjs),
+				}); err != nil {
+					return v1.Errorf(v1.EINTERNAL, "UpdateVectors error %v", err)
+				}
+			}
+			done += len(embs)
+			v1.ReportProgress(ctx, 0.7+0.2*float64(done)/float64(len(ids)))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// NOTE: the models project all the embeddings
+		for _, model := range models {
+			model.Size = len(ids)
+		}
+	}
+
+	if err := p.state.Put(ctx, uid, projStateKey(optionsStateKey, proj), projs.Options); err != nil {
+		return err
+	}
+
+	if err := p.state.Put(ctx, uid, projStateKey(metricsStateKey, proj), projs.Metrics); err != nil {
+		return err
+	}
+
+	if err := p.state.Put(ctx, uid, projStateKey(modelsStateKey, proj), models); err != nil {
+		return err
+	}
+
+	return p.state.Delete(ctx, uid, projStateKey(incompleteStateKey, proj))
 }
 
-// getProviderMetadata returns metadata for the provider with the given uid.
-func (p *ProvidersService) getProviderMetadata(ctx context.Context, uid string) (map[string]any, error) {
-	col, err := p.db.col.Get(ctx, &pb.GetCollectionInfoRequest{CollectionName: uid})
+// ComputeProviderView projects the embeddings matching filter and stores the projections in the named view.
+// NOTE: view projections are stored in the state collection.
+func (p *ProvidersService) ComputeProviderView(ctx context.Context, uid, name string, filter *v1.EmbeddingsFilter, proj v1.Projection, opts *v1.ProjectionOptions) error {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	vecParams, err := p.getVectorParams(ctx, uid)
 	if err != nil {
-		return nil, v1.Errorf(v1.EINTERNAL, "GetCollection error: %v", err)
+		return err
 	}
-	params := col.Result.Config.Params.GetVectorsConfig().GetParamsMap()
 
-	md := make(map[string]any)
+	var pbFilter *pb.Filter
+	if filter != nil {
+		pbFilter, err = embeddingsFilter(filter)
+		if err != nil {
+			return err
+		}
+	}
+	embs, err := p.getAllEmbeddings(ctx, uid, pbFilter)
+	if err != nil {
+		return err
+	}
+	if len(embs) == 0 {
+		return v1.Errorf(v1.EINVALID, "no embeddings of provider %s match the view %s filter", uid, name)
+	}
+	v1.ReportProgress(ctx, 0.1)
+
+	opts = projection.Dims(opts, proj, projDims(vecParams))
+	projs, _, err := projection.Compute(v1.ProgressRange(ctx, 0.1, 0.9), embs, proj, opts)
+	if err != nil {
+		return v1.Errorf(v1.ErrorCode(err), "Compute error %v", err)
+	}
 
-	md["size"] = params.Map[""].Size
-	md["distance"] = params.Map[""].Distance
+	views := make(map[string]*v1.View)
+	if _, err := p.state.Get(ctx, uid, viewsStateKey, &views); err != nil {
+		return err
+	}
+	views[name] = &v1.View{
+		Name:       name,
+		Filter:     filter,
+		Projection: proj,
+		Options:    projs.Options,
+		Metrics:    projs.Metrics,
+		Count:      len(embs),
+	}
 
-	return md, nil
+	if err := p.state.Put(ctx, uid, viewStateKey(name), projs.Embeddings); err != nil {
+		return err
+	}
+
+	return p.state.Put(ctx, uid, viewsStateKey, views)
+}
+
+// GetProviderViews returns the views of the provider with the given uid sorted by their name.
+func (p *ProvidersService) GetProviderViews(ctx context.Context, uid string) ([]*v1.View, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+
+	views := make(map[string]*v1.View)
+	if _, err := p.state.Get(ctx, uid, viewsStateKey, &views); err != nil {
+		return nil, err
+	}
+
+	res := make([]*v1.View, 0, len(views))
+	for _, view := range views {
+		res = append(res, view)
+	}
+	slices.SortFunc(res, func(a, b *v1.View) int { return strings.Compare(a.Name, b.Name) })
+
+	return res, nil
+}
+
+// GetProviderView returns the named view of the provider with the given uid.
+func (p *ProvidersService) GetProviderView(ctx context.Context, uid, name string, filter v1.ProviderFilter) (*v1.View, v1.Page, error) {
+	page := v1.Page{}
+
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	views := make(map[string]*v1.View)
+	if _, err := p.state.Get(ctx, uid, viewsStateKey, &views); err != nil {
+		return nil, page, err
+	}
+	view, ok := views[name]
+	if !ok {
+		return nil, page, v1.Errorf(v1.ENOTFOUND, "view %q of provider %q not found", name, uid)
+	}
+
+	dims := view.Options.Dimensions()
+	if dim := filter.Dim; dim != nil {
+		if !slices.Contains(dims, *dim) {
+			return nil, page, v1.Errorf(v1.EINVALID, "invalid dimension %v for view %q", *dim, name)
+		}
+		dims = []v1.Dim{*dim}
+	}
+
+	projs := make(map[v1.Dim][]v1.Embedding)
+	if _, err := p.state.Get(ctx, uid, viewStateKey(name), &projs); err != nil {
+		return nil, page, err
+	}
+
+	offset, ok := filter.Offset.(int)
+	if !ok {
+		offset = 0
+	}
+
+	count := 0
+	view.Embeddings = make(map[v1.Dim][]v1.Embedding, len(dims))
+	metrics := make(map[v1.Dim]*v1.ProjectionMetrics, len(dims))
+	for _, dim := range dims {
+		count = len(projs[dim])
+		view.Embeddings[dim] = paging.ApplyOffsetLimit(projs[dim], offset, filter.Limit).([]v1.Embedding)
+		if m, ok := view.Metrics[dim]; ok {
+			metrics[dim] = m
+		}
+	}
+	view.Metrics = metrics
+	page.Count = &count
+
+	return view, page, nil
+}
+
+// DropProviderView drops the named view of the provider with the given uid.
+func (p *ProvidersService) DropProviderView(ctx context.Context, uid, name string) error {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	views := make(map[string]*v1.View)
+	if _, err := p.state.Get(ctx, uid, viewsStateKey, &views); err != nil {
+		return err
+	}
+	if _, ok := views[name]; !ok {
+		return v1.Errorf(v1.ENOTFOUND, "view %q of provider %q not found", name, uid)
+	}
+	delete(views, name)
+
+	if err := p.state.Delete(ctx, uid, viewStateKey(name)); err != nil {
+		return err
+	}
+
+	return p.state.Put(ctx, uid, viewsStateKey, views)
+}
+
+// AlignProviderProjections aligns the projections of the provider with the given uid
+// to the projections of the reference provider ref using Procrustes analysis.
+func (p *ProvidersService) AlignProviderProjections(ctx context.Context, uid, ref string, proj v1.Projection, dim v1.Dim, key string) (*v1.Alignment, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	vecName := v1.ProjectionKey(proj, dim)
+	projs := make([][]v1.Embedding, 0, 2)
+	for _, id := range []string{ref, uid} {
+		if _, err := p.GetProviderByUID(ctx, id); err != nil {
+			return nil, err
+		}
+		opts := new(v1.ProjectionOptions)
+		ok, err := p.state.Get(ctx, id, projStateKey(optionsStateKey, proj), opts)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || !slices.Contains(opts.Dimensions(), dim) {
+			return nil, v1.Errorf(v1.EINVALID, "no %s %s projections of provider %q", proj, dim, id)
+		}
+		if err := p.checkComplete(ctx, id, proj); err != nil {
+			return nil, err
+		}
+
+		embs := []v1.Embedding{}
+		if err := p.scrollVectors(ctx, id, vecName, nil, true, func(batch []v1.Embedding) error {
+			embs = append(embs, batch...)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		projs = append(projs, embs)
+	}
+
+	alignment, err := projection.Procrustes(projs[0], projs[1], key)
+	if err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "Procrustes error: %v", err)
+	}
+	alignment.Reference = ref
+	alignment.Projection = proj
+	alignment.Dim = dim
+
+	return alignment, nil
+}
+
+// UpdateProviderMatrix stores a new version of the projection matrix m of the provider with the given uid.
+func (p *ProvidersService) UpdateProviderMatrix(ctx context.Context, uid string, m *v1.ProjectionMatrix) (*v1.ProjectionMatrix, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+	if err := m.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	cur := new(v1.ProjectionMatrix)
+	if _, err := p.state.Get(ctx, uid, matrixStateKey, cur); err != nil {
+		return nil, err
+	}
+	if m.Version != 0 && m.Version != cur.Version {
+		return nil, v1.Errorf(v1.ECONFLICT, "matrix version %d of provider %q does not match: %d", m.Version, uid, cur.Version)
+	}
+
+	newMatrix := *m
+	newMatrix.Version = cur.Version + 1
+	if err := p.state.Put(ctx, uid, matrixStateKey, &newMatrix); err != nil {
+		return nil, err
+	}
+
+	return &newMatrix, nil
+}
+
+// GetProviderMatrix returns the projection matrix of the provider with the given uid.
+func (p *ProvidersService) GetProviderMatrix(ctx context.Context, uid string) (*v1.ProjectionMatrix, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+
+	m := new(v1.ProjectionMatrix)
+	ok, err := p.state.Get(ctx, uid, matrixStateKey, m)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, v1.Errorf(v1.ENOTFOUND, "matrix of provider %q not found", uid)
+	}
+
+	return m, nil
+}
+
+// DropProviderMatrix drops the projection matrix of the provider with the given uid.
+// NOTE: the projections computed using the matrix are kept until they're recomputed.
+func (p *ProvidersService) DropProviderMatrix(ctx context.Context, uid string) error {
+	if _, err := p.GetProviderMatrix(ctx, uid); err != nil {
+		return err
+	}
+
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	return p.state.Delete(ctx, uid, matrixStateKey)
+}
+
+// ClusterProviderEmbeddings clusters embeddings of the provider with the given uid
+// and stores the cluster assignments in the payload of the embeddings points.
+// DBSCAN streams the embeddings in batches; their neighbours are searched by qdrant
+// if the clustering metric matches the collection distance, otherwise the neighbours
+// are found by scanning the embeddings. K-means and HDBSCAN cluster all the embeddings
+// in memory; HDBSCAN is limited to hdbscanMaxEmbeddings as its spanning tree is quadratic.
+func (p *ProvidersService) ClusterProviderEmbeddings(ctx context.Context, uid string, opts *v1.ClusterOptions) (*v1.Clustering, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	switch opts.Algorithm {
+	case v1.DBSCAN:
+		return p.streamDBSCAN(ctx, uid, opts)
+	case v1.HDBSCAN:
+		n, err := p.countPoints(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		if n > hdbscanMaxEmbeddings {
+			return nil, v1.Errorf(v1.EINVALID, "%s supports at most %d embeddings, got: %d; use %s instead",
+				v1.HDBSCAN, hdbscanMaxEmbeddings, n, v1.DBSCAN)
+		}
+	}
+
+	embs := []v1.Embedding{}
+	if err := p.scrollEmbeddings(ctx, uid, nil, false, func(batch []v1.Embedding) error {
+		embs = append(embs, batch...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	clustering, assignments, err := cluster.Cluster(ctx, embs, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, v1.Errorf(v1.EINVALID, "Cluster error: %v", err)
+	}
+
+	payloads := make([]map[string]*pb.Value, len(embs))
+	for i := range embs {
+		payloads[i] = clusterPayload(assignments[i])
+	}
+	if err := p.setPayloads(ctx, uid, embs, payloads); err != nil {
+		return nil, err
+	}
+
+	return clustering, nil
+}
+
+// streamDBSCAN clusters embeddings of the provider with the given uid using DBSCAN
+// without holding them in memory and stores the cluster assignments in their payloads.
+func (p *ProvidersService) streamDBSCAN(ctx context.Context, uid string, opts *v1.ClusterOptions) (*v1.Clustering, error) {
+	params, err := p.getVectorParams(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	batches := func(ctx context.Context, fn func([]v1.Embedding) error) error {
+		return p.scrollEmbeddings(ctx, uid, nil, false, fn)
+	}
+
+	var nbs cluster.Neighbours
+	if threshold, ok := scoreThreshold(params[""].GetDistance(), opts.Metric, opts.Eps); ok {
+		n, err := p.countPoints(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		nbs = p.recommendNeighbours(uid, threshold, n)
+	} else {
+		nbs, err = cluster.ScanNeighbours(batches, opts.Eps, opts.Metric)
+		if err != nil {
+			return nil, v1.Errorf(v1.EINVALID, "Cluster error: %v", err)
+		}
+	}
+
+	clustering, uids, assignments, err := cluster.StreamDBSCAN(ctx, batches, nbs, opts)
+	if err != nil {
+		var e *v1.Error
+		if ctx.Err() != nil || errors.As(err, &e) {
+			return nil, err
+		}
+		return nil, v1.Errorf(v1.EINVALID, "Cluster error: %v", err)
+	}
+
+	embs := make([]v1.Embedding, len(uids))
+	payloads := make([]map[string]*pb.Value, len(uids))
+	for i, eid := range uids {
+		embs[i] = v1.Embedding{UID: eid}
+		payloads[i] = clusterPayload(assignments[i])
+	}
+	if err := p.setPayloads(ctx, uid, embs, payloads); err != nil {
+		return nil, err
+	}
+
+	return clustering, nil
+}
+
+// recommendNeighbours returns cluster.Neighbours which searches the neighbours of the embeddings
+// with scores within the given threshold in the collection with the given uid storing n points.
+// NOTE: the neighbours are searched by the stored vectors of the embeddings in batches.
+func (p *ProvidersService) recommendNeighbours(uid string, threshold float32, n int) cluster.Neighbours {
+	return func(ctx context.Context, embs []v1.Embedding, limit int) ([][]string, error) {
+		if limit == 0 {
+			limit = n
+		}
+
+		res := make([][]string, 0, len(embs))
+		for lo := 0; lo < len(embs); lo += searchBatchSize {
+			batch := embs[lo:min(lo+searchBatchSize, len(embs))]
+			reqs := make([]*pb.RecommendPoints, 0, len(batch))
+			for _, e := range batch {
+				reqs = append(reqs, &pb.RecommendPoints{
+					CollectionName: uid,
+					Positive:       []*pb.PointId{pointID(e.UID)},
+					Limit:          uint64(limit),
+					ScoreThreshold: &threshold,
+				})
+			}
+
+			resp, err := p.db.pts.RecommendBatch(ctx, &pb.RecommendBatchPoints{
+				CollectionName:  uid,
+				RecommendPoints: reqs,
+			})
+			if err != nil {
+				return nil, v1.Errorf(v1.EINTERNAL, "RecommendBatch error %v", err)
+			}
+			if len(resp.GetResult()) != len(batch) {
+				return nil, v1.Errorf(v1.EINTERNAL, "RecommendBatch returned %d results, expected: %d", len(resp.GetResult()), len(batch))
+			}
+
+			for _, r := range resp.GetResult() {
+				uids := make([]string, 0, len(r.GetResult()))
+				for _, pt := range r.GetResult() {
+					uids = append(uids, pt.Id.GetUuid())
+				}
+				res = append(res, uids)
+			}
+		}
+
+		return res, nil
+	}
+}
+
+// countPoints returns the number of points stored in the collection with the given uid.
+func (p *ProvidersService) countPoints(ctx context.Context, uid string) (int, error) {
+	exact := true
+	resp, err := p.db.pts.Count(ctx, &pb.CountPoints{
+		CollectionName: uid,
+		Exact:          &exact,
+	})
+	if err != nil {
+		return 0, v1.Errorf(v1.EINTERNAL, "Count error %v", err)
+	}
+	return int(resp.GetResult().GetCount()), nil
+}
+
+// DetectProviderOutliers scores embeddings of the provider with the given uid for outlierness
+// and stores the scores in the embeddings payload.
+func (p *ProvidersService) DetectProviderOutliers(ctx context.Context, uid string, opts *v1.OutlierOptions) (*v1.Outliers, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	embs, err := p.getAllEmbeddings(ctx, uid, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	scores, outliers, err := cluster.Outliers(ctx, embs, opts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, v1.Errorf(v1.EINVALID, "Outliers error: %v", err)
+	}
+
+	top := v1.DefaultOutlierTop
+	if opts.Top > 0 {
+		top = opts.Top
+	}
+	topK := search.NewTopK(top, true)
+	payloads := make([]map[string]*pb.Value, len(embs))
+	for i, e := range embs {
+		payloads[i] = map[string]*pb.Value{
+			v1.OutlierMetaKey: {
+				Kind: &pb.Value_DoubleValue{DoubleValue: scores[i]},
+			},
+		}
+		e.Metadata[v1.OutlierMetaKey] = scores[i]
+		topK.Push(e, scores[i])
+	}
+	if err := p.setPayloads(ctx, uid, embs, payloads); err != nil {
+		return nil, err
+	}
+	outliers.Embeddings = topK.Embeddings()
+
+	return outliers, nil
+}
+
+// PlaceProviderEmbeddings places embeddings embs into the stored projections of the provider
+// with the given uid using the fitted projection models without storing them.
+// Unless the filter requests a specific projection, the embeddings are placed
+// into the last computed projections.
+func (p *ProvidersService) PlaceProviderEmbeddings(ctx context.Context, uid string, embs []v1.Embedding, filter v1.ProviderFilter) (*v1.Projections, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+
+	proj := v1.PCA
+	if _, err := p.state.Get(ctx, uid, activeStateKey, &proj); err != nil {
+		return nil, err
+	}
+	if filter.Projection != nil {
+		proj = *filter.Projection
+	}
+
+	models := projection.Models{}
+	ok, err := p.state.Get(ctx, uid, projStateKey(modelsStateKey, proj), &models)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || len(models) == 0 {
+		return nil, v1.Errorf(v1.EINVALID, "no %s projections of provider %q", proj, uid)
+	}
+	if err := p.checkComplete(ctx, uid, proj); err != nil {
+		return nil, err
+	}
+	if dim := filter.Dim; dim != nil {
+		m, ok := models[*dim]
+		if !ok {
+			return nil, v1.Errorf(v1.EINVALID, "invalid dimension %v for provider %q", *dim, uid)
+		}
+		models = projection.Models{*dim: m}
+	}
+
+	opts := new(v1.ProjectionOptions)
+	ok, err = p.state.Get(ctx, uid, projStateKey(optionsStateKey, proj), opts)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		opts = nil
+	}
+
+	projs, err := p.placeEmbeddings(ctx, uid, map[v1.Projection]projection.Models{proj: models}, embs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.Projections{
+		Projection: proj,
+		Options:    opts,
+		Embeddings: projs[proj],
+	}, nil
+}
+
+// SearchProviderEmbeddings returns k embeddings of the provider with the given uid
+// most similar to the given embedding values. Embeddings are scored by the vector distance
+// of the provider collection which defaults to dot product.
+func (p *ProvidersService) SearchProviderEmbeddings(ctx context.Context, uid string, vals []float64, k int) ([]v1.ScoredEmbedding, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+	if k <= 0 {
+		return nil, v1.Errorf(v1.EINVALID, "invalid k: %d", k)
+	}
+
+	return p.searchEmbeddings(ctx, uid, vals, k, nil)
+}
+
+// GetProviderEmbeddingNeighbours returns k embeddings of the provider with the given uid
+// nearest to its embedding with the given eid measured by metric.
+// Neighbours are searched natively if metric matches the vector distance of the provider
+// collection; otherwise all the provider embeddings are scrolled and scored.
+func (p *ProvidersService) GetProviderEmbeddingNeighbours(ctx context.Context, uid, eid string, k int, metric v1.Metric) ([]v1.ScoredEmbedding, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+	if k <= 0 {
+		return nil, v1.Errorf(v1.EINVALID, "invalid k: %d", k)
+	}
+	score, higher, err := search.Scorer(metric)
+	if err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "invalid metric: %v", metric)
+	}
+
+	if _, err := uuid.Parse(eid); err != nil {
+		return nil, v1.Errorf(v1.ENOTFOUND, "embedding %q of provider %q not found", eid, uid)
+	}
+	embs, err := p.getPoints(ctx, uid, []*pb.PointId{pointID(eid)})
+	if err != nil {
+		return nil, err
+	}
+	if len(embs) == 0 {
+		return nil, v1.Errorf(v1.ENOTFOUND, "embedding %q of provider %q not found", eid, uid)
+	}
+	vals := embs[0].Values
+
+	vecParams, err := p.getVectorParams(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if dist, ok := metricDistance[metric]; ok && vecParams[""].GetDistance() == dist {
+		return p.searchEmbeddings(ctx, uid, vals, k, []*pb.PointId{pointID(eid)})
+	}
+
+	top := search.NewTopK(k, higher)
+	if err := p.scrollEmbeddings(ctx, uid, nil, true, func(batch []v1.Embedding) error {
+		for _, e := range batch {
+			if e.UID == eid {
+				continue
+			}
+			if len(e.Values) != len(vals) {
+				return v1.Errorf(v1.EINVALID, "embedding dimension mismatch: %d != %d", len(vals), len(e.Values))
+			}
+			top.Push(e, score(vals, e.Values))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return top.Embeddings(), nil
+}
+
+// GetProviderSimilarities returns the pairwise similarity matrix of the embeddings
+// of the provider with the given uid selected either by their UIDs or by the request filter.
+func (p *ProvidersService) GetProviderSimilarities(ctx context.Context, uid string, req *v1.SimilarityRequest) (*v1.Similarity, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+	if err := req.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	var (
+		pbFilter *pb.Filter
+		err      error
+	)
+	if len(req.UIDs) > 0 {
+		ids := make([]*pb.PointId, 0, len(req.UIDs))
+		for _, eid := range req.UIDs {
+			id, err := uuid.Parse(eid)
+			if err != nil {
+				return nil, v1.Errorf(v1.ENOTFOUND, "embedding %q of provider %q not found", eid, uid)
+			}
+			ids = append(ids, pointID(id.String()))
+		}
+		pbFilter = &pb.Filter{
+			Must: []*pb.Condition{
+				{
+					ConditionOneOf: &pb.Condition_HasId{
+						HasId: &pb.HasIdCondition{HasId: ids},
+					},
+				},
+			},
+		}
+	} else {
+		if pbFilter, err = embeddingsFilter(req.Filter); err != nil {
+			return nil, v1.Errorf(v1.EINVALID, "%v", err)
+		}
+	}
+
+	embs := []v1.Embedding{}
+	if err := p.scrollEmbeddings(ctx, uid, pbFilter, true, func(batch []v1.Embedding) error {
+		embs = append(embs, batch...)
+		if len(embs) > v1.MaxSimilarityEmbeddings {
+			return v1.Errorf(v1.EINVALID, "embeddings of provider %q matching the filter exceed max: %d", uid, v1.MaxSimilarityEmbeddings)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(embs) == 0 {
+		return nil, v1.Errorf(v1.EINVALID, "no embeddings of provider %q match the filter", uid)
+	}
+
+	if len(req.UIDs) > 0 {
+		// NOTE: scrolled points are ordered by their IDs rather than by the requested UIDs
+		idx := make(map[string]int, len(embs))
+		for i, e := range embs {
+			idx[e.UID] = i
+		}
+		ordered := make([]v1.Embedding, 0, len(req.UIDs))
+		for _, eid := range req.UIDs {
+			i, ok := idx[uuid.MustParse(eid).String()]
+			if !ok {
+				return nil, v1.Errorf(v1.ENOTFOUND, "embedding %q of provider %q not found", eid, uid)
+			}
+			ordered = append(ordered, embs[i])
+		}
+		embs = ordered
+	}
+
+	metric := v1.DefaultSimilarityMetric
+	if req.Metric != "" {
+		metric = req.Metric
+	}
+	sim, err := search.Similarities(ctx, embs, metric, req.Reorder)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, v1.Errorf(v1.EINVALID, "Similarity error: %v", err)
+	}
+
+	return sim, nil
+}
+
+// UpdateProviderDedup sets the dedup policy of the provider with the given uid.
+func (p *ProvidersService) UpdateProviderDedup(ctx context.Context, uid string, policy *v1.DedupPolicy) (*v1.DedupPolicy, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+
+	newPolicy := *policy
+	if err := p.state.Put(ctx, uid, dedupStateKey, &newPolicy); err != nil {
+		return nil, err
+	}
+
+	return &newPolicy, nil
+}
+
+// GetProviderDedup returns the dedup policy of the provider with the given uid.
+func (p *ProvidersService) GetProviderDedup(ctx context.Context, uid string) (*v1.DedupPolicy, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+
+	return p.getDedupPolicy(ctx, uid)
+}
+
+// GetProviderDuplicates returns the groups of duplicate embeddings
+// of the provider with the given uid detected using the given policy.
+// Duplicate text hashes are matched on all the provider embeddings
+// whereas vector duplicates are found by comparing all the pairs of them.
+func (p *ProvidersService) GetProviderDuplicates(ctx context.Context, uid string, policy *v1.DedupPolicy) (*v1.Duplicates, error) {
+	ctx = metadata.NewOutgoingContext(ctx, p.db.md)
+
+	if _, err := p.GetProviderByUID(ctx, uid); err != nil {
+		return nil, err
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, v1.Errorf(v1.EINVALID, "%v", err)
+	}
+	if policy.Mode == v1.DedupOff {
+		return nil, v1.Errorf(v1.EINVALID, "invalid mode: %v", policy.Mode)
+	}
+
+	embs, err := p.getAllEmbeddings(ctx, uid, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	dups := &v1.Duplicates{
+		Mode:   policy.Mode,
+		Groups: [][]v1.Embedding{},
+	}
+	if policy.Mode == v1.DedupVector {
+		dups.Threshold = policy.MinSimilarity()
+	}
+	for _, g := range search.Duplicates(policy, embs) {
+		group := make([]v1.Embedding, 0, len(g))
+		for _, i := range g {
+			group = append(group, embs[i])
+		}
+		dups.Groups = append(dups.Groups, group)
+	}
+
+	return dups, nil
+}
+
+// searchEmbeddings returns k embeddings of the provider with the given uid
+// most similar to vals measured by the vector distance of the provider collection.
+// The points with exclude IDs are excluded from the search.
+func (p *ProvidersService) searchEmbeddings(ctx context.Context, uid string, vals []float64, k int, exclude []*pb.PointId) ([]v1.ScoredEmbedding, error) {
+	data := make([]float32, 0, len(vals))
+	for _, val := range vals {
+		data = append(data, float32(val))
+	}
+
+	resp, err := p.db.pts.Search(ctx, &pb.SearchPoints{
+		CollectionName: uid,
+		Vector:         data,
+		Filter: &pb.Filter{
+			MustNot: []*pb.Condition{
+				{
+					ConditionOneOf: &pb.Condition_HasId{
+						HasId: &pb.HasIdCondition{HasId: exclude},
+					},
+				},
+			},
+		},
+		Limit: uint64(k),
+		WithVectors: &pb.WithVectorsSelector{
+			SelectorOptions: &pb.WithVectorsSelector_Include{
+				Include: &pb.VectorsSelector{
+					Names: []string{""},
+				},
+			},
+		},
+		WithPayload: &pb.WithPayloadSelector{
+			SelectorOptions: &pb.WithPayloadSelector_Enable{
+				Enable: true,
+			},
+		},
+	})
+	if err != nil {
+		return nil, v1.Errorf(v1.EINTERNAL, "Search error %v", err)
+	}
+
+	embs := make([]v1.ScoredEmbedding, 0, len(resp.Result))
+	for _, pt := range resp.Result {
+		vecs := pt.GetVectors().GetVectors()
+		if vecs == nil {
+			continue
+		}
+		embs = append(embs, v1.ScoredEmbedding{
+			Embedding: v1.Embedding{
+				UID:      pt.Id.GetUuid(),
+				Values:   getVecVals(vecs, ""),
+				Metadata: payload2Meta(pt.Payload),
+			},
+			Score: float64(pt.Score),
+		})
+	}
+
+	return embs, nil
+}
+
+// setPayloads sets the payloads of the points of embs of the provider with the given uid in batches.
+// NOTE: payloads must be in the same order as embs.
+func (p *ProvidersService) setPayloads(ctx context.Context, uid string, embs []v1.Embedding, payloads []map[string]*pb.Value) error {
+	ops := make([]*pb.PointsUpdateOperation, 0, payloadBatchSize)
+	for i, e := range embs {
+		ops = append(ops, &pb.PointsUpdateOperation{
+			Operation: &pb.PointsUpdateOperation_SetPayload_{
+				SetPayload: &pb.PointsUpdateOperation_SetPayload{
+					Payload: payloads[i],
+					PointsSelector: &pb.PointsSelector{
+						PointsSelectorOneOf: &pb.PointsSelector_Points{
+							Points: &pb.PointsIdsList{Ids: []*pb.PointId{pointID(e.UID)}},
+						},
+					},
+				},
+			},
+		})
+		if len(ops) == payloadBatchSize || i == len(embs)-1 {
+			wait := true
+			if _, err := p.db.pts.UpdateBatch(ctx, &pb.UpdateBatchPoints{
+				CollectionName: uid,
+				Wait:           &wait,
+				Operations:     ops,
+			}); err != nil {
+				return v1.Errorf(v1.EINTERNAL, "UpdateBatch error: %v", err)
+			}
+			ops = ops[:0]
+		}
+	}
+	return nil
+}
+
+// getDedupPolicy returns the dedup policy of the provider with the given uid.
+// Dedup is off unless the policy has been set.
+func (p *ProvidersService) getDedupPolicy(ctx context.Context, uid string) (*v1.DedupPolicy, error) {
+	policy := new(v1.DedupPolicy)
+	ok, err := p.state.Get(ctx, uid, dedupStateKey, policy)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &v1.DedupPolicy{Mode: v1.DedupOff}, nil
+	}
+	return policy, nil
+}
+
+// getDuplicateCandidates returns the stored embeddings of the provider with the given uid
+// which may be duplicated by embs under the given policy.
+// NOTE: vector duplicates are only looked for among the dedupCandidates nearest points
+// ranked by the collection distance which matches the cosine similarity for normalised embeddings.
+func (p *ProvidersService) getDuplicateCandidates(ctx context.Context, uid string, policy *v1.DedupPolicy, embs []v1.Embedding) ([]v1.Embedding, error) {
+	switch policy.Mode {
+	case v1.DedupText:
+		conds := make([]*pb.Condition, 0, len(embs))
+		for _, e := range embs {
+			if hash, ok := e.Metadata[v1.TextHashMetaKey].(string); ok && hash != "" {
+				conds = append(conds, matchKeyword(v1.TextHashMetaKey, hash))
+			}
+		}
+		if len(conds) == 0 {
+			return nil, nil
+		}
+		return p.getAllEmbeddings(ctx, uid, &pb.Filter{Should: conds})
+	case v1.DedupVector:
+		var candidates []v1.Embedding
+		seen := make(map[string]struct{})
+		for _, e := range embs {
+			nearest, err := p.searchEmbeddings(ctx, uid, e.Values, dedupCandidates, nil)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range nearest {
+				if _, ok := seen[n.UID]; ok {
+					continue
+				}
+				seen[n.UID] = struct{}{}
+				candidates = append(candidates, n.Embedding)
+			}
+		}
+		return candidates, nil
+	}
+	return nil, nil
+}
+
+// getAllEmbeddings returns all the embeddings of the provider with the given uid which match filter.
+func (p *ProvidersService) getAllEmbeddings(ctx context.Context, uid string, filter *pb.Filter) ([]v1.Embedding, error) {
+	embs := []v1.Embedding{}
+	err := p.scrollEmbeddings(ctx, uid, filter, true, func(batch []v1.Embedding) error {
+		embs = append(embs, batch...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return embs, nil
+}
+
+// scrollEmbeddings scrolls through all the embeddings of the provider with the given uid
+// which match filter in batches and calls fn for every batch of embeddings.
+// Embedding metadata are only fetched if withPayload is true.
+func (p *ProvidersService) scrollEmbeddings(ctx context.Context, uid string, filter *pb.Filter, withPayload bool, fn func([]v1.Embedding) error) error {
+	return p.scrollVectors(ctx, uid, "", filter, withPayload, fn)
+}
+
+// scrollVectors scrolls through the named vectors vecName of the points of the provider with the given uid
+// which match filter in batches and calls fn for every batch of vectors. The default vector name "" stores embeddings.
+// Point metadata are only fetched if withPayload is true.
+func (p *ProvidersService) scrollVectors(ctx context.Context, uid, vecName string, filter *pb.Filter, withPayload bool, fn func([]v1.Embedding) error) error {
+	limit := uint32(scrollBatchSize)
+	req := &pb.ScrollPoints{
+		CollectionName: uid,
+		Filter:         filter,
+		WithVectors: &pb.WithVectorsSelector{
+			SelectorOptions: &pb.WithVectorsSelector_Include{
+				Include: &pb.VectorsSelector{
+					Names: []string{vecName},
+				},
+			},
+		},
+		WithPayload: &pb.WithPayloadSelector{
+			SelectorOptions: &pb.WithPayloadSelector_Enable{
+				Enable: withPayload,
+			},
+		},
+		Limit: &limit,
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return v1.Errorf(v1.EINTERNAL, "Scroll error %v", err)
+		}
+		resp, err := p.db.pts.Scroll(ctx, req)
+		if err != nil {
+			return v1.Errorf(v1.EINTERNAL, "Scroll error %v", err)
+		}
+		next := resp.NextPageOffset
+
+		embs := make([]v1.Embedding, 0, len(resp.GetResult()))
+		for _, p := range resp.GetResult() {
+			// NOTE: points miss the projection vectors
+			// which have not been computed yet.
+			vals := getVecVals(p.GetVectors().GetVectors(), vecName)
+			if len(vals) == 0 {
+				continue
+			}
+			embs = append(embs, v1.Embedding{
+				UID:      p.Id.GetUuid(),
+				Values:   vals,
+				Metadata: payload2Meta(p.GetPayload()),
+			})
+		}
+		if err := fn(embs); err != nil {
+			return err
+		}
+		// stop paging we're done
+		if next == nil {
+			break
+		}
+		req.Offset = next
+	}
+
+	return nil
+}
+
+// getPointIDs returns IDs of all the points of the provider with the given uid.
+func (p *ProvidersService) getPointIDs(ctx context.Context, uid string) ([]*pb.PointId, error) {
+	limit := uint32(scrollIDsBatchSize)
+	req := &pb.ScrollPoints{
+		CollectionName: uid,
+		WithVectors: &pb.WithVectorsSelector{
+			SelectorOptions: &pb.WithVectorsSelector_Enable{
+				Enable: false,
+			},
+		},
+		WithPayload: &pb.WithPayloadSelector{
+			SelectorOptions: &pb.WithPayloadSelector_Enable{
+				Enable: false,
+			},
+		},
+		Limit: &limit,
+	}
+
+	ids := []*pb.PointId{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, v1.Errorf(v1.EINTERNAL, "Scroll error %v", err)
+		}
+		resp, err := p.db.pts.Scroll(ctx, req)
+		if err != nil {
+			return nil, v1.Errorf(v1.EINTERNAL, "Scroll error %v", err)
+		}
+		for _, p := range resp.GetResult() {
+			ids = append(ids, p.GetId())
+		}
+		if resp.NextPageOffset == nil {
+			break
+		}
+		req.Offset = resp.NextPageOffset
+	}
+
+	return ids, nil
+}
+
+// existingPoints returns the UIDs of the stored points of embeddings embs.
+func (p *ProvidersService) existingPoints(ctx context.Context, uid string, embs []v1.Embedding) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for lo := 0; lo < len(embs); lo += scrollBatchSize {
+		ids := make([]*pb.PointId, 0, min(scrollBatchSize, len(embs)-lo))
+		for _, e := range embs[lo:min(lo+scrollBatchSize, len(embs))] {
+			ids = append(ids, pointID(e.UID))
+		}
+		resp, err := p.db.pts.Get(ctx, &pb.GetPoints{
+			CollectionName: uid,
+			Ids:            ids,
+			WithVectors: &pb.WithVectorsSelector{
+				SelectorOptions: &pb.WithVectorsSelector_Enable{
+					Enable: false,
+				},
+			},
+			WithPayload: &pb.WithPayloadSelector{
+				SelectorOptions: &pb.WithPayloadSelector_Enable{
+					Enable: false,
+				},
+			},
+		})
+		if err != nil {
+			return nil, v1.Errorf(v1.EINTERNAL, "Get error %v", err)
+		}
+		for _, p := range resp.GetResult() {
+			existing[p.Id.GetUuid()] = true
+		}
+	}
+
+	return existing, nil
+}
+
+// getPoints returns the embeddings of the points with the given ids
+// of the provider with the given uid. It fetches the points in batches.
+func (p *ProvidersService) getPoints(ctx context.Context, uid string, ids []*pb.PointId) ([]v1.Embedding, error) {
+	embs := make([]v1.Embedding, 0, len(ids))
+	for lo := 0; lo < len(ids); lo += scrollBatchSize {
+		resp, err := p.db.pts.Get(ctx, &pb.GetPoints{
+			CollectionName: uid,
+			Ids:            ids[lo:min(lo+scrollBatchSize, len(ids))],
+			WithVectors: &pb.WithVectorsSelector{
+				SelectorOptions: &pb.WithVectorsSelector_Include{
+					Include: &pb.VectorsSelector{
+						Names: []string{""},
+					},
+				},
+			},
+			WithPayload: &pb.WithPayloadSelector{
+				SelectorOptions: &pb.WithPayloadSelector_Enable{
+					Enable: false,
+				},
+			},
+		})
+		if err != nil {
+			return nil, v1.Errorf(v1.EINTERNAL, "Get error %v", err)
+		}
+		for _, p := range resp.GetResult() {
+			if vecs := p.GetVectors().GetVectors(); vecs != nil {
+				embs = append(embs, v1.Embedding{
+					UID:    p.Id.GetUuid(),
+					Values: getVecVals(vecs, ""),
+				})
+			}
+		}
+	}
+
+	return embs, nil
+}
+
+// getProjectionsState returns the fitted models and the options of all the stored projections.
+// Incomplete projections are skipped as their models don't describe their vectors.
+func (p *ProvidersService) getProjectionsState(ctx context.Context, uid string) (map[v1.Projection]projection.Models, map[v1.Projection]*v1.ProjectionOptions, error) {
+	projModels := make(map[v1.Projection]projection.Models)
+	projOpts := make(map[v1.Projection]*v1.ProjectionOptions)
+
+	for _, proj := range projections() {
+		models := projection.Models{}
+		ok, err := p.state.Get(ctx, uid, projStateKey(modelsStateKey, proj), &models)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			continue
+		}
+		incomplete, err := p.state.Get(ctx, uid, projStateKey(incompleteStateKey, proj), new(bool))
+		if err != nil {
+			return nil, nil, err
+		}
+		if incomplete {
+			continue
+		}
+		opts := new(v1.ProjectionOptions)
+		ok, err = p.state.Get(ctx, uid, projStateKey(optionsStateKey, proj), opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			opts = nil
+		}
+		projModels[proj], projOpts[proj] = models, opts
+	}
+
+	return projModels, projOpts, nil
+}
+
+// checkComplete returns error if the computation of the proj projections
+// of the provider with the given uid has not finished.
+func (p *ProvidersService) checkComplete(ctx context.Context, uid string, proj v1.Projection) error {
+	incomplete, err := p.state.Get(ctx, uid, projStateKey(incompleteStateKey, proj), new(bool))
+	if err != nil {
+		return err
+	}
+	if incomplete {
+		return v1.Errorf(v1.EINVALID, "incomplete %s projections of provider %q must be recomputed", proj, uid)
+	}
+	return nil
+}
+
+// extendProjections projects new embeddings embs using the fitted models and stores
+// their projections without recomputing the projections of the existing embeddings.
+func (p *ProvidersService) extendProjections(ctx context.Context, uid string, projModels map[v1.Projection]projection.Models, embs []v1.Embedding) error {
+	if len(projModels) == 0 {
+		return nil
+	}
+
+	// NOTE: the new embeddings are already stored so we
+	// must exclude them from the nearest neighbour search.
+	ids := make([]*pb.PointId, 0, len(embs))
+	for _, e := range embs {
+		ids = append(ids, pointID(e.UID))
+	}
+
+	projs, err := p.placeEmbeddings(ctx, uid, projModels, embs, ids)
+	if err != nil {
+		return err
+	}
+
+	for proj, models := range projModels {
+		waitUpsert := true
+		if _, err := p.db.pts.UpdateVectors(ctx, &pb.UpdatePointVectors{
+			CollectionName: uid,
+			Wait:           &waitUpsert,
+			Points:         projPointVectors(proj, embs, projs[proj]),
+		}); err != nil {
+			return v1.Errorf(v1.EINTERNAL, "UpdateVectors error %v", err)
+		}
+
+		models.Added(len(embs))
+
+		if err := p.state.Put(ctx, uid, projStateKey(modelsStateKey, proj), models); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// placeEmbeddings projects embeddings embs using the fitted models without storing their projections.
+// Non-linear models place embs among the projections of their nearest stored neighbours;
+// points with the given ids are excluded from the nearest neighbour search.
+// NOTE: projections are placed by the euclidean distances of the neighbours which may differ
+// from the collection distance, so the neighbours are re-ranked among placeCandidates nearest points.
+func (p *ProvidersService) placeEmbeddings(ctx context.Context, uid string, projModels map[v1.Projection]projection.Models, embs []v1.Embedding, exclude []*pb.PointId) (map[v1.Projection]map[v1.Dim][]v1.Embedding, error) {
+	linear := true
+	for _, models := range projModels {
+		for _, m := range models {
+			linear = linear && m.Linear()
+		}
+	}
+
+	var (
+		cands [][]*pb.ScoredPoint
+		err   error
+	)
+	if !linear {
+		cands, err = p.nearestPoints(ctx, uid, embs, exclude, placeCandidates)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	projs := make(map[v1.Projection]map[v1.Dim][]v1.Embedding, len(projModels))
+	for i, e := range embs {
+		var nbs []*pb.ScoredPoint
+		if !linear {
+			nbs = cands[i]
+		}
+		for proj, models := range projModels {
+			refs, refProjs := placeRefs(proj, models, e.Values, nbs)
+			eProjs, err := projection.Extend(models, refs, refProjs, []v1.Embedding{e})
+			if err != nil {
+				return nil, v1.Errorf(v1.EINTERNAL, "Extend error %v", err)
+			}
+			if projs[proj] == nil {
+				projs[proj] = make(map[v1.Dim][]v1.Embedding, len(models))
+			}
+			for dim, dimProjs := range eProjs {
+				projs[proj][dim] = append(projs[proj][dim], dimProjs...)
+			}
+		}
+	}
+
+	return projs, nil
+}
+
+// nearestPoints returns k nearest points of every embedding of embs with all their vectors.
+// The points are searched in batches of searchBatchSize embeddings.
+// Points with the given ids are excluded from the search.
+func (p *ProvidersService) nearestPoints(ctx context.Context, uid string, embs []v1.Embedding, exclude []*pb.PointId, k int) ([][]*pb.ScoredPoint, error) {
+	filter := &pb.Filter{
+		MustNot: []*pb.Condition{
+			{
+				ConditionOneOf: &pb.Condition_HasId{
+					HasId: &pb.HasIdCondition{HasId: exclude},
+				},
+			},
+		},
+	}
+
+	res := make([][]*pb.ScoredPoint, 0, len(embs))
+	for lo := 0; lo < len(embs); lo += searchBatchSize {
+		batch := embs[lo:min(lo+searchBatchSize, len(embs))]
+		searches := make([]*pb.SearchPoints, 0, len(batch))
+		for _, e := range batch {
+			data := make([]float32, 0, len(e.Values))
+			for _, val := range e.Values {
+				data = append(data, float32(val))
+			}
+			searches = append(searches, &pb.SearchPoints{
+				CollectionName: uid,
+				Vector:         data,
+				Filter:         filter,
+				Limit:          uint64(k),
+				WithVectors: &pb.WithVectorsSelector{
+					SelectorOptions: &pb.WithVectorsSelector_Enable{
+						Enable: true,
+					},
+				},
+			})
+		}
+
+		resp, err := p.db.pts.SearchBatch(ctx, &pb.SearchBatchPoints{
+			CollectionName: uid,
+			SearchPoints:   searches,
+		})
+		if err != nil {
+			return nil, v1.Errorf(v1.EINTERNAL, "SearchBatch error %v", err)
+		}
+		if len(resp.GetResult()) != len(batch) {
+			return nil, v1.Errorf(v1.EINTERNAL, "SearchBatch returned %d results, expected: %d", len(resp.GetResult()), len(batch))
+		}
+
+		for _, r := range resp.GetResult() {
+			nbs := make([]*pb.ScoredPoint, 0, len(r.GetResult()))
+			for _, pt := range r.GetResult() {
+				if pt.GetVectors().GetVectors() != nil {
+					nbs = append(nbs, pt)
+				}
+			}
+			res = append(res, nbs)
+		}
+	}
+
+	return res, nil
+}
+
+// getProviderMetadata returns metadata for the provider with the given uid.
+func (p *ProvidersService) getProviderMetadata(ctx context.Context, uid string) (map[string]any, error) {
+	params, err := p.getVectorParams(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	md := make(map[string]any)
+
+	md["size"] = params[""].Size
+	md["distance"] = params[""].Distance
+	md[v1.DimsMetaKey] = projDims(params)
+
+	return md, nil
+}
+
+// getVectorParams returns the params of the named vectors
+// of the collection of the provider with the given uid.
+func (p *ProvidersService) getVectorParams(ctx context.Context, uid string) (map[string]*pb.VectorParams, error) {
+	col, err := p.db.col.Get(ctx, &pb.GetCollectionInfoRequest{CollectionName: uid})
+	if err != nil {
+		return nil, v1.Errorf(v1.EINTERNAL, "GetCollection error: %v", err)
+	}
+	return col.Result.Config.Params.GetVectorsConfig().GetParamsMap().GetMap(), nil
+}
+
+// meta2Payload converts the metadata md to the point payload.
+func meta2Payload(md map[string]any) map[string]*pb.Value {
+	payload := make(map[string]*pb.Value)
+	for k, v := range md {
+		// FIXME: v can be of any type
+		if stringVal, ok := v.(string); ok {
+			payload[k] = &pb.Value{
+				Kind: &pb.Value_StringValue{
+					StringValue: stringVal,
+				},
+			}
+		}
+	}
+	return payload
 }
 
 func payload2Meta(payload map[string]*pb.Value) map[string]any {
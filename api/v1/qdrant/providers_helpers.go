@@ -1,6 +1,13 @@
 package qdrant
 
 import (
+	"math"
+	"sort"
+	"strings"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/internal/cluster"
+	"github.com/milosgajdos/embeviz/api/v1/internal/projection"
 	pb "github.com/qdrant/go-client/qdrant"
 )
 
@@ -8,10 +15,293 @@ import (
 func getVecVals(vecs *pb.NamedVectors, dim string) []float64 {
 	// TODO: check if that key actually exists
 	// and maybe return some flag or error.
-	vecData := vecs.Vectors[dim].Data
+	vecData := vecs.GetVectors()[dim].GetData()
 	vals := make([]float64, 0, len(vecData))
 	for _, val := range vecData {
 		vals = append(vals, float64(val))
 	}
 	return vals
 }
+
+// metricDistance maps metrics to the matching qdrant vector distances.
+var metricDistance = map[v1.Metric]pb.Distance{
+	v1.Cosine:    pb.Distance_Cosine,
+	v1.Dot:       pb.Distance_Dot,
+	v1.Euclidean: pb.Distance_Euclid,
+}
+
+// pointID returns qdrant point ID for the given uid.
+func pointID(uid string) *pb.PointId {
+	return &pb.PointId{
+		PointIdOptions: &pb.PointId_Uuid{
+			Uuid: uid,
+		},
+	}
+}
+
+// projPointVectors returns proj projection vectors of the points of the given embeddings.
+// NOTE: projs must contain the projections of embs in the same order as embs.
+func projPointVectors(proj v1.Projection, embs []v1.Embedding, projs map[v1.Dim][]v1.Embedding) []*pb.PointVectors {
+	pointVecs := make([]*pb.PointVectors, 0, len(embs))
+
+	for i, emb := range embs {
+		namedVecs := make(map[string]*pb.Vector)
+		for dim, dimProjs := range projs {
+			data := make([]float32, 0, len(dimProjs[i].Values))
+			for _, val := range dimProjs[i].Values {
+				data = append(data, float32(val))
+			}
+			namedVecs[v1.ProjectionKey(proj, dim)] = &pb.Vector{
+				Data: data,
+			}
+		}
+		pointVecs = append(pointVecs, &pb.PointVectors{
+			Id: pointID(emb.UID),
+			Vectors: &pb.Vectors{
+				VectorsOptions: &pb.Vectors_Vectors{
+					Vectors: &pb.NamedVectors{
+						Vectors: namedVecs,
+					},
+				},
+			},
+		})
+	}
+
+	return pointVecs
+}
+
+// embeddingsUpdate returns the operations which store the values and the metadata of embeddings embs.
+// Points which don't exist are upserted; the points of the existing embeddings have their values
+// updated and their payloads overwritten so their projection vectors are not overwritten.
+// NOTE: projections are stored separately via UpdateVectors once they've been computed.
+func embeddingsUpdate(embs []v1.Embedding, existing map[string]bool) []*pb.PointsUpdateOperation {
+	var (
+		points  []*pb.PointStruct
+		vectors []*pb.PointVectors
+		ops     []*pb.PointsUpdateOperation
+	)
+
+	for _, e := range embs {
+		data := make([]float32, 0, len(e.Values))
+		for _, val := range e.Values {
+			data = append(data, float32(val))
+		}
+		// NOTE(milosgajdos): empty name vector
+		// is the "default" point vector.
+		vecs := &pb.Vectors{
+			VectorsOptions: &pb.Vectors_Vectors{
+				Vectors: &pb.NamedVectors{
+					Vectors: map[string]*pb.Vector{
+						"": {Data: data},
+					},
+				},
+			},
+		}
+		if !existing[e.UID] {
+			points = append(points, &pb.PointStruct{
+				Id:      pointID(e.UID),
+				Vectors: vecs,
+				Payload: meta2Payload(e.Metadata),
+			})
+			continue
+		}
+		vectors = append(vectors, &pb.PointVectors{
+			Id:      pointID(e.UID),
+			Vectors: vecs,
+		})
+		ops = append(ops, &pb.PointsUpdateOperation{
+			Operation: &pb.PointsUpdateOperation_OverwritePayload{
+				OverwritePayload: &pb.PointsUpdateOperation_SetPayload{
+					Payload: meta2Payload(e.Metadata),
+					PointsSelector: &pb.PointsSelector{
+						PointsSelectorOneOf: &pb.PointsSelector_Points{
+							Points: &pb.PointsIdsList{Ids: []*pb.PointId{pointID(e.UID)}},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	if len(vectors) > 0 {
+		ops = append([]*pb.PointsUpdateOperation{{
+			Operation: &pb.PointsUpdateOperation_UpdateVectors_{
+				UpdateVectors: &pb.PointsUpdateOperation_UpdateVectors{
+					Points: vectors,
+				},
+			},
+		}}, ops...)
+	}
+	if len(points) > 0 {
+		ops = append([]*pb.PointsUpdateOperation{{
+			Operation: &pb.PointsUpdateOperation_Upsert{
+				Upsert: &pb.PointsUpdateOperation_PointStructList{
+					Points: points,
+				},
+			},
+		}}, ops...)
+	}
+
+	return ops
+}
+
+// placeRefs returns projection.DefaultNeighbours nearest neighbours of vals among the candidate points
+// ranked by their euclidean distance and their proj projections keyed by the dimensions of the models.
+// Candidates which don't store all the projections are skipped.
+func placeRefs(proj v1.Projection, models projection.Models, vals []float64, cands []*pb.ScoredPoint) ([]v1.Embedding, map[v1.Dim][]v1.Embedding) {
+	embs := make([]v1.Embedding, 0, len(cands))
+	projs := make(map[v1.Dim][]v1.Embedding, len(models))
+	for _, pt := range cands {
+		vecs := pt.GetVectors().GetVectors()
+		ptProjs := make(map[v1.Dim]v1.Embedding, len(models))
+		for dim := range models {
+			projVals := getVecVals(vecs, v1.ProjectionKey(proj, dim))
+			if len(projVals) == 0 {
+				break
+			}
+			ptProjs[dim] = v1.Embedding{UID: pt.Id.GetUuid(), Values: projVals}
+		}
+		if len(ptProjs) != len(models) {
+			continue
+		}
+		embs = append(embs, v1.Embedding{
+			UID:    pt.Id.GetUuid(),
+			Values: getVecVals(vecs, ""),
+		})
+		for dim, e := range ptProjs {
+			projs[dim] = append(projs[dim], e)
+		}
+	}
+
+	idx := projection.Nearest(vals, embs, projection.DefaultNeighbours)
+	refs := make([]v1.Embedding, 0, len(idx))
+	refProjs := make(map[v1.Dim][]v1.Embedding, len(models))
+	for _, i := range idx {
+		refs = append(refs, embs[i])
+		for dim := range models {
+			refProjs[dim] = append(refProjs[dim], projs[dim][i])
+		}
+	}
+	return refs, refProjs
+}
+
+// clusterPayload returns the payload storing the cluster assignment a.
+func clusterPayload(a cluster.Assignment) map[string]*pb.Value {
+	return map[string]*pb.Value{
+		v1.ClusterMetaKey: {
+			Kind: &pb.Value_IntegerValue{IntegerValue: int64(a.Cluster)},
+		},
+		v1.ClusterDistanceMetaKey: {
+			Kind: &pb.Value_DoubleValue{DoubleValue: a.Distance},
+		},
+	}
+}
+
+// scoreThreshold returns the score threshold of the neighbours within eps distance measured
+// by metric in collections with the given distance. It returns false if qdrant can't search
+// the neighbours, i.e. if the collection distance does not match the metric.
+func scoreThreshold(dist pb.Distance, metric v1.Metric, eps float64) (float32, bool) {
+	if metric == "" {
+		metric = v1.Euclidean
+	}
+	switch {
+	case dist == pb.Distance_Euclid && metric == v1.Euclidean:
+		return float32(eps), true
+	case dist == pb.Distance_Cosine && metric == v1.Cosine:
+		// NOTE: cosine scores are similarities
+		return float32(1 - eps), true
+	}
+	return 0, false
+}
+
+// vectorParams returns the params of the collection vectors which store embeddings of the given size
+// and the projections of all the registered projection algorithms of the given dimensions.
+func vectorParams(size uint64, dist pb.Distance, dims []v1.Dim) map[string]*pb.VectorParams {
+	params := map[string]*pb.VectorParams{
+		// NOTE(milosgajdos): empty name vector
+		// is the "default" point vector.
+		"": {
+			Size:     size,
+			Distance: dist,
+		},
+	}
+	for _, projector := range v1.Projectors() {
+		alg := projector.Algorithm()
+		for _, dim := range dims {
+			if !alg.Supports(dim) {
+				continue
+			}
+			params[v1.ProjectionKey(alg.Name, dim)] = &pb.VectorParams{
+				Size:     uint64(dim.Size()),
+				Distance: dist,
+			}
+		}
+	}
+	return params
+}
+
+// projections returns the names of all registered projections.
+// Projections are stored as named vectors of the collection points.
+// NOTE: qdrant requires all named vectors to be configured when the collection
+// is created, so the collections are created with vectors of all registered projections.
+func projections() []v1.Projection {
+	projectors := v1.Projectors()
+	px := make([]v1.Projection, 0, len(projectors))
+	for _, p := range projectors {
+		px = append(px, p.Algorithm().Name)
+	}
+	return px
+}
+
+// projDims returns the projection dimensions stored in the named vectors
+// with the given params sorted by their size.
+func projDims(params map[string]*pb.VectorParams) []v1.Dim {
+	seen := make(map[v1.Dim]struct{})
+	dims := []v1.Dim{}
+	for name := range params {
+		// NOTE: legacy collections store projections
+		// in the vectors named by their dimensions.
+		dim := v1.Dim(name[strings.LastIndex(name, "/")+1:])
+		if _, ok := seen[dim]; ok || !dim.Valid() {
+			continue
+		}
+		seen[dim] = struct{}{}
+		dims = append(dims, dim)
+	}
+	sort.Slice(dims, func(i, j int) bool { return dims[i].Size() < dims[j].Size() })
+	return dims
+}
+
+// embeddingsFilter returns qdrant filter which matches the payload of the embeddings matching f.
+// It returns error if f contains values which can't be matched by qdrant.
+func embeddingsFilter(f *v1.EmbeddingsFilter) (*pb.Filter, error) {
+	conds := f.Conditions()
+	filter := &pb.Filter{Must: make([]*pb.Condition, 0, len(conds))}
+	for key, val := range conds {
+		var match *pb.Match
+		switch v := val.(type) {
+		case string:
+			match = &pb.Match{MatchValue: &pb.Match_Keyword{Keyword: v}}
+		case bool:
+			match = &pb.Match{MatchValue: &pb.Match_Boolean{Boolean: v}}
+		case int:
+			match = &pb.Match{MatchValue: &pb.Match_Integer{Integer: int64(v)}}
+		case int64:
+			match = &pb.Match{MatchValue: &pb.Match_Integer{Integer: v}}
+		case float64:
+			// NOTE: JSON numbers are decoded as float64
+			if v != math.Trunc(v) {
+				return nil, v1.Errorf(v1.EINVALID, "invalid filter value %v of key %s", val, key)
+			}
+			match = &pb.Match{MatchValue: &pb.Match_Integer{Integer: int64(v)}}
+		default:
+			return nil, v1.Errorf(v1.EINVALID, "invalid filter value %v of key %s", val, key)
+		}
+		filter.Must = append(filter.Must, &pb.Condition{
+			ConditionOneOf: &pb.Condition_Field{
+				Field: &pb.FieldCondition{Key: key, Match: match},
+			},
+		})
+	}
+	return filter, nil
+}
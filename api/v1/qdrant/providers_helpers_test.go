@@ -0,0 +1,342 @@
+package qdrant
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	"github.com/milosgajdos/embeviz/api/v1/internal/projection"
+	pb "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc/metadata"
+)
+
+// DSNEnv is the environment variable with the DSN of the qdrant instance
+// the integration tests run against, e.g. qdrant://@localhost:6334
+// The integration tests are skipped unless it's set.
+const DSNEnv = "QDRANT_DSN"
+
+// TestVectorSize is the size of the embeddings stored by the test providers.
+const TestVectorSize = 4
+
+func MustProvidersService(t *testing.T) *ProvidersService {
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		t.Skipf("skipping integration test: %s not set", DSNEnv)
+	}
+	db, err := NewDB(dsn)
+	if err != nil {
+		t.Fatalf("failed creating new DB: %v", err)
+	}
+	if err := db.Open(); err != nil {
+		t.Fatalf("failed opening DB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ps, err := NewProvidersService(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ps
+}
+
+// MustAddProvider adds a new provider storing embeddings of TestVectorSize
+// and drops its collection and state when the test finishes.
+func MustAddProvider(t *testing.T, ps *ProvidersService) *v1.Provider {
+	return MustAddDistanceProvider(t, ps, defaultDistance)
+}
+
+// MustAddDistanceProvider adds a new provider storing embeddings of TestVectorSize
+// with the given distance and drops its collection and state when the test finishes.
+func MustAddDistanceProvider(t *testing.T, ps *ProvidersService, dist pb.Distance) *v1.Provider {
+	md := map[string]any{"size": uint64(TestVectorSize), "distance": dist}
+	p, err := ps.AddProvider(context.TODO(), uuid.NewString(), md)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		ctx := metadata.NewOutgoingContext(context.TODO(), ps.db.md)
+		if _, err := ps.db.col.Delete(ctx, &pb.DeleteCollection{CollectionName: p.UID}); err != nil {
+			t.Errorf("failed to delete collection: %v", err)
+		}
+		if err := ps.state.Drop(ctx, p.UID); err != nil {
+			t.Errorf("failed to drop state: %v", err)
+		}
+	})
+	return p
+}
+
+// MustSeedEmbeddings stores two well separated blobs of labeled embeddings
+// of the provider p projected by the given projection and returns them.
+func MustSeedEmbeddings(t *testing.T, ps *ProvidersService, p *v1.Provider, proj v1.Projection) []v1.Embedding {
+	embs := []v1.Embedding{
+		{Values: []float64{1.0, 0.0, 0.0, 0.1}, Metadata: map[string]any{v1.LabelMetaKey: "a"}},
+		{Values: []float64{1.1, 0.0, 0.0, 0.0}, Metadata: map[string]any{v1.LabelMetaKey: "a"}},
+		{Values: []float64{1.0, 0.1, 0.0, 0.0}, Metadata: map[string]any{v1.LabelMetaKey: "a"}},
+		{Values: []float64{0.0, 0.0, 5.0, 5.1}, Metadata: map[string]any{v1.LabelMetaKey: "b"}},
+		{Values: []float64{0.0, 0.0, 5.1, 5.0}, Metadata: map[string]any{v1.LabelMetaKey: "b"}},
+		{Values: []float64{0.0, 0.1, 5.0, 5.0}, Metadata: map[string]any{v1.LabelMetaKey: "b"}},
+	}
+	res, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, embs, proj, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestGetVecVals(t *testing.T) {
+	vecs := &pb.NamedVectors{
+		Vectors: map[string]*pb.Vector{
+			"":                                 {Data: []float32{1, 2, 3}},
+			v1.ProjectionKey(v1.PCA, v1.Dim2D): {Data: []float32{0.5, -0.5}},
+		},
+	}
+
+	testCases := []struct {
+		name string
+		exp  []float64
+	}{
+		{name: "", exp: []float64{1, 2, 3}},
+		{name: v1.ProjectionKey(v1.PCA, v1.Dim2D), exp: []float64{0.5, -0.5}},
+		{name: "foo", exp: []float64{}},
+	}
+
+	if vals := getVecVals(nil, ""); len(vals) != 0 {
+		t.Fatalf("expected no values, got: %v", vals)
+	}
+
+	for _, tc := range testCases {
+		if vals := getVecVals(vecs, tc.name); !reflect.DeepEqual(vals, tc.exp) {
+			t.Fatalf("vector %q: expected: %v, got: %v", tc.name, tc.exp, vals)
+		}
+	}
+}
+
+func TestEmbeddingsUpdate(t *testing.T) {
+	embs := []v1.Embedding{
+		{UID: "foo", Values: []float64{1, 2}, Metadata: map[string]any{"foo": "bar"}},
+		{UID: "bar", Values: []float64{3, 4}, Metadata: map[string]any{"bar": "baz"}},
+	}
+	existing := map[string]bool{"bar": true}
+
+	ops := embeddingsUpdate(embs, existing)
+	if len(ops) != 3 {
+		t.Fatalf("expected %d operations, got: %d", 3, len(ops))
+	}
+
+	points := ops[0].GetUpsert().GetPoints()
+	if len(points) != 1 || points[0].GetId().GetUuid() != "foo" {
+		t.Fatalf("expected upserted point: %s, got: %v", "foo", points)
+	}
+	vecs := points[0].GetVectors().GetVectors()
+	if len(vecs.GetVectors()) != 1 {
+		t.Fatalf("expected only the embedding vector, got: %v", vecs.GetVectors())
+	}
+	if vals := getVecVals(vecs, ""); !reflect.DeepEqual(vals, embs[0].Values) {
+		t.Fatalf("expected values: %v, got: %v", embs[0].Values, vals)
+	}
+	if md := payload2Meta(points[0].GetPayload()); !reflect.DeepEqual(md, embs[0].Metadata) {
+		t.Fatalf("expected payload: %v, got: %v", embs[0].Metadata, md)
+	}
+
+	pointVecs := ops[1].GetUpdateVectors().GetPoints()
+	if len(pointVecs) != 1 || pointVecs[0].GetId().GetUuid() != "bar" {
+		t.Fatalf("expected updated point: %s, got: %v", "bar", pointVecs)
+	}
+	vecs = pointVecs[0].GetVectors().GetVectors()
+	if len(vecs.GetVectors()) != 1 {
+		t.Fatalf("expected only the embedding vector, got: %v", vecs.GetVectors())
+	}
+	if vals := getVecVals(vecs, ""); !reflect.DeepEqual(vals, embs[1].Values) {
+		t.Fatalf("expected values: %v, got: %v", embs[1].Values, vals)
+	}
+
+	payload := ops[2].GetOverwritePayload()
+	if ids := payload.GetPointsSelector().GetPoints().GetIds(); len(ids) != 1 || ids[0].GetUuid() != "bar" {
+		t.Fatalf("expected overwritten payload of point: %s, got: %v", "bar", ids)
+	}
+	if md := payload2Meta(payload.GetPayload()); !reflect.DeepEqual(md, embs[1].Metadata) {
+		t.Fatalf("expected payload: %v, got: %v", embs[1].Metadata, md)
+	}
+}
+
+func TestPointID(t *testing.T) {
+	uid := uuid.NewString()
+	if id := pointID(uid); id.GetUuid() != uid {
+		t.Fatalf("expected point ID: %s, got: %s", uid, id.GetUuid())
+	}
+}
+
+func TestProjPointVectors(t *testing.T) {
+	embs := []v1.Embedding{{UID: "foo"}, {UID: "bar"}}
+	projs := map[v1.Dim][]v1.Embedding{
+		v1.Dim2D: {{Values: []float64{1, 2}}, {Values: []float64{3, 4}}},
+		v1.Dim3D: {{Values: []float64{1, 2, 3}}, {Values: []float64{4, 5, 6}}},
+	}
+
+	pointVecs := projPointVectors(v1.TSNE, embs, projs)
+	if len(pointVecs) != len(embs) {
+		t.Fatalf("expected %d points, got: %d", len(embs), len(pointVecs))
+	}
+	for i, pv := range pointVecs {
+		if uid := pv.GetId().GetUuid(); uid != embs[i].UID {
+			t.Fatalf("point %d: expected ID: %s, got: %s", i, embs[i].UID, uid)
+		}
+		vecs := pv.GetVectors().GetVectors()
+		if len(vecs.GetVectors()) != len(projs) {
+			t.Fatalf("point %d: expected %d vectors, got: %v", i, len(projs), vecs.GetVectors())
+		}
+		for dim, dimProjs := range projs {
+			if vals := getVecVals(vecs, v1.ProjectionKey(v1.TSNE, dim)); !reflect.DeepEqual(vals, dimProjs[i].Values) {
+				t.Fatalf("point %d: expected %s vector: %v, got: %v", i, dim, dimProjs[i].Values, vals)
+			}
+		}
+	}
+}
+
+func TestPlaceRefs(t *testing.T) {
+	models := projection.Models{
+		v1.Dim2D: {Projection: v1.TSNE, Dim: v1.Dim2D},
+	}
+	key := v1.ProjectionKey(v1.TSNE, v1.Dim2D)
+
+	point := func(uid string, vals, proj []float32) *pb.ScoredPoint {
+		vecs := map[string]*pb.Vector{"": {Data: vals}}
+		if proj != nil {
+			vecs[key] = &pb.Vector{Data: proj}
+		}
+		return &pb.ScoredPoint{
+			Id: pointID(uid),
+			Vectors: &pb.Vectors{
+				VectorsOptions: &pb.Vectors_Vectors{
+					Vectors: &pb.NamedVectors{Vectors: vecs},
+				},
+			},
+		}
+	}
+
+	// NOTE: candidates are ranked by dot product which
+	// differs from the euclidean distance the refs are ranked by.
+	cands := []*pb.ScoredPoint{
+		point("far", []float32{10, 10}, []float32{1, 1}),
+		point("missing", []float32{1, 1}, nil),
+		point("near", []float32{1, 1}, []float32{2, 2}),
+	}
+	for i := 0; i < projection.DefaultNeighbours; i++ {
+		cands = append(cands, point(uuid.NewString(), []float32{5, 5}, []float32{3, 3}))
+	}
+
+	refs, refProjs := placeRefs(v1.TSNE, models, []float64{1, 1}, cands)
+	if len(refs) != projection.DefaultNeighbours {
+		t.Fatalf("expected %d refs, got: %d", projection.DefaultNeighbours, len(refs))
+	}
+	if len(refProjs[v1.Dim2D]) != len(refs) {
+		t.Fatalf("expected %d projections, got: %d", len(refs), len(refProjs[v1.Dim2D]))
+	}
+	if refs[0].UID != "near" || !reflect.DeepEqual(refProjs[v1.Dim2D][0].Values, []float64{2, 2}) {
+		t.Fatalf("expected nearest ref: %s, got: %v", "near", refs[0])
+	}
+	for i, ref := range refs {
+		if ref.UID == "far" || ref.UID == "missing" {
+			t.Fatalf("unexpected ref %d: %s", i, ref.UID)
+		}
+		if refProjs[v1.Dim2D][i].UID != ref.UID {
+			t.Fatalf("ref %d: expected projection of %s, got: %s", i, ref.UID, refProjs[v1.Dim2D][i].UID)
+		}
+	}
+}
+
+func TestScoreThreshold(t *testing.T) {
+	testCases := []struct {
+		dist      pb.Distance
+		metric    v1.Metric
+		threshold float32
+		ok        bool
+	}{
+		{dist: pb.Distance_Euclid, metric: "", threshold: 0.5, ok: true},
+		{dist: pb.Distance_Euclid, metric: v1.Euclidean, threshold: 0.5, ok: true},
+		{dist: pb.Distance_Euclid, metric: v1.Dot},
+		{dist: pb.Distance_Cosine, metric: v1.Cosine, threshold: 0.5, ok: true},
+		{dist: pb.Distance_Euclid, metric: v1.Cosine},
+		{dist: pb.Distance_Cosine, metric: v1.Euclidean},
+		{dist: pb.Distance_Dot, metric: v1.Dot},
+		{dist: pb.Distance_Dot, metric: v1.Euclidean},
+	}
+
+	for _, tc := range testCases {
+		threshold, ok := scoreThreshold(tc.dist, tc.metric, 0.5)
+		if ok != tc.ok || threshold != tc.threshold {
+			t.Fatalf("%s/%s: expected threshold: %v, %v, got: %v, %v", tc.dist, tc.metric, tc.threshold, tc.ok, threshold, ok)
+		}
+	}
+}
+
+func TestProjDims(t *testing.T) {
+	params := map[string]*pb.VectorParams{
+		"":                                        {Size: 4},
+		v1.ProjectionKey(v1.PCA, v1.NewDim(10)):   {Size: 10},
+		v1.ProjectionKey(v1.PCA, v1.Dim2D):        {Size: 2},
+		v1.ProjectionKey(v1.TSNE, v1.Dim2D):       {Size: 2},
+		v1.ProjectionKey(v1.PCA, v1.Dim1D):        {Size: 1},
+		v1.ProjectionKey(v1.PCA, v1.Dim("fooD")):  {Size: 1},
+		v1.ProjectionKey(v1.UMAP, v1.NewDim(10)):  {Size: 10},
+		v1.ProjectionKey(v1.UMAP, v1.Dim("foo")):  {Size: 1},
+		v1.ProjectionKey(v1.UMAP, v1.NewDim(100)): {Size: 100},
+		"3D": {Size: 3},
+	}
+
+	exp := []v1.Dim{v1.Dim1D, v1.Dim2D, v1.Dim3D, v1.NewDim(10)}
+	if dims := projDims(params); !reflect.DeepEqual(dims, exp) {
+		t.Fatalf("expected dims: %v, got: %v", exp, dims)
+	}
+}
+
+func TestEmbeddingsFilter(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		f := &v1.EmbeddingsFilter{
+			Label: "foo",
+			Metadata: map[string]any{
+				"bool":  true,
+				"int":   1,
+				"int64": int64(2),
+				"float": float64(3),
+			},
+		}
+		filter, err := embeddingsFilter(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		exp := map[string]*pb.Match{
+			v1.RawLabelMetaKey: {MatchValue: &pb.Match_Keyword{Keyword: "foo"}},
+			"bool":             {MatchValue: &pb.Match_Boolean{Boolean: true}},
+			"int":              {MatchValue: &pb.Match_Integer{Integer: 1}},
+			"int64":            {MatchValue: &pb.Match_Integer{Integer: 2}},
+			"float":            {MatchValue: &pb.Match_Integer{Integer: 3}},
+		}
+		if len(filter.Must) != len(exp) {
+			t.Fatalf("expected %d conditions, got: %d", len(exp), len(filter.Must))
+		}
+		for _, cond := range filter.Must {
+			field := cond.GetField()
+			match, ok := exp[field.GetKey()]
+			if !ok {
+				t.Fatalf("unexpected condition key: %s", field.GetKey())
+			}
+			if !reflect.DeepEqual(field.GetMatch().GetMatchValue(), match.GetMatchValue()) {
+				t.Fatalf("key %s: expected match: %v, got: %v", field.GetKey(), match, field.GetMatch())
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, val := range []any{1.5, []string{"foo"}, nil} {
+			f := &v1.EmbeddingsFilter{Metadata: map[string]any{"foo": val}}
+			if _, err := embeddingsFilter(f); v1.ErrorCode(err) != v1.EINVALID {
+				t.Fatalf("value %v: expected error: %s, got: %v", val, v1.EINVALID, err)
+			}
+		}
+	})
+}
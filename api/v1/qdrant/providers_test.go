@@ -0,0 +1,303 @@
+package qdrant
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	pb "github.com/qdrant/go-client/qdrant"
+)
+
+func TestMeta2Payload(t *testing.T) {
+	md := map[string]any{
+		"foo": "bar",
+		"int": 1,
+		"nil": nil,
+	}
+
+	exp := map[string]*pb.Value{
+		"foo": {Kind: &pb.Value_StringValue{StringValue: "bar"}},
+	}
+	if payload := meta2Payload(md); !reflect.DeepEqual(payload, exp) {
+		t.Fatalf("expected payload: %v, got: %v", exp, payload)
+	}
+}
+
+func TestPayload2Meta(t *testing.T) {
+	payload := map[string]*pb.Value{
+		"string": {Kind: &pb.Value_StringValue{StringValue: "bar"}},
+		"int":    {Kind: &pb.Value_IntegerValue{IntegerValue: 1}},
+		"double": {Kind: &pb.Value_DoubleValue{DoubleValue: 1.5}},
+		"bool":   {Kind: &pb.Value_BoolValue{BoolValue: true}},
+		"list":   {Kind: &pb.Value_ListValue{ListValue: &pb.ListValue{}}},
+	}
+
+	exp := map[string]any{
+		"string": "bar",
+		"int":    int64(1),
+		"double": 1.5,
+		"bool":   true,
+	}
+	if md := payload2Meta(payload); !reflect.DeepEqual(md, exp) {
+		t.Fatalf("expected metadata: %v, got: %v", exp, md)
+	}
+}
+
+func TestAddProvider(t *testing.T) {
+	ps := MustProvidersService(t)
+
+	p := MustAddProvider(t, ps)
+
+	if p.UID == "" {
+		t.Fatal("expected non-empty UID")
+	}
+
+	t.Run("Existing", func(t *testing.T) {
+		p2, err := ps.AddProvider(context.TODO(), p.Name, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p2.UID != p.UID {
+			t.Fatalf("expected UID: %s, got: %s", p.UID, p2.UID)
+		}
+		if size := p2.Metadata["size"]; size != uint64(TestVectorSize) {
+			t.Fatalf("expected size: %d, got: %v", TestVectorSize, size)
+		}
+		if dims := p2.Metadata[v1.DimsMetaKey]; !reflect.DeepEqual(dims, v1.DefaultDims) {
+			t.Fatalf("expected dims: %v, got: %v", v1.DefaultDims, dims)
+		}
+	})
+
+	t.Run("MissingSize", func(t *testing.T) {
+		if _, err := ps.AddProvider(context.TODO(), "foo", nil); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+	})
+
+	t.Run("GetByUID", func(t *testing.T) {
+		p2, err := ps.GetProviderByUID(context.TODO(), p.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p2.Name != p.Name {
+			t.Fatalf("expected name: %s, got: %s", p.Name, p2.Name)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.GetProviderByUID(context.TODO(), "foo"); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestUpdateProviderEmbeddings(t *testing.T) {
+	ps := MustProvidersService(t)
+	p := MustAddProvider(t, ps)
+	embs := MustSeedEmbeddings(t, ps, p, v1.PCA)
+
+	t.Run("Embeddings", func(t *testing.T) {
+		res, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(res) != len(embs) {
+			t.Fatalf("expected %d embeddings, got: %d", len(embs), len(res))
+		}
+	})
+
+	t.Run("Projections", func(t *testing.T) {
+		projs, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if projs.Projection != v1.PCA {
+			t.Fatalf("expected projection: %s, got: %s", v1.PCA, projs.Projection)
+		}
+		for _, dim := range v1.DefaultDims {
+			if n := len(projs.Embeddings[dim]); n != len(embs) {
+				t.Fatalf("expected %d %s projections, got: %d", len(embs), dim, n)
+			}
+		}
+	})
+
+	t.Run("Existing", func(t *testing.T) {
+		e := embs[0]
+		e.Values = []float64{1.0, 0.1, 0.1, 0.0}
+		e.Metadata = map[string]any{v1.LabelMetaKey: "c"}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, []v1.Embedding{e}, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		projs, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dim := range v1.DefaultDims {
+			dimProjs := projs.Embeddings[dim]
+			if len(dimProjs) != len(embs) {
+				t.Fatalf("expected %d %s projections, got: %d", len(embs), dim, len(dimProjs))
+			}
+			i := slices.IndexFunc(dimProjs, func(pe v1.Embedding) bool { return pe.UID == e.UID })
+			if i < 0 {
+				t.Fatalf("missing %s projection of embedding %s", dim, e.UID)
+			}
+			if len(dimProjs[i].Values) != dim.Size() || slices.Equal(dimProjs[i].Values, make([]float64, dim.Size())) {
+				t.Fatalf("expected %s projection of embedding %s, got: %v", dim, e.UID, dimProjs[i].Values)
+			}
+			if label := dimProjs[i].Metadata[v1.LabelMetaKey]; label != "c" {
+				t.Fatalf("expected label: %s, got: %v", "c", label)
+			}
+		}
+	})
+
+	t.Run("Incomplete", func(t *testing.T) {
+		// NOTE: simulate the computation interrupted after updating some of the vectors
+		if err := ps.state.Put(context.TODO(), p.UID, projStateKey(incompleteStateKey, v1.PCA), true); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{}); v1.ErrorCode(err) != v1.EINVALID {
+			t.Fatalf("expected error: %s, got: %v", v1.EINVALID, err)
+		}
+
+		// NOTE: the incomplete projection is recomputed rather than extended
+		e := v1.Embedding{Values: []float64{0.0, 0.0, 5.0, 4.9}}
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), p.UID, []v1.Embedding{e}, v1.PCA, nil); err != nil {
+			t.Fatal(err)
+		}
+		projs, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, dim := range v1.DefaultDims {
+			if n := len(projs.Embeddings[dim]); n != len(embs)+1 {
+				t.Fatalf("expected %d %s projections, got: %d", len(embs)+1, dim, n)
+			}
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ps.UpdateProviderEmbeddings(context.TODO(), "foo", embs, v1.PCA, nil); v1.ErrorCode(err) != v1.ENOTFOUND {
+			t.Fatalf("expected error: %s, got: %v", v1.ENOTFOUND, err)
+		}
+	})
+}
+
+func TestDropProviderEmbeddings(t *testing.T) {
+	ps := MustProvidersService(t)
+	p := MustAddProvider(t, ps)
+	MustSeedEmbeddings(t, ps, p, v1.PCA)
+
+	if err := ps.DropProviderEmbeddings(context.TODO(), p.UID); err != nil {
+		t.Fatal(err)
+	}
+
+	res, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 0 {
+		t.Fatalf("expected no embeddings, got: %d", len(res))
+	}
+}
+
+func TestClusterProviderEmbeddings(t *testing.T) {
+	ps := MustProvidersService(t)
+
+	t.Run("KMeans", func(t *testing.T) {
+		p := MustAddProvider(t, ps)
+		embs := MustSeedEmbeddings(t, ps, p, v1.PCA)
+
+		c, err := ps.ClusterProviderEmbeddings(context.TODO(), p.UID, &v1.ClusterOptions{Algorithm: v1.KMeans, K: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.K != 2 {
+			t.Fatalf("expected %d clusters, got: %d", 2, c.K)
+		}
+		if n := c.Sizes[0] + c.Sizes[1]; n != len(embs) {
+			t.Fatalf("expected %d clustered embeddings, got: %d", len(embs), n)
+		}
+	})
+
+	// NOTE: qdrant searches the neighbours of euclidean collections;
+	// the neighbours in dot product collections are found by scanning.
+	for _, dist := range []pb.Distance{pb.Distance_Euclid, pb.Distance_Dot} {
+		t.Run("DBSCAN/"+dist.String(), func(t *testing.T) {
+			p := MustAddDistanceProvider(t, ps, dist)
+			embs := MustSeedEmbeddings(t, ps, p, v1.PCA)
+
+			opts := &v1.ClusterOptions{Algorithm: v1.DBSCAN, Eps: 0.5, MinPoints: 3}
+			c, err := ps.ClusterProviderEmbeddings(context.TODO(), p.UID, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.K != 2 || c.Noise != 0 {
+				t.Fatalf("expected %d clusters without noise, got: %d clusters, %d noise", 2, c.K, c.Noise)
+			}
+
+			res, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res) != len(embs) {
+				t.Fatalf("expected %d embeddings, got: %d", len(embs), len(res))
+			}
+			// NOTE: the embeddings of the same label must be assigned to the same cluster
+			clusters := make(map[any]any)
+			for _, e := range res {
+				label, cl := e.Metadata[v1.LabelMetaKey], e.Metadata[v1.ClusterMetaKey]
+				if c, ok := clusters[label]; ok && c != cl {
+					t.Fatalf("embedding %s assigned to cluster %v, expected: %v", e.UID, cl, c)
+				}
+				clusters[label] = cl
+			}
+		})
+	}
+}
+
+func TestPlaceProviderEmbeddings(t *testing.T) {
+	ps := MustProvidersService(t)
+	p := MustAddProvider(t, ps)
+	MustSeedEmbeddings(t, ps, p, v1.PCA)
+
+	opts := &v1.ProjectionOptions{Perplexity: 1, MaxIterations: 10}
+	if err := ps.ComputeProviderProjections(context.TODO(), p.UID, v1.TSNE, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	prj := v1.TSNE
+	px, _, err := ps.GetProviderProjections(context.TODO(), p.UID, v1.ProviderFilter{Projection: &prj})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _, err := ps.GetProviderEmbeddings(context.TODO(), p.UID, v1.ProviderFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: the queries match stored embeddings so they must be placed onto their projections
+	res, err := ps.PlaceProviderEmbeddings(context.TODO(), p.UID, stored, v1.ProviderFilter{Projection: &prj})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for dim, dimProjs := range px.Embeddings {
+		if len(res.Embeddings[dim]) != len(stored) {
+			t.Fatalf("expected %d %s projections, got: %d", len(stored), dim, len(res.Embeddings[dim]))
+		}
+		for i, e := range stored {
+			j := slices.IndexFunc(dimProjs, func(pe v1.Embedding) bool { return pe.UID == e.UID })
+			if j < 0 {
+				t.Fatalf("missing %s projection of embedding %s", dim, e.UID)
+			}
+			for k, v := range dimProjs[j].Values {
+				if math.Abs(res.Embeddings[dim][i].Values[k]-v) > 1e-6 {
+					t.Fatalf("expected %s projection: %v, got: %v", dim, dimProjs[j].Values, res.Embeddings[dim][i].Values)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package qdrant
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseDSN(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dsn      string
+		scheme   string
+		authKey  string
+		hostAddr string
+		err      error
+	}{
+		{name: "OK", dsn: "qdrant://key@localhost:6334", scheme: "qdrant", authKey: "key", hostAddr: "localhost:6334"},
+		{name: "NoKey", dsn: "qdrant://@localhost:6334", scheme: "qdrant", hostAddr: "localhost:6334"},
+		{name: "NoScheme", dsn: "key@localhost:6334", err: ErrInvalidDSN},
+		{name: "NoAt", dsn: "qdrant://localhost:6334", err: ErrInvalidDSN},
+		{name: "NoHost", dsn: "qdrant://key@", err: ErrInvalidDSN},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scheme, authKey, hostAddr, err := parseDSN(tc.dsn)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error: %v, got: %v", tc.err, err)
+			}
+			if tc.err != nil {
+				return
+			}
+			if scheme != tc.scheme || authKey != tc.authKey || hostAddr != tc.hostAddr {
+				t.Fatalf("expected: %q %q %q, got: %q %q %q", tc.scheme, tc.authKey, tc.hostAddr, scheme, authKey, hostAddr)
+			}
+		})
+	}
+}
@@ -0,0 +1,304 @@
+package qdrant
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/google/uuid"
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	pb "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// StateCollection stores provider state qdrant can't store in collections.
+	// NOTE: qdrant does not allow storing any metadata about the collections,
+	// so we store it as payload of the points in a dedicated collection.
+	StateCollection = "embeviz_state"
+
+	// state payload keys
+	stateProviderKey = "provider"
+	stateKeyKey      = "key"
+	stateValueKey    = "value"
+
+	// state keys
+	optionsStateKey   = "options"
+	modelsStateKey    = "models"
+	metricsStateKey   = "metrics"
+	activeStateKey    = "projection"
+	viewsStateKey     = "views"
+	matrixStateKey    = "matrix"
+	dedupStateKey     = "dedup"
+	migrationStateKey = "migration"
+	// incompleteStateKey marks projections whose computation has not finished
+	// and whose vectors may therefore mix the new and the old projections.
+	incompleteStateKey = "incomplete"
+)
+
+// state manages provider state stored in the state collection.
+type state struct {
+	db *DB
+	// mu guards the state collection initialization.
+	mu    sync.Mutex
+	ready bool
+}
+
+// init creates the state collection if it doesn't exist.
+func (s *state) init(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ready {
+		return nil
+	}
+
+	resp, err := s.db.col.List(ctx, &pb.ListCollectionsRequest{})
+	if err != nil {
+		return v1.Errorf(v1.EINTERNAL, "ListCollections error: %v", err)
+	}
+	for _, c := range resp.Collections {
+		if c.Name == StateCollection {
+			s.ready = true
+			return nil
+		}
+	}
+
+	if _, err := s.db.col.Create(ctx, &pb.CreateCollection{
+		CollectionName: StateCollection,
+		VectorsConfig: &pb.VectorsConfig{
+			Config: &pb.VectorsConfig_Params{
+				// NOTE: qdrant requires a vector for every point
+				// so we store a dummy single dimensional vector.
+				Params: &pb.VectorParams{
+					Size:     1,
+					Distance: pb.Distance_Dot,
+				},
+			},
+		},
+	}); err != nil {
+		return v1.Errorf(v1.EINTERNAL, "CreateCollection error: %v", err)
+	}
+	s.ready = true
+
+	return nil
+}
+
+// Put stores the JSON encoded val under the given key for the provider with the given uid.
+func (s *state) Put(ctx context.Context, uid, key string, val any) error {
+	ctx = metadata.NewOutgoingContext(ctx, s.db.md)
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	payload, err := statePayload(uid, key, val)
+	if err != nil {
+		return err
+	}
+
+	wait := true
+	if _, err := s.db.pts.Upsert(ctx, &pb.UpsertPoints{
+		CollectionName: StateCollection,
+		Wait:           &wait,
+		Points: []*pb.PointStruct{
+			{
+				Id: statePointID(uid, key),
+				Vectors: &pb.Vectors{
+					VectorsOptions: &pb.Vectors_Vector{
+						Vector: &pb.Vector{Data: []float32{1}},
+					},
+				},
+				Payload: payload,
+			},
+		},
+	}); err != nil {
+		return v1.Errorf(v1.EINTERNAL, "Upsert state error: %v", err)
+	}
+
+	return nil
+}
+
+// Get decodes the value stored under the given key for the provider with the given uid into val.
+// It returns false if no value has been stored under the given key.
+func (s *state) Get(ctx context.Context, uid, key string, val any) (bool, error) {
+	ctx = metadata.NewOutgoingContext(ctx, s.db.md)
+	if err := s.init(ctx); err != nil {
+		return false, err
+	}
+
+	resp, err := s.db.pts.Get(ctx, &pb.GetPoints{
+		CollectionName: StateCollection,
+		Ids:            []*pb.PointId{statePointID(uid, key)},
+		WithPayload: &pb.WithPayloadSelector{
+			SelectorOptions: &pb.WithPayloadSelector_Enable{
+				Enable: true,
+			},
+		},
+	})
+	if err != nil {
+		return false, v1.Errorf(v1.EINTERNAL, "Get state error: %v", err)
+	}
+
+	if len(resp.Result) == 0 {
+		return false, nil
+	}
+
+	if err := decodeState(resp.Result[0].Payload, val); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Delete deletes the value stored under the given key for the provider with the given uid.
+func (s *state) Delete(ctx context.Context, uid, key string) error {
+	ctx = metadata.NewOutgoingContext(ctx, s.db.md)
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	wait := true
+	if _, err := s.db.pts.Delete(ctx, &pb.DeletePoints{
+		CollectionName: StateCollection,
+		Wait:           &wait,
+		Points: &pb.PointsSelector{
+			PointsSelectorOneOf: &pb.PointsSelector_Points{
+				Points: &pb.PointsIdsList{
+					Ids: []*pb.PointId{statePointID(uid, key)},
+				},
+			},
+		},
+	}); err != nil {
+		return v1.Errorf(v1.EINTERNAL, "Delete state error: %v", err)
+	}
+
+	return nil
+}
+
+// Drop drops all state of the provider with the given uid.
+func (s *state) Drop(ctx context.Context, uid string) error {
+	ctx = metadata.NewOutgoingContext(ctx, s.db.md)
+	if err := s.init(ctx); err != nil {
+		return err
+	}
+
+	wait := true
+	if _, err := s.db.pts.Delete(ctx, &pb.DeletePoints{
+		CollectionName: StateCollection,
+		Wait:           &wait,
+		Points: &pb.PointsSelector{
+			PointsSelectorOneOf: &pb.PointsSelector_Filter{
+				Filter: &pb.Filter{
+					Must: []*pb.Condition{
+						matchKeyword(stateProviderKey, uid),
+					},
+				},
+			},
+		},
+	}); err != nil {
+		return v1.Errorf(v1.EINTERNAL, "Delete state error: %v", err)
+	}
+
+	return nil
+}
+
+// Providers returns the uids of the providers which store a value under the given key.
+func (s *state) Providers(ctx context.Context, key string) ([]string, error) {
+	ctx = metadata.NewOutgoingContext(ctx, s.db.md)
+	if err := s.init(ctx); err != nil {
+		return nil, err
+	}
+
+	limit := uint32(scrollBatchSize)
+	req := &pb.ScrollPoints{
+		CollectionName: StateCollection,
+		Filter: &pb.Filter{
+			Must: []*pb.Condition{
+				matchKeyword(stateKeyKey, key),
+			},
+		},
+		WithPayload: &pb.WithPayloadSelector{
+			SelectorOptions: &pb.WithPayloadSelector_Include{
+				Include: &pb.PayloadIncludeSelector{
+					Fields: []string{stateProviderKey},
+				},
+			},
+		},
+		Limit: &limit,
+	}
+
+	var uids []string
+	for {
+		resp, err := s.db.pts.Scroll(ctx, req)
+		if err != nil {
+			return nil, v1.Errorf(v1.EINTERNAL, "Scroll state error: %v", err)
+		}
+		for _, p := range resp.GetResult() {
+			uids = append(uids, p.Payload[stateProviderKey].GetStringValue())
+		}
+		if resp.NextPageOffset == nil {
+			break
+		}
+		req.Offset = resp.NextPageOffset
+	}
+
+	return uids, nil
+}
+
+// statePayload returns the payload of the state point storing the JSON encoded val
+// under the given key for the provider with the given uid.
+func statePayload(uid, key string, val any) (map[string]*pb.Value, error) {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, v1.Errorf(v1.EINTERNAL, "state encoding error: %v", err)
+	}
+	return map[string]*pb.Value{
+		stateProviderKey: {Kind: &pb.Value_StringValue{StringValue: uid}},
+		stateKeyKey:      {Kind: &pb.Value_StringValue{StringValue: key}},
+		stateValueKey:    {Kind: &pb.Value_StringValue{StringValue: string(data)}},
+	}, nil
+}
+
+// decodeState decodes the value stored in the payload of the state point into val.
+func decodeState(payload map[string]*pb.Value, val any) error {
+	data := payload[stateValueKey].GetStringValue()
+	if err := json.Unmarshal([]byte(data), val); err != nil {
+		return v1.Errorf(v1.EINTERNAL, "state decoding error: %v", err)
+	}
+	return nil
+}
+
+// projStateKey returns the state key of the given projection.
+func projStateKey(key string, proj v1.Projection) string {
+	return key + "/" + string(proj)
+}
+
+// viewStateKey returns the state key of the projections of the given view.
+func viewStateKey(name string) string {
+	return viewsStateKey + "/" + name
+}
+
+// statePointID returns a deterministic point ID for the given provider uid and key.
+func statePointID(uid, key string) *pb.PointId {
+	return &pb.PointId{
+		PointIdOptions: &pb.PointId_Uuid{
+			Uuid: uuid.NewSHA1(uuid.NameSpaceOID, []byte(uid+"/"+key)).String(),
+		},
+	}
+}
+
+// matchKeyword returns a condition matching the payload key with the given value.
+func matchKeyword(key, val string) *pb.Condition {
+	return &pb.Condition{
+		ConditionOneOf: &pb.Condition_Field{
+			Field: &pb.FieldCondition{
+				Key: key,
+				Match: &pb.Match{
+					MatchValue: &pb.Match_Keyword{
+						Keyword: val,
+					},
+				},
+			},
+		},
+	}
+}
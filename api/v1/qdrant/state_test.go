@@ -0,0 +1,64 @@
+package qdrant
+
+import (
+	"reflect"
+	"testing"
+
+	v1 "github.com/milosgajdos/embeviz/api/v1"
+	pb "github.com/qdrant/go-client/qdrant"
+)
+
+func TestStatePayload(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		seed := int64(1)
+		opts := &v1.ProjectionOptions{Seed: &seed, Dims: []v1.Dim{v1.Dim2D, v1.NewDim(10)}}
+
+		payload, err := statePayload("foo", projStateKey(optionsStateKey, v1.PCA), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uid := payload[stateProviderKey].GetStringValue(); uid != "foo" {
+			t.Fatalf("expected provider: foo, got: %s", uid)
+		}
+		if key := payload[stateKeyKey].GetStringValue(); key != "options/pca" {
+			t.Fatalf("expected key: options/pca, got: %s", key)
+		}
+
+		res := new(v1.ProjectionOptions)
+		if err := decodeState(payload, res); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(res, opts) {
+			t.Fatalf("expected options: %#v, got: %#v", opts, res)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := statePayload("foo", "bar", func() {}); v1.ErrorCode(err) != v1.EINTERNAL {
+			t.Fatalf("expected error: %s, got: %v", v1.EINTERNAL, err)
+		}
+
+		payload := map[string]*pb.Value{
+			stateValueKey: {Kind: &pb.Value_StringValue{StringValue: "{"}},
+		}
+		if err := decodeState(payload, new(v1.ProjectionOptions)); v1.ErrorCode(err) != v1.EINTERNAL {
+			t.Fatalf("expected error: %s, got: %v", v1.EINTERNAL, err)
+		}
+	})
+}
+
+func TestStatePointID(t *testing.T) {
+	id := statePointID("foo", viewStateKey("bar"))
+	if other := statePointID("foo", viewStateKey("bar")); other.GetUuid() != id.GetUuid() {
+		t.Fatalf("expected deterministic ID: %s, got: %s", id.GetUuid(), other.GetUuid())
+	}
+
+	for _, other := range []*pb.PointId{
+		statePointID("foo", viewsStateKey),
+		statePointID("bar", viewStateKey("bar")),
+	} {
+		if other.GetUuid() == id.GetUuid() {
+			t.Fatalf("expected distinct IDs, got: %s", id.GetUuid())
+		}
+	}
+}
@@ -33,8 +33,65 @@ type ProvidersResponse struct {
 
 // ProjectionsResponse is returned when querying provider embeddings projections
 type ProjectionsResponse struct {
+	Projection  Projection                 `json:"projection,omitempty"`
+	Projections map[Dim][]Embedding        `json:"embeddings"`
+	Options     *ProjectionOptions         `json:"options,omitempty"`
+	Metrics     map[Dim]*ProjectionMetrics `json:"metrics,omitempty"`
+	Page        Page                       `json:"page"`
+}
+
+// ProjectionQueryResponse is returned when placing a query into provider projections.
+type ProjectionQueryResponse struct {
+	Projection  Projection          `json:"projection"`
 	Projections map[Dim][]Embedding `json:"embeddings"`
-	Page        Page                `json:"page"`
+}
+
+// SearchResponse is returned when searching provider embeddings.
+type SearchResponse struct {
+	Embeddings []ScoredEmbedding `json:"embeddings"`
+}
+
+// NeighboursResponse is returned when querying neighbours of provider embedding.
+type NeighboursResponse struct {
+	UID        string            `json:"uid"`
+	Metric     Metric            `json:"metric"`
+	Embeddings []ScoredEmbedding `json:"embeddings"`
+}
+
+// DedupResponse is returned when querying or updating provider dedup policy.
+type DedupResponse struct {
+	Policy *DedupPolicy `json:"policy"`
+}
+
+// DuplicatesResponse is returned when querying duplicate provider embeddings.
+type DuplicatesResponse struct {
+	Duplicates *Duplicates `json:"duplicates"`
+}
+
+// MatrixResponse is returned when querying or updating provider projection matrix.
+type MatrixResponse struct {
+	Matrix *ProjectionMatrix `json:"matrix"`
+}
+
+// AlgorithmsResponse is returned when querying projection algorithms.
+type AlgorithmsResponse struct {
+	Algorithms []ProjectionAlgorithm `json:"algorithms"`
+}
+
+// ViewsResponse is returned when querying provider views.
+type ViewsResponse struct {
+	Views []*View `json:"views"`
+}
+
+// ViewResponse is returned when querying a provider view.
+type ViewResponse struct {
+	View *View `json:"view"`
+	Page Page  `json:"page"`
+}
+
+// AlignmentResponse is returned when aligning provider projections.
+type AlignmentResponse struct {
+	Alignment *Alignment `json:"alignment"`
 }
 
 // EmbeddingsResponse is returned when querying provider embeddings.
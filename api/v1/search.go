@@ -0,0 +1,29 @@
+package v1
+
+const (
+	// DefaultSearchLimit is the default number of embeddings returned by search.
+	DefaultSearchLimit = 10
+	// MaxSearchLimit is the maximum number of embeddings returned by search.
+	MaxSearchLimit = 100
+)
+
+// DefaultNeighboursMetric is the default metric of embedding neighbours.
+var DefaultNeighboursMetric = Cosine
+
+// SearchQuery searches provider embeddings nearest to the embedding of the query text.
+type SearchQuery struct {
+	// Text of the query.
+	Text string `json:"text"`
+	// K is the number of the nearest embeddings returned.
+	// It defaults to DefaultSearchLimit.
+	K int `json:"k,omitempty"`
+}
+
+// ScoredEmbedding is an embedding scored by its similarity to the search query.
+type ScoredEmbedding struct {
+	Embedding
+	// Score is the similarity of the embedding to the query.
+	// Higher scores mean more similar embeddings except for
+	// Euclidean metric whose scores are distances.
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,60 @@
+package v1
+
+import "fmt"
+
+// MaxSimilarityEmbeddings is the maximum number of embeddings in the similarity matrix.
+const MaxSimilarityEmbeddings = 1000
+
+// DefaultSimilarityMetric is the default metric of the similarity matrix.
+var DefaultSimilarityMetric = Cosine
+
+// SimilarityRequest requests pairwise similarities of provider embeddings.
+// Embeddings are selected either by their UIDs or by the filter.
+type SimilarityRequest struct {
+	// UIDs of the embeddings.
+	UIDs []string `json:"uids,omitempty"`
+	// Filter selecting the embeddings.
+	Filter *EmbeddingsFilter `json:"filter,omitempty"`
+	// Metric measuring the similarity of the embeddings.
+	// It defaults to DefaultSimilarityMetric.
+	Metric Metric `json:"metric,omitempty"`
+	// Reorder orders the embeddings by hierarchical clustering
+	// so the blocks of similar embeddings are adjacent.
+	Reorder bool `json:"reorder,omitempty"`
+}
+
+// Validate returns error if the request is invalid.
+func (r *SimilarityRequest) Validate() error {
+	if (len(r.UIDs) == 0) == (r.Filter == nil) {
+		return fmt.Errorf("invalid request: either uids or filter must be set")
+	}
+	if len(r.UIDs) > MaxSimilarityEmbeddings {
+		return fmt.Errorf("invalid uids: %d exceed max: %d", len(r.UIDs), MaxSimilarityEmbeddings)
+	}
+	seen := make(map[string]struct{}, len(r.UIDs))
+	for _, uid := range r.UIDs {
+		if _, ok := seen[uid]; ok || uid == "" {
+			return fmt.Errorf("invalid uid: %q", uid)
+		}
+		seen[uid] = struct{}{}
+	}
+	if r.Metric != "" && !r.Metric.Valid() {
+		return fmt.Errorf("invalid metric: %v", r.Metric)
+	}
+	return nil
+}
+
+// Similarity is a pairwise similarity matrix of embeddings.
+type Similarity struct {
+	// Metric measuring the similarity of the embeddings.
+	// Euclidean similarities are distances.
+	Metric Metric `json:"metric"`
+	// Reordered is true if the embeddings are ordered by hierarchical clustering.
+	Reordered bool `json:"reordered"`
+	// UIDs of the embeddings in the order of the matrix rows and columns.
+	UIDs []string `json:"uids"`
+	// Labels of the embeddings in the order of the matrix rows and columns.
+	Labels []string `json:"labels"`
+	// Values of the similarity matrix.
+	Values [][]float64 `json:"values"`
+}
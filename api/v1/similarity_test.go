@@ -0,0 +1,36 @@
+package v1
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSimilarityRequestValidate(t *testing.T) {
+	uids := make([]string, MaxSimilarityEmbeddings+1)
+	for i := range uids {
+		uids[i] = strconv.Itoa(i)
+	}
+
+	testCases := []struct {
+		name  string
+		req   SimilarityRequest
+		valid bool
+	}{
+		{name: "UIDs", req: SimilarityRequest{UIDs: []string{"a", "b"}, Metric: Dot}, valid: true},
+		{name: "Filter", req: SimilarityRequest{Filter: &EmbeddingsFilter{Label: "a"}, Reorder: true}, valid: true},
+		{name: "Empty", req: SimilarityRequest{}},
+		{name: "Both", req: SimilarityRequest{UIDs: []string{"a"}, Filter: &EmbeddingsFilter{Label: "a"}}},
+		{name: "Duplicate", req: SimilarityRequest{UIDs: []string{"a", "a"}}},
+		{name: "EmptyUID", req: SimilarityRequest{UIDs: []string{""}}},
+		{name: "Max", req: SimilarityRequest{UIDs: uids}},
+		{name: "Metric", req: SimilarityRequest{UIDs: []string{"a"}, Metric: "foo"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.req.Validate(); (err == nil) != tc.valid {
+				t.Fatalf("expected valid: %v, got error: %v", tc.valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package v1
+
+import "regexp"
+
+// viewNameRe matches valid view names.
+var viewNameRe = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,64}$`)
+
+// ValidViewName returns true if name is a valid view name.
+// View names consist of at most 64 letters, digits, dots, dashes and underscores.
+func ValidViewName(name string) bool {
+	return viewNameRe.MatchString(name)
+}
+
+// EmbeddingsFilter selects embeddings by their metadata.
+// Embeddings match the filter if their metadata
+// contain all the key-value pairs set in the filter.
+type EmbeddingsFilter struct {
+	// Label supplied when the embeddings were embedded.
+	// It matches the raw label rather than the label joined with the embedded text.
+	Label string `json:"label,omitempty"`
+	// Color of the embeddings.
+	Color string `json:"color,omitempty"`
+	// Metadata key-value pairs of the embeddings.
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// Conditions returns the metadata key-value pairs the embeddings must contain.
+func (f *EmbeddingsFilter) Conditions() map[string]any {
+	conds := make(map[string]any, len(f.Metadata)+2)
+	for k, v := range f.Metadata {
+		conds[k] = v
+	}
+	if f.Label != "" {
+		conds[RawLabelMetaKey] = f.Label
+	}
+	if f.Color != "" {
+		conds[ColorMetaKey] = f.Color
+	}
+	return conds
+}
+
+// Match returns true if the metadata md match the filter.
+// Numeric values match if they are equal regardless of their type.
+func (f *EmbeddingsFilter) Match(md map[string]any) bool {
+	for k, v := range f.Conditions() {
+		mv, ok := md[k]
+		if !ok || !matchValue(mv, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchValue(a, b any) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	switch a.(type) {
+	case string, bool:
+		return a == b
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// View is a named projection of a subset of provider embeddings.
+// NOTE: views are snapshots; they are not updated when new embeddings are added.
+type View struct {
+	// Name of the view.
+	Name string `json:"name"`
+	// Filter selecting the projected embeddings.
+	// If not set, all the provider embeddings are projected.
+	Filter *EmbeddingsFilter `json:"filter,omitempty"`
+	// Projection algorithm used to compute the view projections.
+	Projection Projection `json:"projection"`
+	// Options used to compute the view projections.
+	Options *ProjectionOptions `json:"options,omitempty"`
+	// Metrics of the view projections keyed by projection dimension.
+	Metrics map[Dim]*ProjectionMetrics `json:"metrics,omitempty"`
+	// Count is the number of the projected embeddings.
+	Count int `json:"count"`
+	// Embeddings projections keyed by projection dimension.
+	// They're only set when fetching a single view.
+	Embeddings map[Dim][]Embedding `json:"embeddings,omitempty"`
+}
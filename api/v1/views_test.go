@@ -0,0 +1,57 @@
+package v1
+
+import "testing"
+
+func TestValidViewName(t *testing.T) {
+	testCases := []struct {
+		name  string
+		valid bool
+	}{
+		{"foo", true},
+		{"foo-bar_1.2", true},
+		{"", false},
+		{"foo bar", false},
+		{"foo/bar", false},
+		{string(make([]byte, 65)), false},
+	}
+
+	for _, tc := range testCases {
+		if got := ValidViewName(tc.name); got != tc.valid {
+			t.Errorf("expected %q valid: %v, got: %v", tc.name, tc.valid, got)
+		}
+	}
+}
+
+func TestEmbeddingsFilterMatch(t *testing.T) {
+	md := map[string]any{
+		LabelMetaKey:    "foo: bar",
+		RawLabelMetaKey: "foo",
+		ColorMetaKey:    "red",
+		"count":         2,
+		"ok":            true,
+	}
+
+	testCases := []struct {
+		name   string
+		filter EmbeddingsFilter
+		match  bool
+	}{
+		{"Empty", EmbeddingsFilter{}, true},
+		{"Label", EmbeddingsFilter{Label: "foo"}, true},
+		{"LabelColor", EmbeddingsFilter{Label: "foo", Color: "red"}, true},
+		{"JoinedLabel", EmbeddingsFilter{Label: "foo: bar"}, false},
+		{"Color", EmbeddingsFilter{Color: "blue"}, false},
+		{"Number", EmbeddingsFilter{Metadata: map[string]any{"count": 2.0}}, true},
+		{"NumberMismatch", EmbeddingsFilter{Metadata: map[string]any{"count": "2"}}, false},
+		{"Bool", EmbeddingsFilter{Metadata: map[string]any{"ok": true}}, true},
+		{"Missing", EmbeddingsFilter{Metadata: map[string]any{"bar": "baz"}}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.filter.Match(md); got != tc.match {
+				t.Fatalf("expected match: %v, got: %v", tc.match, got)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 	v1 "github.com/milosgajdos/embeviz/api/v1"
 	"github.com/milosgajdos/embeviz/api/v1/http"
+	"github.com/milosgajdos/embeviz/api/v1/jobs"
 	"github.com/milosgajdos/embeviz/api/v1/memory"
 	"github.com/milosgajdos/embeviz/api/v1/qdrant"
 	"github.com/milosgajdos/go-embeddings/cohere"
@@ -50,8 +51,10 @@ func run(args []string) error {
 	flags := flag.NewFlagSet(cliName, flag.ExitOnError)
 
 	var (
-		addr = flags.String("addr", ":5050", "API server bind address")
-		dsn  = flags.String("dsn", ":memory:", "Database connection string")
+		addr    = flags.String("addr", ":5050", "API server bind address")
+		dsn     = flags.String("dsn", ":memory:", "Database connection string")
+		workers = flags.Int("workers", jobs.DefaultWorkers, "Number of concurrently running jobs")
+		dims    = flags.String("dims", "2D,3D", "Comma separated projection dimensions stored by providers")
 	)
 
 	if err := flags.Parse(args[1:]); err != nil {
@@ -70,6 +73,11 @@ func run(args []string) error {
 		return err
 	}
 
+	projDims, err := parseDims(*dims)
+	if err != nil {
+		return err
+	}
+
 	// creates provider service
 	ps, err := NewProviderService(*dsn)
 	if err != nil {
@@ -77,13 +85,21 @@ func run(args []string) error {
 	}
 
 	// adds default embedders
-	embedders, err := addDefaultEmbedders(ps)
+	embedders, err := addDefaultEmbedders(ps, projDims)
+	if err != nil {
+		return err
+	}
+
+	// creates jobs service
+	js, err := jobs.NewJobsService(*workers)
 	if err != nil {
 		return err
 	}
+	defer js.Close()
 
 	s.Addr = *addr
 	s.ProvidersService = ps
+	s.JobsService = js
 	s.Embedders = embedders
 
 	// Create context that listens for the interrupt signal from the OS.
@@ -145,7 +161,16 @@ func makeQdrantProviderService(dsn string) (v1.ProvidersService, error) {
 	if err := db.Open(); err != nil {
 		return nil, fmt.Errorf("failed opening DB: %v", err)
 	}
-	return qdrant.NewProvidersService(db)
+	ps, err := qdrant.NewProvidersService(db)
+	if err != nil {
+		return nil, err
+	}
+	// NOTE: the collections of the existing providers must
+	// store the projections of all the registered projectors.
+	if err := ps.Migrate(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed migrating providers: %v", err)
+	}
+	return ps, nil
 }
 
 // makeMemoryProvidersService creates an ProvidersService
@@ -161,17 +186,32 @@ func makeMemoryProvidersService(dsn string) (v1.ProvidersService, error) {
 	return memory.NewProvidersService(db)
 }
 
+// parseDims parses comma separated projection dimensions.
+func parseDims(s string) ([]v1.Dim, error) {
+	dims := []v1.Dim{}
+	for _, d := range strings.Split(s, ",") {
+		dim := v1.Dim(strings.ToUpper(strings.TrimSpace(d)))
+		if !dim.Valid() {
+			return nil, fmt.Errorf("invalid projection dimension: %q", d)
+		}
+		dims = append(dims, dim)
+	}
+	return dims, nil
+}
+
 // addDefaultEmbedders adds the default embedders as long as alll the required
 // environment variables are set for eache specific embedding provider:
 // * OPENAI_API_KEY for OpenAI API
 // * COHERE_API_KEY for Cohere API
 // * VERTEXAI_TOKEN, VERTEXAI_MODEL_ID, GOOGLE_PROJECT_ID for Google VertexAI
-func addDefaultEmbedders(ps v1.ProvidersService) (map[string]any, error) {
+// The providers store projections of the given dimensions.
+func addDefaultEmbedders(ps v1.ProvidersService, dims []v1.Dim) (map[string]any, error) {
 	embedders := make(map[string]any)
 
 	if apiKey := os.Getenv("OPENAI_API_KEY"); apiKey != "" {
 		md := map[string]any{
-			"size": uint64(1536),
+			"size":         uint64(1536),
+			v1.DimsMetaKey: dims,
 		}
 		openAI, err := ps.AddProvider(context.Background(), "OpenAI", md)
 		if err != nil {
@@ -182,7 +222,8 @@ func addDefaultEmbedders(ps v1.ProvidersService) (map[string]any, error) {
 
 	if apiKey := os.Getenv("COHERE_API_KEY"); apiKey != "" {
 		md := map[string]any{
-			"size": uint64(1024),
+			"size":         uint64(1024),
+			v1.DimsMetaKey: dims,
 		}
 		cohereAI, err := ps.AddProvider(context.Background(), "Cohere", md)
 		if err != nil {
@@ -194,7 +235,8 @@ func addDefaultEmbedders(ps v1.ProvidersService) (map[string]any, error) {
 	if os.Getenv("VERTEXAI_TOKEN") != "" &&
 		os.Getenv("GOOGLE_PROJECT_ID") != "" {
 		md := map[string]any{
-			"size": uint64(768),
+			"size":         uint64(768),
+			v1.DimsMetaKey: dims,
 		}
 		vertexAI, err := ps.AddProvider(context.Background(), "VertexAI", md)
 		if err != nil {
@@ -6,7 +6,7 @@ import Projection from "./projection";
 import Chunking from "./chunking";
 import Modal from "../modal/modal";
 
-export default function EmbedForm({ onDrop, onFetch }) {
+export default function EmbedForm({ algorithms, onDrop, onFetch }) {
   let params = useParams();
   const navigation = useNavigation();
 
@@ -49,6 +49,7 @@ export default function EmbedForm({ onDrop, onFetch }) {
           onTextChange={(e) => setText(e.target.value)}
         />
         <Projection
+          algorithms={algorithms}
           projection={projection}
           onProjectionChange={(e) => setProjection(e.target.value)}
           onProjectionClearInput={() => {
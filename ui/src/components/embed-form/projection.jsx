@@ -1,4 +1,5 @@
 export default function Projection({
+  algorithms,
   projection,
   onProjectionChange,
   onProjectionClearInput,
@@ -10,28 +11,22 @@ export default function Projection({
     <div id="embed-projection">
       <fieldset>
         <legend>Projection</legend>
-        <div>
-          <input
-            type="radio"
-            id="pca"
-            name="projection"
-            value="pca"
-            checked={projection === "pca"}
-            onChange={onProjectionChange}
-          />
-          <label htmlFor="pca"> pca</label>
-        </div>
-        <div>
-          <input
-            type="radio"
-            id="tsne"
-            name="projection"
-            value="tsne"
-            checked={projection === "tsne"}
-            onChange={onProjectionChange}
-          />
-          <label htmlFor="tsne"> t-SNE</label>
-        </div>
+        {algorithms.map((algorithm) => (
+          <div key={algorithm.name}>
+            <input
+              type="radio"
+              id={algorithm.name}
+              name="projection"
+              value={algorithm.name}
+              checked={projection === algorithm.name}
+              onChange={onProjectionChange}
+            />
+            <label htmlFor={algorithm.name} title={algorithm.description}>
+              {" "}
+              {algorithm.name}
+            </label>
+          </div>
+        ))}
         <div>
           <input
             type="color"
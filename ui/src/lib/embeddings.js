@@ -46,9 +46,30 @@ export async function getProvider(uid) {
   }
 }
 
-export async function getProviderProjections(uid) {
+export async function getProjectionAlgorithms() {
   try {
-    const resp = await fetch(API_URL + "/providers/" + uid + "/projections");
+    const resp = await fetch(API_URL + "/projections");
+    if (!resp.ok) {
+      throw new Error(`HTTP error! Status: ${resp.status}`);
+    }
+
+    const respData = await resp.json();
+    return respData.algorithms ?? [];
+  } catch (error) {
+    console.error("An error occurred:", error.message);
+    throw new Error(
+      `Error fetching projection algorithms! Message: ${error.message}`,
+    );
+  }
+}
+
+export async function getProviderProjections(uid, projection) {
+  let url = API_URL + "/providers/" + uid + "/projections";
+  if (projection) {
+    url += "?projection=" + encodeURIComponent(projection);
+  }
+  try {
+    const resp = await fetch(url);
     if (!resp.ok) {
       throw new Error(`HTTP error! Status: ${resp.status}`);
     }
@@ -129,6 +150,7 @@ export async function deleteData(uid) {
 }
 
 export async function computeData(uid, updates) {
+  let job;
   try {
     const resp = await fetch(API_URL + "/providers/" + uid + "/projections", {
       method: "PATCH",
@@ -141,10 +163,40 @@ export async function computeData(uid, updates) {
     if (!resp.ok) {
       throw new Error(`HTTP error! Status: ${resp.status}`);
     }
+    job = await resp.json();
   } catch (error) {
     console.error("An error occurred:", error.message);
     throw new Error(
       `Error computing data for provider ${uid}! Message: ${error.message}`,
     );
   }
+
+  return waitJob(job.id);
+}
+
+const jobPollInterval = 500;
+
+export async function waitJob(id) {
+  try {
+    for (;;) {
+      const resp = await fetch(API_URL + "/jobs/" + id);
+      if (!resp.ok) {
+        throw new Error(`HTTP error! Status: ${resp.status}`);
+      }
+
+      const job = await resp.json();
+      switch (job.status) {
+        case "done":
+          return job;
+        case "failed":
+          throw new Error(job.error);
+        case "cancelled":
+          throw new Error("job cancelled");
+      }
+      await new Promise((resolve) => setTimeout(resolve, jobPollInterval));
+    }
+  } catch (error) {
+    console.error("An error occurred:", error.message);
+    throw new Error(`Error waiting for job ${id}! Message: ${error.message}`);
+  }
 }
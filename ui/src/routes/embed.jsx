@@ -9,6 +9,7 @@ import { useState } from "react";
 import {
   getProvider,
   getProviderProjections,
+  getProjectionAlgorithms,
   embedData,
   computeData,
 } from "../lib/embeddings";
@@ -68,9 +69,18 @@ export async function loader({ params }) {
       statusText: "Failed reading provider!",
     });
   }
+  let algorithms;
+  try {
+    algorithms = await getProjectionAlgorithms();
+  } catch (error) {
+    throw new Response("", {
+      status: error.Status,
+      statusText: "Fetching projection algorithms failed!",
+    });
+  }
   try {
     const { embeddings } = await getProviderProjections(params.uid);
-    return { provider, embeddings };
+    return { provider, algorithms, embeddings };
   } catch (error) {
     throw new Response("", {
       status: error.Status,
@@ -80,7 +90,7 @@ export async function loader({ params }) {
 }
 
 export default function Embed() {
-  const { provider, embeddings } = useLoaderData();
+  const { provider, algorithms, embeddings } = useLoaderData();
   const navigation = useNavigation();
   const revalidator = useRevalidator();
   const [isFetching, setFetching] = useState(false);
@@ -96,6 +106,7 @@ export default function Embed() {
         />
       </div>
       <EmbedForm
+        algorithms={algorithms}
         // NOTE: we need to "revalidate" the parent component
         // if we drop the data so the charts are rerendered.
         onDrop={() => revalidator.revalidate()}